@@ -1,45 +1,132 @@
 // Package backend provides an abstraction for different storage backends
-// (Git repository or GitHub API) for storing Nomad job configurations.
+// (Git repository, or the GitHub, GitLab or Gitea API) for storing Nomad job
+// configurations.
 package backend
 
+import (
+	"context"
+	"errors"
+
+	gitpkg "github.com/wlame/njgit/internal/git"
+)
+
+// ErrNothingToCommit is returned by Commit when the staged files already
+// have the content they were written with (e.g. another runner committed
+// the same change first)
+var ErrNothingToCommit = errors.New("nothing to commit")
+
 // Backend is the interface that all storage backends must implement.
 // This allows the sync command to work with different storage mechanisms
 // without knowing the implementation details.
+//
+// Every operation takes a context: canceling it (e.g. on Ctrl-C) stops
+// requests in flight, and git.timeout bounds each request.
 type Backend interface {
 	// Initialize prepares the backend for use.
 	// For Git: clones or opens the repository
 	// For GitHub API: validates credentials
-	Initialize() error
+	Initialize(ctx context.Context) error
 
 	// ReadFile reads a file from the backend.
 	// path is relative to the repository root (e.g., "default/web-app.hcl")
 	// Returns the file content, or an error if the file doesn't exist or can't be read.
-	ReadFile(path string) ([]byte, error)
+	ReadFile(ctx context.Context, path string) ([]byte, error)
 
 	// WriteFile writes a file to the backend.
 	// path is relative to the repository root (e.g., "default/web-app.hcl")
 	// content is the file content to write
 	// This does NOT commit - it just stages the change.
-	WriteFile(path string, content []byte) error
+	WriteFile(ctx context.Context, path string, content []byte) error
 
 	// FileExists checks if a file exists in the backend.
 	// path is relative to the repository root
-	FileExists(path string) (bool, error)
+	FileExists(ctx context.Context, path string) (bool, error)
+
+	// History returns the commits on the branch, newest first.
+	// path limits it to commits that changed that file ("" for all commits)
+	// limit caps the number of commits returned (0 for unlimited)
+	History(ctx context.Context, path string, limit int) ([]gitpkg.CommitInfo, error)
+
+	// ReadFileAt reads a file as it was at a revision.
+	// rev is anything ResolveRevision accepts; path is relative to the
+	// repository root
+	ReadFileAt(ctx context.Context, rev, path string) ([]byte, error)
+
+	// ListFiles lists the files under dir at a revision, recursively.
+	// dir is relative to the repository root ("" for all files)
+	// Returns paths relative to the repository root, sorted.
+	ListFiles(ctx context.Context, rev, dir string) ([]string, error)
+
+	// ResolveRevision looks up the commit a revision names.
+	// For Git: full or abbreviated hashes, branches, tags, HEAD~N, ...
+	// For GitHub API: full or abbreviated hashes, branches and tags
+	// Returns the commit with the files it changed.
+	ResolveRevision(ctx context.Context, rev string) (*gitpkg.CommitInfo, error)
+
+	// UseBranch makes branch the one files are read from and committed to,
+	// creating it from the current branch if it doesn't exist.
+	// For Git: checks the branch out (taking it from the remote if it's
+	// there, without pulling it); Close checks the original branch out again
+	// For GitHub API: switches the branch the API calls use
+	UseBranch(ctx context.Context, branch string) error
+
+	// CreateTag creates an annotated tag on the commit rev names, with
+	// njgit (git.author_name/author_email) as the tagger.
+	// For Git: pushes the tag as well when git.push is enabled
+	CreateTag(ctx context.Context, name, rev, message string) (*gitpkg.TagInfo, error)
+
+	// ListTags lists the tags whose names start with prefix, sorted by name.
+	ListTags(ctx context.Context, prefix string) ([]gitpkg.TagInfo, error)
+
+	// ReadRef returns the full hash of the commit a reference points to, or
+	// "" if it doesn't exist.
+	// name is a full reference name, e.g. "refs/njgit/applied/main"
+	// For Git: fetches the reference from the remote first, if there is one
+	ReadRef(ctx context.Context, name string) (string, error)
+
+	// UpdateRef points a reference at the commit rev names, creating it if
+	// it doesn't exist.
+	// For Git: pushes the reference as well when git.push is enabled
+	UpdateRef(ctx context.Context, name, rev string) error
+
+	// CommitsSince lists the commits reachable from rev but not from since,
+	// oldest first.
+	// For Git: each commit lists the files it changed
+	// For GitHub API: Files is not set (one request per page of commits)
+	CommitsSince(ctx context.Context, since, rev string) ([]gitpkg.CommitInfo, error)
+
+	// Pull brings the current branch up to date with the remote before
+	// files are compared and committed.
+	// For Git: rebases the branch onto the remote when git.remote is set,
+	// stopping with a *gitpkg.ConflictError on files changed on both sides
+	// For GitHub API: this is a no-op (reads already see the remote)
+	Pull(ctx context.Context) error
 
 	// Commit creates a commit with the staged changes.
 	// message is the commit message
+	// author is who made the change; njgit (git.author_name/author_email)
+	// is the committer. nil makes njgit the author too.
 	// This creates ONE commit with all staged files.
 	// Returns the commit hash (or empty string for GitHub API).
-	Commit(message string) (string, error)
+	// A context canceled before the commit starts aborts it; once started,
+	// the commit runs to completion so it is never half-written.
+	Commit(ctx context.Context, message string, author *gitpkg.Identity) (string, error)
+
+	// Discard drops the staged changes without committing them.
+	// For Git: restores the written files in the working directory
+	// For GitHub API: forgets the staged files
+	Discard() error
 
 	// Push pushes the commits to the remote.
 	// For Git: pushes to the remote repository
 	// For GitHub API: this is a no-op (commits are already on GitHub)
-	Push() error
+	Push(ctx context.Context) error
 
 	// Close cleans up any resources used by the backend.
-	// For Git: cleans up the repository handle (but keeps local clone)
-	// For GitHub API: this is a no-op
+	// For Git: checks the original branch out again if UseBranch switched it
+	// (the local clone is kept)
+	// For GitHub API: saves the response (ETag) cache and returns its error
+	// For GitLab and Gitea API: this is a no-op
 	Close() error
 
 	// GetName returns a human-readable name for this backend
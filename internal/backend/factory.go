@@ -13,6 +13,8 @@ import (
 // Supported backends:
 //   - "git" (default): Local Git repository backend using go-git
 //   - "github-api": GitHub REST API backend (stateless, no local repo)
+//   - "gitlab-api": GitLab REST API backend (gitlab.com or self-managed)
+//   - "gitea-api": Gitea REST API backend (also Forgejo)
 //
 // Parameters:
 //   - cfg: The Git configuration containing backend type and settings
@@ -49,8 +51,16 @@ func NewBackend(cfg *config.GitConfig) (Backend, error) {
 		// GitHub API backend - uses GitHub REST API directly
 		return NewGitHubBackend(cfg)
 
+	case "gitlab-api":
+		// GitLab API backend - uses the GitLab REST API (v4)
+		return NewGitLabBackend(cfg)
+
+	case "gitea-api":
+		// Gitea API backend - uses the Gitea REST API (v1)
+		return NewGiteaBackend(cfg)
+
 	default:
 		// Unknown backend type
-		return nil, fmt.Errorf("unsupported backend type: %s (supported: git, github-api)", backendType)
+		return nil, fmt.Errorf("unsupported backend type: %s (supported: git, github-api, gitlab-api, gitea-api)", backendType)
 	}
 }
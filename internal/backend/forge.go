@@ -0,0 +1,43 @@
+package backend
+
+import (
+	"context"
+	"time"
+)
+
+// Forge is implemented by backends hosted on a service with pull requests
+// (GitHub, ...). It lets changes be proposed for review instead of being
+// committed directly, and lets merged proposals be found again.
+//
+// Use a type assertion to check whether a backend is a forge:
+//
+//	forge, ok := b.(backend.Forge)
+type Forge interface {
+	// CreatePullRequest opens a pull request from pr.Head into pr.Base,
+	// with pr.Title and pr.Body.
+	// Returns the pull request with its number and URL filled in.
+	CreatePullRequest(ctx context.Context, pr *PullRequest) (*PullRequest, error)
+
+	// MergedPullRequest returns the pull request that was merged as commit
+	// (its merge commit, or the last commit of a squash or rebase merge),
+	// with the files it changed. Returns nil if commit didn't merge one.
+	MergedPullRequest(ctx context.Context, commit string) (*PullRequest, error)
+}
+
+// PullRequest describes a pull request (a merge request on some forges)
+type PullRequest struct {
+	Number int
+	Title  string
+	Body   string
+	Head   string // Branch with the changes
+	Base   string // Branch the changes are merged into
+	URL    string // Web page of the pull request
+
+	// MergeCommit and MergedAt are only set for merged pull requests
+	MergeCommit string
+	MergedAt    time.Time
+
+	// Files lists the files the pull request changed (only set by
+	// MergedPullRequest)
+	Files []string
+}
@@ -2,6 +2,8 @@
 package backend
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -10,19 +12,34 @@ import (
 	gitpkg "github.com/wlame/njgit/internal/git"
 )
 
+// pushAttempts is how many times a push rejected as non-fast-forward is
+// retried after rebasing onto the remote
+const pushAttempts = 3
+
 // GitBackend implements the Backend interface using a local Git repository
-// This backend works with an existing local Git repository only
+// This backend works with an existing local Git repository
 //
 // Key features:
-//   - Local-only operation (no remote operations)
-//   - User manages repository initialization (git init)
-//   - User manages remotes and push/pull manually
-//   - Uses git config for author name/email
+//   - User manages repository initialization (git init) and remotes
+//   - Optional remote sync: with git.remote set, commands that commit pull
+//     (rebasing njgit's own commits) before comparing files, and with
+//     git.push they push after each commit
+//   - Commits as git.author_name/author_email, falling back to git config
+//   - Optional commit signing (git.sign) with a GPG or SSH key
 type GitBackend struct {
 	config      *config.GitConfig
 	repository  *gitpkg.Repository
 	localPath   string   // Path to local repo (e.g., ".")
+	remote      string   // Remote to pull from and push to ("" for local-only)
 	stagedFiles []string // Files staged for commit
+
+	// originals holds the content staged files had before they were first
+	// written (nil if they didn't exist), so Discard can restore them
+	originals map[string][]byte
+
+	// restoreBranch is the branch checked out before the first UseBranch,
+	// switched back to by Close ("" if UseBranch wasn't called)
+	restoreBranch string
 }
 
 // NewGitBackend creates a new Git backend instance
@@ -46,18 +63,27 @@ func NewGitBackend(cfg *config.GitConfig) (*GitBackend, error) {
 	return &GitBackend{
 		config:      cfg,
 		localPath:   cfg.LocalPath,
+		remote:      cfg.Remote,
 		stagedFiles: make([]string, 0),
+		originals:   make(map[string][]byte),
 	}, nil
 }
 
 // Initialize prepares the Git backend for use
 // Opens the existing local Git repository
-// The repository must already exist (user runs 'git init')
+// The repository must already exist (user runs 'git init'), and so must
+// the configured remote (user runs 'git remote add')
+// Nothing is fetched here: read-only commands work on the local branch as
+// it is, and commands that commit call Pull first.
+//
+// Parameters:
+//
+//	ctx - Unused; opening a local repository is not cancellable
 //
 // Returns:
 //
 //	error - Any error that occurred
-func (g *GitBackend) Initialize() error {
+func (g *GitBackend) Initialize(ctx context.Context) error {
 	// Check if local repository exists
 	gitDir := filepath.Join(g.localPath, ".git")
 	stat, err := os.Stat(gitDir)
@@ -77,6 +103,30 @@ func (g *GitBackend) Initialize() error {
 		return fmt.Errorf("failed to open local repository at %s: %w", g.localPath, err)
 	}
 
+	// Sign new commits with git.sign, and verify signatures in history
+	signer, err := gitpkg.NewSigner(g.config)
+	if err != nil {
+		return fmt.Errorf("failed to load signing key: %w", err)
+	}
+	verifier, err := gitpkg.NewVerifier(g.config, signer)
+	if err != nil {
+		return fmt.Errorf("failed to load signature verification keys: %w", err)
+	}
+	g.repository.SetSigning(signer, verifier)
+
+	if g.remote != "" {
+		if !g.repository.HasRemote(g.remote) {
+			return fmt.Errorf("git remote %q not found in %s (add it with: git remote add %s <url>)",
+				g.remote, g.localPath, g.remote)
+		}
+
+		auth, err := gitpkg.ResolveAuth(g.config)
+		if err != nil {
+			return fmt.Errorf("failed to resolve git auth: %w", err)
+		}
+		g.repository.SetAuth(auth)
+	}
+
 	fmt.Printf("📁 Using local repository at: %s\n", g.localPath)
 	return nil
 }
@@ -86,13 +136,14 @@ func (g *GitBackend) Initialize() error {
 //
 // Parameters:
 //
+//	ctx - Unused; local reads are not cancellable
 //	path - Relative path to the file (e.g., "default/web-app.hcl")
 //
 // Returns:
 //
 //	[]byte - File content
 //	error - Any error that occurred
-func (g *GitBackend) ReadFile(path string) ([]byte, error) {
+func (g *GitBackend) ReadFile(ctx context.Context, path string) ([]byte, error) {
 	return g.repository.ReadFile(path)
 }
 
@@ -102,13 +153,26 @@ func (g *GitBackend) ReadFile(path string) ([]byte, error) {
 //
 // Parameters:
 //
+//	ctx - Unused; local writes are not cancellable
 //	path - Relative path to the file (e.g., "default/web-app.hcl")
 //	content - File content to write
 //
 // Returns:
 //
 //	error - Any error that occurred
-func (g *GitBackend) WriteFile(path string, content []byte) error {
+func (g *GitBackend) WriteFile(ctx context.Context, path string, content []byte) error {
+	// Remember what was there before the first write, for Discard
+	if _, seen := g.originals[path]; !seen {
+		original, err := g.repository.ReadFile(path)
+		if err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+			original = nil
+		}
+		g.originals[path] = original
+	}
+
 	// Ensure the directory exists
 	dir := filepath.Dir(path)
 	if err := g.repository.EnsureDirectory(dir); err != nil {
@@ -130,29 +194,286 @@ func (g *GitBackend) WriteFile(path string, content []byte) error {
 //
 // Parameters:
 //
+//	ctx - Unused; local checks are not cancellable
 //	path - Relative path to the file
 //
 // Returns:
 //
 //	bool - true if file exists
 //	error - Any error that occurred
-func (g *GitBackend) FileExists(path string) (bool, error) {
+func (g *GitBackend) FileExists(ctx context.Context, path string) (bool, error) {
 	return g.repository.FileExists(path)
 }
 
+// History returns the commits on the current branch, newest first
+//
+// Parameters:
+//
+//	ctx - Unused; local reads are not cancellable
+//	path - Only commits that changed this file ("" for all commits)
+//	limit - Maximum number of commits (0 for unlimited)
+//
+// Returns:
+//
+//	[]gitpkg.CommitInfo - The commits
+//	error - Any error that occurred
+func (g *GitBackend) History(ctx context.Context, path string, limit int) ([]gitpkg.CommitInfo, error) {
+	return g.repository.GetHistory(path, limit)
+}
+
+// ReadFileAt reads a file from the commit a revision names
+//
+// Parameters:
+//
+//	ctx - Unused; local reads are not cancellable
+//	rev - Commit hash (full or abbreviated), branch, tag, HEAD~N, ...
+//	path - Relative path to the file
+//
+// Returns:
+//
+//	[]byte - File content at that revision
+//	error - If the revision or the file doesn't exist
+func (g *GitBackend) ReadFileAt(ctx context.Context, rev, path string) ([]byte, error) {
+	commit, err := g.repository.ResolveCommit(rev)
+	if err != nil {
+		return nil, err
+	}
+	return g.repository.GetFileAtCommit(commit.FullHash, path)
+}
+
+// ListFiles lists the committed files under a directory at a revision
+//
+// Parameters:
+//
+//	ctx - Unused; local reads are not cancellable
+//	rev - Commit hash (full or abbreviated), branch, tag, HEAD~N, ...
+//	dir - Relative directory path ("" for all files)
+//
+// Returns:
+//
+//	[]string - Paths relative to the repository root
+//	error - Any error that occurred
+func (g *GitBackend) ListFiles(ctx context.Context, rev, dir string) ([]string, error) {
+	commit, err := g.repository.ResolveCommit(rev)
+	if err != nil {
+		return nil, err
+	}
+	return g.repository.ListFilesAt(commit.FullHash, dir)
+}
+
+// ResolveRevision looks up the commit a revision names
+//
+// Parameters:
+//
+//	ctx - Unused; local reads are not cancellable
+//	rev - Commit hash (full or abbreviated), branch, tag, HEAD~N, ...
+//
+// Returns:
+//
+//	*gitpkg.CommitInfo - The commit, with the files it changed
+//	error - If the revision doesn't name a commit
+func (g *GitBackend) ResolveRevision(ctx context.Context, rev string) (*gitpkg.CommitInfo, error) {
+	return g.repository.ResolveCommit(rev)
+}
+
+// UseBranch checks out a branch, creating it if it doesn't exist
+// With a remote configured, a new branch starts from the remote's branch of
+// the same name if there is one; otherwise it starts from HEAD. Close
+// checks out the branch that was current before again.
+//
+// Parameters:
+//
+//	ctx - Cancels the fetch from the remote; git.timeout applies on top
+//	branch - Branch name, e.g. "env/prod"
+//
+// Returns:
+//
+//	error - If there are uncommitted changes or the checkout fails
+func (g *GitBackend) UseBranch(ctx context.Context, branch string) error {
+	current, err := g.repository.CurrentBranch()
+	if err == nil && current == branch {
+		return nil
+	}
+
+	ctx, cancel := g.withTimeout(ctx)
+	defer cancel()
+
+	created, err := g.repository.SwitchBranch(ctx, branch, g.remote)
+	if err != nil {
+		return err
+	}
+
+	if g.restoreBranch == "" {
+		g.restoreBranch = current
+	}
+
+	if created {
+		fmt.Printf("🌿 Created and switched to branch: %s\n", branch)
+	} else {
+		fmt.Printf("🌿 Switched to branch: %s\n", branch)
+	}
+	return nil
+}
+
+// CreateTag creates an annotated tag with njgit as the tagger
+// With git.push enabled the tag is pushed to the remote too.
+//
+// Parameters:
+//
+//	ctx - Cancels the push; git.timeout applies on top
+//	name - Tag name, e.g. "deploy/global/default/web/20240115T143022Z"
+//	rev - The revision to tag
+//	message - The tag message
+//
+// Returns:
+//
+//	*gitpkg.TagInfo - The new tag
+//	error - If the tag exists, the revision doesn't, or the push fails
+func (g *GitBackend) CreateTag(ctx context.Context, name, rev, message string) (*gitpkg.TagInfo, error) {
+	tagger := gitpkg.Identity{}
+	if g.config.AuthorName != "" && g.config.AuthorEmail != "" {
+		tagger = gitpkg.Identity{Name: g.config.AuthorName, Email: g.config.AuthorEmail}
+	}
+
+	tag, err := g.repository.CreateTag(name, rev, message, tagger)
+	if err != nil {
+		return nil, err
+	}
+
+	if g.config.Push && g.remote != "" {
+		ctx, cancel := g.withTimeout(ctx)
+		defer cancel()
+		if err := g.repository.PushTag(ctx, g.remote, name); err != nil {
+			return tag, err
+		}
+	}
+
+	return tag, nil
+}
+
+// ListTags lists the tags whose names start with prefix
+//
+// Parameters:
+//
+//	ctx - Unused; local reads are not cancellable
+//	prefix - Name prefix ("" for all tags)
+//
+// Returns:
+//
+//	[]gitpkg.TagInfo - The tags, sorted by name
+//	error - Any error that occurred
+func (g *GitBackend) ListTags(ctx context.Context, prefix string) ([]gitpkg.TagInfo, error) {
+	return g.repository.ListTags(prefix)
+}
+
+// ReadRef returns the commit a reference points to
+// With a remote configured, the reference is fetched from it first, so
+// several machines share it.
+//
+// Parameters:
+//
+//	ctx - Cancels the fetch; git.timeout applies on top
+//	name - Full reference name, e.g. "refs/njgit/applied/main"
+//
+// Returns:
+//
+//	string - The full commit hash, or "" if the reference doesn't exist
+//	error - If the fetch or the read fails
+func (g *GitBackend) ReadRef(ctx context.Context, name string) (string, error) {
+	if g.remote != "" {
+		ctx, cancel := g.withTimeout(ctx)
+		defer cancel()
+		if err := g.repository.FetchRef(ctx, g.remote, name); err != nil {
+			return "", err
+		}
+	}
+	return g.repository.ReadRef(name)
+}
+
+// UpdateRef points a reference at a commit
+// With git.push enabled the reference is pushed to the remote too.
+//
+// Parameters:
+//
+//	ctx - Cancels the push; git.timeout applies on top
+//	name - Full reference name
+//	rev - The revision to point it at
+//
+// Returns:
+//
+//	error - If the revision doesn't exist or the push fails
+func (g *GitBackend) UpdateRef(ctx context.Context, name, rev string) error {
+	if err := g.repository.SetRef(name, rev); err != nil {
+		return err
+	}
+
+	if g.config.Push && g.remote != "" {
+		ctx, cancel := g.withTimeout(ctx)
+		defer cancel()
+		return g.repository.PushRef(ctx, g.remote, name)
+	}
+	return nil
+}
+
+// CommitsSince lists the commits reachable from rev but not from since
+//
+// Parameters:
+//
+//	ctx - Unused; local reads are not cancellable
+//	since - The revision already seen
+//	rev - The newer revision
+//
+// Returns:
+//
+//	[]gitpkg.CommitInfo - The commits, oldest first, with their files
+//	error - If either revision doesn't exist
+func (g *GitBackend) CommitsSince(ctx context.Context, since, rev string) ([]gitpkg.CommitInfo, error) {
+	return g.repository.CommitsSince(since, rev)
+}
+
+// Pull rebases the current branch onto the remote, so changes are
+// detected and committed against what others pushed
+// It's a no-op without git.remote. Files that were changed both locally
+// and on the remote stop the pull with a *gitpkg.ConflictError.
+//
+// Parameters:
+//
+//	ctx - Cancels the fetch; git.timeout applies on top
+//
+// Returns:
+//
+//	error - If the fetch fails or the rebase conflicts
+func (g *GitBackend) Pull(ctx context.Context) error {
+	if g.remote == "" {
+		return nil
+	}
+	return g.pull(ctx)
+}
+
 // Commit creates a Git commit with all staged files
 // This commits all files that were written since the last Commit() call
-// Author name/email are taken from git config (user must configure)
+// The committer is git.author_name/author_email (git config if unset), and
+// so is the author unless one is given.
+// If the staged files match HEAD already, nothing is committed and
+// ErrNothingToCommit is returned.
+// A context canceled before the commit starts aborts it; the commit itself
+// is local and runs to completion.
 //
 // Parameters:
 //
+//	ctx - Aborts the commit if canceled before it starts
 //	message - Commit message
+//	author - Who made the change (nil for njgit itself)
 //
 // Returns:
 //
 //	string - Commit hash (first 8 characters)
 //	error - Any error that occurred
-func (g *GitBackend) Commit(message string) (string, error) {
+func (g *GitBackend) Commit(ctx context.Context, message string, author *gitpkg.Identity) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", fmt.Errorf("commit aborted: %w", err)
+	}
+
 	// Stage all tracked files
 	for _, path := range g.stagedFiles {
 		if err := g.repository.StageFile(path); err != nil {
@@ -160,14 +481,34 @@ func (g *GitBackend) Commit(message string) (string, error) {
 		}
 	}
 
-	// Create the commit (uses git config for author)
-	hash, err := g.repository.Commit(message, "", "")
+	changed, err := g.repository.HasStagedChanges(g.stagedFiles)
+	if err != nil {
+		return "", err
+	}
+	if !changed {
+		g.stagedFiles = make([]string, 0)
+		g.originals = make(map[string][]byte)
+		return "", ErrNothingToCommit
+	}
+
+	// njgit commits; the author is whoever changed the job, if known
+	committer := gitpkg.Identity{}
+	if g.config.AuthorName != "" && g.config.AuthorEmail != "" {
+		committer = gitpkg.Identity{Name: g.config.AuthorName, Email: g.config.AuthorEmail}
+	}
+	commitAuthor := committer
+	if author != nil {
+		commitAuthor = *author
+	}
+
+	hash, err := g.repository.CommitAs(message, commitAuthor, committer)
 	if err != nil {
 		return "", fmt.Errorf("failed to create commit: %w", err)
 	}
 
 	// Clear staged files list
 	g.stagedFiles = make([]string, 0)
+	g.originals = make(map[string][]byte)
 
 	// Return first 8 characters of hash
 	if len(hash) > 8 {
@@ -176,27 +517,111 @@ func (g *GitBackend) Commit(message string) (string, error) {
 	return hash, nil
 }
 
-// Push is a no-op for local Git backend
-// User must manually push to remote using git commands
+// Discard drops the staged files without committing them
+// Files written since the last commit get their previous content back;
+// files that didn't exist before are removed.
 //
 // Returns:
 //
-//	error - Always returns nil
-func (g *GitBackend) Push() error {
-	// Git backend is local-only - no push operation
-	// User manages remotes and push/pull manually
+//	error - The first file that couldn't be restored
+func (g *GitBackend) Discard() error {
+	var firstErr error
+	for path, original := range g.originals {
+		var err error
+		if original == nil {
+			err = g.repository.DeleteFile(path)
+		} else {
+			err = g.repository.WriteFile(path, original)
+		}
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to restore %s: %w", path, err)
+		}
+	}
+
+	g.stagedFiles = make([]string, 0)
+	g.originals = make(map[string][]byte)
+	return firstErr
+}
+
+// pull rebases the branch onto the remote
+// Files already written for a commit stay as they are; their recorded
+// originals are updated so Discard restores the pulled version.
+func (g *GitBackend) pull(ctx context.Context) error {
+	ctx, cancel := g.withTimeout(ctx)
+	defer cancel()
+
+	result, err := g.repository.PullRebase(ctx, g.remote, g.stagedFiles)
+	if err != nil {
+		return fmt.Errorf("failed to pull from %s: %w", g.remote, err)
+	}
+
+	for _, path := range result.Changed {
+		if _, staged := g.originals[path]; !staged {
+			continue
+		}
+		content, err := g.repository.GetFileAtCommit(result.Head, path)
+		if err != nil {
+			content = nil
+		}
+		g.originals[path] = content
+	}
+
 	return nil
 }
 
+// Push pushes the branch to the configured remote
+// It's a no-op unless git.push is enabled; then a push rejected because
+// someone else pushed first is retried after rebasing onto the remote.
+//
+// Parameters:
+//
+//	ctx - Cancels the push; git.timeout applies to each attempt
+//
+// Returns:
+//
+//	error - Any error that occurred
+func (g *GitBackend) Push(ctx context.Context) error {
+	if !g.config.Push || g.remote == "" {
+		return nil
+	}
+
+	ctx, cancel := g.withTimeout(ctx)
+	defer cancel()
+
+	return g.repository.PushBranch(ctx, g.remote, pushAttempts)
+}
+
+// withTimeout derives the context for a remote operation
+// git.timeout applies on top of the caller's context.
+func (g *GitBackend) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if g.config.Timeout > 0 {
+		return context.WithTimeout(ctx, g.config.Timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
 // Close cleans up resources used by the backend
 // Note: This does NOT delete the local repository
-// The local repo is kept for reuse on the next run
+// The local repo is kept for reuse on the next run. If UseBranch switched
+// branches, the branch checked out before is checked out again, so later
+// commands read the configured branch.
 //
 // Returns:
 //
-//	error - Always returns nil for Git backend
+//	error - If the original branch can't be checked out again
 func (g *GitBackend) Close() error {
-	// Nothing to clean up - we keep the local repo for reuse
+	if g.restoreBranch == "" {
+		return nil
+	}
+	branch := g.restoreBranch
+	g.restoreBranch = ""
+
+	if current, err := g.repository.CurrentBranch(); err == nil && current == branch {
+		return nil
+	}
+	if _, err := g.repository.SwitchBranch(context.Background(), branch, ""); err != nil {
+		return fmt.Errorf("failed to switch back to branch %s: %w", branch, err)
+	}
 	return nil
 }
 
@@ -207,6 +632,9 @@ func (g *GitBackend) Close() error {
 //
 //	string - Backend name with local path
 func (g *GitBackend) GetName() string {
+	if g.remote != "" {
+		return fmt.Sprintf("Git (local: %s, remote: %s)", g.localPath, g.remote)
+	}
 	return fmt.Sprintf("Git (local: %s)", g.localPath)
 }
 
@@ -0,0 +1,814 @@
+package backend
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/wlame/njgit/internal/config"
+	gitpkg "github.com/wlame/njgit/internal/git"
+)
+
+// GiteaBackend implements the Backend interface using the Gitea REST API
+// (v1, Gitea 1.22 or newer; Forgejo works too).
+// Gitea's API mostly mirrors GitHub's, so commits and pull requests are
+// decoded with the GitHub types. All staged files are committed at once
+// through the change-files API.
+type GiteaBackend struct {
+	config      *config.GitConfig
+	client      *restClient
+	repoPath    string            // /repos/{owner}/{repo}
+	branch      string            // Branch read from and committed to (git.branch, or UseBranch)
+	stagedFiles map[string][]byte // Map of path -> content for files to commit
+}
+
+// giteaCommit represents a commit from the commits and compare APIs
+type giteaCommit struct {
+	githubCommit
+	Parents []struct {
+		SHA string `json:"sha"`
+	} `json:"parents"`
+}
+
+// giteaChangeFile is one file change in a change-files request
+type giteaChangeFile struct {
+	Operation string `json:"operation"` // "create" or "update"
+	Path      string `json:"path"`
+	Content   string `json:"content"`       // Base64 encoded
+	SHA       string `json:"sha,omitempty"` // Blob being replaced, required for updates
+}
+
+// giteaChangeFilesRequest represents the request body for
+// POST /repos/{owner}/{repo}/contents
+type giteaChangeFilesRequest struct {
+	Branch    string            `json:"branch"`
+	Message   string            `json:"message"`
+	Files     []giteaChangeFile `json:"files"`
+	Author    *githubCommitter  `json:"author,omitempty"`
+	Committer *githubCommitter  `json:"committer,omitempty"`
+}
+
+// giteaBranch represents a branch from the branches API
+type giteaBranch struct {
+	Name   string `json:"name"`
+	Commit struct {
+		ID string `json:"id"`
+	} `json:"commit"`
+}
+
+// giteaTag represents a tag from the tags API
+type giteaTag struct {
+	Name    string `json:"name"`
+	Message string `json:"message"`
+	Commit  struct {
+		SHA string `json:"sha"`
+	} `json:"commit"`
+}
+
+// NewGiteaBackend creates a new Gitea API backend
+//
+// Parameters:
+//   - cfg: Git configuration (api_url, owner, repo, token, branch)
+//
+// Returns:
+//   - *GiteaBackend: The backend
+//   - error: If required settings are missing
+func NewGiteaBackend(cfg *config.GitConfig) (*GiteaBackend, error) {
+	if cfg.APIURL == "" {
+		return nil, fmt.Errorf("api_url is required for gitea-api backend")
+	}
+	if cfg.Owner == "" {
+		return nil, fmt.Errorf("owner is required for gitea-api backend")
+	}
+	if cfg.Repo == "" {
+		return nil, fmt.Errorf("repo is required for gitea-api backend")
+	}
+	if cfg.Token == "" {
+		return nil, fmt.Errorf("gitea token is required for gitea-api backend (set via GITEA_TOKEN env var)")
+	}
+
+	if cfg.Branch == "" {
+		cfg.Branch = "main"
+	}
+
+	token := cfg.Token
+	client := newRestClient("gitea", apiRoot(cfg.APIURL, "/api/v1"), cfg.Timeout, func(req *http.Request) {
+		req.Header.Set("Authorization", "token "+token)
+	})
+
+	return &GiteaBackend{
+		config:      cfg,
+		client:      client,
+		repoPath:    fmt.Sprintf("/repos/%s/%s", url.PathEscape(cfg.Owner), url.PathEscape(cfg.Repo)),
+		branch:      cfg.Branch,
+		stagedFiles: make(map[string][]byte),
+	}, nil
+}
+
+// repoURL returns the endpoint of a repository resource, e.g.
+// repoURL("/commits") -> /repos/{owner}/{repo}/commits
+func (g *GiteaBackend) repoURL(format string, args ...interface{}) string {
+	return g.repoPath + fmt.Sprintf(format, args...)
+}
+
+// escapePath escapes each segment of a slash-separated path, keeping the
+// slashes, e.g. for file paths and branch names in URLs
+func escapePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// Initialize checks that the token can access the repository.
+//
+// Parameters:
+//   - ctx: Cancels the request; git.timeout applies on top
+//
+// Returns:
+//   - error: If the token is invalid or the repository can't be found
+func (g *GiteaBackend) Initialize(ctx context.Context) error {
+	status, _, err := g.client.do(ctx, http.MethodGet, g.repoURL(""), nil, nil, nil)
+	switch {
+	case status == http.StatusUnauthorized:
+		return fmt.Errorf("invalid Gitea token (check GITEA_TOKEN)")
+	case status == http.StatusNotFound:
+		return fmt.Errorf("repository not found: %s/%s (or the token has no access)", g.config.Owner, g.config.Repo)
+	case err != nil:
+		return fmt.Errorf("failed to access repository: %w", err)
+	}
+	return nil
+}
+
+// ReadFile reads a file from the current branch.
+//
+// Parameters:
+//   - ctx: Cancels the request; git.timeout applies on top
+//   - path: The file path relative to repository root
+//
+// Returns:
+//   - []byte: The file content
+//   - error: If the file doesn't exist or can't be read
+func (g *GiteaBackend) ReadFile(ctx context.Context, path string) ([]byte, error) {
+	file, err := g.getContents(ctx, g.branch, path)
+	if err != nil {
+		return nil, err
+	}
+	if file == nil {
+		return nil, fmt.Errorf("file not found: %s", path)
+	}
+
+	content, err := base64.StdEncoding.DecodeString(file.Content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode file content: %w", err)
+	}
+	return content, nil
+}
+
+// getContents gets a file through the contents API (nil if it doesn't exist)
+func (g *GiteaBackend) getContents(ctx context.Context, ref, path string) (*githubFileResponse, error) {
+	query := url.Values{}
+	query.Set("ref", ref)
+
+	var file githubFileResponse
+	status, _, err := g.client.do(ctx, http.MethodGet, g.repoURL("/contents/%s", escapePath(path)), query, nil, &file)
+	if status == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if file.Type != "file" {
+		return nil, fmt.Errorf("%s is not a file", path)
+	}
+	return &file, nil
+}
+
+// WriteFile stages a file to be committed by the next Commit.
+//
+// Parameters:
+//   - ctx: Unused; staging is local
+//   - path: The file path relative to repository root
+//   - content: The file content
+//
+// Returns:
+//   - error: If the path is empty
+func (g *GiteaBackend) WriteFile(ctx context.Context, path string, content []byte) error {
+	if path == "" {
+		return fmt.Errorf("file path cannot be empty")
+	}
+	path = strings.TrimPrefix(filepath.Clean(path), "./")
+	g.stagedFiles[path] = content
+	return nil
+}
+
+// FileExists checks if a file exists on the current branch.
+//
+// Parameters:
+//   - ctx: Cancels the request; git.timeout applies on top
+//   - path: The file path relative to repository root
+//
+// Returns:
+//   - bool: true if the file exists
+//   - error: Any error other than the file not existing
+func (g *GiteaBackend) FileExists(ctx context.Context, path string) (bool, error) {
+	file, err := g.getContents(ctx, g.branch, path)
+	if err != nil {
+		return false, err
+	}
+	return file != nil, nil
+}
+
+// History lists the commits on the current branch, with the files each
+// changed, through the commits API.
+//
+// Parameters:
+//   - ctx: Cancels the requests; git.timeout applies to each
+//   - path: Only commits that changed this file ("" for all commits)
+//   - limit: Maximum number of commits (0 for unlimited)
+//
+// Returns:
+//   - []gitpkg.CommitInfo: The commits, newest first
+//   - error: Any error encountered
+func (g *GiteaBackend) History(ctx context.Context, path string, limit int) ([]gitpkg.CommitInfo, error) {
+	const perPage = 50 // Gitea's default maximum page size
+
+	var commits []gitpkg.CommitInfo
+	for page := 1; ; page++ {
+		query := url.Values{}
+		query.Set("sha", g.branch)
+		query.Set("limit", strconv.Itoa(perPage))
+		query.Set("page", strconv.Itoa(page))
+		query.Set("stat", "false")
+		if path != "" {
+			query.Set("path", path)
+		}
+
+		var listed []giteaCommit
+		status, _, err := g.client.do(ctx, http.MethodGet, g.repoURL("/commits"), query, nil, &listed)
+		if status == http.StatusConflict {
+			// Gitea answers 409 for a repository without commits
+			return commits, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list commits: %w", err)
+		}
+
+		for i := range listed {
+			commits = append(commits, *githubCommitInfo(&listed[i].githubCommit))
+			if limit > 0 && len(commits) >= limit {
+				return commits, nil
+			}
+		}
+
+		if len(listed) < perPage {
+			return commits, nil
+		}
+	}
+}
+
+// ReadFileAt reads a file as it was at a revision.
+//
+// Parameters:
+//   - ctx: Cancels the requests; git.timeout applies to each
+//   - rev: Commit hash (full or abbreviated), branch or tag
+//   - path: The file path relative to repository root
+//
+// Returns:
+//   - []byte: The file content at that revision
+//   - error: If the revision or the file doesn't exist
+func (g *GiteaBackend) ReadFileAt(ctx context.Context, rev, path string) ([]byte, error) {
+	commit, err := g.resolveCommit(ctx, rev)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := g.getContents(ctx, commit.SHA, path)
+	if err != nil {
+		return nil, err
+	}
+	if file == nil {
+		return nil, fmt.Errorf("file not found: %s at commit %s", path, rev)
+	}
+
+	content, err := base64.StdEncoding.DecodeString(file.Content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode file content: %w", err)
+	}
+	return content, nil
+}
+
+// ListFiles lists the files under a directory at a revision through the
+// git trees API.
+//
+// Parameters:
+//   - ctx: Cancels the requests; git.timeout applies to each
+//   - rev: Commit hash (full or abbreviated), branch or tag
+//   - dir: Relative directory path ("" for all files)
+//
+// Returns:
+//   - []string: Paths relative to the repository root, sorted
+//   - error: Any error encountered
+func (g *GiteaBackend) ListFiles(ctx context.Context, rev, dir string) ([]string, error) {
+	commit, err := g.resolveCommit(ctx, rev)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := strings.Trim(dir, "/")
+	if prefix == "." {
+		prefix = ""
+	}
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	const perPage = 1000
+	files := []string{}
+	seen := 0
+	for page := 1; ; page++ {
+		query := url.Values{}
+		query.Set("recursive", "true")
+		query.Set("per_page", strconv.Itoa(perPage))
+		query.Set("page", strconv.Itoa(page))
+
+		var tree struct {
+			Tree []struct {
+				Path string `json:"path"`
+				Type string `json:"type"`
+			} `json:"tree"`
+			Truncated  bool `json:"truncated"`
+			TotalCount int  `json:"total_count"`
+		}
+		if _, _, err := g.client.do(ctx, http.MethodGet, g.repoURL("/git/trees/%s", commit.SHA), query, nil, &tree); err != nil {
+			return nil, fmt.Errorf("failed to list files at commit %s: %w", rev, err)
+		}
+
+		for _, entry := range tree.Tree {
+			if entry.Type == "blob" && strings.HasPrefix(entry.Path, prefix) {
+				files = append(files, entry.Path)
+			}
+		}
+		seen += len(tree.Tree)
+		if !tree.Truncated || len(tree.Tree) == 0 || seen >= tree.TotalCount {
+			break
+		}
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// ResolveRevision looks up a commit, with the files it changed, through
+// the git commits API.
+//
+// Parameters:
+//   - ctx: Cancels the request; git.timeout applies on top
+//   - rev: Commit hash (full or abbreviated), branch or tag
+//
+// Returns:
+//   - *gitpkg.CommitInfo: The commit, with the files it changed
+//   - error: If the revision doesn't name a commit
+func (g *GiteaBackend) ResolveRevision(ctx context.Context, rev string) (*gitpkg.CommitInfo, error) {
+	commit, err := g.resolveCommit(ctx, rev)
+	if err != nil {
+		return nil, err
+	}
+	return githubCommitInfo(&commit.githubCommit), nil
+}
+
+// resolveCommit looks up a commit by hash, branch or tag
+func (g *GiteaBackend) resolveCommit(ctx context.Context, rev string) (*giteaCommit, error) {
+	var commit giteaCommit
+	status, _, err := g.client.do(ctx, http.MethodGet, g.repoURL("/git/commits/%s", url.PathEscape(rev)), nil, nil, &commit)
+	if status == http.StatusNotFound || status == http.StatusUnprocessableEntity {
+		return nil, fmt.Errorf("revision %s not found", rev)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit %s: %w", rev, err)
+	}
+	return &commit, nil
+}
+
+// UseBranch switches the branch reads and commits use, creating it from
+// the current branch if it doesn't exist.
+//
+// Parameters:
+//   - ctx: Cancels the requests; git.timeout applies to each
+//   - branch: Branch name, e.g. "env/prod"
+//
+// Returns:
+//   - error: If the branch can't be looked up or created
+func (g *GiteaBackend) UseBranch(ctx context.Context, branch string) error {
+	if branch == g.branch {
+		return nil
+	}
+
+	status, _, err := g.client.do(ctx, http.MethodGet, g.repoURL("/branches/%s", escapePath(branch)), nil, nil, nil)
+	switch {
+	case status == http.StatusNotFound:
+		if err := g.createBranch(ctx, branch, g.branch); err != nil {
+			return err
+		}
+		fmt.Printf("🌿 Created branch on Gitea: %s\n", branch)
+	case err != nil:
+		return fmt.Errorf("failed to look up branch %s: %w", branch, err)
+	default:
+		fmt.Printf("🌿 Using branch on Gitea: %s\n", branch)
+	}
+
+	g.branch = branch
+	return nil
+}
+
+// createBranch creates a branch at a ref (branch, tag or commit)
+func (g *GiteaBackend) createBranch(ctx context.Context, branch, ref string) error {
+	request := map[string]string{"new_branch_name": branch, "old_ref_name": ref}
+	if _, _, err := g.client.do(ctx, http.MethodPost, g.repoURL("/branches"), nil, request, nil); err != nil {
+		return fmt.Errorf("failed to create branch %s: %w", branch, err)
+	}
+	return nil
+}
+
+// CreateTag creates an annotated tag through the tags API.
+// Gitea records the token's user as the tagger.
+//
+// Parameters:
+//   - ctx: Cancels the requests; git.timeout applies to each
+//   - name: Tag name, e.g. "deploy/global/default/web/20240115T143022Z"
+//   - rev: The revision to tag
+//   - message: The tag message
+//
+// Returns:
+//   - *gitpkg.TagInfo: The new tag
+//   - error: If the tag exists or the revision doesn't
+func (g *GiteaBackend) CreateTag(ctx context.Context, name, rev, message string) (*gitpkg.TagInfo, error) {
+	commit, err := g.resolveCommit(ctx, rev)
+	if err != nil {
+		return nil, err
+	}
+
+	if status, _, _ := g.client.do(ctx, http.MethodGet, g.repoURL("/tags/%s", escapePath(name)), nil, nil, nil); status == http.StatusOK {
+		return nil, fmt.Errorf("tag %s already exists", name)
+	}
+
+	request := map[string]string{"tag_name": name, "target": commit.SHA, "message": message}
+	var tag giteaTag
+	if _, _, err := g.client.do(ctx, http.MethodPost, g.repoURL("/tags"), nil, request, &tag); err != nil {
+		return nil, fmt.Errorf("failed to create tag %s: %w", name, err)
+	}
+
+	return &gitpkg.TagInfo{
+		Name:    tag.Name,
+		Commit:  tag.Commit.SHA,
+		Message: strings.TrimSpace(tag.Message),
+	}, nil
+}
+
+// ListTags lists the tags whose names start with prefix.
+// The tags API returns no dates, so tags are listed without one.
+//
+// Parameters:
+//   - ctx: Cancels the requests; git.timeout applies to each
+//   - prefix: Name prefix ("" for all tags)
+//
+// Returns:
+//   - []gitpkg.TagInfo: The tags, sorted by name
+//   - error: Any error encountered
+func (g *GiteaBackend) ListTags(ctx context.Context, prefix string) ([]gitpkg.TagInfo, error) {
+	const perPage = 50
+
+	tags := []gitpkg.TagInfo{}
+	for page := 1; ; page++ {
+		query := url.Values{}
+		query.Set("limit", strconv.Itoa(perPage))
+		query.Set("page", strconv.Itoa(page))
+
+		var listed []giteaTag
+		if _, _, err := g.client.do(ctx, http.MethodGet, g.repoURL("/tags"), query, nil, &listed); err != nil {
+			return nil, fmt.Errorf("failed to list tags: %w", err)
+		}
+		for _, tag := range listed {
+			if strings.HasPrefix(tag.Name, prefix) {
+				tags = append(tags, gitpkg.TagInfo{
+					Name:    tag.Name,
+					Commit:  tag.Commit.SHA,
+					Message: strings.TrimSpace(tag.Message),
+				})
+			}
+		}
+		if len(listed) < perPage {
+			break
+		}
+	}
+
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+	return tags, nil
+}
+
+// ReadRef returns the commit a reference points to.
+// Gitea only stores branches and tags, so refs outside refs/heads/ and
+// refs/tags/ are kept as branches (refs/njgit/applied/main is the branch
+// njgit/applied/main).
+//
+// Parameters:
+//   - ctx: Cancels the request; git.timeout applies on top
+//   - name: Full reference name
+//
+// Returns:
+//   - string: The full commit hash, or "" if the reference doesn't exist
+//   - error: Any other error
+func (g *GiteaBackend) ReadRef(ctx context.Context, name string) (string, error) {
+	kind, short := refTarget(name)
+
+	var commitID string
+	var status int
+	var err error
+	if kind == "tag" {
+		var tag giteaTag
+		status, _, err = g.client.do(ctx, http.MethodGet, g.repoURL("/tags/%s", escapePath(short)), nil, nil, &tag)
+		commitID = tag.Commit.SHA
+	} else {
+		var branch giteaBranch
+		status, _, err = g.client.do(ctx, http.MethodGet, g.repoURL("/branches/%s", escapePath(short)), nil, nil, &branch)
+		commitID = branch.Commit.ID
+	}
+	if status == http.StatusNotFound {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	return commitID, nil
+}
+
+// UpdateRef points a reference at a commit by deleting and recreating the
+// branch or tag that stores it (see ReadRef). Gitea can't move them in one
+// request, so if the new one can't be created the old one is put back.
+//
+// Parameters:
+//   - ctx: Cancels the requests; git.timeout applies to each
+//   - name: Full reference name
+//   - rev: The revision to point it at
+//
+// Returns:
+//   - error: If the revision doesn't exist or the update fails
+func (g *GiteaBackend) UpdateRef(ctx context.Context, name, rev string) error {
+	commit, err := g.resolveCommit(ctx, rev)
+	if err != nil {
+		return err
+	}
+	old, err := g.ReadRef(ctx, name)
+	if err != nil {
+		return err
+	}
+	if old == commit.SHA {
+		return nil
+	}
+
+	kind, short := refTarget(name)
+	resource := "/branches/%s"
+	if kind == "tag" {
+		resource = "/tags/%s"
+	}
+	if old != "" {
+		status, _, err := g.client.do(ctx, http.MethodDelete, g.repoURL(resource, escapePath(short)), nil, nil, nil)
+		if err != nil && status != http.StatusNotFound {
+			return fmt.Errorf("failed to update %s: %w", name, err)
+		}
+	}
+
+	if err := g.createRef(ctx, kind, short, commit.SHA); err != nil {
+		return restoreRef(name, old, err, func() error { return g.createRef(context.WithoutCancel(ctx), kind, short, old) })
+	}
+	return nil
+}
+
+// createRef creates the branch or tag (kind, see refTarget) that stores a
+// reference
+func (g *GiteaBackend) createRef(ctx context.Context, kind, short, sha string) error {
+	if kind == "tag" {
+		request := map[string]string{"tag_name": short, "target": sha}
+		if _, _, err := g.client.do(ctx, http.MethodPost, g.repoURL("/tags"), nil, request, nil); err != nil {
+			return fmt.Errorf("failed to create tag %s: %w", short, err)
+		}
+		return nil
+	}
+	return g.createBranch(ctx, short, sha)
+}
+
+// CommitsSince lists the commits reachable from rev but not from since
+// through the compare API.
+//
+// Parameters:
+//   - ctx: Cancels the request; git.timeout applies on top
+//   - since: The revision already seen
+//   - rev: The newer revision
+//
+// Returns:
+//   - []gitpkg.CommitInfo: The commits, oldest first (without Files)
+//   - error: If either revision doesn't exist
+func (g *GiteaBackend) CommitsSince(ctx context.Context, since, rev string) ([]gitpkg.CommitInfo, error) {
+	var compare struct {
+		Commits []giteaCommit `json:"commits"`
+	}
+	endpoint := g.repoURL("/compare/%s...%s", url.PathEscape(since), url.PathEscape(rev))
+	status, _, err := g.client.do(ctx, http.MethodGet, endpoint, nil, nil, &compare)
+	if status == http.StatusNotFound {
+		return nil, fmt.Errorf("revision %s or %s not found", since, rev)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare %s with %s: %w", since, rev, err)
+	}
+
+	ids := make([]string, len(compare.Commits))
+	parents := make(map[string][]string, len(compare.Commits))
+	for i, c := range compare.Commits {
+		ids[i] = c.SHA
+		for _, parent := range c.Parents {
+			parents[c.SHA] = append(parents[c.SHA], parent.SHA)
+		}
+	}
+
+	commits := make([]gitpkg.CommitInfo, 0, len(compare.Commits))
+	for _, i := range parentsFirst(ids, parents) {
+		info := githubCommitInfo(&compare.Commits[i].githubCommit)
+		info.Files = nil
+		commits = append(commits, *info)
+	}
+	return commits, nil
+}
+
+// Commit creates one commit with all staged files through the
+// change-files API. The commit is atomic: either every file is committed
+// or none is.
+//
+// Parameters:
+//   - ctx: A context canceled before the commit starts aborts it
+//   - message: The commit message
+//   - author: Who made the change (nil for njgit: git.author_name/author_email)
+//
+// Returns:
+//   - string: The short commit hash ("" if nothing was staged)
+//   - error: If the commit fails; the files stay staged
+func (g *GiteaBackend) Commit(ctx context.Context, message string, author *gitpkg.Identity) (string, error) {
+	if len(g.stagedFiles) == 0 {
+		return "", nil // Nothing to commit
+	}
+
+	if err := ctx.Err(); err != nil {
+		return "", fmt.Errorf("commit aborted: %w", err)
+	}
+	ctx = context.WithoutCancel(ctx)
+
+	request := giteaChangeFilesRequest{
+		Branch:  g.branch,
+		Message: message,
+	}
+	if g.config.AuthorName != "" && g.config.AuthorEmail != "" {
+		request.Committer = &githubCommitter{Name: g.config.AuthorName, Email: g.config.AuthorEmail}
+		request.Author = request.Committer
+	}
+	if author != nil {
+		request.Author = &githubCommitter{Name: author.Name, Email: author.Email}
+	}
+
+	for _, path := range stagedPaths(g.stagedFiles) {
+		existing, err := g.getContents(ctx, g.branch, path)
+		if err != nil {
+			return "", err
+		}
+		change := giteaChangeFile{
+			Operation: "create",
+			Path:      path,
+			Content:   base64.StdEncoding.EncodeToString(g.stagedFiles[path]),
+		}
+		if existing != nil {
+			change.Operation = "update"
+			change.SHA = existing.SHA
+		}
+		request.Files = append(request.Files, change)
+	}
+
+	var response struct {
+		Commit struct {
+			SHA string `json:"sha"`
+		} `json:"commit"`
+	}
+	if _, _, err := g.client.do(ctx, http.MethodPost, g.repoURL("/contents"), nil, request, &response); err != nil {
+		return "", fmt.Errorf("failed to commit: %w", err)
+	}
+
+	g.stagedFiles = make(map[string][]byte)
+	fmt.Printf("✅ Committed to Gitea: %s\n", shortSHA(response.Commit.SHA))
+	return shortSHA(response.Commit.SHA), nil
+}
+
+// CreatePullRequest opens a pull request through the pulls API.
+//
+// Parameters:
+//   - ctx: Cancels the request; git.timeout applies on top
+//   - pr: The source (Head) and target (Base) branches, title and body
+//
+// Returns:
+//   - *PullRequest: The new pull request
+//   - error: If the pull request can't be created
+func (g *GiteaBackend) CreatePullRequest(ctx context.Context, pr *PullRequest) (*PullRequest, error) {
+	request := map[string]string{
+		"head":  pr.Head,
+		"base":  pr.Base,
+		"title": pr.Title,
+		"body":  pr.Body,
+	}
+
+	var created githubPullRequest
+	if _, _, err := g.client.do(ctx, http.MethodPost, g.repoURL("/pulls"), nil, request, &created); err != nil {
+		return nil, fmt.Errorf("failed to open pull request from %s into %s: %w", pr.Head, pr.Base, err)
+	}
+	return created.toPullRequest(), nil
+}
+
+// MergedPullRequest finds the pull request merged as a commit.
+//
+// Parameters:
+//   - ctx: Cancels the requests; git.timeout applies to each
+//   - commit: Full commit hash
+//
+// Returns:
+//   - *PullRequest: The pull request with its files, or nil if commit
+//     didn't merge one
+//   - error: Any error encountered
+func (g *GiteaBackend) MergedPullRequest(ctx context.Context, commit string) (*PullRequest, error) {
+	var pull githubPullRequest
+	status, _, err := g.client.do(ctx, http.MethodGet, g.repoURL("/commits/%s/pull", commit), nil, nil, &pull)
+	if status == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up pull requests for commit %s: %w", commit, err)
+	}
+	if pull.MergedAt == nil || pull.MergeCommitSHA != commit {
+		return nil, nil
+	}
+
+	pr := pull.toPullRequest()
+
+	const perPage = 50
+	for page := 1; ; page++ {
+		query := url.Values{}
+		query.Set("limit", strconv.Itoa(perPage))
+		query.Set("page", strconv.Itoa(page))
+
+		var files []struct {
+			Filename         string `json:"filename"`
+			PreviousFilename string `json:"previous_filename"`
+		}
+		if _, _, err := g.client.do(ctx, http.MethodGet, g.repoURL("/pulls/%d/files", pull.Number), query, nil, &files); err != nil {
+			return nil, fmt.Errorf("failed to list files of pull request #%d: %w", pull.Number, err)
+		}
+		for _, f := range files {
+			pr.Files = append(pr.Files, f.Filename)
+			if f.PreviousFilename != "" {
+				pr.Files = append(pr.Files, f.PreviousFilename)
+			}
+		}
+		if len(files) < perPage {
+			break
+		}
+	}
+
+	return pr, nil
+}
+
+// Discard forgets the staged files.
+func (g *GiteaBackend) Discard() error {
+	g.stagedFiles = make(map[string][]byte)
+	return nil
+}
+
+// Pull is a no-op: every read goes to Gitea, so it is always up to date.
+func (g *GiteaBackend) Pull(ctx context.Context) error {
+	return nil
+}
+
+// Push is a no-op: commits are created on Gitea directly.
+func (g *GiteaBackend) Push(ctx context.Context) error {
+	return nil
+}
+
+// Close is a no-op: there are no resources to release.
+func (g *GiteaBackend) Close() error {
+	return nil
+}
+
+// GetName returns the name of this backend.
+func (g *GiteaBackend) GetName() string {
+	return "gitea-api"
+}
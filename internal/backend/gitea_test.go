@@ -0,0 +1,507 @@
+package backend
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/wlame/njgit/internal/config"
+	gitpkg "github.com/wlame/njgit/internal/git"
+)
+
+// TestNewGiteaBackend_Validation tests that NewGiteaBackend validates
+// required fields
+func TestNewGiteaBackend_Validation(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      *config.GitConfig
+		wantErrText string
+	}{
+		{
+			name:        "missing api_url",
+			config:      &config.GitConfig{Owner: "o", Repo: "r", Token: "t"},
+			wantErrText: "api_url is required",
+		},
+		{
+			name:        "missing owner",
+			config:      &config.GitConfig{APIURL: "https://gitea.example.com", Repo: "r", Token: "t"},
+			wantErrText: "owner is required",
+		},
+		{
+			name:        "missing repo",
+			config:      &config.GitConfig{APIURL: "https://gitea.example.com", Owner: "o", Token: "t"},
+			wantErrText: "repo is required",
+		},
+		{
+			name:        "missing token",
+			config:      &config.GitConfig{APIURL: "https://gitea.example.com", Owner: "o", Repo: "r"},
+			wantErrText: "GITEA_TOKEN",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewGiteaBackend(tt.config)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErrText) {
+				t.Errorf("NewGiteaBackend() error = %v, want it to contain %q", err, tt.wantErrText)
+			}
+		})
+	}
+
+	b, err := NewGiteaBackend(&config.GitConfig{APIURL: "https://gitea.example.com/", Owner: "o", Repo: "r", Token: "t"})
+	if err != nil {
+		t.Fatalf("NewGiteaBackend() unexpected error: %v", err)
+	}
+	if b.client.baseURL != "https://gitea.example.com/api/v1" || b.repoPath != "/repos/o/r" || b.branch != "main" || b.GetName() != "gitea-api" {
+		t.Errorf("base URL = %q, repo path = %q, branch = %q, name = %q", b.client.baseURL, b.repoPath, b.branch, b.GetName())
+	}
+}
+
+// fakeGitea serves the Gitea API for one repository
+// (test-owner/test-repo) from the in-memory store of fakeGitHub. Pull
+// requests are squash merged.
+type fakeGitea struct {
+	*fakeGitHub
+	commitRequests []giteaChangeFilesRequest
+	rejectBranchAt string // Creating a branch at this commit fails
+}
+
+func (f *fakeGitea) commitJSON(c *fakeCommit) map[string]interface{} {
+	parents := []map[string]string{}
+	if c.parent != "" {
+		parents = append(parents, map[string]string{"sha": c.parent})
+	}
+	files := []map[string]string{}
+	for _, file := range f.changedFiles(c) {
+		files = append(files, map[string]string{"filename": file})
+	}
+	return map[string]interface{}{
+		"sha": c.sha,
+		"commit": map[string]interface{}{
+			"message": c.message,
+			"author":  map[string]string{"name": "Test", "email": "test@example.com", "date": "2026-01-02T03:04:05Z"},
+		},
+		"parents": parents,
+		"files":   files,
+	}
+}
+
+func (f *fakeGitea) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	reply := func(status int, v interface{}) {
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(v)
+	}
+	notFound := func() { reply(http.StatusNotFound, map[string]string{"message": "The target couldn't be found."}) }
+	query := r.URL.Query()
+
+	if r.Header.Get("Authorization") != "token test-token" {
+		reply(http.StatusUnauthorized, map[string]string{"message": "token is required"})
+		return
+	}
+	path, ok := strings.CutPrefix(r.URL.Path, "/api/v1/repos/test-owner/test-repo")
+	if !ok {
+		notFound()
+		return
+	}
+
+	switch {
+	case path == "" && r.Method == http.MethodGet:
+		reply(http.StatusOK, map[string]interface{}{"id": 1, "full_name": "test-owner/test-repo"})
+
+	case path == "/contents" && r.Method == http.MethodPost:
+		var req giteaChangeFilesRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		f.commitRequests = append(f.commitRequests, req)
+		head := f.resolve(req.Branch)
+		if head == nil {
+			reply(http.StatusNotFound, map[string]string{"message": "branch does not exist"})
+			return
+		}
+		files := make(map[string]string)
+		for p, c := range head.files {
+			files[p] = c
+		}
+		for _, change := range req.Files {
+			old, exists := files[change.Path]
+			if (change.Operation == "create") == exists || (exists && change.SHA != fmt.Sprintf("blob-%x", len(old))) {
+				reply(http.StatusUnprocessableEntity, map[string]string{"message": "sha does not match"})
+				return
+			}
+			content, _ := base64.StdEncoding.DecodeString(change.Content)
+			files[change.Path] = string(content)
+		}
+		sha := f.addCommit(head.sha, req.Message, files)
+		f.refs["refs/heads/"+req.Branch] = sha
+		reply(http.StatusCreated, map[string]interface{}{"commit": map[string]string{"sha": sha}})
+
+	case strings.HasPrefix(path, "/contents/"):
+		file := strings.TrimPrefix(path, "/contents/")
+		commit := f.resolveAny(query.Get("ref"))
+		content, ok := "", false
+		if commit != nil {
+			content, ok = commit.files[file]
+		}
+		if !ok {
+			notFound()
+			return
+		}
+		reply(http.StatusOK, map[string]string{
+			"path":    file,
+			"type":    "file",
+			"sha":     fmt.Sprintf("blob-%x", len(content)),
+			"content": base64.StdEncoding.EncodeToString([]byte(content)),
+		})
+
+	case path == "/commits":
+		commits := []map[string]interface{}{}
+		if query.Get("page") == "1" {
+			for c := f.resolve(query.Get("sha")); c != nil; c = f.commits[c.parent] {
+				if p := query.Get("path"); p != "" && !contains(f.changedFiles(c), p) {
+					continue
+				}
+				commits = append(commits, f.commitJSON(c))
+			}
+		}
+		reply(http.StatusOK, commits)
+
+	case strings.HasPrefix(path, "/commits/") && strings.HasSuffix(path, "/pull"):
+		sha := strings.TrimSuffix(strings.TrimPrefix(path, "/commits/"), "/pull")
+		for _, pull := range f.pulls {
+			if pull.mergeCommit == sha {
+				reply(http.StatusOK, f.pullJSON(pull))
+				return
+			}
+		}
+		notFound()
+
+	case strings.HasPrefix(path, "/git/commits/"):
+		commit := f.resolveAny(strings.TrimPrefix(path, "/git/commits/"))
+		if commit == nil {
+			notFound()
+			return
+		}
+		reply(http.StatusOK, f.commitJSON(commit))
+
+	case strings.HasPrefix(path, "/git/trees/"):
+		commit := f.resolve(strings.TrimPrefix(path, "/git/trees/"))
+		entries := []map[string]string{}
+		for file := range commit.files {
+			entries = append(entries, map[string]string{"path": file, "type": "blob"})
+		}
+		reply(http.StatusOK, map[string]interface{}{"sha": commit.sha, "tree": entries, "truncated": false, "total_count": len(entries)})
+
+	case path == "/branches" && r.Method == http.MethodPost:
+		var req map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		branch := "refs/heads/" + req["new_branch_name"]
+		commit := f.resolve(req["old_ref_name"])
+		if _, exists := f.refs[branch]; exists {
+			reply(http.StatusConflict, map[string]string{"message": "The branch already exists."})
+			return
+		}
+		if commit == nil {
+			notFound()
+			return
+		}
+		if commit.sha == f.rejectBranchAt {
+			reply(http.StatusInternalServerError, map[string]string{"message": "internal error"})
+			return
+		}
+		f.refs[branch] = commit.sha
+		reply(http.StatusCreated, map[string]interface{}{"name": req["new_branch_name"], "commit": map[string]string{"id": commit.sha}})
+
+	case strings.HasPrefix(path, "/branches/"):
+		branch := "refs/heads/" + strings.TrimPrefix(path, "/branches/")
+		sha, exists := f.refs[branch]
+		if !exists {
+			notFound()
+			return
+		}
+		if r.Method == http.MethodDelete {
+			delete(f.refs, branch)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		reply(http.StatusOK, map[string]interface{}{"name": branch, "commit": map[string]string{"id": sha}})
+
+	case path == "/tags" && r.Method == http.MethodPost:
+		var req map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		tag := "refs/tags/" + req["tag_name"]
+		if _, exists := f.refs[tag]; exists {
+			reply(http.StatusConflict, map[string]string{"message": "tag already exists"})
+			return
+		}
+		f.refs[tag] = req["target"]
+		reply(http.StatusCreated, map[string]interface{}{"name": req["tag_name"], "message": req["message"], "commit": map[string]string{"sha": req["target"]}})
+
+	case path == "/tags":
+		tags := []map[string]interface{}{}
+		if query.Get("page") == "1" {
+			for ref, sha := range f.refs {
+				if name, ok := strings.CutPrefix(ref, "refs/tags/"); ok {
+					tags = append(tags, map[string]interface{}{"name": name, "commit": map[string]string{"sha": sha}})
+				}
+			}
+		}
+		reply(http.StatusOK, tags)
+
+	case strings.HasPrefix(path, "/tags/"):
+		name := strings.TrimPrefix(path, "/tags/")
+		sha, exists := f.refs["refs/tags/"+name]
+		if !exists {
+			notFound()
+			return
+		}
+		reply(http.StatusOK, map[string]interface{}{"name": name, "commit": map[string]string{"sha": sha}})
+
+	case strings.HasPrefix(path, "/compare/"):
+		revs := strings.SplitN(strings.TrimPrefix(path, "/compare/"), "...", 2)
+		since, head := f.resolve(revs[0]), f.resolve(revs[1])
+		if since == nil || head == nil {
+			notFound()
+			return
+		}
+		// Newest first, to check the backend orders them
+		commits := []map[string]interface{}{}
+		for c := head; c != nil && c.sha != since.sha; c = f.commits[c.parent] {
+			commits = append(commits, f.commitJSON(c))
+		}
+		reply(http.StatusOK, map[string]interface{}{"total_commits": len(commits), "commits": commits})
+
+	case path == "/pulls" && r.Method == http.MethodPost:
+		var req map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if _, ok := f.refs["refs/heads/"+req["head"]]; !ok {
+			reply(http.StatusNotFound, map[string]string{"message": "head branch not found"})
+			return
+		}
+		pull := &fakePull{number: len(f.pulls) + 1, title: req["title"], body: req["body"], head: req["head"], base: req["base"]}
+		f.pulls = append(f.pulls, pull)
+		reply(http.StatusCreated, f.pullJSON(pull))
+
+	case strings.HasPrefix(path, "/pulls/") && strings.HasSuffix(path, "/files"):
+		var number int
+		_, _ = fmt.Sscanf(path, "/pulls/%d/files", &number)
+		files := []map[string]string{}
+		if query.Get("page") == "1" {
+			for _, file := range f.pulls[number-1].files {
+				files = append(files, map[string]string{"filename": file})
+			}
+		}
+		reply(http.StatusOK, files)
+
+	default:
+		f.t.Errorf("unexpected request: %s %s", r.Method, r.URL)
+		notFound()
+	}
+}
+
+// newTestGitea starts a fake Gitea and a backend pointed at it
+func newTestGitea(t *testing.T, files map[string]string) (*fakeGitea, *GiteaBackend) {
+	t.Helper()
+	fake := &fakeGitea{fakeGitHub: newFakeGitHub(t, files)}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	b, err := NewGiteaBackend(&config.GitConfig{
+		APIURL:      server.URL,
+		Owner:       "test-owner",
+		Repo:        "test-repo",
+		Token:       "test-token",
+		Branch:      "main",
+		AuthorName:  "njgit",
+		AuthorEmail: "njgit@example.com",
+	})
+	if err != nil {
+		t.Fatalf("NewGiteaBackend() unexpected error: %v", err)
+	}
+	return fake, b
+}
+
+// TestGiteaBackend_Commit tests that staged files are committed in one
+// atomic commit through the change-files API, and read back
+func TestGiteaBackend_Commit(t *testing.T) {
+	const webFile, apiFile = "global/default/web.hcl", "global/default/api.hcl"
+	fake, b := newTestGitea(t, map[string]string{webFile: "job \"web\" { count = 1 }\n"})
+	ctx := context.Background()
+
+	if err := b.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize() unexpected error: %v", err)
+	}
+
+	if err := b.WriteFile(ctx, webFile, []byte("job \"web\" { count = 3 }\n")); err != nil {
+		t.Fatalf("WriteFile() unexpected error: %v", err)
+	}
+	if err := b.WriteFile(ctx, apiFile, []byte("job \"api\" {}\n")); err != nil {
+		t.Fatalf("WriteFile() unexpected error: %v", err)
+	}
+	author := &gitpkg.Identity{Name: "Jane Doe", Email: "jane@example.com"}
+	hash, err := b.Commit(ctx, "Update jobs", author)
+	if err != nil {
+		t.Fatalf("Commit() unexpected error: %v", err)
+	}
+
+	if len(fake.commitRequests) != 1 {
+		t.Fatalf("Commit() made %d commit requests, want 1", len(fake.commitRequests))
+	}
+	req := fake.commitRequests[0]
+	if len(req.Files) != 2 ||
+		req.Files[0].Path != apiFile || req.Files[0].Operation != "create" ||
+		req.Files[1].Path != webFile || req.Files[1].Operation != "update" || req.Files[1].SHA == "" {
+		t.Errorf("commit files = %+v, want create %s and update %s", req.Files, apiFile, webFile)
+	}
+	if req.Author == nil || req.Author.Name != "Jane Doe" || req.Committer == nil || req.Committer.Name != "njgit" {
+		t.Errorf("commit author = %+v, committer = %+v; want Jane Doe committed by njgit", req.Author, req.Committer)
+	}
+
+	head := fake.refs["refs/heads/main"]
+	if hash != head[:8] {
+		t.Errorf("Commit() = %q, want %q", hash, head[:8])
+	}
+	content, err := b.ReadFile(ctx, webFile)
+	if err != nil || string(content) != "job \"web\" { count = 3 }\n" {
+		t.Errorf("ReadFile() = %q, %v", content, err)
+	}
+	if exists, err := b.FileExists(ctx, "global/default/missing.hcl"); err != nil || exists {
+		t.Errorf("FileExists(missing) = %v, %v", exists, err)
+	}
+
+	info, err := b.ResolveRevision(ctx, "main")
+	if err != nil || info.FullHash != head || len(info.Files) != 2 {
+		t.Errorf("ResolveRevision() = %+v, %v; want both files in one commit", info, err)
+	}
+	history, err := b.History(ctx, webFile, 0)
+	if err != nil || len(history) != 2 || history[0].FullHash != head {
+		t.Errorf("History(%s) = %+v, %v", webFile, history, err)
+	}
+	if history, _ := b.History(ctx, "", 1); len(history) != 1 {
+		t.Errorf("History(limit 1) returned %d commits", len(history))
+	}
+	old, err := b.ReadFileAt(ctx, history[1].FullHash, webFile)
+	if err != nil || string(old) != "job \"web\" { count = 1 }\n" {
+		t.Errorf("ReadFileAt(initial) = %q, %v", old, err)
+	}
+	files, err := b.ListFiles(ctx, "main", "global/default")
+	if err != nil || len(files) != 2 || files[0] != apiFile {
+		t.Errorf("ListFiles() = %v, %v", files, err)
+	}
+	if _, err := b.ListFiles(ctx, "no-such-rev", ""); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("ListFiles(missing revision) error = %v", err)
+	}
+}
+
+// TestGiteaBackend_UpdateRefRestores tests that a ref whose new branch
+// can't be created keeps pointing at its old commit
+func TestGiteaBackend_UpdateRefRestores(t *testing.T) {
+	fake, b := newTestGitea(t, map[string]string{"global/default/web.hcl": "job \"web\" {}\n"})
+	ctx := context.Background()
+	appliedRef := gitpkg.AppliedRef("main")
+
+	if err := b.UpdateRef(ctx, appliedRef, "main"); err != nil {
+		t.Fatalf("UpdateRef() unexpected error: %v", err)
+	}
+	start := fake.refs["refs/heads/main"]
+	next := fake.commitFile("main", "global/default/web.hcl", "job \"web\" { count = 2 }\n", "Update global/default/web")
+
+	fake.rejectBranchAt = next
+	err := b.UpdateRef(ctx, appliedRef, "main")
+	if err == nil || !strings.Contains(err.Error(), "left at "+start[:8]) {
+		t.Errorf("UpdateRef() error = %v, want it left at %s", err, start[:8])
+	}
+	if applied, _ := b.ReadRef(ctx, appliedRef); applied != start {
+		t.Errorf("ReadRef() after a failed UpdateRef = %q, want %s", applied, start)
+	}
+
+	fake.rejectBranchAt = ""
+	if err := b.UpdateRef(ctx, appliedRef, "main"); err != nil {
+		t.Fatalf("UpdateRef() unexpected error: %v", err)
+	}
+	if applied, _ := b.ReadRef(ctx, appliedRef); applied != next {
+		t.Errorf("ReadRef() after UpdateRef = %q, want %s", applied, next)
+	}
+}
+
+// TestGiteaBackend_RefsAndPullRequests tests branches, tags, refs and
+// finding merged pull requests against a fake Gitea
+func TestGiteaBackend_RefsAndPullRequests(t *testing.T) {
+	const jobFile = "global/default/web.hcl"
+	fake, b := newTestGitea(t, map[string]string{jobFile: "job \"web\" { count = 1 }\n"})
+	ctx := context.Background()
+	var forge Forge = b
+
+	appliedRef := gitpkg.AppliedRef("main")
+	if applied, err := b.ReadRef(ctx, appliedRef); err != nil || applied != "" {
+		t.Fatalf("ReadRef() = %q, %v; want no ref", applied, err)
+	}
+	if err := b.UpdateRef(ctx, appliedRef, "main"); err != nil {
+		t.Fatalf("UpdateRef() unexpected error: %v", err)
+	}
+	start := fake.refs["refs/heads/main"]
+	if applied, _ := b.ReadRef(ctx, appliedRef); applied != start {
+		t.Fatalf("ReadRef() after UpdateRef = %q, want %s", applied, start)
+	}
+
+	tagName := "deploy/global/default/web/20260102T030405Z"
+	if tag, err := b.CreateTag(ctx, tagName, "main", "Deployed"); err != nil || tag.Commit != start {
+		t.Fatalf("CreateTag() = %+v, %v", tag, err)
+	}
+	if _, err := b.CreateTag(ctx, tagName, "main", "Again"); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("CreateTag(existing) error = %v", err)
+	}
+	if _, err := b.CreateTag(ctx, "release/1", "main", "Release"); err != nil {
+		t.Fatalf("CreateTag() unexpected error: %v", err)
+	}
+	tags, err := b.ListTags(ctx, "deploy/")
+	if err != nil || len(tags) != 1 || tags[0].Name != tagName {
+		t.Errorf("ListTags(deploy/) = %+v, %v", tags, err)
+	}
+
+	if err := b.UseBranch(ctx, "njgit/propose/web"); err != nil {
+		t.Fatalf("UseBranch() unexpected error: %v", err)
+	}
+	if err := b.WriteFile(ctx, jobFile, []byte("job \"web\" { count = 3 }\n")); err != nil {
+		t.Fatalf("WriteFile() unexpected error: %v", err)
+	}
+	if _, err := b.Commit(ctx, "Update global/default/web", nil); err != nil {
+		t.Fatalf("Commit() unexpected error: %v", err)
+	}
+	pr, err := forge.CreatePullRequest(ctx, &PullRequest{Title: "Scale web", Head: "njgit/propose/web", Base: "main"})
+	if err != nil || pr.Number != 1 || pr.Base != "main" {
+		t.Fatalf("CreatePullRequest() = %+v, %v", pr, err)
+	}
+
+	mergeSHA := fake.merge(1)
+	fake.commitFile("main", "global/default/api.hcl", "job \"api\" {}\n", "Update global/default/api")
+
+	commits, err := b.CommitsSince(ctx, start, "main")
+	if err != nil {
+		t.Fatalf("CommitsSince() unexpected error: %v", err)
+	}
+	if len(commits) != 2 || commits[0].FullHash != mergeSHA || commits[1].Message != "Update global/default/api" {
+		t.Fatalf("CommitsSince() = %+v, want the merge then the direct commit", commits)
+	}
+
+	merged, err := forge.MergedPullRequest(ctx, mergeSHA)
+	if err != nil {
+		t.Fatalf("MergedPullRequest() unexpected error: %v", err)
+	}
+	if merged == nil || merged.Number != 1 || len(merged.Files) != 1 || merged.Files[0] != jobFile {
+		t.Fatalf("MergedPullRequest() = %+v, want #1 changing %s", merged, jobFile)
+	}
+	if merged, err := forge.MergedPullRequest(ctx, commits[1].FullHash); err != nil || merged != nil {
+		t.Errorf("MergedPullRequest() of a direct commit = %+v, %v; want nil", merged, err)
+	}
+
+	if err := b.UpdateRef(ctx, appliedRef, "main"); err != nil {
+		t.Fatalf("UpdateRef() unexpected error: %v", err)
+	}
+	if applied, _ := b.ReadRef(ctx, appliedRef); applied != fake.refs["refs/heads/main"] {
+		t.Errorf("ReadRef() = %q, want main's head %s", applied, fake.refs["refs/heads/main"])
+	}
+}
@@ -2,16 +2,21 @@ package backend
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/wlame/njgit/internal/config"
+	gitpkg "github.com/wlame/njgit/internal/git"
 )
 
 // GitHubBackend implements the Backend interface using the GitHub REST API.
@@ -20,13 +25,17 @@ import (
 //
 // Key features:
 // - No local Git repository required
-// - Direct API calls to GitHub
+// - Direct API calls to GitHub or GitHub Enterprise Server (git.api_url)
 // - Automatic commit creation on WriteFile
-// - Requires GitHub personal access token for authentication
+// - Authenticates with a personal access token or as a GitHub App
+// - Retries rate-limited requests and makes reads conditional (ETags)
 type GitHubBackend struct {
 	config      *config.GitConfig
-	httpClient  *http.Client
-	baseURL     string
+	branch      string            // Branch read from and committed to (git.branch, or UseBranch)
+	httpClient  *http.Client      // Through githubTransport: auth, timeouts, retries, ETags
+	cache       *githubCache      // Responses with ETags, saved by Close
+	apiURL      string            // Repository API root: .../repos/{owner}/{repo}
+	baseURL     string            // Contents API: {apiURL}/contents
 	stagedFiles map[string][]byte // Map of path -> content for files to commit
 	fileSHAs    map[string]string // Map of path -> SHA for existing files (needed for updates)
 }
@@ -49,6 +58,7 @@ type githubCommitRequest struct {
 	Content   string           `json:"content"` // Base64 encoded
 	Branch    string           `json:"branch"`
 	SHA       string           `json:"sha,omitempty"` // Required for updates, omit for new files
+	Author    *githubCommitter `json:"author,omitempty"`
 	Committer *githubCommitter `json:"committer,omitempty"`
 }
 
@@ -68,6 +78,84 @@ type githubCommitResponse struct {
 	} `json:"commit"`
 }
 
+// githubCommit represents a commit from GET /repos/{owner}/{repo}/commits
+// Files is only filled in when a single commit is requested
+type githubCommit struct {
+	SHA    string `json:"sha"`
+	Commit struct {
+		Message string `json:"message"`
+		Author  struct {
+			Name  string    `json:"name"`
+			Email string    `json:"email"`
+			Date  time.Time `json:"date"`
+		} `json:"author"`
+		Committer struct {
+			Name  string `json:"name"`
+			Email string `json:"email"`
+		} `json:"committer"`
+		Verification struct {
+			Verified  bool   `json:"verified"`
+			Reason    string `json:"reason"`
+			Signature string `json:"signature"`
+		} `json:"verification"`
+	} `json:"commit"`
+	Files []struct {
+		Filename string `json:"filename"`
+	} `json:"files"`
+}
+
+// githubTreeResponse represents the response from
+// GET /repos/{owner}/{repo}/git/trees/{sha}?recursive=1
+type githubTreeResponse struct {
+	SHA  string `json:"sha"`
+	Tree []struct {
+		Path string `json:"path"`
+		Type string `json:"type"` // "blob", "tree" or "commit" (submodule)
+	} `json:"tree"`
+	Truncated bool `json:"truncated"`
+}
+
+// githubCompareResponse represents the response from
+// GET /repos/{owner}/{repo}/compare/{base}...{head}
+type githubCompareResponse struct {
+	Status       string         `json:"status"` // "ahead", "behind", "diverged" or "identical"
+	TotalCommits int            `json:"total_commits"`
+	Commits      []githubCommit `json:"commits"` // Oldest first
+}
+
+// githubPullRequest represents a pull request from the pulls API
+type githubPullRequest struct {
+	Number  int    `json:"number"`
+	Title   string `json:"title"`
+	Body    string `json:"body"`
+	HTMLURL string `json:"html_url"`
+	Head    struct {
+		Ref string `json:"ref"`
+	} `json:"head"`
+	Base struct {
+		Ref string `json:"ref"`
+	} `json:"base"`
+	MergedAt       *time.Time `json:"merged_at"`
+	MergeCommitSHA string     `json:"merge_commit_sha"`
+}
+
+// toPullRequest converts a pull request from the pulls API
+func (p *githubPullRequest) toPullRequest() *PullRequest {
+	pr := &PullRequest{
+		Number: p.Number,
+		Title:  p.Title,
+		Body:   p.Body,
+		Head:   p.Head.Ref,
+		Base:   p.Base.Ref,
+		URL:    p.HTMLURL,
+	}
+	if p.MergedAt != nil {
+		pr.MergeCommit = p.MergeCommitSHA
+		pr.MergedAt = *p.MergedAt
+	}
+	return pr
+}
+
 // githubErrorResponse represents an error response from the GitHub API
 type githubErrorResponse struct {
 	Message       string `json:"message"`
@@ -75,9 +163,12 @@ type githubErrorResponse struct {
 }
 
 // NewGitHubBackend creates a new GitHub API backend.
+// With app_id set it authenticates as that GitHub App's installation on
+// the repository, otherwise with the token.
 //
 // Parameters:
-//   - cfg: The Git configuration containing GitHub API settings (owner, repo, token, branch)
+//   - cfg: The Git configuration containing GitHub API settings (owner, repo,
+//     token or app, branch, api_url)
 //
 // Returns:
 //   - *GitHubBackend: A new GitHub backend instance
@@ -90,8 +181,8 @@ func NewGitHubBackend(cfg *config.GitConfig) (*GitHubBackend, error) {
 	if cfg.Repo == "" {
 		return nil, fmt.Errorf("github repo is required for github-api backend")
 	}
-	if cfg.Token == "" {
-		return nil, fmt.Errorf("github token is required for github-api backend (set via GITHUB_TOKEN or GH_TOKEN env var)")
+	if cfg.Token == "" && cfg.AppID == 0 {
+		return nil, fmt.Errorf("github token is required for github-api backend (set via GITHUB_TOKEN or GH_TOKEN env var, or configure a GitHub App)")
 	}
 
 	// Default branch to "main" if not specified
@@ -99,19 +190,40 @@ func NewGitHubBackend(cfg *config.GitConfig) (*GitHubBackend, error) {
 		cfg.Branch = "main"
 	}
 
-	// Create HTTP client with timeout
-	httpClient := &http.Client{
-		Timeout: 30 * time.Second,
+	// Construct base URLs for API calls
+	// GitHub API v3: https://api.github.com/repos/{owner}/{repo}/contents/{path}
+	root := githubAPIRoot(cfg.APIURL)
+	repoPath := fmt.Sprintf("/repos/%s/%s", cfg.Owner, cfg.Repo)
+	apiURL := root + repoPath
+
+	var credentials githubCredentials = githubToken(cfg.Token)
+	if cfg.AppID != 0 {
+		key, err := loadGitHubAppKey(cfg.AppPrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		appJWT := &githubAppJWT{appID: cfg.AppID, key: key, now: time.Now}
+		credentials = &githubAppInstallation{
+			client:         &http.Client{Transport: newGitHubTransport(appJWT, nil, cfg.Timeout)},
+			apiRoot:        root,
+			repoPath:       repoPath,
+			installationID: cfg.AppInstallationID,
+			now:            time.Now,
+		}
 	}
 
-	// Construct base URL for API calls
-	// GitHub API v3: https://api.github.com/repos/{owner}/{repo}/contents/{path}
-	baseURL := fmt.Sprintf("https://api.github.com/repos/%s/%s/contents", cfg.Owner, cfg.Repo)
+	// Requests are bounded by a per-attempt context deadline instead of
+	// http.Client.Timeout, so callers can cancel them as well
+	cache := newGitHubCache(githubCachePath(cfg.CacheDir, root, cfg.Owner, cfg.Repo))
+	httpClient := &http.Client{Transport: newGitHubTransport(credentials, cache, cfg.Timeout)}
 
 	return &GitHubBackend{
 		config:      cfg,
+		branch:      cfg.Branch,
 		httpClient:  httpClient,
-		baseURL:     baseURL,
+		cache:       cache,
+		apiURL:      apiURL,
+		baseURL:     apiURL + "/contents",
 		stagedFiles: make(map[string][]byte),
 		fileSHAs:    make(map[string]string),
 	}, nil
@@ -120,18 +232,19 @@ func NewGitHubBackend(cfg *config.GitConfig) (*GitHubBackend, error) {
 // Initialize validates the GitHub API credentials and repository access.
 // For the GitHub backend, this checks that we can access the repository.
 //
+// Parameters:
+//   - ctx: Cancels the request; git.timeout applies on top
+//
 // Returns:
 //   - error: Any error encountered during initialization
-func (g *GitHubBackend) Initialize() error {
+func (g *GitHubBackend) Initialize(ctx context.Context) error {
 	// Try to get the repository root to verify access
 	// We use a HEAD request to avoid downloading content
-	req, err := http.NewRequest("HEAD", g.baseURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "HEAD", g.baseURL, nil)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
-	// Add authentication header
-	req.Header.Set("Authorization", "token "+g.config.Token)
 	req.Header.Set("Accept", "application/vnd.github.v3+json")
 
 	// Make the request
@@ -158,28 +271,27 @@ func (g *GitHubBackend) Initialize() error {
 // ReadFile reads a file from the GitHub repository using the API.
 //
 // Parameters:
+//   - ctx: Cancels the request; git.timeout applies on top
 //   - path: The file path relative to repository root (e.g., "default/web-app.hcl")
 //
 // Returns:
 //   - []byte: The file content
 //   - error: Any error encountered during reading
-func (g *GitHubBackend) ReadFile(path string) ([]byte, error) {
+func (g *GitHubBackend) ReadFile(ctx context.Context, path string) ([]byte, error) {
 	// Construct URL for this specific file
 	url := fmt.Sprintf("%s/%s", g.baseURL, path)
 
 	// Create request
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	// Add authentication and headers
-	req.Header.Set("Authorization", "token "+g.config.Token)
 	req.Header.Set("Accept", "application/vnd.github.v3+json")
 
 	// Add branch parameter
 	q := req.URL.Query()
-	q.Add("ref", g.config.Branch)
+	q.Add("ref", g.branch)
 	req.URL.RawQuery = q.Encode()
 
 	// Make the request
@@ -231,12 +343,13 @@ func (g *GitHubBackend) ReadFile(path string) ([]byte, error) {
 // The file is NOT immediately written - it's staged for the next Commit() call.
 //
 // Parameters:
+//   - ctx: Unused; staging makes no requests
 //   - path: The file path relative to repository root (e.g., "default/web-app.hcl")
 //   - content: The file content to write
 //
 // Returns:
 //   - error: Any error encountered during staging
-func (g *GitHubBackend) WriteFile(path string, content []byte) error {
+func (g *GitHubBackend) WriteFile(ctx context.Context, path string, content []byte) error {
 	// Validate path
 	if path == "" {
 		return fmt.Errorf("file path cannot be empty")
@@ -255,28 +368,27 @@ func (g *GitHubBackend) WriteFile(path string, content []byte) error {
 // FileExists checks if a file exists in the GitHub repository.
 //
 // Parameters:
+//   - ctx: Cancels the request; git.timeout applies on top
 //   - path: The file path relative to repository root
 //
 // Returns:
 //   - bool: true if the file exists, false otherwise
 //   - error: Any error encountered during the check
-func (g *GitHubBackend) FileExists(path string) (bool, error) {
+func (g *GitHubBackend) FileExists(ctx context.Context, path string) (bool, error) {
 	// Construct URL for this specific file
 	url := fmt.Sprintf("%s/%s", g.baseURL, path)
 
 	// Create HEAD request (don't download content)
-	req, err := http.NewRequest("HEAD", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "HEAD", url, nil)
 	if err != nil {
 		return false, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	// Add authentication and headers
-	req.Header.Set("Authorization", "token "+g.config.Token)
 	req.Header.Set("Accept", "application/vnd.github.v3+json")
 
 	// Add branch parameter
 	q := req.URL.Query()
-	q.Add("ref", g.config.Branch)
+	q.Add("ref", g.branch)
 	req.URL.RawQuery = q.Encode()
 
 	// Make the request
@@ -300,141 +412,848 @@ func (g *GitHubBackend) FileExists(path string) (bool, error) {
 	return false, fmt.Errorf("github API error: status %d", resp.StatusCode)
 }
 
+// History lists the commits on the branch through the commits API,
+// newest first. The list endpoint doesn't include changed files, so each
+// commit is fetched once more for its files.
+//
+// Parameters:
+//   - ctx: Cancels the requests; git.timeout applies to each
+//   - path: Only commits that changed this file ("" for all commits)
+//   - limit: Maximum number of commits (0 for unlimited)
+//
+// Returns:
+//   - []gitpkg.CommitInfo: The commits
+//   - error: Any error encountered
+func (g *GitHubBackend) History(ctx context.Context, path string, limit int) ([]gitpkg.CommitInfo, error) {
+	perPage := 100
+	if limit > 0 && limit < perPage {
+		perPage = limit
+	}
+
+	var commits []gitpkg.CommitInfo
+	for page := 1; ; page++ {
+		query := url.Values{}
+		query.Set("sha", g.branch)
+		if path != "" {
+			query.Set("path", path)
+		}
+		query.Set("per_page", strconv.Itoa(perPage))
+		query.Set("page", strconv.Itoa(page))
+
+		var listed []githubCommit
+		status, err := g.getJSON(ctx, g.apiURL+"/commits", query, &listed)
+		if err != nil {
+			// An empty repository has no branch to list
+			if status == http.StatusConflict {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("failed to list commits: %w", err)
+		}
+
+		for _, c := range listed {
+			info, err := g.ResolveRevision(ctx, c.SHA)
+			if err != nil {
+				return nil, err
+			}
+			commits = append(commits, *info)
+			if limit > 0 && len(commits) >= limit {
+				return commits, nil
+			}
+		}
+
+		if len(listed) < perPage {
+			return commits, nil
+		}
+	}
+}
+
+// ReadFileAt reads a file as it was at a revision through the contents API.
+//
+// Parameters:
+//   - ctx: Cancels the requests; git.timeout applies to each
+//   - rev: Commit hash (full or abbreviated), branch or tag
+//   - path: The file path relative to repository root
+//
+// Returns:
+//   - []byte: The file content at that revision
+//   - error: If the revision or the file doesn't exist
+func (g *GitHubBackend) ReadFileAt(ctx context.Context, rev, path string) ([]byte, error) {
+	commit, err := g.ResolveRevision(ctx, rev)
+	if err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	query.Set("ref", commit.FullHash)
+
+	var fileResp githubFileResponse
+	status, err := g.getJSON(ctx, fmt.Sprintf("%s/%s", g.baseURL, path), query, &fileResp)
+	if status == http.StatusNotFound {
+		return nil, fmt.Errorf("file %s not found at commit %s", path, rev)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s at commit %s: %w", path, rev, err)
+	}
+	if fileResp.Type != "file" {
+		return nil, fmt.Errorf("%s is not a file at commit %s", path, rev)
+	}
+
+	content, err := base64.StdEncoding.DecodeString(fileResp.Content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode file content: %w", err)
+	}
+
+	return content, nil
+}
+
+// ListFiles lists the files under a directory at a revision through the
+// git trees API.
+//
+// Parameters:
+//   - ctx: Cancels the requests; git.timeout applies to each
+//   - rev: Commit hash (full or abbreviated), branch or tag
+//   - dir: Relative directory path ("" for all files)
+//
+// Returns:
+//   - []string: Paths relative to the repository root, sorted
+//   - error: Any error encountered, including a tree too large for the API
+func (g *GitHubBackend) ListFiles(ctx context.Context, rev, dir string) ([]string, error) {
+	commit, err := g.ResolveRevision(ctx, rev)
+	if err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	query.Set("recursive", "1")
+
+	var treeResp githubTreeResponse
+	if _, err := g.getJSON(ctx, fmt.Sprintf("%s/git/trees/%s", g.apiURL, commit.FullHash), query, &treeResp); err != nil {
+		return nil, fmt.Errorf("failed to list files at commit %s: %w", rev, err)
+	}
+	if treeResp.Truncated {
+		return nil, fmt.Errorf("failed to list files at commit %s: tree is too large for the GitHub API", rev)
+	}
+
+	prefix := strings.Trim(dir, "/")
+	if prefix == "." {
+		prefix = ""
+	}
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	files := []string{}
+	for _, entry := range treeResp.Tree {
+		if entry.Type == "blob" && strings.HasPrefix(entry.Path, prefix) {
+			files = append(files, entry.Path)
+		}
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// ResolveRevision looks up a commit through the commits API.
+//
+// Parameters:
+//   - ctx: Cancels the request; git.timeout applies on top
+//   - rev: Commit hash (full or abbreviated), branch or tag
+//
+// Returns:
+//   - *gitpkg.CommitInfo: The commit, with the files it changed
+//   - error: If the revision doesn't name a commit
+func (g *GitHubBackend) ResolveRevision(ctx context.Context, rev string) (*gitpkg.CommitInfo, error) {
+	var commit githubCommit
+	status, err := g.getJSON(ctx, fmt.Sprintf("%s/commits/%s", g.apiURL, rev), nil, &commit)
+	if status == http.StatusNotFound || status == http.StatusUnprocessableEntity {
+		return nil, fmt.Errorf("revision %s not found", rev)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit %s: %w", rev, err)
+	}
+
+	return githubCommitInfo(&commit), nil
+}
+
+// githubCommitInfo converts a commit from the commits or compare API
+func githubCommitInfo(commit *githubCommit) *gitpkg.CommitInfo {
+	shortHash := commit.SHA
+	if len(shortHash) > 8 {
+		shortHash = shortHash[:8]
+	}
+
+	var files []string
+	for _, f := range commit.Files {
+		files = append(files, f.Filename)
+	}
+
+	return &gitpkg.CommitInfo{
+		Hash:     shortHash,
+		FullHash: commit.SHA,
+		Message:  strings.TrimSpace(commit.Commit.Message),
+		Author:   commit.Commit.Author.Name,
+		Email:    commit.Commit.Author.Email,
+		Date:     commit.Commit.Author.Date,
+		Files:    files,
+
+		Signature: githubSignature(commit),
+		Job:       gitpkg.ParseJobTrailers(commit.Commit.Message),
+	}
+}
+
+// githubSignature converts GitHub's verification of a commit signature
+// GitHub checks that the signing key belongs to the committer's account,
+// so a verified commit is reported as signed by the committer.
+func githubSignature(commit *githubCommit) gitpkg.SignatureInfo {
+	verification := commit.Commit.Verification
+	if verification.Signature == "" || verification.Reason == "unsigned" {
+		return gitpkg.SignatureInfo{Status: gitpkg.SignatureUnsigned}
+	}
+
+	info := gitpkg.SignatureInfo{Type: "gpg"}
+	switch {
+	case strings.HasPrefix(verification.Signature, "-----BEGIN SSH SIGNATURE-----"):
+		info.Type = "ssh"
+	case strings.HasPrefix(verification.Signature, "-----BEGIN SIGNED MESSAGE-----"):
+		info.Type = "x509"
+	}
+
+	switch {
+	case verification.Verified:
+		info.Status = gitpkg.SignatureVerified
+		info.Signer = fmt.Sprintf("%s <%s>", commit.Commit.Committer.Name, commit.Commit.Committer.Email)
+	case verification.Reason == "invalid" || verification.Reason == "malformed_signature":
+		info.Status = gitpkg.SignatureBad
+	default:
+		// e.g. unknown_key, no_user, unverified_email, expired_key
+		info.Status = gitpkg.SignatureUnverified
+		info.Signer = verification.Reason
+	}
+
+	return info
+}
+
+// githubRef represents a reference from GET /repos/{owner}/{repo}/git/ref(s)
+type githubRef struct {
+	Ref    string `json:"ref"`
+	Object struct {
+		SHA  string `json:"sha"`
+		Type string `json:"type"` // "commit" or "tag" (annotated tag object)
+	} `json:"object"`
+}
+
+// githubTag represents an annotated tag object from
+// GET/POST /repos/{owner}/{repo}/git/tags
+type githubTag struct {
+	SHA     string        `json:"sha,omitempty"`
+	Tag     string        `json:"tag"`
+	Message string        `json:"message"`
+	Tagger  *githubTagger `json:"tagger,omitempty"`
+	Object  struct {
+		SHA  string `json:"sha"`
+		Type string `json:"type"`
+	} `json:"object"`
+}
+
+// githubTagger represents who created an annotated tag, and when
+type githubTagger struct {
+	Name  string    `json:"name"`
+	Email string    `json:"email"`
+	Date  time.Time `json:"date"`
+}
+
+// UseBranch switches the branch the API calls read from and commit to,
+// creating it from the current branch if it doesn't exist.
+//
+// Parameters:
+//   - ctx: Cancels the requests; git.timeout applies to each
+//   - branch: Branch name, e.g. "env/prod"
+//
+// Returns:
+//   - error: If the branch can't be looked up or created
+func (g *GitHubBackend) UseBranch(ctx context.Context, branch string) error {
+	if branch == g.branch {
+		return nil
+	}
+
+	var ref githubRef
+	status, err := g.getJSON(ctx, fmt.Sprintf("%s/git/ref/heads/%s", g.apiURL, branch), nil, &ref)
+	switch {
+	case status == http.StatusNotFound:
+		var current githubRef
+		if _, err := g.getJSON(ctx, fmt.Sprintf("%s/git/ref/heads/%s", g.apiURL, g.branch), nil, &current); err != nil {
+			return fmt.Errorf("failed to look up branch %s: %w", g.branch, err)
+		}
+		create := map[string]string{"ref": "refs/heads/" + branch, "sha": current.Object.SHA}
+		if _, err := g.postJSON(ctx, g.apiURL+"/git/refs", create, nil); err != nil {
+			return fmt.Errorf("failed to create branch %s: %w", branch, err)
+		}
+		fmt.Printf("🌿 Created branch on GitHub: %s\n", branch)
+	case err != nil:
+		return fmt.Errorf("failed to look up branch %s: %w", branch, err)
+	default:
+		fmt.Printf("🌿 Using branch on GitHub: %s\n", branch)
+	}
+
+	// File SHAs belong to the old branch
+	g.branch = branch
+	g.fileSHAs = make(map[string]string)
+	return nil
+}
+
+// CreateTag creates an annotated tag through the git data API: a tag
+// object, then a reference to it.
+//
+// Parameters:
+//   - ctx: Cancels the requests; git.timeout applies to each
+//   - name: Tag name, e.g. "deploy/global/default/web/20240115T143022Z"
+//   - rev: The revision to tag
+//   - message: The tag message
+//
+// Returns:
+//   - *gitpkg.TagInfo: The new tag
+//   - error: If the tag exists or the revision doesn't
+func (g *GitHubBackend) CreateTag(ctx context.Context, name, rev, message string) (*gitpkg.TagInfo, error) {
+	commit, err := g.ResolveRevision(ctx, rev)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check first so a duplicate doesn't leave a dangling tag object behind
+	var existing githubRef
+	if status, _ := g.getJSON(ctx, fmt.Sprintf("%s/git/ref/tags/%s", g.apiURL, name), nil, &existing); status == http.StatusOK {
+		return nil, fmt.Errorf("tag %s already exists", name)
+	}
+
+	tag := githubTag{Tag: name, Message: message}
+	tag.Object.SHA = commit.FullHash
+	tag.Object.Type = "commit"
+	if g.config.AuthorName != "" && g.config.AuthorEmail != "" {
+		tag.Tagger = &githubTagger{Name: g.config.AuthorName, Email: g.config.AuthorEmail, Date: time.Now().UTC().Truncate(time.Second)}
+	}
+
+	var created githubTag
+	if _, err := g.postJSON(ctx, g.apiURL+"/git/tags", tag, &created); err != nil {
+		return nil, fmt.Errorf("failed to create tag %s: %w", name, err)
+	}
+
+	ref := map[string]string{"ref": "refs/tags/" + name, "sha": created.SHA}
+	status, err := g.postJSON(ctx, g.apiURL+"/git/refs", ref, nil)
+	if status == http.StatusUnprocessableEntity {
+		return nil, fmt.Errorf("tag %s already exists", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tag %s: %w", name, err)
+	}
+
+	return githubTagInfo(name, &created), nil
+}
+
+// ListTags lists the tags whose names start with prefix through the
+// matching-refs API; annotated tags are looked up for their messages.
+//
+// Parameters:
+//   - ctx: Cancels the requests; git.timeout applies to each
+//   - prefix: Name prefix ("" for all tags)
+//
+// Returns:
+//   - []gitpkg.TagInfo: The tags, sorted by name
+//   - error: Any error encountered
+func (g *GitHubBackend) ListTags(ctx context.Context, prefix string) ([]gitpkg.TagInfo, error) {
+	var refs []githubRef
+	if _, err := g.getJSON(ctx, fmt.Sprintf("%s/git/matching-refs/tags/%s", g.apiURL, prefix), nil, &refs); err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+
+	tags := make([]gitpkg.TagInfo, 0, len(refs))
+	for _, ref := range refs {
+		name := strings.TrimPrefix(ref.Ref, "refs/tags/")
+		if ref.Object.Type != "tag" {
+			tags = append(tags, gitpkg.TagInfo{Name: name, Commit: ref.Object.SHA})
+			continue
+		}
+
+		var tag githubTag
+		if _, err := g.getJSON(ctx, fmt.Sprintf("%s/git/tags/%s", g.apiURL, ref.Object.SHA), nil, &tag); err != nil {
+			return nil, fmt.Errorf("failed to read tag %s: %w", name, err)
+		}
+		tags = append(tags, *githubTagInfo(name, &tag))
+	}
+
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+	return tags, nil
+}
+
+// ReadRef returns the commit a reference points to through the git
+// references API.
+//
+// Parameters:
+//   - ctx: Cancels the request; git.timeout applies on top
+//   - name: Full reference name, e.g. "refs/njgit/applied/main"
+//
+// Returns:
+//   - string: The full commit hash, or "" if the reference doesn't exist
+//   - error: Any other error
+func (g *GitHubBackend) ReadRef(ctx context.Context, name string) (string, error) {
+	var ref githubRef
+	status, err := g.getJSON(ctx, fmt.Sprintf("%s/git/ref/%s", g.apiURL, strings.TrimPrefix(name, "refs/")), nil, &ref)
+	if status == http.StatusNotFound {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	return ref.Object.SHA, nil
+}
+
+// UpdateRef points a reference at a commit, creating the reference if it
+// doesn't exist. The update is forced: the new commit doesn't have to
+// contain the old one.
+//
+// Parameters:
+//   - ctx: Cancels the requests; git.timeout applies to each
+//   - name: Full reference name
+//   - rev: The revision to point it at
+//
+// Returns:
+//   - error: If the revision doesn't exist or the update fails
+func (g *GitHubBackend) UpdateRef(ctx context.Context, name, rev string) error {
+	commit, err := g.ResolveRevision(ctx, rev)
+	if err != nil {
+		return err
+	}
+
+	update := map[string]interface{}{"sha": commit.FullHash, "force": true}
+	status, err := g.requestJSON(ctx, "PATCH", fmt.Sprintf("%s/git/refs/%s", g.apiURL, strings.TrimPrefix(name, "refs/")), nil, update, nil)
+	if status == http.StatusNotFound || status == http.StatusUnprocessableEntity {
+		// The reference doesn't exist yet
+		create := map[string]string{"ref": name, "sha": commit.FullHash}
+		_, err = g.postJSON(ctx, g.apiURL+"/git/refs", create, nil)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update %s: %w", name, err)
+	}
+	return nil
+}
+
+// CommitsSince lists the commits reachable from rev but not from since
+// through the compare API.
+//
+// Parameters:
+//   - ctx: Cancels the requests; git.timeout applies to each
+//   - since: The revision already seen
+//   - rev: The newer revision
+//
+// Returns:
+//   - []gitpkg.CommitInfo: The commits, oldest first (without Files)
+//   - error: If either revision doesn't exist
+func (g *GitHubBackend) CommitsSince(ctx context.Context, since, rev string) ([]gitpkg.CommitInfo, error) {
+	const perPage = 100
+
+	var commits []gitpkg.CommitInfo
+	for page := 1; ; page++ {
+		query := url.Values{}
+		query.Set("per_page", strconv.Itoa(perPage))
+		query.Set("page", strconv.Itoa(page))
+
+		var compare githubCompareResponse
+		status, err := g.getJSON(ctx, fmt.Sprintf("%s/compare/%s...%s", g.apiURL, since, rev), query, &compare)
+		if status == http.StatusNotFound {
+			return nil, fmt.Errorf("revision %s or %s not found", since, rev)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to compare %s with %s: %w", since, rev, err)
+		}
+
+		for i := range compare.Commits {
+			commits = append(commits, *githubCommitInfo(&compare.Commits[i]))
+		}
+		if len(compare.Commits) < perPage || len(commits) >= compare.TotalCommits {
+			return commits, nil
+		}
+	}
+}
+
+// CreatePullRequest opens a pull request through the pulls API.
+//
+// Parameters:
+//   - ctx: Cancels the request; git.timeout applies on top
+//   - pr: The head and base branches, title and body
+//
+// Returns:
+//   - *PullRequest: The new pull request, with its number and URL
+//   - error: If the pull request can't be created (e.g. one already
+//     exists for the branch, or the branches have no differences)
+func (g *GitHubBackend) CreatePullRequest(ctx context.Context, pr *PullRequest) (*PullRequest, error) {
+	request := map[string]string{
+		"title": pr.Title,
+		"head":  pr.Head,
+		"base":  pr.Base,
+		"body":  pr.Body,
+	}
+
+	var created githubPullRequest
+	if _, err := g.postJSON(ctx, g.apiURL+"/pulls", request, &created); err != nil {
+		return nil, fmt.Errorf("failed to open pull request from %s into %s: %w", pr.Head, pr.Base, err)
+	}
+	return created.toPullRequest(), nil
+}
+
+// MergedPullRequest finds the pull request merged as a commit through the
+// commits API, and lists its files through the pulls API.
+//
+// Parameters:
+//   - ctx: Cancels the requests; git.timeout applies to each
+//   - commit: Full commit hash
+//
+// Returns:
+//   - *PullRequest: The pull request, or nil if commit didn't merge one
+//   - error: Any error encountered
+func (g *GitHubBackend) MergedPullRequest(ctx context.Context, commit string) (*PullRequest, error) {
+	var pulls []githubPullRequest
+	if _, err := g.getJSON(ctx, fmt.Sprintf("%s/commits/%s/pulls", g.apiURL, commit), nil, &pulls); err != nil {
+		return nil, fmt.Errorf("failed to look up pull requests for commit %s: %w", commit, err)
+	}
+
+	for _, pull := range pulls {
+		if pull.MergedAt == nil || pull.MergeCommitSHA != commit {
+			continue
+		}
+
+		pr := pull.toPullRequest()
+		const perPage = 100
+		for page := 1; ; page++ {
+			query := url.Values{}
+			query.Set("per_page", strconv.Itoa(perPage))
+			query.Set("page", strconv.Itoa(page))
+
+			var files []struct {
+				Filename         string `json:"filename"`
+				PreviousFilename string `json:"previous_filename"`
+			}
+			if _, err := g.getJSON(ctx, fmt.Sprintf("%s/pulls/%d/files", g.apiURL, pull.Number), query, &files); err != nil {
+				return nil, fmt.Errorf("failed to list files of pull request #%d: %w", pull.Number, err)
+			}
+			for _, f := range files {
+				pr.Files = append(pr.Files, f.Filename)
+				if f.PreviousFilename != "" {
+					pr.Files = append(pr.Files, f.PreviousFilename)
+				}
+			}
+			if len(files) < perPage {
+				return pr, nil
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// githubTagInfo converts an annotated tag object
+func githubTagInfo(name string, tag *githubTag) *gitpkg.TagInfo {
+	info := &gitpkg.TagInfo{
+		Name:    name,
+		Commit:  tag.Object.SHA,
+		Message: strings.TrimSpace(tag.Message),
+	}
+	if tag.Tagger != nil {
+		info.Tagger = fmt.Sprintf("%s <%s>", tag.Tagger.Name, tag.Tagger.Email)
+		info.Date = tag.Tagger.Date
+	}
+	return info
+}
+
+// getJSON makes an authenticated GET request and decodes the JSON response
+//
+// Parameters:
+//   - ctx: Cancels the request; git.timeout applies on top
+//   - endpoint: The API URL
+//   - query: Query parameters (may be nil)
+//   - out: Where to decode the response body
+//
+// Returns:
+//   - int: The response status code (0 if the request failed)
+//   - error: Any error, including status codes >= 400
+func (g *GitHubBackend) getJSON(ctx context.Context, endpoint string, query url.Values, out interface{}) (int, error) {
+	return g.requestJSON(ctx, "GET", endpoint, query, nil, out)
+}
+
+// postJSON makes an authenticated POST request with a JSON body and
+// decodes the JSON response (see getJSON)
+func (g *GitHubBackend) postJSON(ctx context.Context, endpoint string, in, out interface{}) (int, error) {
+	return g.requestJSON(ctx, "POST", endpoint, nil, in, out)
+}
+
+// requestJSON makes an authenticated API request
+// in is encoded as the JSON request body (nil for none); the response body
+// is decoded into out (nil to ignore it).
+func (g *GitHubBackend) requestJSON(ctx context.Context, method, endpoint string, query url.Values, in, out interface{}) (int, error) {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return 0, fmt.Errorf("failed to marshal request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	if query != nil {
+		req.URL.RawQuery = query.Encode()
+	}
+
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("github API request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode >= 400 {
+		var errResp githubErrorResponse
+		if json.Unmarshal(respBody, &errResp) == nil && errResp.Message != "" {
+			return resp.StatusCode, fmt.Errorf("github API error: %s", errResp.Message)
+		}
+		return resp.StatusCode, fmt.Errorf("github API error: status %d", resp.StatusCode)
+	}
+
+	if out == nil {
+		return resp.StatusCode, nil
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return resp.StatusCode, fmt.Errorf("failed to parse GitHub response: %w", err)
+	}
+
+	return resp.StatusCode, nil
+}
+
 // Commit creates commits for all staged files on GitHub.
 // For the GitHub API backend, each file gets its own commit because
 // the GitHub API doesn't support multi-file commits.
 //
+// A context canceled before Commit starts aborts it without touching the
+// repository. Once the first file is committed, cancellation is ignored
+// (only git.timeout applies), so an interrupt can't leave half of the files
+// committed.
+//
 // Parameters:
+//   - ctx: Aborts the commit if canceled before it starts
 //   - message: The commit message to use for all commits
+//   - author: Who made the change (nil for njgit itself)
 //
 // Returns:
 //   - string: Empty string (GitHub API doesn't return a single commit hash)
 //   - error: Any error encountered during commit
-func (g *GitHubBackend) Commit(message string) (string, error) {
+func (g *GitHubBackend) Commit(ctx context.Context, message string, author *gitpkg.Identity) (string, error) {
 	// Check if there are any staged files
 	if len(g.stagedFiles) == 0 {
 		return "", nil // Nothing to commit
 	}
 
-	// Commit each staged file
+	if err := ctx.Err(); err != nil {
+		return "", fmt.Errorf("commit aborted: %w", err)
+	}
+	ctx = context.WithoutCancel(ctx)
+
+	// Commit each staged file in a stable order
 	// NOTE: GitHub API doesn't support multi-file commits, so each file
 	// gets its own commit. This is a limitation of the API.
-	for path, content := range g.stagedFiles {
-		// Check if we need to get the SHA first (for updates)
-		sha := g.fileSHAs[path]
-		if sha == "" {
-			// Try to get the file to see if it exists
-			// If it exists, we need its SHA for the update
-			exists, err := g.FileExists(path)
-			if err != nil {
-				return "", fmt.Errorf("failed to check file existence for %s: %w", path, err)
-			}
+	paths := make([]string, 0, len(g.stagedFiles))
+	for path := range g.stagedFiles {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
 
-			if exists {
-				// File exists, need to read it to get SHA
-				_, err := g.ReadFile(path)
-				if err != nil {
-					return "", fmt.Errorf("failed to get SHA for %s: %w", path, err)
-				}
-				sha = g.fileSHAs[path]
-			}
+	for _, path := range paths {
+		if err := g.commitFile(ctx, path, g.stagedFiles[path], message, author); err != nil {
+			return "", err
 		}
+		delete(g.stagedFiles, path)
+	}
 
-		// Encode content as base64
-		encodedContent := base64.StdEncoding.EncodeToString(content)
+	// GitHub API backend doesn't return a single commit hash because
+	// each file gets its own commit
+	return "", nil
+}
 
-		// Create commit request
-		commitReq := githubCommitRequest{
-			Message: message,
-			Content: encodedContent,
-			Branch:  g.config.Branch,
-			SHA:     sha, // Empty for new files, required for updates
+// commitFile creates or updates one file on GitHub with a commit
+//
+// Parameters:
+//   - ctx: The context for the requests; git.timeout applies to each
+//   - path: The file path relative to repository root
+//   - content: The new file content
+//   - message: The commit message
+//   - author: Who made the change (nil for njgit itself)
+//
+// Returns:
+//   - error: Any error encountered during the commit
+func (g *GitHubBackend) commitFile(ctx context.Context, path string, content []byte, message string, author *gitpkg.Identity) error {
+	// Check if we need to get the SHA first (for updates)
+	sha := g.fileSHAs[path]
+	if sha == "" {
+		// Try to get the file to see if it exists
+		// If it exists, we need its SHA for the update
+		exists, err := g.FileExists(ctx, path)
+		if err != nil {
+			return fmt.Errorf("failed to check file existence for %s: %w", path, err)
 		}
 
-		// Add committer info if provided
-		if g.config.AuthorName != "" && g.config.AuthorEmail != "" {
-			commitReq.Committer = &githubCommitter{
-				Name:  g.config.AuthorName,
-				Email: g.config.AuthorEmail,
+		if exists {
+			// File exists, need to read it to get SHA
+			_, err := g.ReadFile(ctx, path)
+			if err != nil {
+				return fmt.Errorf("failed to get SHA for %s: %w", path, err)
 			}
+			sha = g.fileSHAs[path]
 		}
+	}
 
-		// Marshal request to JSON
-		reqBody, err := json.Marshal(commitReq)
-		if err != nil {
-			return "", fmt.Errorf("failed to marshal commit request for %s: %w", path, err)
-		}
-
-		// Create HTTP request
-		url := fmt.Sprintf("%s/%s", g.baseURL, path)
-		req, err := http.NewRequest("PUT", url, bytes.NewReader(reqBody))
-		if err != nil {
-			return "", fmt.Errorf("failed to create commit request for %s: %w", path, err)
-		}
+	// Encode content as base64
+	encodedContent := base64.StdEncoding.EncodeToString(content)
 
-		// Add headers
-		req.Header.Set("Authorization", "token "+g.config.Token)
-		req.Header.Set("Accept", "application/vnd.github.v3+json")
-		req.Header.Set("Content-Type", "application/json")
+	// Create commit request
+	commitReq := githubCommitRequest{
+		Message: message,
+		Content: encodedContent,
+		Branch:  g.branch,
+		SHA:     sha, // Empty for new files, required for updates
+	}
 
-		// Make the request
-		resp, err := g.httpClient.Do(req)
-		if err != nil {
-			return "", fmt.Errorf("failed to commit file %s: %w", path, err)
+	// njgit is the committer and, unless someone else made the change, the
+	// author. With sign = "github" GitHub must be the committer (it only
+	// signs its own commits), so njgit is left out unless it's the author.
+	if g.config.AuthorName != "" && g.config.AuthorEmail != "" {
+		identity := &githubCommitter{
+			Name:  g.config.AuthorName,
+			Email: g.config.AuthorEmail,
 		}
-		defer func() { _ = resp.Body.Close() }()
-
-		// Check response
-		if resp.StatusCode >= 400 {
-			body, _ := io.ReadAll(resp.Body)
-			var errResp githubErrorResponse
-			if json.Unmarshal(body, &errResp) == nil {
-				return "", fmt.Errorf("failed to commit %s: %s", path, errResp.Message)
-			}
-			return "", fmt.Errorf("failed to commit %s: status %d", path, resp.StatusCode)
+		commitReq.Author = identity
+		if g.config.Sign != "github" {
+			commitReq.Committer = identity
 		}
+	}
+	if author != nil {
+		commitReq.Author = &githubCommitter{Name: author.Name, Email: author.Email}
+	}
 
-		// Parse response to get the new SHA
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return "", fmt.Errorf("failed to read commit response for %s: %w", path, err)
-		}
+	// Marshal request to JSON
+	reqBody, err := json.Marshal(commitReq)
+	if err != nil {
+		return fmt.Errorf("failed to marshal commit request for %s: %w", path, err)
+	}
+
+	// Create HTTP request
+	url := fmt.Sprintf("%s/%s", g.baseURL, path)
+	req, err := http.NewRequestWithContext(ctx, "PUT", url, bytes.NewReader(reqBody))
+	if err != nil {
+		return fmt.Errorf("failed to create commit request for %s: %w", path, err)
+	}
+
+	// Add headers
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	req.Header.Set("Content-Type", "application/json")
 
-		var commitResp githubCommitResponse
-		if err := json.Unmarshal(body, &commitResp); err != nil {
-			return "", fmt.Errorf("failed to parse commit response for %s: %w", path, err)
+	// Make the request
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to commit file %s: %w", path, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	// Check response
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(resp.Body)
+		var errResp githubErrorResponse
+		if json.Unmarshal(body, &errResp) == nil {
+			return fmt.Errorf("failed to commit %s: %s", path, errResp.Message)
 		}
+		return fmt.Errorf("failed to commit %s: status %d", path, resp.StatusCode)
+	}
 
-		// Update the SHA for this file
-		g.fileSHAs[path] = commitResp.Content.SHA
+	// Parse response to get the new SHA
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read commit response for %s: %w", path, err)
 	}
 
-	// Clear staged files
+	var commitResp githubCommitResponse
+	if err := json.Unmarshal(body, &commitResp); err != nil {
+		return fmt.Errorf("failed to parse commit response for %s: %w", path, err)
+	}
+
+	// Update the SHA for this file
+	g.fileSHAs[path] = commitResp.Content.SHA
+
+	return nil
+}
+
+// Discard drops all staged files without committing them.
+//
+// Returns:
+//   - error: Always nil
+func (g *GitHubBackend) Discard() error {
 	g.stagedFiles = make(map[string][]byte)
+	return nil
+}
 
-	// GitHub API backend doesn't return a single commit hash because
-	// each file gets its own commit
-	return "", nil
+// Pull is a no-op for the GitHub API backend.
+// Every read goes to GitHub, so the branch is always up to date.
+//
+// Parameters:
+//   - ctx: Unused
+//
+// Returns:
+//   - error: Always nil
+func (g *GitHubBackend) Pull(ctx context.Context) error {
+	return nil
 }
 
 // Push is a no-op for the GitHub API backend.
 // Commits are already on GitHub after Commit() is called.
 //
+// Parameters:
+//   - ctx: Unused
+//
 // Returns:
 //   - error: Always nil
-func (g *GitHubBackend) Push() error {
+func (g *GitHubBackend) Push(ctx context.Context) error {
 	// No-op: commits are already on GitHub
 	return nil
 }
 
-// Close is a no-op for the GitHub API backend.
-// There are no resources to clean up.
+// Close saves the response cache, so the next run can make conditional
+// requests. Nothing on GitHub depends on it: a cache that can't be saved
+// only costs rate limit on the next run.
 //
 // Returns:
-//   - error: Always nil
+//   - error: If the cache can't be saved
 func (g *GitHubBackend) Close() error {
-	// No-op: nothing to clean up
-	return nil
+	return g.cache.save()
+}
+
+// githubAPIRoot returns the REST API root for git.api_url: api.github.com
+// by default, or {server}/api/v3 for GitHub Enterprise Server
+func githubAPIRoot(apiURL string) string {
+	if apiURL == "" {
+		return "https://api.github.com"
+	}
+	apiURL = strings.TrimRight(apiURL, "/")
+	if u, err := url.Parse(apiURL); err == nil && u.Host == "api.github.com" {
+		return apiURL
+	}
+	return apiRoot(apiURL, "/api/v3")
 }
 
 // GetName returns the name of this backend for logging and user messages.
@@ -0,0 +1,224 @@
+package backend
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// githubCredentials supplies the Authorization header of GitHub API requests
+type githubCredentials interface {
+	// authorization returns the Authorization header value
+	authorization(ctx context.Context) (string, error)
+
+	// expire drops a cached token the API rejected, so the next
+	// authorization fetches a new one
+	// Returns false if there is nothing to refresh.
+	expire() bool
+}
+
+// githubToken authenticates with a personal access token
+type githubToken string
+
+func (t githubToken) authorization(ctx context.Context) (string, error) {
+	return "token " + string(t), nil
+}
+
+func (t githubToken) expire() bool {
+	return false
+}
+
+// githubAppJWT authenticates as a GitHub App itself, with a JWT signed by
+// the app's private key. Only the /app endpoints accept it.
+type githubAppJWT struct {
+	appID int64
+	key   *rsa.PrivateKey
+	now   func() time.Time
+}
+
+// githubJWTLifetime is how long app JWTs are valid; GitHub allows at most
+// 10 minutes
+const githubJWTLifetime = 9 * time.Minute
+
+func (a *githubAppJWT) authorization(ctx context.Context) (string, error) {
+	token, err := a.sign()
+	if err != nil {
+		return "", err
+	}
+	return "Bearer " + token, nil
+}
+
+func (a *githubAppJWT) expire() bool {
+	return false
+}
+
+// sign creates a JWT (RS256) for the app
+// It is backdated a minute to allow for clock drift, as GitHub recommends.
+func (a *githubAppJWT) sign() (string, error) {
+	now := a.now()
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	claims, err := json.Marshal(map[string]interface{}{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(githubJWTLifetime).Unix(),
+		"iss": strconv.FormatInt(a.appID, 10),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode JWT claims: %w", err)
+	}
+
+	signingInput := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, a.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign JWT: %w", err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// githubAppInstallation authenticates with an installation token of a
+// GitHub App, exchanged for an app JWT and refreshed before it expires
+type githubAppInstallation struct {
+	client         *http.Client // Authenticates with the app JWT
+	apiRoot        string       // e.g. https://api.github.com
+	repoPath       string       // /repos/{owner}/{repo}, to look up the installation
+	installationID int64        // 0 until looked up
+	now            func() time.Time
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+// githubTokenRefreshMargin is how long before it expires an installation
+// token is replaced (they are valid for an hour)
+const githubTokenRefreshMargin = 5 * time.Minute
+
+func (a *githubAppInstallation) authorization(ctx context.Context) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.token == "" || !a.now().Add(githubTokenRefreshMargin).Before(a.expiresAt) {
+		if err := a.refresh(ctx); err != nil {
+			return "", err
+		}
+	}
+	return "token " + a.token, nil
+}
+
+func (a *githubAppInstallation) expire() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.token = ""
+	return true
+}
+
+// refresh creates a new installation token, looking up the installation
+// first if it isn't configured
+func (a *githubAppInstallation) refresh(ctx context.Context) error {
+	if a.installationID == 0 {
+		var installation struct {
+			ID int64 `json:"id"`
+		}
+		status, err := a.request(ctx, http.MethodGet, a.repoPath+"/installation", &installation)
+		if status == http.StatusNotFound {
+			return fmt.Errorf("github app is not installed on %s", strings.TrimPrefix(a.repoPath, "/repos/"))
+		}
+		if err != nil {
+			return fmt.Errorf("failed to look up github app installation: %w", err)
+		}
+		a.installationID = installation.ID
+	}
+
+	var token struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	endpoint := fmt.Sprintf("/app/installations/%d/access_tokens", a.installationID)
+	if _, err := a.request(ctx, http.MethodPost, endpoint, &token); err != nil {
+		return fmt.Errorf("failed to create github app installation token: %w", err)
+	}
+
+	a.token = token.Token
+	a.expiresAt = token.ExpiresAt
+	return nil
+}
+
+// request makes a request authenticated as the app and decodes the response
+func (a *githubAppInstallation) request(ctx context.Context, method, endpoint string, out interface{}) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, a.apiRoot+endpoint, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("github API request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, fmt.Errorf("failed to read response body: %w", err)
+	}
+	if resp.StatusCode >= 400 {
+		var errResp githubErrorResponse
+		if json.Unmarshal(body, &errResp) == nil && errResp.Message != "" {
+			return resp.StatusCode, fmt.Errorf("github API error: %s", errResp.Message)
+		}
+		return resp.StatusCode, fmt.Errorf("github API error: status %d", resp.StatusCode)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return resp.StatusCode, fmt.Errorf("failed to parse GitHub response: %w", err)
+	}
+	return resp.StatusCode, nil
+}
+
+// loadGitHubAppKey reads a GitHub App private key (PEM, PKCS#1 as GitHub
+// issues them, or PKCS#8)
+func loadGitHubAppKey(path string) (*rsa.PrivateKey, error) {
+	if strings.HasPrefix(path, "~") {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get home directory: %w", err)
+		}
+		path = filepath.Join(homeDir, path[1:])
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read github app private key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("github app private key %s is not PEM encoded", path)
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse github app private key %s: %w", path, err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("github app private key %s is not an RSA key", path)
+	}
+	return key, nil
+}
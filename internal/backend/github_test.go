@@ -1,14 +1,21 @@
 package backend
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"sort"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/wlame/njgit/internal/config"
+	gitpkg "github.com/wlame/njgit/internal/git"
 )
 
 // TestNewGitHubBackend_ValidationErrors tests that NewGitHubBackend validates required fields
@@ -42,6 +49,16 @@ func TestNewGitHubBackend_ValidationErrors(t *testing.T) {
 			},
 			wantErrText: "github token is required",
 		},
+		{
+			name: "unreadable app private key",
+			config: &config.GitConfig{
+				Owner:         "test-owner",
+				Repo:          "test-repo",
+				AppID:         7,
+				AppPrivateKey: "/nonexistent/app.pem",
+			},
+			wantErrText: "failed to read github app private key",
+		},
 	}
 
 	for _, tt := range tests {
@@ -127,7 +144,7 @@ func TestGitHubBackend_Initialize(t *testing.T) {
 			backend.baseURL = server.URL
 
 			// Test Initialize
-			err = backend.Initialize()
+			err = backend.Initialize(context.Background())
 			if tt.wantErr {
 				if err == nil {
 					t.Errorf("Initialize() expected error, got nil")
@@ -216,7 +233,7 @@ func TestGitHubBackend_ReadFile(t *testing.T) {
 			backend.baseURL = server.URL
 
 			// Test ReadFile
-			content, err := backend.ReadFile("default/test.hcl")
+			content, err := backend.ReadFile(context.Background(), "default/test.hcl")
 			if tt.wantErr {
 				if err == nil {
 					t.Errorf("ReadFile() expected error, got nil")
@@ -287,7 +304,7 @@ func TestGitHubBackend_FileExists(t *testing.T) {
 			backend.baseURL = server.URL
 
 			// Test FileExists
-			exists, err := backend.FileExists("default/test.hcl")
+			exists, err := backend.FileExists(context.Background(), "default/test.hcl")
 			if tt.wantErr {
 				if err == nil {
 					t.Errorf("FileExists() expected error, got nil")
@@ -373,13 +390,13 @@ func TestGitHubBackend_WriteAndCommit(t *testing.T) {
 	backend.baseURL = server.URL
 
 	// Test WriteFile
-	err = backend.WriteFile("default/test.hcl", []byte("test content"))
+	err = backend.WriteFile(context.Background(), "default/test.hcl", []byte("test content"))
 	if err != nil {
 		t.Errorf("WriteFile() unexpected error: %v", err)
 	}
 
 	// Test Commit
-	hash, err := backend.Commit("Test commit")
+	hash, err := backend.Commit(context.Background(), "Test commit", nil)
 	if err != nil {
 		t.Errorf("Commit() unexpected error: %v", err)
 	}
@@ -425,7 +442,7 @@ func TestGitHubBackend_PushAndClose(t *testing.T) {
 	}
 
 	// Push should be a no-op
-	if err := backend.Push(); err != nil {
+	if err := backend.Push(context.Background()); err != nil {
 		t.Errorf("Push() unexpected error: %v", err)
 	}
 
@@ -434,3 +451,823 @@ func TestGitHubBackend_PushAndClose(t *testing.T) {
 		t.Errorf("Close() unexpected error: %v", err)
 	}
 }
+
+// TestGitHubBackend_TimeoutAndCancel tests that requests honor git.timeout
+// and that a canceled context aborts a commit before anything is written
+func TestGitHubBackend_TimeoutAndCancel(t *testing.T) {
+	var puts atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			puts.Add(1)
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		// Hang until the client gives up
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	cfg := &config.GitConfig{
+		Owner:   "test-owner",
+		Repo:    "test-repo",
+		Token:   "test-token",
+		Branch:  "main",
+		Timeout: 100 * time.Millisecond,
+	}
+
+	backend, err := NewGitHubBackend(cfg)
+	if err != nil {
+		t.Fatalf("NewGitHubBackend() unexpected error: %v", err)
+	}
+	backend.baseURL = server.URL
+
+	_, err = backend.ReadFile(context.Background(), "default/test.hcl")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ReadFile() error = %v, want deadline exceeded", err)
+	}
+
+	if err := backend.WriteFile(context.Background(), "default/test.hcl", []byte("test content")); err != nil {
+		t.Fatalf("WriteFile() unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := backend.Commit(ctx, "Test commit", nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("Commit() error = %v, want canceled", err)
+	}
+	if n := puts.Load(); n != 0 {
+		t.Errorf("Commit() with a canceled context made %d PUT requests, want 0", n)
+	}
+
+	if err := backend.Discard(); err != nil {
+		t.Errorf("Discard() unexpected error: %v", err)
+	}
+	if len(backend.stagedFiles) != 0 {
+		t.Errorf("Discard() left %d staged files", len(backend.stagedFiles))
+	}
+}
+
+// TestGitHubBackend_ReadAPI tests History, ReadFileAt, ListFiles and
+// ResolveRevision against a fake of the commits, contents and trees APIs
+func TestGitHubBackend_ReadAPI(t *testing.T) {
+	const (
+		newSHA = "2222222222222222222222222222222222222222"
+		oldSHA = "1111111111111111111111111111111111111111"
+	)
+	commits := map[string]string{newSHA: "Update web-app", oldSHA: "Add web-app"}
+	contents := map[string]string{
+		newSHA: `job "web-app" { datacenters = ["dc1", "dc2"] }`,
+		oldSHA: `job "web-app" { datacenters = ["dc1"] }`,
+	}
+
+	commitJSON := func(sha string) map[string]interface{} {
+		return map[string]interface{}{
+			"sha": sha,
+			"commit": map[string]interface{}{
+				"message": commits[sha] + "\n",
+				"author": map[string]interface{}{
+					"name":  "Test",
+					"email": "test@example.com",
+					"date":  "2026-01-02T03:04:05Z",
+				},
+			},
+		}
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "token test-token" {
+			t.Errorf("Expected auth header 'token test-token', got %s", auth)
+		}
+
+		switch {
+		case r.URL.Path == "/commits":
+			if sha := r.URL.Query().Get("sha"); sha != "main" {
+				t.Errorf("Expected sha=main, got %s", sha)
+			}
+			list := []interface{}{commitJSON(newSHA), commitJSON(oldSHA)}
+			if r.URL.Query().Get("per_page") == "1" {
+				list = list[:1]
+			}
+			if r.URL.Query().Get("page") != "1" {
+				list = nil
+			}
+			_ = json.NewEncoder(w).Encode(list)
+
+		case strings.HasPrefix(r.URL.Path, "/commits/"):
+			rev := strings.TrimPrefix(r.URL.Path, "/commits/")
+			sha := ""
+			switch {
+			case rev == "main":
+				sha = newSHA
+			case len(rev) >= 7 && strings.HasPrefix(newSHA, rev):
+				sha = newSHA
+			case len(rev) >= 7 && strings.HasPrefix(oldSHA, rev):
+				sha = oldSHA
+			}
+			if sha == "" {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				_ = json.NewEncoder(w).Encode(githubErrorResponse{Message: "No commit found for SHA: " + rev})
+				return
+			}
+			resp := commitJSON(sha)
+			resp["files"] = []map[string]string{{"filename": "global/default/web-app.hcl"}}
+			_ = json.NewEncoder(w).Encode(resp)
+
+		case r.URL.Path == "/contents/global/default/web-app.hcl":
+			content, ok := contents[r.URL.Query().Get("ref")]
+			if !ok {
+				t.Errorf("Expected ref to be a full commit SHA, got %s", r.URL.Query().Get("ref"))
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(githubFileResponse{
+				Path:    "global/default/web-app.hcl",
+				Type:    "file",
+				Content: base64.StdEncoding.EncodeToString([]byte(content)),
+			})
+
+		case r.URL.Path == "/git/trees/"+newSHA:
+			if r.URL.Query().Get("recursive") != "1" {
+				t.Errorf("Expected recursive=1, got %s", r.URL.RawQuery)
+			}
+			_, _ = w.Write([]byte(`{"sha":"t","truncated":false,"tree":[
+				{"path":"README.md","type":"blob"},
+				{"path":"global","type":"tree"},
+				{"path":"global/default","type":"tree"},
+				{"path":"global/default/web-app.hcl","type":"blob"},
+				{"path":"global/default/api.hcl","type":"blob"}]}`))
+
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(githubErrorResponse{Message: "Not Found"})
+		}
+	}))
+	defer server.Close()
+
+	cfg := &config.GitConfig{
+		Owner:  "test-owner",
+		Repo:   "test-repo",
+		Token:  "test-token",
+		Branch: "main",
+	}
+	backend, err := NewGitHubBackend(cfg)
+	if err != nil {
+		t.Fatalf("NewGitHubBackend() unexpected error: %v", err)
+	}
+	backend.apiURL = server.URL
+	backend.baseURL = server.URL + "/contents"
+	ctx := context.Background()
+
+	history, err := backend.History(ctx, "", 0)
+	if err != nil {
+		t.Fatalf("History() unexpected error: %v", err)
+	}
+	if len(history) != 2 || history[0].FullHash != newSHA || history[1].FullHash != oldSHA {
+		t.Fatalf("History() = %+v, want the two commits newest first", history)
+	}
+	if history[0].Hash != newSHA[:8] || history[0].Message != "Update web-app" || history[0].Author != "Test" {
+		t.Errorf("History()[0] = %+v", history[0])
+	}
+	if len(history[0].Files) != 1 || history[0].Files[0] != "global/default/web-app.hcl" {
+		t.Errorf("History()[0].Files = %v, want [global/default/web-app.hcl]", history[0].Files)
+	}
+	if want := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC); !history[0].Date.Equal(want) {
+		t.Errorf("History()[0].Date = %v, want %v", history[0].Date, want)
+	}
+
+	history, err = backend.History(ctx, "global/default/web-app.hcl", 1)
+	if err != nil {
+		t.Fatalf("History() with limit unexpected error: %v", err)
+	}
+	if len(history) != 1 || history[0].FullHash != newSHA {
+		t.Errorf("History() with limit 1 = %+v", history)
+	}
+
+	commit, err := backend.ResolveRevision(ctx, oldSHA[:7])
+	if err != nil {
+		t.Fatalf("ResolveRevision() unexpected error: %v", err)
+	}
+	if commit.FullHash != oldSHA {
+		t.Errorf("ResolveRevision() = %s, want %s", commit.FullHash, oldSHA)
+	}
+	if _, err := backend.ResolveRevision(ctx, "deadbeef"); err == nil || err.Error() != "revision deadbeef not found" {
+		t.Errorf("ResolveRevision() of an unknown commit error = %v", err)
+	}
+
+	content, err := backend.ReadFileAt(ctx, oldSHA[:8], "global/default/web-app.hcl")
+	if err != nil {
+		t.Fatalf("ReadFileAt() unexpected error: %v", err)
+	}
+	if string(content) != contents[oldSHA] {
+		t.Errorf("ReadFileAt() = %s, want %s", content, contents[oldSHA])
+	}
+	if _, err := backend.ReadFileAt(ctx, "main", "global/default/missing.hcl"); err == nil {
+		t.Errorf("ReadFileAt() of a missing file expected error, got nil")
+	}
+
+	files, err := backend.ListFiles(ctx, "main", "global/")
+	if err != nil {
+		t.Fatalf("ListFiles() unexpected error: %v", err)
+	}
+	want := []string{"global/default/api.hcl", "global/default/web-app.hcl"}
+	if strings.Join(files, ",") != strings.Join(want, ",") {
+		t.Errorf("ListFiles() = %v, want %v", files, want)
+	}
+}
+
+// TestGitHubBackend_Signing tests that sign = "github" lets GitHub sign the
+// commit and that GitHub's verification is mapped to a signature status
+func TestGitHubBackend_Signing(t *testing.T) {
+	var got githubCommitRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodHead:
+			w.WriteHeader(http.StatusNotFound)
+		case http.MethodPut:
+			_ = json.NewDecoder(r.Body).Decode(&got)
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{}`))
+		}
+	}))
+	defer server.Close()
+
+	cfg := &config.GitConfig{
+		Owner:       "test-owner",
+		Repo:        "test-repo",
+		Token:       "test-token",
+		Branch:      "main",
+		AuthorName:  "Test Author",
+		AuthorEmail: "test@example.com",
+		Sign:        "github",
+	}
+	backend, err := NewGitHubBackend(cfg)
+	if err != nil {
+		t.Fatalf("NewGitHubBackend() unexpected error: %v", err)
+	}
+	backend.baseURL = server.URL
+
+	if err := backend.WriteFile(context.Background(), "default/test.hcl", []byte("x")); err != nil {
+		t.Fatalf("WriteFile() unexpected error: %v", err)
+	}
+	if _, err := backend.Commit(context.Background(), "Test commit", nil); err != nil {
+		t.Fatalf("Commit() unexpected error: %v", err)
+	}
+	if got.Committer != nil {
+		t.Errorf("committer = %+v, want nil so GitHub commits and signs", got.Committer)
+	}
+	if got.Author == nil || got.Author.Name != "Test Author" {
+		t.Errorf("author = %+v, want Test Author", got.Author)
+	}
+
+	tests := []struct {
+		name      string
+		verified  bool
+		reason    string
+		signature string
+		want      string
+	}{
+		{"unsigned", false, "unsigned", "", "unsigned"},
+		{"verified ssh", true, "valid", "-----BEGIN SSH SIGNATURE-----\n", "verified (ssh: GitHub <noreply@github.com>)"},
+		{"verified gpg", true, "valid", "-----BEGIN PGP SIGNATURE-----\n", "verified (gpg: GitHub <noreply@github.com>)"},
+		{"unknown key", false, "unknown_key", "-----BEGIN PGP SIGNATURE-----\n", "unverified (gpg: unknown_key)"},
+		{"invalid", false, "invalid", "-----BEGIN SSH SIGNATURE-----\n", "bad (ssh)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var commit githubCommit
+			commit.Commit.Committer.Name = "GitHub"
+			commit.Commit.Committer.Email = "noreply@github.com"
+			commit.Commit.Verification.Verified = tt.verified
+			commit.Commit.Verification.Reason = tt.reason
+			commit.Commit.Verification.Signature = tt.signature
+			if got := githubSignature(&commit).String(); got != tt.want {
+				t.Errorf("githubSignature() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestGitHubBackend_CommitAuthor tests that a given author is sent as the
+// commit author while njgit stays the committer
+func TestGitHubBackend_CommitAuthor(t *testing.T) {
+	var got githubCommitRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodHead:
+			w.WriteHeader(http.StatusNotFound)
+		case http.MethodPut:
+			_ = json.NewDecoder(r.Body).Decode(&got)
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{}`))
+		}
+	}))
+	defer server.Close()
+
+	backend, err := NewGitHubBackend(&config.GitConfig{
+		Owner:       "test-owner",
+		Repo:        "test-repo",
+		Token:       "test-token",
+		Branch:      "main",
+		AuthorName:  "njgit",
+		AuthorEmail: "njgit@example.com",
+	})
+	if err != nil {
+		t.Fatalf("NewGitHubBackend() unexpected error: %v", err)
+	}
+	backend.baseURL = server.URL
+
+	if err := backend.WriteFile(context.Background(), "default/test.hcl", []byte("x")); err != nil {
+		t.Fatalf("WriteFile() unexpected error: %v", err)
+	}
+	author := &gitpkg.Identity{Name: "Jane Doe", Email: "jane@example.com"}
+	if _, err := backend.Commit(context.Background(), "Test commit", author); err != nil {
+		t.Fatalf("Commit() unexpected error: %v", err)
+	}
+
+	if got.Author == nil || got.Author.Email != "jane@example.com" {
+		t.Errorf("author = %+v, want jane@example.com", got.Author)
+	}
+	if got.Committer == nil || got.Committer.Email != "njgit@example.com" {
+		t.Errorf("committer = %+v, want njgit@example.com", got.Committer)
+	}
+}
+
+func TestGitHubBackend_TagsAndBranches(t *testing.T) {
+	const (
+		mainSHA = "1111111111111111111111111111111111111111"
+		tagSHA  = "3333333333333333333333333333333333333333"
+	)
+	refs := map[string]string{"refs/heads/main": mainSHA}
+	var createdTag githubTag
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/git/ref/"):
+			ref := "refs/" + strings.TrimPrefix(r.URL.Path, "/git/ref/")
+			sha, ok := refs[ref]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"message":"Not Found"}`))
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"ref": ref, "object": map[string]string{"sha": sha, "type": "commit"}})
+		case r.Method == http.MethodPost && r.URL.Path == "/git/refs":
+			var body map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			if _, exists := refs[body["ref"]]; exists {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				_, _ = w.Write([]byte(`{"message":"Reference already exists"}`))
+				return
+			}
+			refs[body["ref"]] = body["sha"]
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{}`))
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/commits/"):
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"sha": mainSHA})
+		case r.Method == http.MethodPost && r.URL.Path == "/git/tags":
+			_ = json.NewDecoder(r.Body).Decode(&createdTag)
+			createdTag.SHA = tagSHA
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(createdTag)
+		case r.Method == http.MethodGet && r.URL.Path == "/git/tags/"+tagSHA:
+			_ = json.NewEncoder(w).Encode(createdTag)
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/git/matching-refs/tags/"):
+			prefix := "refs/tags/" + strings.TrimPrefix(r.URL.Path, "/git/matching-refs/tags/")
+			var matched []map[string]interface{}
+			for ref, sha := range refs {
+				if strings.HasPrefix(ref, prefix) {
+					matched = append(matched, map[string]interface{}{"ref": ref, "object": map[string]string{"sha": sha, "type": "tag"}})
+				}
+			}
+			_ = json.NewEncoder(w).Encode(matched)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	backend, err := NewGitHubBackend(&config.GitConfig{
+		Owner:       "test-owner",
+		Repo:        "test-repo",
+		Token:       "test-token",
+		Branch:      "main",
+		AuthorName:  "njgit",
+		AuthorEmail: "njgit@example.com",
+	})
+	if err != nil {
+		t.Fatalf("NewGitHubBackend() unexpected error: %v", err)
+	}
+	backend.apiURL = server.URL
+	backend.baseURL = server.URL + "/contents"
+	ctx := context.Background()
+
+	// A missing branch is created from the current one
+	if err := backend.UseBranch(ctx, "env/prod"); err != nil {
+		t.Fatalf("UseBranch() unexpected error: %v", err)
+	}
+	if refs["refs/heads/env/prod"] != mainSHA {
+		t.Errorf("env/prod = %q, want it created at %s", refs["refs/heads/env/prod"], mainSHA)
+	}
+	if backend.branch != "env/prod" {
+		t.Errorf("branch = %q, want env/prod", backend.branch)
+	}
+
+	const name = "deploy/global/default/web/20240115T143022Z"
+	tag, err := backend.CreateTag(ctx, name, "HEAD", "Deploy global/default/web")
+	if err != nil {
+		t.Fatalf("CreateTag() unexpected error: %v", err)
+	}
+	if tag.Commit != mainSHA || tag.Tagger != "njgit <njgit@example.com>" {
+		t.Errorf("CreateTag() = %+v, want commit %s tagged by njgit", tag, mainSHA)
+	}
+	if createdTag.Object.Type != "commit" || refs["refs/tags/"+name] != tagSHA {
+		t.Errorf("tag object = %+v, refs = %v", createdTag, refs)
+	}
+	if _, err := backend.CreateTag(ctx, name, "HEAD", "again"); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("CreateTag() of an existing tag error = %v, want already exists", err)
+	}
+
+	tags, err := backend.ListTags(ctx, "deploy/")
+	if err != nil {
+		t.Fatalf("ListTags() unexpected error: %v", err)
+	}
+	if len(tags) != 1 || tags[0].Name != name || tags[0].Commit != mainSHA || tags[0].Message != "Deploy global/default/web" {
+		t.Errorf("ListTags() = %+v, want the deploy tag", tags)
+	}
+}
+
+// fakeGitHub is a small in-memory GitHub: branches, commits with a file
+// snapshot each, the contents API and pull requests. History is linear;
+// pull requests are squash merged.
+type fakeGitHub struct {
+	t       *testing.T
+	refs    map[string]string // Full ref -> commit SHA
+	commits map[string]*fakeCommit
+	pulls   []*fakePull
+	serial  int
+}
+
+type fakeCommit struct {
+	sha     string
+	parent  string
+	message string
+	files   map[string]string
+}
+
+type fakePull struct {
+	number      int
+	title, body string
+	head, base  string
+	mergeCommit string
+	files       []string
+}
+
+func newFakeGitHub(t *testing.T, files map[string]string) *fakeGitHub {
+	f := &fakeGitHub{t: t, refs: make(map[string]string), commits: make(map[string]*fakeCommit)}
+	f.refs["refs/heads/main"] = f.addCommit("", "Initial commit", files)
+	return f
+}
+
+// addCommit stores a commit and returns its SHA
+func (f *fakeGitHub) addCommit(parent, message string, files map[string]string) string {
+	f.serial++
+	sha := fmt.Sprintf("%040x", f.serial)
+	f.commits[sha] = &fakeCommit{sha: sha, parent: parent, message: message, files: files}
+	return sha
+}
+
+// commitFile commits one file to a branch, as if pushed directly
+func (f *fakeGitHub) commitFile(branch, path, content, message string) string {
+	parent := f.commits[f.refs["refs/heads/"+branch]]
+	files := make(map[string]string)
+	for p, c := range parent.files {
+		files[p] = c
+	}
+	files[path] = content
+	sha := f.addCommit(parent.sha, message, files)
+	f.refs["refs/heads/"+branch] = sha
+	return sha
+}
+
+// merge squash merges a pull request into its base branch
+func (f *fakeGitHub) merge(number int) string {
+	pull := f.pulls[number-1]
+	base := f.commits[f.refs["refs/heads/"+pull.base]]
+	head := f.commits[f.refs["refs/heads/"+pull.head]]
+
+	files := make(map[string]string)
+	for p, c := range base.files {
+		files[p] = c
+	}
+	for p, c := range head.files {
+		if base.files[p] != c {
+			files[p] = c
+			pull.files = append(pull.files, p)
+		}
+	}
+	pull.mergeCommit = f.addCommit(base.sha, fmt.Sprintf("%s (#%d)", pull.title, number), files)
+	f.refs["refs/heads/"+pull.base] = pull.mergeCommit
+	return pull.mergeCommit
+}
+
+// resolve finds the commit a branch name or SHA names
+func (f *fakeGitHub) resolve(rev string) *fakeCommit {
+	if sha, ok := f.refs["refs/heads/"+rev]; ok {
+		return f.commits[sha]
+	}
+	return f.commits[rev]
+}
+
+// changedFiles lists the files a commit changed compared to its parent
+func (f *fakeGitHub) changedFiles(c *fakeCommit) []string {
+	parent := map[string]string{}
+	if p := f.commits[c.parent]; p != nil {
+		parent = p.files
+	}
+	var files []string
+	for path, content := range c.files {
+		if old, ok := parent[path]; !ok || old != content {
+			files = append(files, path)
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+// resolveAny finds the commit a branch, tag or SHA names
+func (f *fakeGitHub) resolveAny(rev string) *fakeCommit {
+	if sha, ok := f.refs["refs/tags/"+rev]; ok {
+		return f.commits[sha]
+	}
+	return f.resolve(rev)
+}
+
+func (f *fakeGitHub) pullJSON(pull *fakePull) map[string]interface{} {
+	out := map[string]interface{}{
+		"number":   pull.number,
+		"title":    pull.title,
+		"body":     pull.body,
+		"html_url": fmt.Sprintf("https://github.com/test-owner/test-repo/pull/%d", pull.number),
+		"head":     map[string]string{"ref": pull.head},
+		"base":     map[string]string{"ref": pull.base},
+	}
+	if pull.mergeCommit != "" {
+		out["merged_at"] = "2026-01-02T03:04:05Z"
+		out["merge_commit_sha"] = pull.mergeCommit
+	}
+	return out
+}
+
+func (f *fakeGitHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	reply := func(status int, v interface{}) {
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(v)
+	}
+	notFound := func() { reply(http.StatusNotFound, map[string]string{"message": "Not Found"}) }
+	path := r.URL.Path
+
+	switch {
+	case path == "/contents" && r.Method == http.MethodHead:
+		w.WriteHeader(http.StatusOK)
+
+	case strings.HasPrefix(path, "/contents/") && (r.Method == http.MethodGet || r.Method == http.MethodHead):
+		file := strings.TrimPrefix(path, "/contents/")
+		commit := f.resolve(r.URL.Query().Get("ref"))
+		content, ok := "", false
+		if commit != nil {
+			content, ok = commit.files[file]
+		}
+		if !ok {
+			notFound()
+			return
+		}
+		reply(http.StatusOK, map[string]string{
+			"path":    file,
+			"type":    "file",
+			"sha":     fmt.Sprintf("blob-%x", len(content)),
+			"content": base64.StdEncoding.EncodeToString([]byte(content)),
+		})
+
+	case strings.HasPrefix(path, "/contents/") && r.Method == http.MethodPut:
+		var req githubCommitRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		content, _ := base64.StdEncoding.DecodeString(req.Content)
+		sha := f.commitFile(req.Branch, strings.TrimPrefix(path, "/contents/"), string(content), req.Message)
+		reply(http.StatusOK, map[string]interface{}{
+			"content": map[string]string{"sha": fmt.Sprintf("blob-%x", len(content))},
+			"commit":  map[string]string{"sha": sha},
+		})
+
+	case strings.HasPrefix(path, "/git/ref/") && r.Method == http.MethodGet:
+		ref := "refs/" + strings.TrimPrefix(path, "/git/ref/")
+		sha, ok := f.refs[ref]
+		if !ok {
+			notFound()
+			return
+		}
+		reply(http.StatusOK, map[string]interface{}{"ref": ref, "object": map[string]string{"sha": sha, "type": "commit"}})
+
+	case path == "/git/refs" && r.Method == http.MethodPost:
+		var req map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if _, exists := f.refs[req["ref"]]; exists {
+			reply(http.StatusUnprocessableEntity, map[string]string{"message": "Reference already exists"})
+			return
+		}
+		f.refs[req["ref"]] = req["sha"]
+		reply(http.StatusCreated, map[string]string{"ref": req["ref"]})
+
+	case strings.HasPrefix(path, "/git/refs/") && r.Method == http.MethodPatch:
+		ref := "refs/" + strings.TrimPrefix(path, "/git/refs/")
+		var req map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if _, exists := f.refs[ref]; !exists {
+			reply(http.StatusUnprocessableEntity, map[string]string{"message": "Reference does not exist"})
+			return
+		}
+		f.refs[ref] = req["sha"].(string)
+		reply(http.StatusOK, map[string]string{"ref": ref})
+
+	case strings.HasPrefix(path, "/commits/") && strings.HasSuffix(path, "/pulls"):
+		sha := strings.TrimSuffix(strings.TrimPrefix(path, "/commits/"), "/pulls")
+		pulls := []map[string]interface{}{}
+		for _, pull := range f.pulls {
+			if pull.mergeCommit == sha {
+				pulls = append(pulls, f.pullJSON(pull))
+			}
+		}
+		reply(http.StatusOK, pulls)
+
+	case strings.HasPrefix(path, "/commits/"):
+		commit := f.resolve(strings.TrimPrefix(path, "/commits/"))
+		if commit == nil {
+			reply(http.StatusUnprocessableEntity, map[string]string{"message": "No commit found"})
+			return
+		}
+		reply(http.StatusOK, f.commitJSON(commit))
+
+	case strings.HasPrefix(path, "/compare/"):
+		revs := strings.SplitN(strings.TrimPrefix(path, "/compare/"), "...", 2)
+		since, head := f.resolve(revs[0]), f.resolve(revs[1])
+		if since == nil || head == nil {
+			notFound()
+			return
+		}
+		var commits []map[string]interface{}
+		for c := head; c != nil && c.sha != since.sha; c = f.commits[c.parent] {
+			commits = append([]map[string]interface{}{f.commitJSON(c)}, commits...)
+		}
+		reply(http.StatusOK, map[string]interface{}{"status": "ahead", "total_commits": len(commits), "commits": commits})
+
+	case path == "/pulls" && r.Method == http.MethodPost:
+		var req map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if _, ok := f.refs["refs/heads/"+req["head"]]; !ok {
+			reply(http.StatusUnprocessableEntity, map[string]string{"message": "Validation Failed"})
+			return
+		}
+		pull := &fakePull{number: len(f.pulls) + 1, title: req["title"], body: req["body"], head: req["head"], base: req["base"]}
+		f.pulls = append(f.pulls, pull)
+		reply(http.StatusCreated, f.pullJSON(pull))
+
+	case strings.HasPrefix(path, "/pulls/") && strings.HasSuffix(path, "/files"):
+		var number int
+		_, _ = fmt.Sscanf(path, "/pulls/%d/files", &number)
+		files := []map[string]string{}
+		if r.URL.Query().Get("page") == "1" {
+			for _, file := range f.pulls[number-1].files {
+				files = append(files, map[string]string{"filename": file})
+			}
+		}
+		reply(http.StatusOK, files)
+
+	default:
+		f.t.Errorf("unexpected request: %s %s", r.Method, r.URL)
+		notFound()
+	}
+}
+
+func (f *fakeGitHub) commitJSON(c *fakeCommit) map[string]interface{} {
+	return map[string]interface{}{
+		"sha": c.sha,
+		"commit": map[string]interface{}{
+			"message": c.message,
+			"author":  map[string]string{"name": "Test", "email": "test@example.com", "date": "2026-01-02T03:04:05Z"},
+		},
+	}
+}
+
+// contains reports whether values includes value
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// TestGitHubBackend_ProposeAndApply walks through proposing a change as a
+// pull request and finding it again once merged, against a fake GitHub
+func TestGitHubBackend_ProposeAndApply(t *testing.T) {
+	const jobFile = "global/default/web.hcl"
+	fake := newFakeGitHub(t, map[string]string{jobFile: "job \"web\" { count = 1 }\n"})
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	b, err := NewGitHubBackend(&config.GitConfig{
+		Owner:       "test-owner",
+		Repo:        "test-repo",
+		Token:       "test-token",
+		Branch:      "main",
+		AuthorName:  "njgit",
+		AuthorEmail: "njgit@example.com",
+	})
+	if err != nil {
+		t.Fatalf("NewGitHubBackend() unexpected error: %v", err)
+	}
+	b.apiURL = server.URL
+	b.baseURL = server.URL + "/contents"
+	ctx := context.Background()
+	var forge Forge = b
+
+	// Nothing has been applied yet; record the starting point
+	appliedRef := gitpkg.AppliedRef("main")
+	applied, err := b.ReadRef(ctx, appliedRef)
+	if err != nil || applied != "" {
+		t.Fatalf("ReadRef() = %q, %v; want no ref", applied, err)
+	}
+	if err := b.UpdateRef(ctx, appliedRef, "main"); err != nil {
+		t.Fatalf("UpdateRef() unexpected error: %v", err)
+	}
+	start := fake.refs["refs/heads/main"]
+	if applied, _ := b.ReadRef(ctx, appliedRef); applied != start {
+		t.Fatalf("ReadRef() after UpdateRef = %q, want %s", applied, start)
+	}
+
+	// Propose: branch, commit, pull request
+	if err := b.UseBranch(ctx, "njgit/propose/web"); err != nil {
+		t.Fatalf("UseBranch() unexpected error: %v", err)
+	}
+	if err := b.WriteFile(ctx, jobFile, []byte("job \"web\" { count = 3 }\n")); err != nil {
+		t.Fatalf("WriteFile() unexpected error: %v", err)
+	}
+	if _, err := b.Commit(ctx, "Update global/default/web", nil); err != nil {
+		t.Fatalf("Commit() unexpected error: %v", err)
+	}
+	pr, err := forge.CreatePullRequest(ctx, &PullRequest{Title: "Scale web", Head: "njgit/propose/web", Base: "main"})
+	if err != nil {
+		t.Fatalf("CreatePullRequest() unexpected error: %v", err)
+	}
+	if pr.Number != 1 || pr.URL != "https://github.com/test-owner/test-repo/pull/1" || pr.Base != "main" {
+		t.Errorf("CreatePullRequest() = %+v", pr)
+	}
+	if fake.refs["refs/heads/main"] != start {
+		t.Errorf("main moved before the pull request was merged")
+	}
+	if _, err := forge.CreatePullRequest(ctx, &PullRequest{Title: "x", Head: "no-such-branch", Base: "main"}); err == nil {
+		t.Errorf("CreatePullRequest() from a missing branch should fail")
+	}
+
+	// Merge it, then someone commits directly (like sync does)
+	mergeSHA := fake.merge(1)
+	fake.commitFile("main", "global/default/api.hcl", "job \"api\" {}\n", "Update global/default/api")
+
+	commits, err := b.CommitsSince(ctx, start, "main")
+	if err != nil {
+		t.Fatalf("CommitsSince() unexpected error: %v", err)
+	}
+	if len(commits) != 2 || commits[0].FullHash != mergeSHA || commits[1].Message != "Update global/default/api" {
+		t.Fatalf("CommitsSince() = %+v, want the merge then the direct commit", commits)
+	}
+
+	merged, err := forge.MergedPullRequest(ctx, commits[0].FullHash)
+	if err != nil {
+		t.Fatalf("MergedPullRequest() unexpected error: %v", err)
+	}
+	if merged == nil || merged.Number != 1 || merged.MergeCommit != mergeSHA || len(merged.Files) != 1 || merged.Files[0] != jobFile {
+		t.Fatalf("MergedPullRequest() = %+v, want #1 changing %s", merged, jobFile)
+	}
+	if merged, err := forge.MergedPullRequest(ctx, commits[1].FullHash); err != nil || merged != nil {
+		t.Errorf("MergedPullRequest() of a direct commit = %+v, %v; want nil", merged, err)
+	}
+
+	content, err := b.ReadFileAt(ctx, merged.MergeCommit, jobFile)
+	if err != nil || string(content) != "job \"web\" { count = 3 }\n" {
+		t.Errorf("ReadFileAt(merge) = %q, %v", content, err)
+	}
+
+	// Record the new position (updates the existing ref)
+	if err := b.UpdateRef(ctx, appliedRef, "main"); err != nil {
+		t.Fatalf("UpdateRef() unexpected error: %v", err)
+	}
+	if fake.refs[appliedRef] != fake.refs["refs/heads/main"] {
+		t.Errorf("applied ref = %s, want main's head %s", fake.refs[appliedRef], fake.refs["refs/heads/main"])
+	}
+}
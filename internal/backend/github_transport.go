@@ -0,0 +1,410 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// githubTransport is the http.RoundTripper of GitHub API requests. It:
+//   - adds the Authorization header, refreshing expired app tokens
+//   - limits each attempt to the request timeout (git.timeout)
+//   - retries rate-limited requests (403 and 429), waiting as long as
+//     GitHub asks to
+//   - makes GET and HEAD requests conditional on the ETag of the last
+//     response, answering from the cache when GitHub says 304 Not Modified
+type githubTransport struct {
+	base        http.RoundTripper
+	credentials githubCredentials
+	cache       *githubCache  // nil: no conditional requests
+	timeout     time.Duration // Per-attempt timeout (zero means none)
+	maxRetries  int           // Retries of rate-limited requests
+	maxWait     time.Duration // Longest wait for a rate limit to reset
+
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+const (
+	// githubMaxRetries is how often a rate-limited request is retried
+	githubMaxRetries = 3
+
+	// githubMaxRetryWait is the longest njgit waits for a rate limit to
+	// reset; requests limited for longer fail right away
+	githubMaxRetryWait = 2 * time.Minute
+
+	// githubSecondaryBackoff is the first wait after a secondary rate limit
+	// without a Retry-After header; it doubles with each retry
+	githubSecondaryBackoff = time.Minute
+)
+
+// newGitHubTransport creates the transport for a backend
+func newGitHubTransport(credentials githubCredentials, cache *githubCache, timeout time.Duration) *githubTransport {
+	return &githubTransport{
+		base:        http.DefaultTransport,
+		credentials: credentials,
+		cache:       cache,
+		timeout:     timeout,
+		maxRetries:  githubMaxRetries,
+		maxWait:     githubMaxRetryWait,
+		now:         time.Now,
+		sleep:       sleepContext,
+	}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *githubTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if req.Body != nil && req.GetBody != nil {
+		// Each attempt gets a fresh copy of the body
+		_ = req.Body.Close()
+	}
+
+	var cached *githubCacheEntry
+	key := ""
+	if t.cache != nil && (req.Method == http.MethodGet || req.Method == http.MethodHead) {
+		key = req.Method + " " + req.URL.String() + " " + req.Header.Get("Accept")
+		cached = t.cache.get(key)
+	}
+
+	resp, err := t.send(ctx, req, cached)
+	if err != nil {
+		return nil, err
+	}
+
+	// An expired or revoked app token: get a new one and try again once
+	if resp.StatusCode == http.StatusUnauthorized && t.credentials.expire() {
+		drain(resp)
+		if resp, err = t.send(ctx, req, cached); err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		drain(resp)
+		return cached.response(req, resp.Header), nil
+
+	case key != "" && resp.StatusCode == http.StatusOK && resp.Header.Get("ETag") != "":
+		body, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		t.cache.put(key, &githubCacheEntry{ETag: resp.Header.Get("ETag"), Body: body})
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	return resp, nil
+}
+
+// send makes a request, retrying while it is rate limited
+func (t *githubTransport) send(ctx context.Context, req *http.Request, cached *githubCacheEntry) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := t.attempt(ctx, req, cached)
+		if err != nil {
+			return nil, err
+		}
+
+		wait, limited := t.rateLimitWait(resp, attempt)
+		if !limited || attempt >= t.maxRetries || wait > t.maxWait {
+			return resp, nil
+		}
+
+		drain(resp)
+		if err := t.sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// attempt makes one request with a fresh body, credentials and deadline
+// The deadline covers reading the body: it is released when the body is
+// closed.
+func (t *githubTransport) attempt(ctx context.Context, req *http.Request, cached *githubCacheEntry) (*http.Response, error) {
+	authorization, err := t.credentials.authorization(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cancel := context.CancelFunc(func() {})
+	if t.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, t.timeout)
+	}
+
+	out := req.Clone(ctx)
+	if req.GetBody != nil {
+		if out.Body, err = req.GetBody(); err != nil {
+			cancel()
+			return nil, err
+		}
+	}
+	out.Header.Set("Authorization", authorization)
+	if cached != nil {
+		out.Header.Set("If-None-Match", cached.ETag)
+	}
+
+	resp, err := t.base.RoundTrip(out)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// rateLimitWait tells whether a response is rate limited and how long to
+// wait before retrying. GitHub answers 429, or 403 with either a
+// Retry-After header (secondary limits), X-RateLimit-Remaining: 0 (the
+// primary limit) or a message about a secondary rate limit.
+func (t *githubTransport) rateLimitWait(resp *http.Response, attempt int) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusForbidden {
+		return 0, false
+	}
+
+	if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+		if seconds, err := strconv.Atoi(retryAfter); err == nil {
+			return time.Duration(seconds) * time.Second, true
+		}
+		if at, err := http.ParseTime(retryAfter); err == nil {
+			return at.Sub(t.now()), true
+		}
+	}
+
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			// A second more, as the reset time is rounded down
+			return time.Unix(reset, 0).Sub(t.now()) + time.Second, true
+		}
+	}
+
+	if resp.StatusCode == http.StatusForbidden && !mentionsSecondaryRateLimit(resp) {
+		return 0, false
+	}
+	return githubSecondaryBackoff << attempt, true
+}
+
+// mentionsSecondaryRateLimit checks a 403 response's message for a
+// secondary rate limit, keeping the body readable
+func mentionsSecondaryRateLimit(resp *http.Response) bool {
+	body, err := io.ReadAll(resp.Body)
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{bytes.NewReader(body), resp.Body}
+	return err == nil && strings.Contains(strings.ToLower(string(body)), "secondary rate limit")
+}
+
+// drain discards and closes a response body so the connection can be reused
+func drain(resp *http.Response) {
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+}
+
+// sleepContext waits for d, or until ctx is canceled
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// cancelOnClose releases a request's deadline when its body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
+
+// githubCache keeps the last response to GET and HEAD requests with its
+// ETag. With a path, it is loaded from and saved to a file, so successive
+// runs (e.g. periodic syncs) make conditional requests too.
+type githubCache struct {
+	path string // "" to keep the cache in memory only
+
+	mu      sync.Mutex
+	entries map[string]*githubCacheEntry
+	loaded  bool
+	dirty   bool
+}
+
+// githubCacheEntry is one cached response
+type githubCacheEntry struct {
+	ETag string    `json:"etag"`
+	Body []byte    `json:"body"`
+	Used time.Time `json:"used"`
+}
+
+const (
+	// githubCacheMaxEntries bounds the cache; the least recently used
+	// responses are dropped first
+	githubCacheMaxEntries = 2000
+
+	// githubCacheMaxBody is the largest response body that is cached
+	githubCacheMaxBody = 1 << 20
+)
+
+// newGitHubCache creates a cache saved to path ("" for memory only)
+func newGitHubCache(path string) *githubCache {
+	return &githubCache{path: path, entries: make(map[string]*githubCacheEntry)}
+}
+
+// get returns the cached response for a request key, or nil
+func (c *githubCache) get(key string) *githubCacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.load()
+
+	entry := c.entries[key]
+	if entry != nil {
+		entry.Used = time.Now()
+	}
+	return entry
+}
+
+// put caches a response
+func (c *githubCache) put(key string, entry *githubCacheEntry) {
+	if len(entry.Body) > githubCacheMaxBody {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.load()
+
+	entry.Used = time.Now()
+	c.entries[key] = entry
+	c.dirty = true
+
+	if len(c.entries) > githubCacheMaxEntries {
+		keys := make([]string, 0, len(c.entries))
+		for k := range c.entries {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool { return c.entries[keys[i]].Used.Before(c.entries[keys[j]].Used) })
+		for _, k := range keys[:len(keys)-githubCacheMaxEntries] {
+			delete(c.entries, k)
+		}
+	}
+}
+
+// load reads the cache file once; a missing or unreadable file starts an
+// empty cache. The caller holds c.mu.
+func (c *githubCache) load() {
+	if c.loaded || c.path == "" {
+		return
+	}
+	c.loaded = true
+
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return
+	}
+	var entries map[string]*githubCacheEntry
+	if json.Unmarshal(data, &entries) == nil && entries != nil {
+		c.entries = entries
+	}
+}
+
+// save writes the cache file if anything changed
+// The file is replaced atomically and only readable by the user, as it
+// holds repository content.
+func (c *githubCache) save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.dirty || c.path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(c.entries)
+	if err != nil {
+		return fmt.Errorf("failed to encode github cache: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0700); err != nil {
+		return fmt.Errorf("failed to create github cache directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), ".github-cache-*")
+	if err != nil {
+		return fmt.Errorf("failed to write github cache: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write github cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write github cache: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		return fmt.Errorf("failed to write github cache: %w", err)
+	}
+
+	c.dirty = false
+	return nil
+}
+
+// response rebuilds a 200 response from the cache, with the headers of the
+// 304 response (which carry the current rate limit)
+func (e *githubCacheEntry) response(req *http.Request, header http.Header) *http.Response {
+	header = header.Clone()
+	header.Set("ETag", e.ETag)
+	header.Del("Content-Length")
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
+
+// githubCachePath returns the cache file of a repository under cacheDir
+// cacheDir "" means the user cache directory, "none" no file. Without a
+// home or cache directory the cache is kept in memory.
+func githubCachePath(cacheDir, apiRoot, owner, repo string) string {
+	if cacheDir == "none" {
+		return ""
+	}
+	if cacheDir == "" {
+		userCache, err := os.UserCacheDir()
+		if err != nil {
+			return ""
+		}
+		cacheDir = filepath.Join(userCache, "njgit")
+	} else if strings.HasPrefix(cacheDir, "~") {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		cacheDir = filepath.Join(homeDir, cacheDir[1:])
+	}
+
+	host := strings.TrimPrefix(strings.TrimPrefix(apiRoot, "https://"), "http://")
+	name := strings.NewReplacer("/", "_", ":", "_").Replace(fmt.Sprintf("%s_%s_%s", host, owner, repo))
+	return filepath.Join(cacheDir, "github", name+".json")
+}
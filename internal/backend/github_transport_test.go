@@ -0,0 +1,343 @@
+package backend
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/wlame/njgit/internal/config"
+)
+
+// TestGitHubAPIRoot tests the REST API root derived from git.api_url
+func TestGitHubAPIRoot(t *testing.T) {
+	tests := []struct {
+		apiURL string
+		want   string
+	}{
+		{"", "https://api.github.com"},
+		{"https://api.github.com/", "https://api.github.com"},
+		{"https://github.example.com", "https://github.example.com/api/v3"},
+		{"https://github.example.com/api/v3", "https://github.example.com/api/v3"},
+	}
+
+	for _, tt := range tests {
+		if got := githubAPIRoot(tt.apiURL); got != tt.want {
+			t.Errorf("githubAPIRoot(%q) = %q, want %q", tt.apiURL, got, tt.want)
+		}
+	}
+}
+
+// TestGitHubBackend_Enterprise tests that requests go to the /api/v3 root
+// of a GitHub Enterprise Server
+func TestGitHubBackend_Enterprise(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		_ = json.NewEncoder(w).Encode(githubFileResponse{
+			Path:    "default/test.hcl",
+			SHA:     "abc123",
+			Content: base64.StdEncoding.EncodeToString([]byte("job")),
+			Type:    "file",
+		})
+	}))
+	defer server.Close()
+
+	backend, err := NewGitHubBackend(&config.GitConfig{
+		Owner:    "test-owner",
+		Repo:     "test-repo",
+		Token:    "test-token",
+		APIURL:   server.URL,
+		CacheDir: "none",
+	})
+	if err != nil {
+		t.Fatalf("NewGitHubBackend() unexpected error: %v", err)
+	}
+
+	if _, err := backend.ReadFile(context.Background(), "default/test.hcl"); err != nil {
+		t.Fatalf("ReadFile() unexpected error: %v", err)
+	}
+	want := "/api/v3/repos/test-owner/test-repo/contents/default/test.hcl"
+	if len(paths) != 1 || paths[0] != want {
+		t.Errorf("ReadFile() requested %v, want [%s]", paths, want)
+	}
+}
+
+// TestGitHubBackend_AppAuth tests authenticating as a GitHub App
+// installation: the installation is looked up with a JWT, its token is
+// used for requests and replaced when it nears expiry or is rejected
+func TestGitHubBackend_AppAuth(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	keyPath := filepath.Join(t.TempDir(), "app.pem")
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	if err := os.WriteFile(keyPath, keyPEM, 0600); err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	var lookups, tokens atomic.Int32
+	revoked := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		switch {
+		case r.URL.Path == "/api/v3/repos/test-owner/test-repo/installation":
+			checkGitHubAppJWT(t, auth, &key.PublicKey)
+			lookups.Add(1)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"id": 42})
+
+		case r.URL.Path == "/api/v3/app/installations/42/access_tokens" && r.Method == http.MethodPost:
+			checkGitHubAppJWT(t, auth, &key.PublicKey)
+			n := tokens.Add(1)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"token":      fmt.Sprintf("installation-%d", n),
+				"expires_at": now.Add(time.Hour),
+			})
+
+		case strings.HasPrefix(r.URL.Path, "/api/v3/repos/test-owner/test-repo/contents/"):
+			if !strings.HasPrefix(auth, "token installation-") || auth == revoked {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(githubFileResponse{
+				SHA:     "abc123",
+				Content: base64.StdEncoding.EncodeToString([]byte(auth)),
+				Type:    "file",
+			})
+
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	backend, err := NewGitHubBackend(&config.GitConfig{
+		Owner:         "test-owner",
+		Repo:          "test-repo",
+		APIURL:        server.URL,
+		AppID:         7,
+		AppPrivateKey: keyPath,
+		CacheDir:      "none",
+	})
+	if err != nil {
+		t.Fatalf("NewGitHubBackend() unexpected error: %v", err)
+	}
+	installation := backend.httpClient.Transport.(*githubTransport).credentials.(*githubAppInstallation)
+	installation.now = func() time.Time { return now }
+
+	read := func(want string) {
+		t.Helper()
+		content, err := backend.ReadFile(context.Background(), "default/test.hcl")
+		if err != nil {
+			t.Fatalf("ReadFile() unexpected error: %v", err)
+		}
+		if string(content) != "token "+want {
+			t.Errorf("ReadFile() authenticated with %q, want %q", content, "token "+want)
+		}
+	}
+
+	read("installation-1")
+	read("installation-1")
+
+	// Near expiry the token is replaced before it is used
+	now = now.Add(56 * time.Minute)
+	read("installation-2")
+
+	// A rejected token is replaced and the request retried
+	revoked = "token installation-2"
+	read("installation-3")
+
+	if n := lookups.Load(); n != 1 {
+		t.Errorf("installation looked up %d times, want 1", n)
+	}
+}
+
+// checkGitHubAppJWT verifies an app JWT's signature and issuer
+func checkGitHubAppJWT(t *testing.T, auth string, key *rsa.PublicKey) {
+	t.Helper()
+	parts := strings.Split(strings.TrimPrefix(auth, "Bearer "), ".")
+	if !strings.HasPrefix(auth, "Bearer ") || len(parts) != 3 {
+		t.Errorf("Authorization = %q, want a Bearer JWT", auth)
+		return
+	}
+
+	signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		t.Errorf("JWT signature invalid: %v", err)
+	}
+
+	var claims struct {
+		Iss string `json:"iss"`
+	}
+	payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Iss != "7" {
+		t.Errorf("JWT claims = %s, want iss 7", payload)
+	}
+}
+
+// TestGitHubTransport_RateLimit tests that rate-limited requests are
+// retried after the wait GitHub asks for, with their body intact
+func TestGitHubTransport_RateLimit(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	var bodies []string
+	responses := []func(w http.ResponseWriter){
+		func(w http.ResponseWriter) {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = io.WriteString(w, `{"message":"You have exceeded a secondary rate limit."}`)
+		},
+		func(w http.ResponseWriter) {
+			w.Header().Set("Retry-After", "3")
+			w.WriteHeader(http.StatusTooManyRequests)
+		},
+		func(w http.ResponseWriter) {
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", fmt.Sprint(now.Unix()+20))
+			w.WriteHeader(http.StatusForbidden)
+		},
+		func(w http.ResponseWriter) {
+			w.WriteHeader(http.StatusCreated)
+		},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		responses[len(bodies)-1](w)
+	}))
+	defer server.Close()
+
+	var waits []time.Duration
+	transport := newGitHubTransport(githubToken("test-token"), nil, 0)
+	transport.now = func() time.Time { return now }
+	transport.sleep = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+	client := &http.Client{Transport: transport}
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL, strings.NewReader("payload"))
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("request unexpected error: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusCreated)
+	}
+	wantWaits := []time.Duration{time.Minute, 3 * time.Second, 21 * time.Second}
+	if fmt.Sprint(waits) != fmt.Sprint(wantWaits) {
+		t.Errorf("waits = %v, want %v", waits, wantWaits)
+	}
+	for i, body := range bodies {
+		if body != "payload" {
+			t.Errorf("attempt %d body = %q, want %q", i+1, body, "payload")
+		}
+	}
+}
+
+// TestGitHubTransport_RateLimitTooLong tests that a request limited for
+// longer than njgit waits fails right away
+func TestGitHubTransport_RateLimitTooLong(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", fmt.Sprint(time.Now().Add(time.Hour).Unix()))
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	transport := newGitHubTransport(githubToken("test-token"), nil, 0)
+	transport.sleep = func(ctx context.Context, d time.Duration) error {
+		t.Errorf("slept %v, want no retry", d)
+		return nil
+	}
+
+	resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+	if err != nil {
+		t.Fatalf("request unexpected error: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusForbidden || requests.Load() != 1 {
+		t.Errorf("status = %d after %d requests, want 403 after 1", resp.StatusCode, requests.Load())
+	}
+}
+
+// TestGitHubBackend_ETagCache tests that reads are conditional on the last
+// ETag, answered from the cache on 304, and that the cache outlives the
+// backend when saved to cache_dir
+func TestGitHubBackend_ETagCache(t *testing.T) {
+	var conditional, notModified atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") != "" {
+			conditional.Add(1)
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		_ = json.NewEncoder(w).Encode(githubFileResponse{
+			SHA:     "abc123",
+			Content: base64.StdEncoding.EncodeToString([]byte("job")),
+			Type:    "file",
+		})
+	}))
+	defer server.Close()
+
+	cfg := &config.GitConfig{
+		Owner:    "test-owner",
+		Repo:     "test-repo",
+		Token:    "test-token",
+		APIURL:   server.URL,
+		CacheDir: t.TempDir(),
+	}
+
+	for run := 1; run <= 2; run++ {
+		backend, err := NewGitHubBackend(cfg)
+		if err != nil {
+			t.Fatalf("NewGitHubBackend() unexpected error: %v", err)
+		}
+		for i := 0; i < 2; i++ {
+			content, err := backend.ReadFile(context.Background(), "default/test.hcl")
+			if err != nil {
+				t.Fatalf("ReadFile() unexpected error: %v", err)
+			}
+			if string(content) != "job" {
+				t.Errorf("ReadFile() = %q, want %q", content, "job")
+			}
+		}
+		if err := backend.Close(); err != nil {
+			t.Fatalf("Close() unexpected error: %v", err)
+		}
+	}
+
+	// Only the very first read is unconditional
+	if n := conditional.Load(); n != 3 {
+		t.Errorf("%d conditional requests, want 3", n)
+	}
+	if n := notModified.Load(); n != 3 {
+		t.Errorf("%d requests answered from the cache, want 3", n)
+	}
+}
@@ -0,0 +1,928 @@
+package backend
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wlame/njgit/internal/config"
+	gitpkg "github.com/wlame/njgit/internal/git"
+)
+
+// defaultGitLabURL is the server used when git.api_url isn't set
+const defaultGitLabURL = "https://gitlab.com"
+
+// GitLabBackend implements the Backend interface using the GitLab REST API
+// (v4), for gitlab.com and self-managed GitLab.
+// Like the GitHub backend it keeps no local repository, but all staged
+// files are committed at once: the commits API takes several file actions
+// in one atomic commit.
+type GitLabBackend struct {
+	config      *config.GitConfig
+	client      *restClient
+	project     string            // Escaped project ID or path, for /projects/{project}
+	branch      string            // Branch read from and committed to (git.branch, or UseBranch)
+	stagedFiles map[string][]byte // Map of path -> content for files to commit
+}
+
+// gitlabCommit represents a commit from the commits API
+type gitlabCommit struct {
+	ID           string    `json:"id"`
+	Message      string    `json:"message"`
+	AuthorName   string    `json:"author_name"`
+	AuthorEmail  string    `json:"author_email"`
+	AuthoredDate time.Time `json:"authored_date"`
+	ParentIDs    []string  `json:"parent_ids"`
+}
+
+// gitlabDiff represents one changed file from the commit and merge
+// request diff APIs
+type gitlabDiff struct {
+	OldPath string `json:"old_path"`
+	NewPath string `json:"new_path"`
+}
+
+// gitlabFile represents a file from GET /projects/{id}/repository/files/{path}
+type gitlabFile struct {
+	FilePath string `json:"file_path"`
+	Content  string `json:"content"`  // Base64 encoded
+	Encoding string `json:"encoding"` // "base64"
+}
+
+// gitlabCommitAction is one file change in a commit request
+type gitlabCommitAction struct {
+	Action   string `json:"action"` // "create" or "update"
+	FilePath string `json:"file_path"`
+	Content  string `json:"content"`
+	Encoding string `json:"encoding"`
+}
+
+// gitlabCommitRequest represents the request body for
+// POST /projects/{id}/repository/commits
+type gitlabCommitRequest struct {
+	Branch        string               `json:"branch"`
+	CommitMessage string               `json:"commit_message"`
+	Actions       []gitlabCommitAction `json:"actions"`
+	AuthorName    string               `json:"author_name,omitempty"`
+	AuthorEmail   string               `json:"author_email,omitempty"`
+}
+
+// gitlabBranch represents a branch from the branches API
+type gitlabBranch struct {
+	Name   string       `json:"name"`
+	Commit gitlabCommit `json:"commit"`
+}
+
+// gitlabTag represents a tag from the tags API
+type gitlabTag struct {
+	Name      string       `json:"name"`
+	Message   string       `json:"message"`
+	Target    string       `json:"target"` // Tag object for annotated tags, else the commit
+	Commit    gitlabCommit `json:"commit"`
+	CreatedAt *time.Time   `json:"created_at"`
+}
+
+// gitlabMergeRequest represents a merge request from the merge requests API
+type gitlabMergeRequest struct {
+	IID             int        `json:"iid"`
+	Title           string     `json:"title"`
+	Description     string     `json:"description"`
+	SourceBranch    string     `json:"source_branch"`
+	TargetBranch    string     `json:"target_branch"`
+	WebURL          string     `json:"web_url"`
+	State           string     `json:"state"` // "opened", "closed", "merged", ...
+	SHA             string     `json:"sha"`   // Head of the source branch
+	MergeCommitSHA  string     `json:"merge_commit_sha"`
+	SquashCommitSHA string     `json:"squash_commit_sha"`
+	MergedAt        *time.Time `json:"merged_at"`
+}
+
+// NewGitLabBackend creates a new GitLab API backend
+//
+// Parameters:
+//   - cfg: Git configuration (api_url, project or owner/repo, token, branch)
+//
+// Returns:
+//   - *GitLabBackend: The backend
+//   - error: If required settings are missing
+func NewGitLabBackend(cfg *config.GitConfig) (*GitLabBackend, error) {
+	project := cfg.Project
+	if project == "" && cfg.Owner != "" && cfg.Repo != "" {
+		project = cfg.Owner + "/" + cfg.Repo
+	}
+	if project == "" {
+		return nil, fmt.Errorf("project is required for gitlab-api backend")
+	}
+	if cfg.Token == "" {
+		return nil, fmt.Errorf("gitlab token is required for gitlab-api backend (set via GITLAB_TOKEN env var)")
+	}
+
+	if cfg.Branch == "" {
+		cfg.Branch = "main"
+	}
+
+	serverURL := cfg.APIURL
+	if serverURL == "" {
+		serverURL = defaultGitLabURL
+	}
+
+	token := cfg.Token
+	client := newRestClient("gitlab", apiRoot(serverURL, "/api/v4"), cfg.Timeout, func(req *http.Request) {
+		req.Header.Set("PRIVATE-TOKEN", token)
+	})
+
+	return &GitLabBackend{
+		config:      cfg,
+		client:      client,
+		project:     url.PathEscape(project),
+		branch:      cfg.Branch,
+		stagedFiles: make(map[string][]byte),
+	}, nil
+}
+
+// projectURL returns the endpoint of a project resource, e.g.
+// projectURL("/repository/commits") -> /projects/{project}/repository/commits
+func (g *GitLabBackend) projectURL(format string, args ...interface{}) string {
+	return "/projects/" + g.project + fmt.Sprintf(format, args...)
+}
+
+// Initialize checks that the token can access the project.
+//
+// Parameters:
+//   - ctx: Cancels the request; git.timeout applies on top
+//
+// Returns:
+//   - error: If the token is invalid or the project can't be found
+func (g *GitLabBackend) Initialize(ctx context.Context) error {
+	status, _, err := g.client.do(ctx, http.MethodGet, g.projectURL(""), nil, nil, nil)
+	switch {
+	case status == http.StatusUnauthorized:
+		return fmt.Errorf("invalid GitLab token (check GITLAB_TOKEN)")
+	case status == http.StatusNotFound:
+		project, _ := url.PathUnescape(g.project)
+		return fmt.Errorf("project not found: %s (or the token has no access)", project)
+	case err != nil:
+		return fmt.Errorf("failed to access project: %w", err)
+	}
+	return nil
+}
+
+// ReadFile reads a file from the current branch.
+//
+// Parameters:
+//   - ctx: Cancels the request; git.timeout applies on top
+//   - path: The file path relative to repository root
+//
+// Returns:
+//   - []byte: The file content
+//   - error: If the file doesn't exist or can't be read
+func (g *GitLabBackend) ReadFile(ctx context.Context, path string) ([]byte, error) {
+	return g.readFile(ctx, g.branch, path)
+}
+
+// readFile reads a file at a ref through the repository files API
+func (g *GitLabBackend) readFile(ctx context.Context, ref, path string) ([]byte, error) {
+	query := url.Values{}
+	query.Set("ref", ref)
+
+	var file gitlabFile
+	status, _, err := g.client.do(ctx, http.MethodGet, g.projectURL("/repository/files/%s", url.PathEscape(path)), query, nil, &file)
+	if status == http.StatusNotFound {
+		return nil, fmt.Errorf("file not found: %s", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	content, err := base64.StdEncoding.DecodeString(file.Content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode file content: %w", err)
+	}
+	return content, nil
+}
+
+// WriteFile stages a file to be committed by the next Commit.
+//
+// Parameters:
+//   - ctx: Unused; staging is local
+//   - path: The file path relative to repository root
+//   - content: The file content
+//
+// Returns:
+//   - error: If the path is empty
+func (g *GitLabBackend) WriteFile(ctx context.Context, path string, content []byte) error {
+	if path == "" {
+		return fmt.Errorf("file path cannot be empty")
+	}
+	path = strings.TrimPrefix(filepath.Clean(path), "./")
+	g.stagedFiles[path] = content
+	return nil
+}
+
+// FileExists checks if a file exists on the current branch.
+//
+// Parameters:
+//   - ctx: Cancels the request; git.timeout applies on top
+//   - path: The file path relative to repository root
+//
+// Returns:
+//   - bool: true if the file exists
+//   - error: Any error other than the file not existing
+func (g *GitLabBackend) FileExists(ctx context.Context, path string) (bool, error) {
+	query := url.Values{}
+	query.Set("ref", g.branch)
+
+	status, _, err := g.client.do(ctx, http.MethodHead, g.projectURL("/repository/files/%s", url.PathEscape(path)), query, nil, nil)
+	if status == http.StatusNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check %s: %w", path, err)
+	}
+	return true, nil
+}
+
+// History lists the commits on the current branch through the commits API.
+// Each commit's files are looked up with one more request.
+//
+// Parameters:
+//   - ctx: Cancels the requests; git.timeout applies to each
+//   - path: Only commits that changed this file ("" for all commits)
+//   - limit: Maximum number of commits (0 for unlimited)
+//
+// Returns:
+//   - []gitpkg.CommitInfo: The commits, newest first
+//   - error: Any error encountered
+func (g *GitLabBackend) History(ctx context.Context, path string, limit int) ([]gitpkg.CommitInfo, error) {
+	const perPage = 100
+
+	var commits []gitpkg.CommitInfo
+	for page := 1; ; page++ {
+		query := url.Values{}
+		query.Set("ref_name", g.branch)
+		query.Set("per_page", strconv.Itoa(perPage))
+		query.Set("page", strconv.Itoa(page))
+		if path != "" {
+			query.Set("path", path)
+		}
+
+		var listed []gitlabCommit
+		if _, _, err := g.client.do(ctx, http.MethodGet, g.projectURL("/repository/commits"), query, nil, &listed); err != nil {
+			return nil, fmt.Errorf("failed to list commits: %w", err)
+		}
+
+		for i := range listed {
+			info, err := g.commitInfo(ctx, &listed[i])
+			if err != nil {
+				return nil, err
+			}
+			commits = append(commits, *info)
+			if limit > 0 && len(commits) >= limit {
+				return commits, nil
+			}
+		}
+
+		if len(listed) < perPage {
+			return commits, nil
+		}
+	}
+}
+
+// ReadFileAt reads a file as it was at a revision.
+//
+// Parameters:
+//   - ctx: Cancels the requests; git.timeout applies to each
+//   - rev: Commit hash (full or abbreviated), branch or tag
+//   - path: The file path relative to repository root
+//
+// Returns:
+//   - []byte: The file content at that revision
+//   - error: If the revision or the file doesn't exist
+func (g *GitLabBackend) ReadFileAt(ctx context.Context, rev, path string) ([]byte, error) {
+	commit, err := g.resolveCommit(ctx, rev)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := g.readFile(ctx, commit.ID, path)
+	if err != nil {
+		return nil, fmt.Errorf("%w at commit %s", err, rev)
+	}
+	return content, nil
+}
+
+// ListFiles lists the files under a directory at a revision through the
+// repository tree API.
+//
+// Parameters:
+//   - ctx: Cancels the requests; git.timeout applies to each
+//   - rev: Commit hash (full or abbreviated), branch or tag
+//   - dir: Relative directory path ("" for all files)
+//
+// Returns:
+//   - []string: Paths relative to the repository root, sorted
+//   - error: Any error encountered
+func (g *GitLabBackend) ListFiles(ctx context.Context, rev, dir string) ([]string, error) {
+	commit, err := g.resolveCommit(ctx, rev)
+	if err != nil {
+		return nil, err
+	}
+
+	dir = strings.Trim(dir, "/")
+	if dir == "." {
+		dir = ""
+	}
+
+	const perPage = 100
+	files := []string{}
+	for page := 1; ; page++ {
+		query := url.Values{}
+		query.Set("ref", commit.ID)
+		query.Set("recursive", "true")
+		query.Set("per_page", strconv.Itoa(perPage))
+		query.Set("page", strconv.Itoa(page))
+		if dir != "" {
+			query.Set("path", dir)
+		}
+
+		var entries []struct {
+			Path string `json:"path"`
+			Type string `json:"type"` // "blob", "tree" or "commit" (submodule)
+		}
+		status, _, err := g.client.do(ctx, http.MethodGet, g.projectURL("/repository/tree"), query, nil, &entries)
+		if status == http.StatusNotFound && dir != "" {
+			// The directory doesn't exist at this commit
+			return files, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list files at commit %s: %w", rev, err)
+		}
+
+		for _, entry := range entries {
+			if entry.Type == "blob" {
+				files = append(files, entry.Path)
+			}
+		}
+		if len(entries) < perPage {
+			break
+		}
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// ResolveRevision looks up a commit through the commits API, and the
+// files it changed through the commit diff API.
+//
+// Parameters:
+//   - ctx: Cancels the requests; git.timeout applies to each
+//   - rev: Commit hash (full or abbreviated), branch or tag
+//
+// Returns:
+//   - *gitpkg.CommitInfo: The commit, with the files it changed
+//   - error: If the revision doesn't name a commit
+func (g *GitLabBackend) ResolveRevision(ctx context.Context, rev string) (*gitpkg.CommitInfo, error) {
+	commit, err := g.resolveCommit(ctx, rev)
+	if err != nil {
+		return nil, err
+	}
+	return g.commitInfo(ctx, commit)
+}
+
+// resolveCommit looks up a commit without its files
+func (g *GitLabBackend) resolveCommit(ctx context.Context, rev string) (*gitlabCommit, error) {
+	var commit gitlabCommit
+	status, _, err := g.client.do(ctx, http.MethodGet, g.projectURL("/repository/commits/%s", url.PathEscape(rev)), nil, nil, &commit)
+	if status == http.StatusNotFound {
+		return nil, fmt.Errorf("revision %s not found", rev)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit %s: %w", rev, err)
+	}
+	return &commit, nil
+}
+
+// commitInfo converts a commit, looking up the files it changed
+func (g *GitLabBackend) commitInfo(ctx context.Context, commit *gitlabCommit) (*gitpkg.CommitInfo, error) {
+	diffs, err := g.listDiffs(ctx, g.projectURL("/repository/commits/%s/diff", commit.ID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files of commit %s: %w", commit.ID, err)
+	}
+
+	info := gitlabCommitInfo(commit)
+	info.Files = diffs
+	return info, nil
+}
+
+// gitlabCommitInfo converts a commit without looking up its files
+// GitLab reports signatures through a separate API, so commits are shown
+// as unsigned.
+func gitlabCommitInfo(commit *gitlabCommit) *gitpkg.CommitInfo {
+	return &gitpkg.CommitInfo{
+		Hash:     shortSHA(commit.ID),
+		FullHash: commit.ID,
+		Message:  strings.TrimSpace(commit.Message),
+		Author:   commit.AuthorName,
+		Email:    commit.AuthorEmail,
+		Date:     commit.AuthoredDate,
+		Job:      gitpkg.ParseJobTrailers(commit.Message),
+	}
+}
+
+// listDiffs lists the paths in a paginated diff endpoint (a commit's or a
+// merge request's); renamed files are listed under both names
+func (g *GitLabBackend) listDiffs(ctx context.Context, endpoint string) ([]string, error) {
+	const perPage = 100
+
+	var files []string
+	for page := 1; ; page++ {
+		query := url.Values{}
+		query.Set("per_page", strconv.Itoa(perPage))
+		query.Set("page", strconv.Itoa(page))
+
+		var diffs []gitlabDiff
+		if _, _, err := g.client.do(ctx, http.MethodGet, endpoint, query, nil, &diffs); err != nil {
+			return nil, err
+		}
+		for _, diff := range diffs {
+			files = append(files, diff.NewPath)
+			if diff.OldPath != "" && diff.OldPath != diff.NewPath {
+				files = append(files, diff.OldPath)
+			}
+		}
+		if len(diffs) < perPage {
+			return files, nil
+		}
+	}
+}
+
+// UseBranch switches the branch reads and commits use, creating it from
+// the current branch if it doesn't exist.
+//
+// Parameters:
+//   - ctx: Cancels the requests; git.timeout applies to each
+//   - branch: Branch name, e.g. "env/prod"
+//
+// Returns:
+//   - error: If the branch can't be looked up or created
+func (g *GitLabBackend) UseBranch(ctx context.Context, branch string) error {
+	if branch == g.branch {
+		return nil
+	}
+
+	status, _, err := g.client.do(ctx, http.MethodGet, g.projectURL("/repository/branches/%s", url.PathEscape(branch)), nil, nil, nil)
+	switch {
+	case status == http.StatusNotFound:
+		if err := g.createBranch(ctx, branch, g.branch); err != nil {
+			return err
+		}
+		fmt.Printf("🌿 Created branch on GitLab: %s\n", branch)
+	case err != nil:
+		return fmt.Errorf("failed to look up branch %s: %w", branch, err)
+	default:
+		fmt.Printf("🌿 Using branch on GitLab: %s\n", branch)
+	}
+
+	g.branch = branch
+	return nil
+}
+
+// createBranch creates a branch at a ref (branch, tag or commit)
+func (g *GitLabBackend) createBranch(ctx context.Context, branch, ref string) error {
+	query := url.Values{}
+	query.Set("branch", branch)
+	query.Set("ref", ref)
+	if _, _, err := g.client.do(ctx, http.MethodPost, g.projectURL("/repository/branches"), query, nil, nil); err != nil {
+		return fmt.Errorf("failed to create branch %s: %w", branch, err)
+	}
+	return nil
+}
+
+// CreateTag creates an annotated tag through the tags API.
+// GitLab records the token's user as the tagger.
+//
+// Parameters:
+//   - ctx: Cancels the requests; git.timeout applies to each
+//   - name: Tag name, e.g. "deploy/global/default/web/20240115T143022Z"
+//   - rev: The revision to tag
+//   - message: The tag message
+//
+// Returns:
+//   - *gitpkg.TagInfo: The new tag
+//   - error: If the tag exists or the revision doesn't
+func (g *GitLabBackend) CreateTag(ctx context.Context, name, rev, message string) (*gitpkg.TagInfo, error) {
+	commit, err := g.resolveCommit(ctx, rev)
+	if err != nil {
+		return nil, err
+	}
+
+	if status, _, _ := g.client.do(ctx, http.MethodGet, g.projectURL("/repository/tags/%s", url.PathEscape(name)), nil, nil, nil); status == http.StatusOK {
+		return nil, fmt.Errorf("tag %s already exists", name)
+	}
+
+	request := map[string]string{"tag_name": name, "ref": commit.ID, "message": message}
+	var tag gitlabTag
+	if _, _, err := g.client.do(ctx, http.MethodPost, g.projectURL("/repository/tags"), nil, request, &tag); err != nil {
+		return nil, fmt.Errorf("failed to create tag %s: %w", name, err)
+	}
+	return gitlabTagInfo(&tag), nil
+}
+
+// ListTags lists the tags whose names start with prefix.
+//
+// Parameters:
+//   - ctx: Cancels the requests; git.timeout applies to each
+//   - prefix: Name prefix ("" for all tags)
+//
+// Returns:
+//   - []gitpkg.TagInfo: The tags, sorted by name
+//   - error: Any error encountered
+func (g *GitLabBackend) ListTags(ctx context.Context, prefix string) ([]gitpkg.TagInfo, error) {
+	const perPage = 100
+
+	tags := []gitpkg.TagInfo{}
+	for page := 1; ; page++ {
+		query := url.Values{}
+		query.Set("per_page", strconv.Itoa(perPage))
+		query.Set("page", strconv.Itoa(page))
+		if prefix != "" {
+			query.Set("search", "^"+prefix)
+		}
+
+		var listed []gitlabTag
+		if _, _, err := g.client.do(ctx, http.MethodGet, g.projectURL("/repository/tags"), query, nil, &listed); err != nil {
+			return nil, fmt.Errorf("failed to list tags: %w", err)
+		}
+		for i := range listed {
+			if strings.HasPrefix(listed[i].Name, prefix) {
+				tags = append(tags, *gitlabTagInfo(&listed[i]))
+			}
+		}
+		if len(listed) < perPage {
+			break
+		}
+	}
+
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+	return tags, nil
+}
+
+// gitlabTagInfo converts a tag from the tags API
+func gitlabTagInfo(tag *gitlabTag) *gitpkg.TagInfo {
+	info := &gitpkg.TagInfo{
+		Name:    tag.Name,
+		Commit:  tag.Commit.ID,
+		Message: strings.TrimSpace(tag.Message),
+	}
+	if tag.CreatedAt != nil {
+		info.Date = *tag.CreatedAt
+	}
+	return info
+}
+
+// ReadRef returns the commit a reference points to.
+// GitLab only stores branches and tags, so refs outside refs/heads/ and
+// refs/tags/ are kept as branches (refs/njgit/applied/main is the branch
+// njgit/applied/main).
+//
+// Parameters:
+//   - ctx: Cancels the request; git.timeout applies on top
+//   - name: Full reference name
+//
+// Returns:
+//   - string: The full commit hash, or "" if the reference doesn't exist
+//   - error: Any other error
+func (g *GitLabBackend) ReadRef(ctx context.Context, name string) (string, error) {
+	kind, short := refTarget(name)
+
+	var commitID string
+	var status int
+	var err error
+	if kind == "tag" {
+		var tag gitlabTag
+		status, _, err = g.client.do(ctx, http.MethodGet, g.projectURL("/repository/tags/%s", url.PathEscape(short)), nil, nil, &tag)
+		commitID = tag.Commit.ID
+	} else {
+		var branch gitlabBranch
+		status, _, err = g.client.do(ctx, http.MethodGet, g.projectURL("/repository/branches/%s", url.PathEscape(short)), nil, nil, &branch)
+		commitID = branch.Commit.ID
+	}
+	if status == http.StatusNotFound {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	return commitID, nil
+}
+
+// UpdateRef points a reference at a commit by deleting and recreating the
+// branch or tag that stores it (see ReadRef). GitLab can't move them in
+// one request, so if the new one can't be created the old one is put back.
+//
+// Parameters:
+//   - ctx: Cancels the requests; git.timeout applies to each
+//   - name: Full reference name
+//   - rev: The revision to point it at
+//
+// Returns:
+//   - error: If the revision doesn't exist or the update fails
+func (g *GitLabBackend) UpdateRef(ctx context.Context, name, rev string) error {
+	commit, err := g.resolveCommit(ctx, rev)
+	if err != nil {
+		return err
+	}
+	old, err := g.ReadRef(ctx, name)
+	if err != nil {
+		return err
+	}
+	if old == commit.ID {
+		return nil
+	}
+
+	kind, short := refTarget(name)
+	resource := "/repository/branches/%s"
+	if kind == "tag" {
+		resource = "/repository/tags/%s"
+	}
+	if old != "" {
+		status, _, err := g.client.do(ctx, http.MethodDelete, g.projectURL(resource, url.PathEscape(short)), nil, nil, nil)
+		if err != nil && status != http.StatusNotFound {
+			return fmt.Errorf("failed to update %s: %w", name, err)
+		}
+	}
+
+	if err := g.createRef(ctx, kind, short, commit.ID); err != nil {
+		return restoreRef(name, old, err, func() error { return g.createRef(context.WithoutCancel(ctx), kind, short, old) })
+	}
+	return nil
+}
+
+// createRef creates the branch or tag (kind, see refTarget) that stores a
+// reference
+func (g *GitLabBackend) createRef(ctx context.Context, kind, short, sha string) error {
+	if kind == "tag" {
+		request := map[string]string{"tag_name": short, "ref": sha}
+		if _, _, err := g.client.do(ctx, http.MethodPost, g.projectURL("/repository/tags"), nil, request, nil); err != nil {
+			return fmt.Errorf("failed to create tag %s: %w", short, err)
+		}
+		return nil
+	}
+	return g.createBranch(ctx, short, sha)
+}
+
+// CommitsSince lists the commits reachable from rev but not from since
+// through the compare API.
+//
+// Parameters:
+//   - ctx: Cancels the request; git.timeout applies on top
+//   - since: The revision already seen
+//   - rev: The newer revision
+//
+// Returns:
+//   - []gitpkg.CommitInfo: The commits, oldest first (without Files)
+//   - error: If either revision doesn't exist
+func (g *GitLabBackend) CommitsSince(ctx context.Context, since, rev string) ([]gitpkg.CommitInfo, error) {
+	query := url.Values{}
+	query.Set("from", since)
+	query.Set("to", rev)
+
+	var compare struct {
+		Commits []gitlabCommit `json:"commits"`
+	}
+	status, _, err := g.client.do(ctx, http.MethodGet, g.projectURL("/repository/compare"), query, nil, &compare)
+	if status == http.StatusNotFound {
+		return nil, fmt.Errorf("revision %s or %s not found", since, rev)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare %s with %s: %w", since, rev, err)
+	}
+
+	ids := make([]string, len(compare.Commits))
+	parents := make(map[string][]string, len(compare.Commits))
+	for i, c := range compare.Commits {
+		ids[i] = c.ID
+		parents[c.ID] = c.ParentIDs
+	}
+
+	commits := make([]gitpkg.CommitInfo, 0, len(compare.Commits))
+	for _, i := range parentsFirst(ids, parents) {
+		commits = append(commits, *gitlabCommitInfo(&compare.Commits[i]))
+	}
+	return commits, nil
+}
+
+// Commit creates one commit with all staged files through the commits API.
+// The commit is atomic: either every file is committed or none is.
+//
+// Parameters:
+//   - ctx: A context canceled before the commit starts aborts it
+//   - message: The commit message
+//   - author: Who made the change (nil for njgit: git.author_name/author_email)
+//
+// Returns:
+//   - string: The short commit hash ("" if nothing was staged)
+//   - error: If the commit fails; the files stay staged
+func (g *GitLabBackend) Commit(ctx context.Context, message string, author *gitpkg.Identity) (string, error) {
+	if len(g.stagedFiles) == 0 {
+		return "", nil // Nothing to commit
+	}
+
+	if err := ctx.Err(); err != nil {
+		return "", fmt.Errorf("commit aborted: %w", err)
+	}
+	ctx = context.WithoutCancel(ctx)
+
+	request := gitlabCommitRequest{
+		Branch:        g.branch,
+		CommitMessage: message,
+		AuthorName:    g.config.AuthorName,
+		AuthorEmail:   g.config.AuthorEmail,
+	}
+	if author != nil {
+		request.AuthorName = author.Name
+		request.AuthorEmail = author.Email
+	}
+
+	for _, path := range stagedPaths(g.stagedFiles) {
+		exists, err := g.FileExists(ctx, path)
+		if err != nil {
+			return "", err
+		}
+		action := "create"
+		if exists {
+			action = "update"
+		}
+		request.Actions = append(request.Actions, gitlabCommitAction{
+			Action:   action,
+			FilePath: path,
+			Content:  base64.StdEncoding.EncodeToString(g.stagedFiles[path]),
+			Encoding: "base64",
+		})
+	}
+
+	var commit gitlabCommit
+	if _, _, err := g.client.do(ctx, http.MethodPost, g.projectURL("/repository/commits"), nil, request, &commit); err != nil {
+		return "", fmt.Errorf("failed to commit: %w", err)
+	}
+
+	g.stagedFiles = make(map[string][]byte)
+	fmt.Printf("✅ Committed to GitLab: %s\n", shortSHA(commit.ID))
+	return shortSHA(commit.ID), nil
+}
+
+// CreatePullRequest opens a merge request through the merge requests API.
+//
+// Parameters:
+//   - ctx: Cancels the request; git.timeout applies on top
+//   - pr: The source (Head) and target (Base) branches, title and body
+//
+// Returns:
+//   - *PullRequest: The new merge request; Number is its project-level IID
+//   - error: If the merge request can't be created
+func (g *GitLabBackend) CreatePullRequest(ctx context.Context, pr *PullRequest) (*PullRequest, error) {
+	request := map[string]string{
+		"source_branch": pr.Head,
+		"target_branch": pr.Base,
+		"title":         pr.Title,
+		"description":   pr.Body,
+	}
+
+	var created gitlabMergeRequest
+	if _, _, err := g.client.do(ctx, http.MethodPost, g.projectURL("/merge_requests"), nil, request, &created); err != nil {
+		return nil, fmt.Errorf("failed to open merge request from %s into %s: %w", pr.Head, pr.Base, err)
+	}
+	return created.toPullRequest(), nil
+}
+
+// MergedPullRequest finds the merge request merged as a commit: its merge
+// commit, its squash commit, or (for fast-forward merges) its last commit.
+//
+// Parameters:
+//   - ctx: Cancels the requests; git.timeout applies to each
+//   - commit: Full commit hash
+//
+// Returns:
+//   - *PullRequest: The merge request with its files, or nil if commit
+//     didn't merge one
+//   - error: Any error encountered
+func (g *GitLabBackend) MergedPullRequest(ctx context.Context, commit string) (*PullRequest, error) {
+	var mrs []gitlabMergeRequest
+	if _, _, err := g.client.do(ctx, http.MethodGet, g.projectURL("/repository/commits/%s/merge_requests", commit), nil, nil, &mrs); err != nil {
+		return nil, fmt.Errorf("failed to look up merge requests for commit %s: %w", commit, err)
+	}
+
+	for i := range mrs {
+		mr := &mrs[i]
+		if mr.State != "merged" || mr.MergedAt == nil {
+			continue
+		}
+		fastForward := mr.MergeCommitSHA == "" && mr.SquashCommitSHA == "" && mr.SHA == commit
+		if mr.MergeCommitSHA != commit && mr.SquashCommitSHA != commit && !fastForward {
+			continue
+		}
+
+		files, err := g.listDiffs(ctx, g.projectURL("/merge_requests/%d/diffs", mr.IID))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list files of merge request !%d: %w", mr.IID, err)
+		}
+		pr := mr.toPullRequest()
+		pr.MergeCommit = commit
+		pr.Files = files
+		return pr, nil
+	}
+
+	return nil, nil
+}
+
+// toPullRequest converts a merge request
+func (mr *gitlabMergeRequest) toPullRequest() *PullRequest {
+	pr := &PullRequest{
+		Number: mr.IID,
+		Title:  mr.Title,
+		Body:   mr.Description,
+		Head:   mr.SourceBranch,
+		Base:   mr.TargetBranch,
+		URL:    mr.WebURL,
+	}
+	if mr.MergedAt != nil {
+		pr.MergeCommit = mr.MergeCommitSHA
+		if mr.SquashCommitSHA != "" {
+			pr.MergeCommit = mr.SquashCommitSHA
+		}
+		pr.MergedAt = *mr.MergedAt
+	}
+	return pr
+}
+
+// Discard forgets the staged files.
+func (g *GitLabBackend) Discard() error {
+	g.stagedFiles = make(map[string][]byte)
+	return nil
+}
+
+// Pull is a no-op: every read goes to GitLab, so it is always up to date.
+func (g *GitLabBackend) Pull(ctx context.Context) error {
+	return nil
+}
+
+// Push is a no-op: commits are created on GitLab directly.
+func (g *GitLabBackend) Push(ctx context.Context) error {
+	return nil
+}
+
+// Close is a no-op: there are no resources to release.
+func (g *GitLabBackend) Close() error {
+	return nil
+}
+
+// GetName returns the name of this backend.
+func (g *GitLabBackend) GetName() string {
+	return "gitlab-api"
+}
+
+// shortSHA abbreviates a commit hash to 8 characters
+func shortSHA(sha string) string {
+	if len(sha) > 8 {
+		return sha[:8]
+	}
+	return sha
+}
+
+// parentsFirst orders commits so that parents come before their children
+// (oldest first), given each commit's parents
+// Returns indexes into ids. Parents outside ids are ignored.
+func parentsFirst(ids []string, parents map[string][]string) []int {
+	index := make(map[string]int, len(ids))
+	for i, id := range ids {
+		index[id] = i
+	}
+
+	order := make([]int, 0, len(ids))
+	added := make(map[string]bool, len(ids))
+	var visit func(id string)
+	visit = func(id string) {
+		if added[id] {
+			return
+		}
+		added[id] = true
+		for _, parent := range parents[id] {
+			if _, ok := index[parent]; ok {
+				visit(parent)
+			}
+		}
+		order = append(order, index[id])
+	}
+	for _, id := range ids {
+		visit(id)
+	}
+	return order
+}
@@ -0,0 +1,577 @@
+package backend
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/wlame/njgit/internal/config"
+	gitpkg "github.com/wlame/njgit/internal/git"
+)
+
+// TestNewGitLabBackend_Validation tests that NewGitLabBackend validates
+// required fields and works out the project and API root
+func TestNewGitLabBackend_Validation(t *testing.T) {
+	if _, err := NewGitLabBackend(&config.GitConfig{Token: "t"}); err == nil || !strings.Contains(err.Error(), "project is required") {
+		t.Errorf("NewGitLabBackend() without a project error = %v", err)
+	}
+	if _, err := NewGitLabBackend(&config.GitConfig{Project: "group/repo"}); err == nil || !strings.Contains(err.Error(), "GITLAB_TOKEN") {
+		t.Errorf("NewGitLabBackend() without a token error = %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		config      *config.GitConfig
+		wantProject string
+		wantBaseURL string
+	}{
+		{
+			name:        "owner and repo on gitlab.com",
+			config:      &config.GitConfig{Owner: "group/sub", Repo: "nomad-jobs", Token: "t"},
+			wantProject: "group%2Fsub%2Fnomad-jobs",
+			wantBaseURL: "https://gitlab.com/api/v4",
+		},
+		{
+			name:        "numeric project on a self-managed server",
+			config:      &config.GitConfig{Project: "42", APIURL: "https://git.example.com/", Token: "t"},
+			wantProject: "42",
+			wantBaseURL: "https://git.example.com/api/v4",
+		},
+		{
+			name:        "api_url with the API path",
+			config:      &config.GitConfig{Project: "group/repo", APIURL: "https://git.example.com/api/v4", Token: "t"},
+			wantProject: "group%2Frepo",
+			wantBaseURL: "https://git.example.com/api/v4",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := NewGitLabBackend(tt.config)
+			if err != nil {
+				t.Fatalf("NewGitLabBackend() unexpected error: %v", err)
+			}
+			if b.project != tt.wantProject || b.client.baseURL != tt.wantBaseURL {
+				t.Errorf("project = %q, base URL = %q; want %q, %q", b.project, b.client.baseURL, tt.wantProject, tt.wantBaseURL)
+			}
+			if b.branch != "main" || b.GetName() != "gitlab-api" {
+				t.Errorf("branch = %q, name = %q", b.branch, b.GetName())
+			}
+		})
+	}
+}
+
+// fakeGitLab serves the GitLab API for one project (group/nomad-jobs)
+// from the in-memory store of fakeGitHub. Merge requests are squash merged.
+type fakeGitLab struct {
+	*fakeGitHub
+	commitRequests []gitlabCommitRequest
+	rejectBranchAt string // Creating a branch at this commit fails
+}
+
+func (f *fakeGitLab) commitJSON(c *fakeCommit) map[string]interface{} {
+	parents := []string{}
+	if c.parent != "" {
+		parents = append(parents, c.parent)
+	}
+	return map[string]interface{}{
+		"id":            c.sha,
+		"short_id":      c.sha[:8],
+		"message":       c.message,
+		"author_name":   "Test",
+		"author_email":  "test@example.com",
+		"authored_date": "2026-01-02T03:04:05Z",
+		"parent_ids":    parents,
+	}
+}
+
+func (f *fakeGitLab) mergeRequestJSON(pull *fakePull) map[string]interface{} {
+	out := map[string]interface{}{
+		"iid":               pull.number,
+		"title":             pull.title,
+		"description":       pull.body,
+		"source_branch":     pull.head,
+		"target_branch":     pull.base,
+		"web_url":           fmt.Sprintf("https://gitlab.example.com/group/nomad-jobs/-/merge_requests/%d", pull.number),
+		"state":             "opened",
+		"merge_commit_sha":  nil,
+		"squash_commit_sha": nil,
+	}
+	if pull.mergeCommit != "" {
+		out["state"] = "merged"
+		out["merged_at"] = "2026-01-02T03:04:05Z"
+		out["squash_commit_sha"] = pull.mergeCommit
+	}
+	return out
+}
+
+func (f *fakeGitLab) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	reply := func(status int, v interface{}) {
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(v)
+	}
+	notFound := func() { reply(http.StatusNotFound, map[string]string{"message": "404 Not Found"}) }
+	query := r.URL.Query()
+
+	if r.Header.Get("PRIVATE-TOKEN") != "test-token" {
+		reply(http.StatusUnauthorized, map[string]string{"message": "401 Unauthorized"})
+		return
+	}
+	path, ok := strings.CutPrefix(r.URL.EscapedPath(), "/api/v4/projects/group%2Fnomad-jobs")
+	if !ok {
+		notFound()
+		return
+	}
+	// Unescape the last segment (file, branch or tag names)
+	unescape := func(prefix string) string {
+		name, _ := url.PathUnescape(strings.TrimPrefix(path, prefix))
+		return name
+	}
+
+	switch {
+	case path == "" && r.Method == http.MethodGet:
+		reply(http.StatusOK, map[string]interface{}{"id": 42, "path_with_namespace": "group/nomad-jobs"})
+
+	case strings.HasPrefix(path, "/repository/files/"):
+		file := unescape("/repository/files/")
+		commit := f.resolveAny(query.Get("ref"))
+		content, ok := "", false
+		if commit != nil {
+			content, ok = commit.files[file]
+		}
+		if !ok {
+			notFound()
+			return
+		}
+		reply(http.StatusOK, map[string]string{
+			"file_path": file,
+			"encoding":  "base64",
+			"content":   base64.StdEncoding.EncodeToString([]byte(content)),
+		})
+
+	case path == "/repository/commits" && r.Method == http.MethodPost:
+		var req gitlabCommitRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		f.commitRequests = append(f.commitRequests, req)
+		head := f.resolve(req.Branch)
+		if head == nil {
+			reply(http.StatusBadRequest, map[string]string{"message": "You can only create or edit files when you are on a branch"})
+			return
+		}
+		files := make(map[string]string)
+		for p, c := range head.files {
+			files[p] = c
+		}
+		for _, action := range req.Actions {
+			_, exists := files[action.FilePath]
+			if (action.Action == "create") == exists {
+				reply(http.StatusBadRequest, map[string]string{"message": "A file with this name doesn't exist"})
+				return
+			}
+			content, _ := base64.StdEncoding.DecodeString(action.Content)
+			files[action.FilePath] = string(content)
+		}
+		sha := f.addCommit(head.sha, req.CommitMessage, files)
+		f.refs["refs/heads/"+req.Branch] = sha
+		reply(http.StatusCreated, f.commitJSON(f.commits[sha]))
+
+	case path == "/repository/commits":
+		var commits []map[string]interface{}
+		if query.Get("page") == "1" {
+			for c := f.resolve(query.Get("ref_name")); c != nil; c = f.commits[c.parent] {
+				changed := f.changedFiles(c)
+				if p := query.Get("path"); p != "" && (len(changed) == 0 || !contains(changed, p)) {
+					continue
+				}
+				commits = append(commits, f.commitJSON(c))
+			}
+		}
+		reply(http.StatusOK, commits)
+
+	case strings.HasSuffix(path, "/diff"):
+		commit := f.resolve(strings.TrimSuffix(strings.TrimPrefix(path, "/repository/commits/"), "/diff"))
+		diffs := []map[string]string{}
+		if query.Get("page") == "1" {
+			for _, file := range f.changedFiles(commit) {
+				diffs = append(diffs, map[string]string{"old_path": file, "new_path": file})
+			}
+		}
+		reply(http.StatusOK, diffs)
+
+	case strings.HasSuffix(path, "/merge_requests") && strings.HasPrefix(path, "/repository/commits/"):
+		sha := strings.TrimSuffix(strings.TrimPrefix(path, "/repository/commits/"), "/merge_requests")
+		mrs := []map[string]interface{}{}
+		for _, pull := range f.pulls {
+			if pull.mergeCommit == sha {
+				mrs = append(mrs, f.mergeRequestJSON(pull))
+			}
+		}
+		reply(http.StatusOK, mrs)
+
+	case strings.HasPrefix(path, "/repository/commits/"):
+		commit := f.resolveAny(unescape("/repository/commits/"))
+		if commit == nil {
+			notFound()
+			return
+		}
+		reply(http.StatusOK, f.commitJSON(commit))
+
+	case path == "/repository/tree":
+		commit := f.resolve(query.Get("ref"))
+		entries := []map[string]string{}
+		if query.Get("page") == "1" {
+			for file := range commit.files {
+				if strings.HasPrefix(file, query.Get("path")+"/") {
+					entries = append(entries, map[string]string{"path": file, "type": "blob"})
+				}
+			}
+		}
+		reply(http.StatusOK, entries)
+
+	case path == "/repository/branches" && r.Method == http.MethodPost:
+		branch := "refs/heads/" + query.Get("branch")
+		commit := f.resolve(query.Get("ref"))
+		if commit != nil && commit.sha == f.rejectBranchAt {
+			reply(http.StatusInternalServerError, map[string]string{"message": "500 Internal Server Error"})
+			return
+		}
+		if _, exists := f.refs[branch]; exists || commit == nil {
+			reply(http.StatusBadRequest, map[string]string{"message": "Branch already exists"})
+			return
+		}
+		f.refs[branch] = commit.sha
+		reply(http.StatusCreated, map[string]interface{}{"name": query.Get("branch"), "commit": f.commitJSON(commit)})
+
+	case strings.HasPrefix(path, "/repository/branches/"):
+		branch := "refs/heads/" + unescape("/repository/branches/")
+		sha, exists := f.refs[branch]
+		if !exists {
+			notFound()
+			return
+		}
+		if r.Method == http.MethodDelete {
+			delete(f.refs, branch)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		reply(http.StatusOK, map[string]interface{}{"name": branch, "commit": f.commitJSON(f.commits[sha])})
+
+	case path == "/repository/tags" && r.Method == http.MethodPost:
+		var req map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		tag := "refs/tags/" + req["tag_name"]
+		if _, exists := f.refs[tag]; exists {
+			reply(http.StatusBadRequest, map[string]string{"message": "Tag " + req["tag_name"] + " already exists"})
+			return
+		}
+		f.refs[tag] = req["ref"]
+		reply(http.StatusCreated, map[string]interface{}{
+			"name":    req["tag_name"],
+			"message": req["message"],
+			"commit":  f.commitJSON(f.commits[req["ref"]]),
+		})
+
+	case path == "/repository/tags":
+		prefix := strings.TrimPrefix(query.Get("search"), "^")
+		tags := []map[string]interface{}{}
+		for ref, sha := range f.refs {
+			if name, ok := strings.CutPrefix(ref, "refs/tags/"); ok && strings.HasPrefix(name, prefix) {
+				tags = append(tags, map[string]interface{}{"name": name, "commit": f.commitJSON(f.commits[sha])})
+			}
+		}
+		reply(http.StatusOK, tags)
+
+	case strings.HasPrefix(path, "/repository/tags/"):
+		name := unescape("/repository/tags/")
+		sha, exists := f.refs["refs/tags/"+name]
+		if !exists {
+			notFound()
+			return
+		}
+		reply(http.StatusOK, map[string]interface{}{"name": name, "commit": f.commitJSON(f.commits[sha])})
+
+	case path == "/repository/compare":
+		from, to := f.resolve(query.Get("from")), f.resolve(query.Get("to"))
+		if from == nil || to == nil {
+			notFound()
+			return
+		}
+		// Newest first, to check the backend orders them
+		commits := []map[string]interface{}{}
+		for c := to; c != nil && c.sha != from.sha; c = f.commits[c.parent] {
+			commits = append(commits, f.commitJSON(c))
+		}
+		reply(http.StatusOK, map[string]interface{}{"commits": commits})
+
+	case path == "/merge_requests" && r.Method == http.MethodPost:
+		var req map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if _, ok := f.refs["refs/heads/"+req["source_branch"]]; !ok {
+			reply(http.StatusBadRequest, map[string]string{"message": "Source branch does not exist"})
+			return
+		}
+		pull := &fakePull{number: len(f.pulls) + 1, title: req["title"], body: req["description"], head: req["source_branch"], base: req["target_branch"]}
+		f.pulls = append(f.pulls, pull)
+		reply(http.StatusCreated, f.mergeRequestJSON(pull))
+
+	case strings.HasPrefix(path, "/merge_requests/") && strings.HasSuffix(path, "/diffs"):
+		var iid int
+		_, _ = fmt.Sscanf(path, "/merge_requests/%d/diffs", &iid)
+		diffs := []map[string]string{}
+		if query.Get("page") == "1" {
+			for _, file := range f.pulls[iid-1].files {
+				diffs = append(diffs, map[string]string{"old_path": file, "new_path": file})
+			}
+		}
+		reply(http.StatusOK, diffs)
+
+	default:
+		f.t.Errorf("unexpected request: %s %s", r.Method, r.URL)
+		notFound()
+	}
+}
+
+// newTestGitLab starts a fake GitLab and a backend pointed at it
+func newTestGitLab(t *testing.T, files map[string]string) (*fakeGitLab, *GitLabBackend) {
+	t.Helper()
+	fake := &fakeGitLab{fakeGitHub: newFakeGitHub(t, files)}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	b, err := NewGitLabBackend(&config.GitConfig{
+		APIURL:      server.URL,
+		Owner:       "group",
+		Repo:        "nomad-jobs",
+		Token:       "test-token",
+		Branch:      "main",
+		AuthorName:  "njgit",
+		AuthorEmail: "njgit@example.com",
+	})
+	if err != nil {
+		t.Fatalf("NewGitLabBackend() unexpected error: %v", err)
+	}
+	return fake, b
+}
+
+// TestGitLabBackend_Commit tests that staged files are committed in one
+// atomic commit through the commits API, and read back
+func TestGitLabBackend_Commit(t *testing.T) {
+	const webFile, apiFile = "global/default/web.hcl", "global/default/api.hcl"
+	fake, b := newTestGitLab(t, map[string]string{webFile: "job \"web\" { count = 1 }\n"})
+	ctx := context.Background()
+
+	if err := b.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize() unexpected error: %v", err)
+	}
+	if exists, err := b.FileExists(ctx, apiFile); err != nil || exists {
+		t.Errorf("FileExists(new file) = %v, %v", exists, err)
+	}
+
+	if err := b.WriteFile(ctx, webFile, []byte("job \"web\" { count = 3 }\n")); err != nil {
+		t.Fatalf("WriteFile() unexpected error: %v", err)
+	}
+	if err := b.WriteFile(ctx, apiFile, []byte("job \"api\" {}\n")); err != nil {
+		t.Fatalf("WriteFile() unexpected error: %v", err)
+	}
+	author := &gitpkg.Identity{Name: "Jane Doe", Email: "jane@example.com"}
+	hash, err := b.Commit(ctx, "Update jobs", author)
+	if err != nil {
+		t.Fatalf("Commit() unexpected error: %v", err)
+	}
+
+	if len(fake.commitRequests) != 1 {
+		t.Fatalf("Commit() made %d commit requests, want 1", len(fake.commitRequests))
+	}
+	req := fake.commitRequests[0]
+	if len(req.Actions) != 2 ||
+		req.Actions[0].FilePath != apiFile || req.Actions[0].Action != "create" ||
+		req.Actions[1].FilePath != webFile || req.Actions[1].Action != "update" {
+		t.Errorf("commit actions = %+v, want create %s and update %s", req.Actions, apiFile, webFile)
+	}
+	if req.Branch != "main" || req.AuthorName != "Jane Doe" || req.AuthorEmail != "jane@example.com" {
+		t.Errorf("commit request = %+v, want Jane Doe on main", req)
+	}
+
+	head := fake.refs["refs/heads/main"]
+	if hash != head[:8] {
+		t.Errorf("Commit() = %q, want %q", hash, head[:8])
+	}
+	content, err := b.ReadFile(ctx, webFile)
+	if err != nil || string(content) != "job \"web\" { count = 3 }\n" {
+		t.Errorf("ReadFile() = %q, %v", content, err)
+	}
+	if _, err := b.ReadFile(ctx, "global/default/missing.hcl"); err == nil || !strings.Contains(err.Error(), "file not found") {
+		t.Errorf("ReadFile(missing) error = %v", err)
+	}
+
+	info, err := b.ResolveRevision(ctx, "main")
+	if err != nil {
+		t.Fatalf("ResolveRevision() unexpected error: %v", err)
+	}
+	if info.FullHash != head || len(info.Files) != 2 || info.Message != "Update jobs" {
+		t.Errorf("ResolveRevision() = %+v, want both files in one commit", info)
+	}
+	if _, err := b.ResolveRevision(ctx, "no-such-rev"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("ResolveRevision(missing) error = %v", err)
+	}
+
+	history, err := b.History(ctx, webFile, 0)
+	if err != nil {
+		t.Fatalf("History() unexpected error: %v", err)
+	}
+	if len(history) != 2 || history[0].FullHash != head {
+		t.Errorf("History(%s) = %+v, want the commit and the initial one", webFile, history)
+	}
+
+	old, err := b.ReadFileAt(ctx, history[1].FullHash, webFile)
+	if err != nil || string(old) != "job \"web\" { count = 1 }\n" {
+		t.Errorf("ReadFileAt(initial) = %q, %v", old, err)
+	}
+	files, err := b.ListFiles(ctx, "main", "global")
+	if err != nil || len(files) != 2 || files[0] != apiFile {
+		t.Errorf("ListFiles() = %v, %v", files, err)
+	}
+
+	// A rejected commit commits nothing and keeps the files staged
+	b.branch = "no-such-branch"
+	if err := b.WriteFile(ctx, webFile, []byte("job \"web\" {}\n")); err != nil {
+		t.Fatalf("WriteFile() unexpected error: %v", err)
+	}
+	if _, err := b.Commit(ctx, "Rejected", nil); err == nil {
+		t.Fatalf("Commit() to a missing branch should fail")
+	}
+	if len(b.stagedFiles) != 1 || fake.refs["refs/heads/main"] != head {
+		t.Errorf("after a failed commit: %d staged files, main = %s", len(b.stagedFiles), fake.refs["refs/heads/main"])
+	}
+}
+
+// TestGitLabBackend_UpdateRefRestores tests that a ref whose new branch
+// can't be created keeps pointing at its old commit
+func TestGitLabBackend_UpdateRefRestores(t *testing.T) {
+	fake, b := newTestGitLab(t, map[string]string{"global/default/web.hcl": "job \"web\" {}\n"})
+	ctx := context.Background()
+	appliedRef := gitpkg.AppliedRef("main")
+
+	if err := b.UpdateRef(ctx, appliedRef, "main"); err != nil {
+		t.Fatalf("UpdateRef() unexpected error: %v", err)
+	}
+	start := fake.refs["refs/heads/main"]
+	next := fake.commitFile("main", "global/default/web.hcl", "job \"web\" { count = 2 }\n", "Update global/default/web")
+
+	fake.rejectBranchAt = next
+	err := b.UpdateRef(ctx, appliedRef, "main")
+	if err == nil || !strings.Contains(err.Error(), "left at "+start[:8]) {
+		t.Errorf("UpdateRef() error = %v, want it left at %s", err, start[:8])
+	}
+	if applied, _ := b.ReadRef(ctx, appliedRef); applied != start {
+		t.Errorf("ReadRef() after a failed UpdateRef = %q, want %s", applied, start)
+	}
+
+	fake.rejectBranchAt = ""
+	if err := b.UpdateRef(ctx, appliedRef, "main"); err != nil {
+		t.Fatalf("UpdateRef() unexpected error: %v", err)
+	}
+	if applied, _ := b.ReadRef(ctx, appliedRef); applied != next {
+		t.Errorf("ReadRef() after UpdateRef = %q, want %s", applied, next)
+	}
+}
+
+// TestGitLabBackend_RefsAndMergeRequests tests branches, tags, refs and
+// finding merged merge requests against a fake GitLab
+func TestGitLabBackend_RefsAndMergeRequests(t *testing.T) {
+	const jobFile = "global/default/web.hcl"
+	fake, b := newTestGitLab(t, map[string]string{jobFile: "job \"web\" { count = 1 }\n"})
+	ctx := context.Background()
+	var forge Forge = b
+
+	// The applied ref is kept as a branch
+	appliedRef := gitpkg.AppliedRef("main")
+	if applied, err := b.ReadRef(ctx, appliedRef); err != nil || applied != "" {
+		t.Fatalf("ReadRef() = %q, %v; want no ref", applied, err)
+	}
+	if err := b.UpdateRef(ctx, appliedRef, "main"); err != nil {
+		t.Fatalf("UpdateRef() unexpected error: %v", err)
+	}
+	start := fake.refs["refs/heads/main"]
+	if fake.refs["refs/heads/njgit/applied/main"] != start {
+		t.Fatalf("applied ref not stored as branch njgit/applied/main: %v", fake.refs)
+	}
+	if applied, _ := b.ReadRef(ctx, appliedRef); applied != start {
+		t.Errorf("ReadRef() after UpdateRef = %q, want %s", applied, start)
+	}
+
+	// Tags
+	tagName := "deploy/global/default/web/20260102T030405Z"
+	tag, err := b.CreateTag(ctx, tagName, "main", "Deployed")
+	if err != nil || tag.Commit != start || tag.Name != tagName {
+		t.Fatalf("CreateTag() = %+v, %v", tag, err)
+	}
+	if _, err := b.CreateTag(ctx, tagName, "main", "Again"); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("CreateTag(existing) error = %v", err)
+	}
+	if _, err := b.CreateTag(ctx, "release/1", "main", "Release"); err != nil {
+		t.Fatalf("CreateTag() unexpected error: %v", err)
+	}
+	tags, err := b.ListTags(ctx, "deploy/")
+	if err != nil || len(tags) != 1 || tags[0].Name != tagName {
+		t.Errorf("ListTags(deploy/) = %+v, %v", tags, err)
+	}
+
+	// Propose on a new branch and open a merge request
+	if err := b.UseBranch(ctx, "njgit/propose/web"); err != nil {
+		t.Fatalf("UseBranch() unexpected error: %v", err)
+	}
+	if err := b.WriteFile(ctx, jobFile, []byte("job \"web\" { count = 3 }\n")); err != nil {
+		t.Fatalf("WriteFile() unexpected error: %v", err)
+	}
+	if _, err := b.Commit(ctx, "Update global/default/web", nil); err != nil {
+		t.Fatalf("Commit() unexpected error: %v", err)
+	}
+	if fake.refs["refs/heads/main"] != start {
+		t.Fatalf("Commit() on the proposal branch moved main")
+	}
+	pr, err := forge.CreatePullRequest(ctx, &PullRequest{Title: "Scale web", Body: "Traffic", Head: "njgit/propose/web", Base: "main"})
+	if err != nil {
+		t.Fatalf("CreatePullRequest() unexpected error: %v", err)
+	}
+	if pr.Number != 1 || pr.Body != "Traffic" || !strings.HasSuffix(pr.URL, "/merge_requests/1") {
+		t.Errorf("CreatePullRequest() = %+v", pr)
+	}
+
+	// Squash merge it, then commit directly
+	mergeSHA := fake.merge(1)
+	fake.commitFile("main", "global/default/api.hcl", "job \"api\" {}\n", "Update global/default/api")
+
+	commits, err := b.CommitsSince(ctx, start, "main")
+	if err != nil {
+		t.Fatalf("CommitsSince() unexpected error: %v", err)
+	}
+	if len(commits) != 2 || commits[0].FullHash != mergeSHA || commits[1].Message != "Update global/default/api" {
+		t.Fatalf("CommitsSince() = %+v, want the merge then the direct commit", commits)
+	}
+
+	merged, err := forge.MergedPullRequest(ctx, mergeSHA)
+	if err != nil {
+		t.Fatalf("MergedPullRequest() unexpected error: %v", err)
+	}
+	if merged == nil || merged.Number != 1 || merged.MergeCommit != mergeSHA || len(merged.Files) != 1 || merged.Files[0] != jobFile {
+		t.Fatalf("MergedPullRequest() = %+v, want !1 changing %s", merged, jobFile)
+	}
+	if merged, err := forge.MergedPullRequest(ctx, commits[1].FullHash); err != nil || merged != nil {
+		t.Errorf("MergedPullRequest() of a direct commit = %+v, %v; want nil", merged, err)
+	}
+
+	// Moving the applied ref recreates the branch
+	if err := b.UpdateRef(ctx, appliedRef, "main"); err != nil {
+		t.Fatalf("UpdateRef() unexpected error: %v", err)
+	}
+	if applied, _ := b.ReadRef(ctx, appliedRef); applied != fake.refs["refs/heads/main"] {
+		t.Errorf("ReadRef() = %q, want main's head %s", applied, fake.refs["refs/heads/main"])
+	}
+}
@@ -0,0 +1,179 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// restClient makes JSON requests to a forge's REST API (GitLab, Gitea)
+type restClient struct {
+	service    string // Name used in errors, e.g. "gitlab"
+	baseURL    string // API root, e.g. https://gitlab.com/api/v4
+	httpClient *http.Client
+	timeout    time.Duration           // Per-request timeout (zero means none)
+	authorize  func(req *http.Request) // Adds the credentials to a request
+}
+
+// newRestClient creates a client for an API root
+// Requests are bounded by a per-request context deadline instead of
+// http.Client.Timeout, so callers can cancel them as well.
+func newRestClient(service, baseURL string, timeout time.Duration, authorize func(req *http.Request)) *restClient {
+	return &restClient{
+		service:    service,
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{},
+		timeout:    timeout,
+		authorize:  authorize,
+	}
+}
+
+// apiRoot appends the API path to a server URL unless it already has it,
+// e.g. https://gitlab.example.com -> https://gitlab.example.com/api/v4
+func apiRoot(serverURL, apiPath string) string {
+	serverURL = strings.TrimRight(serverURL, "/")
+	if strings.HasSuffix(serverURL, apiPath) {
+		return serverURL
+	}
+	return serverURL + apiPath
+}
+
+// do makes an authenticated request to an endpoint under the API root
+//
+// Parameters:
+//   - ctx: Cancels the request; the client timeout applies on top
+//   - method: HTTP method
+//   - endpoint: Path under the API root, already escaped, e.g. "/projects/1"
+//   - query: Query parameters (may be nil)
+//   - in: Encoded as the JSON request body (nil for none)
+//   - out: Where to decode the response body (nil to ignore it)
+//
+// Returns:
+//   - int: The response status code (0 if the request failed)
+//   - http.Header: The response headers (nil if the request failed)
+//   - error: Any error, including status codes >= 400
+func (c *restClient) do(ctx context.Context, method, endpoint string, query url.Values, in, out interface{}) (int, http.Header, error) {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to marshal request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+endpoint, body)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if query != nil {
+		req.URL.RawQuery = query.Encode()
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	c.authorize(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%s API request failed: %w", c.service, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, resp.Header, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode >= 400 {
+		return resp.StatusCode, resp.Header, fmt.Errorf("%s API error: %s", c.service, restErrorMessage(resp.StatusCode, respBody))
+	}
+
+	if out == nil || method == http.MethodHead || len(respBody) == 0 {
+		return resp.StatusCode, resp.Header, nil
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return resp.StatusCode, resp.Header, fmt.Errorf("failed to parse %s response: %w", c.service, err)
+	}
+
+	return resp.StatusCode, resp.Header, nil
+}
+
+// restErrorMessage extracts the message from an error response
+// GitLab puts it in "message" (a string, or an object of field errors) or
+// "error"; Gitea in "message".
+func restErrorMessage(status int, body []byte) string {
+	var errResp struct {
+		Message interface{} `json:"message"`
+		Error   string      `json:"error"`
+	}
+	if json.Unmarshal(body, &errResp) == nil {
+		switch msg := errResp.Message.(type) {
+		case string:
+			if msg != "" {
+				return msg
+			}
+		case nil:
+		default:
+			if data, err := json.Marshal(msg); err == nil {
+				return string(data)
+			}
+		}
+		if errResp.Error != "" {
+			return errResp.Error
+		}
+	}
+	return fmt.Sprintf("status %d", status)
+}
+
+// refTarget maps a full reference name to what the GitLab and Gitea APIs
+// can store: a branch or a tag. Neither lets arbitrary refs be written, so
+// other refs become branches named without "refs/", e.g.
+// refs/njgit/applied/main -> branch njgit/applied/main.
+func refTarget(name string) (kind, short string) {
+	switch {
+	case strings.HasPrefix(name, "refs/heads/"):
+		return "branch", strings.TrimPrefix(name, "refs/heads/")
+	case strings.HasPrefix(name, "refs/tags/"):
+		return "tag", strings.TrimPrefix(name, "refs/tags/")
+	default:
+		return "branch", strings.TrimPrefix(name, "refs/")
+	}
+}
+
+// restoreRef builds UpdateRef's error when the new reference couldn't be
+// created after the old one was deleted. The old one (old, "" if there was
+// none) is put back first, so a failed update doesn't lose it.
+func restoreRef(name, old string, err error, restore func() error) error {
+	if old == "" {
+		return fmt.Errorf("failed to update %s: %w", name, err)
+	}
+	if restoreErr := restore(); restoreErr != nil {
+		return fmt.Errorf("failed to update %s: %w (restoring it to %s failed too: %v)", name, err, shortSHA(old), restoreErr)
+	}
+	return fmt.Errorf("failed to update %s (left at %s): %w", name, shortSHA(old), err)
+}
+
+// stagedPaths returns the paths of the staged files, sorted
+func stagedPaths(staged map[string][]byte) []string {
+	paths := make([]string, 0, len(staged))
+	for path := range staged {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
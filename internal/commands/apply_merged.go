@@ -0,0 +1,236 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/wlame/njgit/internal/backend"
+	gitpkg "github.com/wlame/njgit/internal/git"
+	"github.com/wlame/njgit/internal/nomad"
+)
+
+var (
+	applyMergedDryRun bool
+	applyMergedNoTag  bool
+)
+
+// applyMergedCmd represents the apply-merged command
+var applyMergedCmd = &cobra.Command{
+	Use:   "apply-merged [flags]",
+	Short: "Deploy the jobs changed by merged pull requests",
+	Long: `Deploy the job changes from pull requests merged since the last run.
+
+The last commit applied is recorded in the ref refs/njgit/applied/<branch>,
+where <branch> is the branch njgit commits to. Each run:
+  1. Lists the commits since the recorded one
+  2. Finds the pull requests merged by those commits
+  3. Deploys each job the pull requests changed, as of the last merge that
+     changed it (commits made without a pull request, like sync commits,
+     are not deployed)
+  4. Records the branch's latest commit
+
+The first run only records the latest commit; pull requests merged after it
+are deployed by the next run. If a deploy fails, nothing is recorded, so the
+next run tries again.
+
+Pull requests need a backend on a forge: github-api, gitlab-api (merge
+requests) or gitea-api.
+
+Examples:
+  # Deploy what was merged
+  njgit apply-merged
+
+  # Show what would be deployed
+  njgit apply-merged --dry-run`,
+	Args: cobra.NoArgs,
+	RunE: applyMergedRun,
+}
+
+func init() {
+	applyMergedCmd.Flags().BoolVar(&applyMergedDryRun, "dry-run", false, "Show what would be deployed without deploying")
+	applyMergedCmd.Flags().BoolVar(&applyMergedNoTag, "no-tag", false, "Don't tag the deployed commits")
+
+	rootCmd.AddCommand(applyMergedCmd)
+}
+
+// mergedJob is a job changed by a merged pull request
+type mergedJob struct {
+	region    string
+	namespace string
+	name      string
+	commit    string // Merge commit to deploy the job from
+	pr        *backend.PullRequest
+}
+
+func applyMergedRun(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	ctx := cmd.Context()
+	b, err := openBackend(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeBackend(b)
+
+	forge, ok := b.(backend.Forge)
+	if !ok {
+		return fmt.Errorf("the %s backend can't look up pull requests (use github-api, gitlab-api or gitea-api)", b.GetName())
+	}
+
+	base := baseBranch(cfg)
+	if err := b.UseBranch(ctx, base); err != nil {
+		return fmt.Errorf("failed to switch to branch %s: %w", base, err)
+	}
+	head, err := b.ResolveRevision(ctx, base)
+	if err != nil {
+		return fmt.Errorf("failed to resolve branch %s: %w", base, err)
+	}
+
+	appliedRef := gitpkg.AppliedRef(base)
+	applied, err := b.ReadRef(ctx, appliedRef)
+	if err != nil {
+		return err
+	}
+
+	if applied == "" {
+		if applyMergedDryRun {
+			PrintInfo(fmt.Sprintf("Nothing applied on %s yet; a real run would record %s as the starting point", base, head.Hash))
+			return nil
+		}
+		if err := b.UpdateRef(ctx, appliedRef, head.FullHash); err != nil {
+			return err
+		}
+		PrintSuccess(fmt.Sprintf("Recorded %s as the last applied commit of %s", head.Hash, base))
+		PrintInfo("Pull requests merged from now on are deployed by the next run")
+		return nil
+	}
+	if applied == head.FullHash {
+		PrintInfo(fmt.Sprintf("Nothing merged into %s since %s", base, shortHash(applied)))
+		return nil
+	}
+
+	PrintInfo(fmt.Sprintf("Looking for pull requests merged into %s since %s...", base, shortHash(applied)))
+	commits, err := b.CommitsSince(ctx, applied, head.FullHash)
+	if err != nil {
+		return fmt.Errorf("failed to list commits since %s: %w", shortHash(applied), err)
+	}
+
+	// The last merge that changed a job wins
+	jobs := make(map[string]*mergedJob)
+	var order []string
+	for _, commit := range commits {
+		pr, err := forge.MergedPullRequest(ctx, commit.FullHash)
+		if err != nil {
+			return err
+		}
+		if pr == nil || pr.Base != base {
+			continue
+		}
+
+		for _, file := range pr.Files {
+			parts := strings.Split(jobPathFromFile(file), "/")
+			if len(parts) != 3 {
+				continue
+			}
+			jobPath := strings.Join(parts, "/")
+			if _, seen := jobs[jobPath]; !seen {
+				order = append(order, jobPath)
+			}
+			jobs[jobPath] = &mergedJob{region: parts[0], namespace: parts[1], name: parts[2], commit: commit.FullHash, pr: pr}
+		}
+	}
+
+	if len(order) == 0 {
+		PrintInfo("No merged pull requests changed any jobs")
+	} else {
+		fmt.Println()
+		for _, jobPath := range order {
+			job := jobs[jobPath]
+			fmt.Printf("  %s  from #%d %s (%s)\n", jobPath, job.pr.Number, job.pr.Title, shortHash(job.commit))
+		}
+		fmt.Println()
+	}
+
+	if applyMergedDryRun {
+		PrintInfo("This is a dry run - no changes were made to Nomad")
+		return nil
+	}
+
+	if len(order) > 0 {
+		PrintInfo("Connecting to Nomad...")
+		nomadAuth, err := nomad.ResolveAuth(&cfg.Nomad, "", "")
+		if err != nil {
+			return fmt.Errorf("failed to resolve Nomad auth: %w", err)
+		}
+		nomadClient, err := nomad.NewClient(nomadAuth)
+		if err != nil {
+			return fmt.Errorf("failed to create Nomad client: %w", err)
+		}
+		defer func() { _ = nomadClient.Close() }()
+
+		for _, jobPath := range order {
+			job := jobs[jobPath]
+
+			jobFile := jobFilePath(job.region, job.namespace, job.name)
+			jobHCL, err := b.ReadFileAt(ctx, job.commit, jobFile)
+			if err != nil {
+				// Removing a job file doesn't stop the job
+				PrintWarning(fmt.Sprintf("%s: not in %s (removed?); skipping", jobPath, shortHash(job.commit)))
+				continue
+			}
+			varsHCL, err := readJobVariables(ctx, b, job.commit, jobFile, jobHCL)
+			if err != nil {
+				return fmt.Errorf("%s: %w", jobPath, err)
+			}
+
+			resolver, err := newSecretsResolver(ctx, cfg, nomadClient, job.namespace, job.name)
+			if err != nil {
+				return fmt.Errorf("%s: refusing to deploy: %w", jobPath, err)
+			}
+			if varsHCL != nil {
+				if varsHCL, err = resolveVariables(varsHCL, resolver); err != nil {
+					return fmt.Errorf("%s: refusing to deploy: %w", jobPath, err)
+				}
+			}
+
+			nomadJob, err := parseDeployJob(ctx, cfg, jobHCL, varsHCL, job.region, job.namespace)
+			if err != nil {
+				return fmt.Errorf("%s: %w", jobPath, err)
+			}
+			if err := resolveSecrets(nomadJob, resolver); err != nil {
+				return fmt.Errorf("%s: refusing to deploy: %w", jobPath, err)
+			}
+
+			PrintInfo(fmt.Sprintf("Deploying %s from #%d...", jobPath, job.pr.Number))
+			evalID, err := nomadClient.DeployJob(ctx, nomadJob)
+			if err != nil {
+				return fmt.Errorf("failed to deploy %s: %w", jobPath, err)
+			}
+			PrintSuccess(fmt.Sprintf("Deployed %s (evaluation %s)", jobPath, evalID))
+
+			if cfg.Git.TagDeploys && !applyMergedNoTag {
+				if _, err := tagDeploy(ctx, cfg, b, job.commit, job.region, job.namespace, job.name, evalID); err != nil {
+					PrintWarning(fmt.Sprintf("Deployed %s, but couldn't tag the commit: %v", jobPath, err))
+				}
+			}
+		}
+	}
+
+	if err := b.UpdateRef(ctx, appliedRef, head.FullHash); err != nil {
+		return fmt.Errorf("deployed, but failed to record the last applied commit: %w", err)
+	}
+	PrintSuccess(fmt.Sprintf("Applied %s up to %s", base, head.Hash))
+	return nil
+}
+
+// shortHash abbreviates a commit hash for display
+func shortHash(hash string) string {
+	if len(hash) > 8 {
+		return hash[:8]
+	}
+	return hash
+}
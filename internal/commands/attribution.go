@@ -0,0 +1,76 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/wlame/njgit/internal/config"
+	gitpkg "github.com/wlame/njgit/internal/git"
+	"github.com/wlame/njgit/internal/nomad"
+)
+
+// jobAuthors finds the people who changed a job, from the sources in
+// git.attribution in order
+// The first one is the commit author; the rest become Co-authored-by
+// trailers. Sources that name nobody usable are skipped (reported with
+// --verbose), so an empty result makes njgit the author.
+func jobAuthors(ctx context.Context, cfg *config.Config, nomadClient *nomad.Client, job *api.Job, jobPath string) []gitpkg.Identity {
+	var authors []gitpkg.Identity
+	seen := make(map[string]bool)
+	add := func(source, value string) {
+		identity, ok := gitpkg.ParseIdentity(value, cfg.Git.AttributionEmailDomain)
+		if !ok {
+			if IsVerbose() {
+				PrintWarning(fmt.Sprintf("%s: Can't attribute to %q from %s (need an email or attribution_email_domain)",
+					jobPath, value, source))
+			}
+			return
+		}
+		key := strings.ToLower(identity.Email)
+		if !seen[key] {
+			seen[key] = true
+			authors = append(authors, identity)
+		}
+	}
+
+	for _, source := range cfg.Git.Attribution {
+		switch source {
+		case "meta":
+			key := cfg.Git.AttributionMetaKey
+			for _, person := range strings.Split(job.Meta[key], ",") {
+				if strings.TrimSpace(person) != "" {
+					add("meta."+key, person)
+				}
+			}
+		case "token":
+			if job.NomadTokenID == nil || *job.NomadTokenID == "" {
+				continue
+			}
+			name, err := nomadClient.TokenName(ctx, *job.NomadTokenID)
+			if err != nil {
+				if IsVerbose() {
+					PrintWarning(fmt.Sprintf("%s: Can't attribute to the submitting token: %v", jobPath, err))
+				}
+				continue
+			}
+			if name != "" {
+				add("the submitting token", name)
+			}
+		}
+	}
+
+	return authors
+}
+
+// withCoAuthors appends a Co-authored-by trailer for each co-author
+// message must already end with its trailer block (see buildCommitMessage)
+func withCoAuthors(message string, coAuthors []gitpkg.Identity) string {
+	var msg strings.Builder
+	msg.WriteString(message)
+	for _, coAuthor := range coAuthors {
+		msg.WriteString(fmt.Sprintf("\nCo-authored-by: %s", coAuthor))
+	}
+	return msg.String()
+}
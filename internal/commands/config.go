@@ -1,8 +1,10 @@
 package commands
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/wlame/njgit/internal/backend"
@@ -24,7 +26,7 @@ var configShowCmd = &cobra.Command{
 	Long:  `Load and display the current configuration from file and environment variables.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Load configuration
-		cfg, err := config.Load(GetConfigFile())
+		cfg, err := loadConfig()
 		if err != nil {
 			return fmt.Errorf("failed to load config: %w", err)
 		}
@@ -60,7 +62,7 @@ var configValidateCmd = &cobra.Command{
 	Long:  `Load and validate the configuration file.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Load configuration
-		cfg, err := config.Load(GetConfigFile())
+		cfg, err := loadConfig()
 		if err != nil {
 			return fmt.Errorf("failed to load config: %w", err)
 		}
@@ -79,7 +81,7 @@ var configValidateCmd = &cobra.Command{
 		if backendType == "git" {
 			PrintInfo(fmt.Sprintf("Git repository: %s (local)", cfg.Git.LocalPath))
 		} else {
-			PrintInfo(fmt.Sprintf("GitHub repository: %s/%s", cfg.Git.Owner, cfg.Git.Repo))
+			PrintInfo(fmt.Sprintf("Repository (%s): %s", backendType, apiRepository(&cfg.Git)))
 		}
 		PrintInfo(fmt.Sprintf("Nomad address: %s", cfg.Nomad.Address))
 		PrintInfo(fmt.Sprintf("Tracking %d jobs", len(cfg.Jobs)))
@@ -125,7 +127,7 @@ func configCheckRun(cmd *cobra.Command, args []string) error {
 
 	// Check 1: Load configuration
 	fmt.Println("1️⃣  Loading configuration file...")
-	cfg, err := config.Load(GetConfigFile())
+	cfg, err := loadConfig()
 	if err != nil {
 		PrintError(fmt.Errorf("   ❌ Failed to load configuration: %w", err))
 		fmt.Println()
@@ -158,10 +160,13 @@ func configCheckRun(cmd *cobra.Command, args []string) error {
 	if backendType == "git" {
 		fmt.Printf("      Local path: %s\n", cfg.Git.LocalPath)
 	} else {
-		fmt.Printf("      GitHub: %s/%s\n", cfg.Git.Owner, cfg.Git.Repo)
+		fmt.Printf("      Repository: %s\n", apiRepository(&cfg.Git))
 		fmt.Printf("      Branch: %s\n", cfg.Git.Branch)
 	}
 	fmt.Printf("      Nomad: %s\n", cfg.Nomad.Address)
+	if cfg.Nomad.ClientCert != "" {
+		fmt.Printf("      Nomad client certificate: %s\n", cfg.Nomad.ClientCert)
+	}
 	fmt.Printf("      Jobs to track: %d\n", len(cfg.Jobs))
 
 	// Check 3: Test Nomad connection
@@ -179,7 +184,15 @@ func configCheckRun(cmd *cobra.Command, args []string) error {
 		} else {
 			defer func() { _ = nomadClient.Close() }()
 
-			if err := nomadClient.Ping(); err != nil {
+			// Ping reads the agent's info, which needs agent:read; a token
+			// scoped to namespaces is refused, but the cluster is reachable
+			pingErr := nomadClient.Ping(cmd.Context())
+			if pingErr != nil && strings.Contains(pingErr.Error(), "Permission denied") {
+				fmt.Println("   Token has no agent:read capability (not needed by njgit)")
+				pingErr = nil
+			}
+
+			if err := pingErr; err != nil {
 				PrintError(fmt.Errorf("   ❌ Failed to connect to Nomad: %w", err))
 				checksFailed++
 				fmt.Println()
@@ -187,13 +200,27 @@ func configCheckRun(cmd *cobra.Command, args []string) error {
 				fmt.Println("      • Check if Nomad is running and accessible")
 				fmt.Println("      • Verify NOMAD_ADDR is correct")
 				fmt.Println("      • Check if ACL token is valid (if using ACLs)")
+				fmt.Println("      • If the cluster requires mutual TLS, set client_cert and client_key")
 			} else {
 				PrintSuccess("   ✅ Successfully connected to Nomad")
 				checksPassed++
 
-				// Check 4: Verify jobs exist in Nomad
+				// Check 4: Verify the token can do what njgit needs
 				fmt.Println()
-				fmt.Println("4️⃣  Checking configured jobs in Nomad...")
+				fmt.Println("4️⃣  Checking token permissions...")
+				failed, warned := checkTokenPermissions(cmd.Context(), nomadClient, cfg)
+				if failed {
+					checksFailed++
+				} else {
+					checksPassed++
+				}
+				if warned {
+					warnings++
+				}
+
+				// Check 5: Verify jobs exist in Nomad
+				fmt.Println()
+				fmt.Println("5️⃣  Checking configured jobs in Nomad...")
 
 				if len(cfg.Jobs) == 0 {
 					PrintWarning("   ⚠️  No jobs configured to track")
@@ -205,7 +232,7 @@ func configCheckRun(cmd *cobra.Command, args []string) error {
 
 					for _, jobCfg := range cfg.Jobs {
 						jobPath := fmt.Sprintf("%s/%s", jobCfg.Namespace, jobCfg.Name)
-						_, err := nomadClient.FetchJobSpec(jobCfg.Namespace, jobCfg.Name)
+						_, err := nomadClient.FetchJobSpec(cmd.Context(), jobCfg.Namespace, jobCfg.Name)
 						if err != nil {
 							if _, ok := err.(nomad.JobNotFoundError); ok {
 								fmt.Printf("   ⚠️  Job not found: %s\n", jobPath)
@@ -237,9 +264,9 @@ func configCheckRun(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	// Check 5: Test backend connection
+	// Check 6: Test backend connection
 	fmt.Println()
-	fmt.Println("5️⃣  Testing backend connection...")
+	fmt.Println("6️⃣  Testing backend connection...")
 
 	backend, err := backend.NewBackend(&cfg.Git)
 	if err != nil {
@@ -249,9 +276,16 @@ func configCheckRun(cmd *cobra.Command, args []string) error {
 		if backendType == "github-api" {
 			fmt.Println()
 			fmt.Println("   💡 Tips for GitHub API backend:")
-			fmt.Println("      • Set GITHUB_TOKEN environment variable")
+			fmt.Println("      • Set GITHUB_TOKEN environment variable, or app_id and app_private_key")
 			fmt.Println("      • Ensure token has 'repo' scope")
+			fmt.Println("      • Check app_private_key points at the app's PEM key")
 			fmt.Println("      • Verify owner and repo names are correct")
+		} else if backendType == "gitlab-api" || backendType == "gitea-api" {
+			fmt.Println()
+			fmt.Println("   💡 Tips for GitLab/Gitea API backends:")
+			fmt.Println("      • Set GITLAB_TOKEN (gitlab-api) or GITEA_TOKEN (gitea-api)")
+			fmt.Println("      • Set api_url to your server, e.g. https://git.example.com")
+			fmt.Println("      • Verify the project (or owner and repo) is correct")
 		} else {
 			fmt.Println()
 			fmt.Println("   💡 Tips for Git backend:")
@@ -260,9 +294,9 @@ func configCheckRun(cmd *cobra.Command, args []string) error {
 			fmt.Println("      • Verify repository URL is correct")
 		}
 	} else {
-		defer func() { _ = backend.Close() }()
+		defer closeBackend(backend)
 
-		if err := backend.Initialize(); err != nil {
+		if err := backend.Initialize(cmd.Context()); err != nil {
 			PrintError(fmt.Errorf("   ❌ Failed to initialize backend: %w", err))
 			checksFailed++
 
@@ -270,8 +304,16 @@ func configCheckRun(cmd *cobra.Command, args []string) error {
 				fmt.Println()
 				fmt.Println("   💡 Common issues:")
 				fmt.Println("      • Invalid or expired GitHub token")
+				fmt.Println("      • GitHub App not installed on the repository")
+				fmt.Println("      • api_url doesn't point at your GitHub Enterprise Server")
 				fmt.Println("      • Repository doesn't exist or is private without access")
 				fmt.Println("      • Token missing 'repo' permissions")
+			} else if backendType == "gitlab-api" || backendType == "gitea-api" {
+				fmt.Println()
+				fmt.Println("   💡 Common issues:")
+				fmt.Println("      • Invalid or expired token")
+				fmt.Println("      • api_url doesn't point at the server")
+				fmt.Println("      • Token missing the 'api' (GitLab) or 'write:repository' (Gitea) scope")
 			} else {
 				fmt.Println()
 				fmt.Println("   💡 Common issues:")
@@ -318,3 +360,107 @@ func configCheckRun(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// checkTokenPermissions reports what the Nomad token may do in every tracked
+// namespace
+// Missing read-job fails the check, since sync can't fetch jobs without it;
+// missing list-jobs (child job reporting), submit-job (deploy) and parse-job
+// (deploy and verify) are warnings.
+//
+// Returns:
+//   - failed: The token can't be checked or lacks read-job somewhere
+//   - warned: The token lacks an optional capability somewhere
+func checkTokenPermissions(ctx context.Context, nomadClient *nomad.Client, cfg *config.Config) (failed, warned bool) {
+	var namespaces []string
+	seen := make(map[string]bool)
+	for _, jobCfg := range cfg.Jobs {
+		if !seen[jobCfg.Namespace] {
+			seen[jobCfg.Namespace] = true
+			namespaces = append(namespaces, jobCfg.Namespace)
+		}
+	}
+
+	perms, err := nomadClient.CheckPermissions(ctx, namespaces)
+	if err != nil {
+		PrintError(fmt.Errorf("   ❌ %w", err))
+		fmt.Println("   💡 Check that the token is valid and not expired (njgit login for SSO)")
+		return true, false
+	}
+
+	switch {
+	case perms.ACLDisabled:
+		PrintSuccess("   ✅ ACLs are disabled on this cluster; no token needed")
+		return false, false
+	case perms.TokenType == "management":
+		PrintSuccess(fmt.Sprintf("   ✅ Management token %q has all permissions", perms.TokenName))
+		return false, false
+	}
+
+	fmt.Printf("   Token: %s (%s)\n", perms.TokenName, perms.TokenType)
+	fmt.Printf("   Policies: %s\n", strings.Join(perms.Policies, ", "))
+	for _, unreadable := range perms.Unreadable {
+		PrintWarning(fmt.Sprintf("   ⚠️  Could not evaluate %s; results may be incomplete", unreadable))
+		warned = true
+	}
+
+	mark := func(ok bool) string {
+		if ok {
+			return "✅"
+		}
+		return "❌"
+	}
+
+	fmt.Println()
+	fmt.Printf("   %-20s %-6s %-6s %-8s %-7s %s\n", "Namespace", "list", "read", "submit", "parse", "variables")
+	for _, ns := range perms.Namespaces {
+		fmt.Printf("   %-20s %-6s %-6s %-8s %-7s %s\n", ns.Namespace, mark(ns.ListJobs), mark(ns.ReadJob), mark(ns.SubmitJob), mark(ns.ParseJob), mark(ns.ReadVariables))
+	}
+
+	for _, ns := range perms.Namespaces {
+		var missing []string
+		if !ns.ReadJob {
+			missing = append(missing, nomad.CapabilityReadJob)
+			PrintError(fmt.Errorf("   ❌ %s: missing %s, sync can't fetch jobs in this namespace", ns.Namespace, nomad.CapabilityReadJob))
+			failed = true
+		}
+		if !ns.ListJobs {
+			missing = append(missing, nomad.CapabilityListJobs)
+			PrintWarning(fmt.Sprintf("   ⚠️  %s: missing %s, child jobs of periodic jobs won't be reported", ns.Namespace, nomad.CapabilityListJobs))
+			warned = true
+		}
+		if !ns.SubmitJob {
+			missing = append(missing, nomad.CapabilitySubmitJob)
+			PrintWarning(fmt.Sprintf("   ⚠️  %s: missing %s, njgit deploy will fail in this namespace", ns.Namespace, nomad.CapabilitySubmitJob))
+			warned = true
+		}
+		if !ns.ParseJob {
+			missing = append(missing, nomad.CapabilityParseJob)
+			PrintWarning(fmt.Sprintf("   ⚠️  %s: missing %s, njgit deploy and verify will fail in this namespace", ns.Namespace, nomad.CapabilityParseJob))
+			warned = true
+		}
+		if len(missing) > 0 {
+			fmt.Println("   💡 Add to a policy attached to the token:")
+			for _, line := range strings.Split(nomad.PolicySuggestion(ns.Namespace, missing), "\n") {
+				fmt.Printf("        %s\n", line)
+			}
+		}
+	}
+
+	if !failed {
+		PrintSuccess("   ✅ Token can read jobs in all tracked namespaces")
+	}
+	return failed, warned
+}
+
+// apiRepository describes the repository an API backend uses, e.g.
+// "myorg/nomad-jobs" or "myorg/nomad-jobs on https://git.example.com"
+func apiRepository(g *config.GitConfig) string {
+	repo := fmt.Sprintf("%s/%s", g.Owner, g.Repo)
+	if g.Backend == "gitlab-api" && g.Project != "" {
+		repo = g.Project
+	}
+	if g.APIURL != "" {
+		repo += " on " + g.APIURL
+	}
+	return repo
+}
@@ -1,25 +1,31 @@
 package commands
 
 import (
+	"context"
 	"fmt"
 	"path/filepath"
+	"time"
 
+	"github.com/hashicorp/nomad/api"
 	"github.com/spf13/cobra"
+	"github.com/wlame/njgit/internal/backend"
 	"github.com/wlame/njgit/internal/config"
 	gitpkg "github.com/wlame/njgit/internal/git"
 	"github.com/wlame/njgit/internal/hcl"
 	"github.com/wlame/njgit/internal/nomad"
+	"github.com/wlame/njgit/internal/redact"
 )
 
 var (
 	deployNamespace string
 	deployRegion    string
 	deployDryRun    bool
+	deployNoTag     bool
 )
 
 // deployCmd represents the deploy command
 var deployCmd = &cobra.Command{
-	Use:   "deploy <commit-hash> [job-name] [flags]",
+	Use:   "deploy <revision> [job-name] [flags]",
 	Short: "Deploy a specific version of a job to Nomad",
 	Long: `Deploy a job configuration from a specific commit to your Nomad cluster.
 
@@ -33,9 +39,17 @@ The command will:
   2. Parse the HCL configuration
   3. Submit it to Nomad for deployment
 
+The revision can be a commit hash, a tag (e.g. a deploy tag or one made with
+njgit tag) or a branch (e.g. env/prod).
+
 If job-name is not provided, it will be automatically detected from the files
 changed in the commit. This works when the commit only affects a single job.
 
+After a successful deploy the commit is tagged
+deploy/<region>/<namespace>/<job>/<timestamp>, so the last known good version
+of a job can be found with njgit tag --list deploy/<region>/<namespace>/<job>/
+(set git.tag_deploys = false or pass --no-tag to skip it).
+
 IMPORTANT: This will deploy the exact configuration from that commit,
 potentially overwriting current job settings.
 
@@ -52,6 +66,9 @@ Examples:
   # Preview what would be deployed (dry run)
   njgit deploy a1b2c3d4 --dry-run
 
+  # Redeploy a tagged version
+  njgit deploy deploy/global/default/web-app/20240115T143022Z
+
 Workflow:
   1. Find the commit: njgit history
   2. Review the version: njgit show <commit>
@@ -64,6 +81,7 @@ func init() {
 	deployCmd.Flags().StringVar(&deployNamespace, "namespace", "default", "Nomad namespace")
 	deployCmd.Flags().StringVar(&deployRegion, "region", "global", "Nomad region")
 	deployCmd.Flags().BoolVar(&deployDryRun, "dry-run", false, "Show what would be deployed without actually deploying")
+	deployCmd.Flags().BoolVar(&deployNoTag, "no-tag", false, "Don't tag the deployed commit")
 
 	rootCmd.AddCommand(deployCmd)
 }
@@ -85,15 +103,22 @@ func deployRun(cmd *cobra.Command, args []string) error {
 	}
 
 	// Load configuration
-	cfg, err := config.Load(GetConfigFile())
+	cfg, err := loadConfig()
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
+	ctx := cmd.Context()
+	b, err := openBackend(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeBackend(b)
+
 	// Auto-detect job name if not provided
 	if jobName == "" {
 		PrintInfo(fmt.Sprintf("Auto-detecting job name from commit %s...", commitHash))
-		detectedJob, err := detectJobFromCommit(cfg, commitHash, deployRegion, deployNamespace)
+		detectedJob, err := detectJobFromCommit(ctx, b, commitHash, deployRegion, deployNamespace)
 		if err != nil {
 			return err
 		}
@@ -104,26 +129,46 @@ func deployRun(cmd *cobra.Command, args []string) error {
 	// Get job HCL from the commit
 	PrintInfo(fmt.Sprintf("Loading job %s/%s/%s from commit %s...", deployRegion, deployNamespace, jobName, commitHash))
 
-	jobHCL, err := getJobFromCommit(cfg, commitHash, deployRegion, deployNamespace, jobName)
+	jobHCL, jobFile, err := getJobFromCommit(ctx, b, commitHash, deployRegion, deployNamespace, jobName)
 	if err != nil {
 		return err
 	}
-
-	// Parse HCL to Job struct
-	// We need to pass the Nomad address because ParseHCL makes a request to Nomad
-	PrintInfo("Parsing job specification...")
-	job, err := hcl.ParseHCL(jobHCL, hcl.ParseOptions{
-		NomadAddr:     cfg.Nomad.Address,
-		TLSSkipVerify: cfg.Nomad.TLSSkipVerify || unsafeSkipTLS,
-		CACert:        cfg.Nomad.CACert,
-	})
+	varsHCL, err := readJobVariables(ctx, b, commitHash, jobFile, jobHCL)
 	if err != nil {
-		return fmt.Errorf("failed to parse HCL: %w", err)
+		return err
 	}
 
-	// Ensure namespace is set
-	if job.Namespace == nil || *job.Namespace == "" {
-		job.Namespace = &deployNamespace
+	// Secrets are restored from the running job and the secrets file; a dry
+	// run doesn't look them up, so its variables keep their placeholders
+	var nomadClient *nomad.Client
+	resolver := redact.NewResolver()
+	if !deployDryRun {
+		PrintInfo("Connecting to Nomad...")
+		nomadAuth, err := nomad.ResolveAuth(&cfg.Nomad, "", "")
+		if err != nil {
+			return fmt.Errorf("failed to resolve Nomad auth: %w", err)
+		}
+
+		nomadClient, err = nomad.NewClient(nomadAuth)
+		if err != nil {
+			return fmt.Errorf("failed to create Nomad client: %w", err)
+		}
+		defer func() { _ = nomadClient.Close() }()
+
+		resolver, err = newSecretsResolver(ctx, cfg, nomadClient, deployNamespace, jobName)
+		if err != nil {
+			return fmt.Errorf("refusing to deploy: %w", err)
+		}
+		if varsHCL != nil {
+			if varsHCL, err = resolveVariables(varsHCL, resolver); err != nil {
+				return fmt.Errorf("refusing to deploy: %w", err)
+			}
+		}
+	}
+
+	job, err := parseDeployJob(ctx, cfg, jobHCL, varsHCL, deployRegion, deployNamespace)
+	if err != nil {
+		return err
 	}
 
 	if deployDryRun {
@@ -142,32 +187,40 @@ func deployRun(cmd *cobra.Command, args []string) error {
 		if len(job.Datacenters) > 0 {
 			fmt.Printf("Datacenters: %v\n", job.Datacenters)
 		}
+		if nomad.IsMultiregion(job) {
+			regions := make([]string, 0, len(job.Multiregion.Regions))
+			for _, r := range job.Multiregion.Regions {
+				regions = append(regions, r.Name)
+			}
+			fmt.Printf("Multiregion: %v\n", regions)
+		}
 		fmt.Println()
 		fmt.Println("Job specification:")
 		fmt.Println(string(jobHCL))
 		fmt.Println()
+		if varsHCL != nil {
+			fmt.Println("Variables:")
+			fmt.Println(string(varsHCL))
+			fmt.Println()
+		}
+		placeholders := len(redact.PlaceholderPattern.FindAll(jobHCL, -1)) + len(redact.PlaceholderPattern.FindAll(varsHCL, -1))
+		if placeholders > 0 {
+			PrintWarning(fmt.Sprintf("The job has %d redacted values; deploy restores them from the running job or the secrets file", placeholders))
+		}
 		PrintInfo("This is a dry run - no changes were made to Nomad")
 		fmt.Println("Remove --dry-run flag to actually deploy")
 		return nil
 	}
 
-	// Connect to Nomad
-	PrintInfo("Connecting to Nomad...")
-	nomadAuth, err := nomad.ResolveAuth(&cfg.Nomad, "", "")
-	if err != nil {
-		return fmt.Errorf("failed to resolve Nomad auth: %w", err)
-	}
-
-	nomadClient, err := nomad.NewClient(nomadAuth)
-	if err != nil {
-		return fmt.Errorf("failed to create Nomad client: %w", err)
+	// Restore secrets that were redacted when the file was written
+	if err := resolveSecrets(job, resolver); err != nil {
+		return fmt.Errorf("refusing to deploy: %w", err)
 	}
-	defer func() { _ = nomadClient.Close() }()
 
 	// Deploy to Nomad
 	PrintInfo(fmt.Sprintf("Deploying %s/%s to Nomad...", *job.Namespace, *job.ID))
 
-	evalID, err := nomadClient.DeployJob(job)
+	evalID, err := nomadClient.DeployJob(ctx, job)
 	if err != nil {
 		return fmt.Errorf("failed to deploy job: %w", err)
 	}
@@ -180,6 +233,13 @@ func deployRun(cmd *cobra.Command, args []string) error {
 	fmt.Println()
 	fmt.Printf("Evaluation ID: %s\n", evalID)
 	fmt.Printf("Commit:        %s\n", commitHash)
+	if cfg.Git.TagDeploys && !deployNoTag {
+		if tag, err := tagDeploy(ctx, cfg, b, commitHash, deployRegion, *job.Namespace, *job.ID, evalID); err != nil {
+			PrintWarning(fmt.Sprintf("Deployed, but couldn't tag the commit: %v", err))
+		} else {
+			fmt.Printf("Tag:           %s\n", tag.Name)
+		}
+	}
 	fmt.Println()
 	fmt.Println("💡 Monitor deployment:")
 	fmt.Printf("  nomad eval status %s\n", evalID)
@@ -189,62 +249,90 @@ func deployRun(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func detectJobFromCommit(cfg *config.Config, commitHash, region, namespace string) (string, error) {
-	// Check backend type
-	backendType := cfg.Git.Backend
-	if backendType == "" {
-		backendType = "git"
+// parseDeployJob parses a stored job file into the job to register
+// varsHCL is the var-file stored next to a submitted jobspec (nil if there
+// is none), with its placeholders already restored. The job gets namespace
+// if it doesn't name one; a multiregion job is registered in region if that
+// is one of its regions.
+func parseDeployJob(ctx context.Context, cfg *config.Config, jobHCL, varsHCL []byte, region, namespace string) (*api.Job, error) {
+	// We need to pass the Nomad address because ParseHCL makes a request to Nomad
+	PrintInfo("Parsing job specification...")
+	opts := nomadParseOptions(cfg)
+	opts.Variables = string(varsHCL)
+	job, err := hcl.ParseHCL(ctx, jobHCL, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HCL: %w", err)
 	}
 
-	if backendType != "git" {
-		return "", fmt.Errorf("auto-detection only supports git backend\n\n"+
-			"Please specify job name explicitly:\n"+
-			"  njgit deploy %s <job-name>", commitHash)
+	// Ensure namespace is set
+	if job.Namespace == nil || *job.Namespace == "" {
+		job.Namespace = &namespace
 	}
 
-	// Open local repository
-	repo, err := gitpkg.NewLocalRepository(cfg.Git.LocalPath)
-	if err != nil {
-		return "", fmt.Errorf("failed to open repository at %s: %w", cfg.Git.LocalPath, err)
+	// Multiregion jobs are registered in one of their own regions and
+	// fanned out by Nomad; --region picks it if it's one of them
+	if nomad.IsMultiregion(job) {
+		deployRegion := nomad.MultiregionDeployRegion(job, region)
+		job.Region = &deployRegion
+		PrintInfo(fmt.Sprintf("Multiregion job: registering in region %s", deployRegion))
 	}
 
-	// Get commits to find full hash and commit info
-	commits, err := repo.GetHistory("", 0)
+	return job, nil
+}
+
+// tagDeploy tags the deployed commit deploy/<region>/<namespace>/<job>/<timestamp>
+// The tag message carries the job trailers, with Njgit-Action: deploy.
+func tagDeploy(ctx context.Context, cfg *config.Config, b backend.Backend, rev, region, namespace, jobName, evalID string) (*gitpkg.TagInfo, error) {
+	commitInfo, err := b.ResolveRevision(ctx, rev)
 	if err != nil {
-		return "", fmt.Errorf("failed to get history: %w", err)
+		return nil, err
 	}
 
-	// Find the commit
-	var commitInfo *gitpkg.CommitInfo
-	for i := range commits {
-		if commits[i].Hash == commitHash || commits[i].FullHash == commitHash {
-			commitInfo = &commits[i]
-			break
-		}
+	trailers := &gitpkg.JobTrailers{
+		Job:       jobName,
+		Namespace: namespace,
+		Region:    region,
+		Cluster:   cfg.Nomad.ClusterName(),
+		Action:    gitpkg.ActionDeploy,
 	}
+	message := fmt.Sprintf("Deploy %s from %s\n\nEvaluation: %s\n\n%s",
+		trailers.Path(), commitInfo.Hash, evalID, trailers.Format())
+
+	return b.CreateTag(ctx, gitpkg.DeployTagName(region, namespace, jobName, time.Now()), commitInfo.FullHash, message)
+}
 
-	if commitInfo == nil {
-		return "", fmt.Errorf("commit %s not found", commitHash)
+// detectJobFromCommit finds the single job in region/namespace that a
+// commit changed
+func detectJobFromCommit(ctx context.Context, b backend.Backend, commitHash, region, namespace string) (string, error) {
+	commitInfo, err := b.ResolveRevision(ctx, commitHash)
+	if err != nil {
+		return "", fmt.Errorf("failed to load commit: %w", err)
 	}
 
-	// Extract job names from changed files
+	// The commit's trailers name its job
+	if job := commitInfo.Job; job != nil && job.Namespace == namespace && job.Region == region {
+		return job.Job, nil
+	}
+
+	// Older commits without trailers: extract job names from changed files
 	// Files follow pattern: <region>/<namespace>/<job-name>.hcl
 	jobNames := make(map[string]bool)
 	targetPath := filepath.Join(region, namespace)
+	multiregionPath := filepath.Join(multiregionDir, namespace)
 
 	for _, file := range commitInfo.Files {
 		// Parse the file path
 		dir := filepath.Dir(file)
 		base := filepath.Base(file)
 
-		// Check if it's in the target region/namespace
-		if dir != targetPath {
+		// Check if it's in the target region/namespace (or is a multiregion
+		// job in the target namespace)
+		if dir != targetPath && dir != multiregionPath {
 			continue
 		}
 
-		// Extract job name (remove .hcl extension)
-		if filepath.Ext(base) == ".hcl" {
-			jobName := base[:len(base)-4]
+		// Extract job name (source and variables files map to their job)
+		if jobName := jobPathFromFile(base); jobName != "" {
 			jobNames[jobName] = true
 		}
 	}
@@ -280,54 +368,57 @@ func detectJobFromCommit(cfg *config.Config, commitHash, region, namespace strin
 	return "", fmt.Errorf("unexpected error detecting job name")
 }
 
-func getJobFromCommit(cfg *config.Config, commitHash, region, namespace, jobName string) ([]byte, error) {
-	// Check backend type
-	backendType := cfg.Git.Backend
-	if backendType == "" {
-		backendType = "git"
-	}
-
-	if backendType != "git" {
-		return nil, fmt.Errorf("deploy command currently only supports git backend\n\n"+
-			"For GitHub API backend:\n"+
-			"  1. View the file on GitHub: njgit show %s --job %s\n"+
-			"  2. Copy the job specification\n"+
-			"  3. Deploy manually: nomad job run <file>", commitHash, jobName)
-	}
-
-	// Open local repository
-	repo, err := gitpkg.NewLocalRepository(cfg.Git.LocalPath)
+// getJobFromCommit reads a job file as it was at a commit
+// Returns the file's content and its path (the multiregion path for jobs
+// stored outside the region directories).
+func getJobFromCommit(ctx context.Context, b backend.Backend, commitHash, region, namespace, jobName string) ([]byte, string, error) {
+	commitInfo, err := b.ResolveRevision(ctx, commitHash)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open repository at %s: %w", cfg.Git.LocalPath, err)
+		return nil, "", fmt.Errorf("failed to load commit: %w", err)
 	}
+	fullHash := commitInfo.FullHash
 
-	// Get commits to find full hash
-	commits, err := repo.GetHistory("", 0)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get history: %w", err)
-	}
+	// Build file path
+	filePath := jobFilePath(region, namespace, jobName)
 
-	// Find the commit
-	var fullHash string
-	for _, c := range commits {
-		if c.Hash == commitHash || c.FullHash == commitHash {
-			fullHash = c.FullHash
-			break
+	// Get file content at this commit
+	content, err := b.ReadFileAt(ctx, fullHash, filePath)
+	if err != nil {
+		// Multiregion jobs are stored once, outside the region directories
+		mrPath := multiregionJobFilePath(namespace, jobName)
+		if mrContent, mrErr := b.ReadFileAt(ctx, fullHash, mrPath); mrErr == nil {
+			return mrContent, mrPath, nil
 		}
+		return nil, "", fmt.Errorf("failed to get file %s at commit %s: %w", filePath, commitHash, err)
 	}
 
-	if fullHash == "" {
-		return nil, fmt.Errorf("commit %s not found", commitHash)
-	}
+	return content, filePath, nil
+}
 
-	// Build file path
-	filePath := filepath.Join(region, namespace, jobName+".hcl")
+// readJobVariables reads the var-file stored next to a job file at a
+// revision (<job>.vars.hcl, see submissionVarsPath)
+// Returns nil if there is none, or if jobHCL declares no variables: with
+// source = "both" the job file is the generated HCL, which has the values
+// filled in already.
+func readJobVariables(ctx context.Context, b backend.Backend, rev, jobFile string, jobHCL []byte) ([]byte, error) {
+	if !hcl.DeclaresVariables(jobHCL) {
+		return nil, nil
+	}
 
-	// Get file content at this commit
-	content, err := repo.GetFileAtCommit(fullHash, filePath)
+	varsFile := submissionVarsPath(jobFile)
+	files, err := b.ListFiles(ctx, rev, filepath.Dir(jobFile))
 	if err != nil {
-		return nil, fmt.Errorf("failed to get file %s at commit %s: %w", filePath, commitHash, err)
+		return nil, fmt.Errorf("failed to list %s at %s: %w", filepath.Dir(jobFile), rev, err)
 	}
-
-	return content, nil
+	for _, file := range files {
+		if file != varsFile {
+			continue
+		}
+		content, err := b.ReadFileAt(ctx, rev, varsFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get file %s at %s: %w", varsFile, rev, err)
+		}
+		return content, nil
+	}
+	return nil, nil
 }
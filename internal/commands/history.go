@@ -1,14 +1,15 @@
 package commands
 
 import (
+	"context"
 	"fmt"
-	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/wlame/njgit/internal/config"
 	gitpkg "github.com/wlame/njgit/internal/git"
+	"github.com/wlame/njgit/internal/nomad"
 )
 
 var (
@@ -27,6 +28,7 @@ var historyCmd = &cobra.Command{
 This shows all changes made to your job configurations over time, allowing you to:
   • See when jobs were changed
   • Identify specific versions for rollback
+  • Check who signed each change (signed commits show their signature status)
 
 You can filter by job name/namespace or show all changes.
 
@@ -53,34 +55,29 @@ func init() {
 
 func historyRun(cmd *cobra.Command, args []string) error {
 	// Load configuration
-	cfg, err := config.Load(GetConfigFile())
+	cfg, err := loadConfig()
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
-	// Check backend type
-	backendType := cfg.Git.Backend
-	if backendType == "" {
-		backendType = "git"
+	// Child jobs (periodic/dispatched instances) share the parent's spec,
+	// which is the only one stored in Git
+	if parent, ok := nomad.ParentJobID(historyJob); ok {
+		PrintInfo(fmt.Sprintf("%s is a child job; showing history of parent %s", historyJob, parent))
+		historyJob = parent
 	}
 
-	if backendType != "git" {
-		// For GitHub API backend, show link to GitHub
-		return showGitHubHistory(cfg)
-	}
-
-	// For Git backend, show local history
-	return showGitHistory(cfg)
+	return showHistory(cmd.Context(), cfg)
 }
 
-func showGitHistory(cfg *config.Config) error {
-	PrintInfo("Loading Git repository history...")
+func showHistory(ctx context.Context, cfg *config.Config) error {
+	PrintInfo("Loading repository history...")
 
-	// Open local repository
-	repo, err := gitpkg.NewLocalRepository(cfg.Git.LocalPath)
+	b, err := openBackend(ctx, cfg)
 	if err != nil {
-		return fmt.Errorf("failed to open repository at %s: %w", cfg.Git.LocalPath, err)
+		return err
 	}
+	defer closeBackend(b)
 
 	// Build file path filter if job specified
 	var filePath string
@@ -91,16 +88,27 @@ func showGitHistory(cfg *config.Config) error {
 		if historyRegion == "" {
 			historyRegion = "global"
 		}
-		filePath = filepath.Join(historyRegion, historyNamespace, historyJob+".hcl")
+		filePath = jobFilePath(historyRegion, historyNamespace, historyJob)
 		PrintInfo(fmt.Sprintf("Filtering by job: %s/%s/%s", historyRegion, historyNamespace, historyJob))
 	}
 
 	// Get history
-	commits, err := repo.GetHistory(filePath, historyLimit)
+	commits, err := b.History(ctx, filePath, historyLimit)
 	if err != nil {
 		return fmt.Errorf("failed to get history: %w", err)
 	}
 
+	// Multiregion jobs are stored once, outside the region directories
+	if len(commits) == 0 && historyJob != "" {
+		mrPath := multiregionJobFilePath(historyNamespace, historyJob)
+		if commits, err = b.History(ctx, mrPath, historyLimit); err != nil {
+			return fmt.Errorf("failed to get history: %w", err)
+		}
+		if len(commits) > 0 {
+			filePath = mrPath
+		}
+	}
+
 	if len(commits) == 0 {
 		if filePath != "" {
 			PrintWarning(fmt.Sprintf("No commits found for %s", filePath))
@@ -121,69 +129,35 @@ func showGitHistory(cfg *config.Config) error {
 		// Format date
 		dateStr := formatDate(commit.Date)
 
-		// Extract job name from files if available
+		// The job comes from the commit's trailers; older commits without
+		// them fall back to the first changed file
 		jobName := ""
-		if len(commit.Files) > 0 {
+		if commit.Job != nil {
+			jobName = commit.Job.Path()
+		} else if len(commit.Files) > 0 {
 			// Files are in format: region/namespace/jobname.hcl
-			// Extract the full job path and remove .hcl extension
-			filePath := commit.Files[0]
-			jobName = strings.TrimSuffix(filePath, ".hcl")
+			// (plus .source.hcl / .vars.hcl when the submission is stored)
+			jobName = jobPathFromFile(commit.Files[0])
 		}
 
 		// Get first line of commit message
 		messageLines := strings.Split(commit.Message, "\n")
 		firstLine := messageLines[0]
 
-		// Print one-line format: date hash job message
-		if jobName != "" {
-			fmt.Printf("%s %s %s %s\n", dateStr, commit.Hash, jobName, firstLine)
-		} else {
-			fmt.Printf("%s %s %s\n", dateStr, commit.Hash, firstLine)
+		// Signed commits get their signature status appended
+		signature := ""
+		if commit.Signature.Status != "" && commit.Signature.Status != gitpkg.SignatureUnsigned {
+			signature = fmt.Sprintf(" [%s]", commit.Signature)
 		}
-	}
-
-	return nil
-}
-
-func showGitHubHistory(cfg *config.Config) error {
-	owner := cfg.Git.Owner
-	repo := cfg.Git.Repo
-	branch := cfg.Git.Branch
-	if branch == "" {
-		branch = "main"
-	}
 
-	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-	fmt.Println("📜 GitHub History")
-	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-	fmt.Println()
-
-	PrintInfo("Using GitHub API backend - history available on GitHub")
-	fmt.Println()
-
-	// Build GitHub URLs
-	if historyJob != "" {
-		if historyNamespace == "" {
-			historyNamespace = "default"
+		// Print one-line format: date hash job message [signature]
+		if jobName != "" {
+			fmt.Printf("%s %s %s %s%s\n", dateStr, commit.Hash, jobName, firstLine, signature)
+		} else {
+			fmt.Printf("%s %s %s%s\n", dateStr, commit.Hash, firstLine, signature)
 		}
-		filePath := filepath.Join(historyNamespace, historyJob+".hcl")
-		fileURL := fmt.Sprintf("https://github.com/%s/%s/commits/%s/%s", owner, repo, branch, filePath)
-
-		fmt.Printf("📄 Job: %s/%s\n", historyNamespace, historyJob)
-		fmt.Printf("🔗 View history: %s\n", fileURL)
-	} else {
-		repoURL := fmt.Sprintf("https://github.com/%s/%s/commits/%s", owner, repo, branch)
-		fmt.Printf("🔗 View all commits: %s\n", repoURL)
 	}
 
-	fmt.Println()
-	fmt.Println("💡 Using GitHub API backend:")
-	fmt.Println("  • History is stored on GitHub")
-	fmt.Println("  • Click the link above to view commits in your browser")
-	fmt.Println("  • Use 'njgit show <commit-hash>' to view specific versions")
-	fmt.Println("  • Use 'njgit deploy <commit-hash> <job>' to rollback")
-	fmt.Println()
-
 	return nil
 }
 
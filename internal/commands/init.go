@@ -71,8 +71,12 @@ func initRun(cmd *cobra.Command, args []string) error {
 	fmt.Println("     • Automatic push to GitHub")
 	fmt.Println("     • Best for CI/CD environments")
 	fmt.Println()
+	fmt.Println("  3) GitLab API Backend - gitlab.com or self-managed GitLab")
+	fmt.Println("  4) Gitea API Backend - self-hosted Gitea or Forgejo")
+	fmt.Println("     • Like the GitHub API backend, for other forges")
+	fmt.Println()
 
-	backend := promptChoice(reader, "Select backend", []string{"git", "github-api"}, "git")
+	backend := promptChoice(reader, "Select backend", []string{"git", "github-api", "gitlab-api", "gitea-api"}, "git")
 
 	var config strings.Builder
 	config.WriteString("# njgit configuration\n")
@@ -83,10 +87,13 @@ func initRun(cmd *cobra.Command, args []string) error {
 	config.WriteString("[git]\n")
 	config.WriteString(fmt.Sprintf("backend = \"%s\"\n", backend))
 
-	if backend == "git" {
+	switch backend {
+	case "git":
 		configureGitBackend(reader, &config)
-	} else {
+	case "github-api":
 		configureGitHubAPIBackend(reader, &config)
+	default:
+		configureForgeAPIBackend(reader, &config, backend)
 	}
 
 	// Nomad configuration
@@ -246,6 +253,45 @@ func configureGitHubAPIBackend(reader *bufio.Reader, config *strings.Builder) {
 	fmt.Fprintf(config, "author_name = \"%s\"\n", authorName)
 	fmt.Fprintf(config, "author_email = \"%s\"\n", authorEmail)
 	config.WriteString("# token = \"\"  # Or set GITHUB_TOKEN/GH_TOKEN environment variable\n")
+	config.WriteString("# api_url = \"https://github.example.com\"  # GitHub Enterprise Server\n")
+	config.WriteString("# app_id = 123456  # Authenticate as a GitHub App instead of with a token\n")
+	config.WriteString("# app_private_key = \"~/.njgit/app.pem\"\n")
+}
+
+// configureForgeAPIBackend prompts for the gitlab-api or gitea-api backend
+func configureForgeAPIBackend(reader *bufio.Reader, config *strings.Builder, backend string) {
+	forge, defaultURL, tokenVar := "GitLab", "https://gitlab.com", "GITLAB_TOKEN"
+	if backend == "gitea-api" {
+		forge, defaultURL, tokenVar = "Gitea", "", "GITEA_TOKEN"
+	}
+
+	fmt.Println()
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	fmt.Printf("🔧 %s API Backend Configuration\n", forge)
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	fmt.Println()
+
+	fmt.Println("For repository: git.example.com/myorg/nomad-jobs")
+	fmt.Println("  • Server URL: https://git.example.com")
+	fmt.Println("  • Owner:      myorg")
+	fmt.Println("  • Repo:       nomad-jobs")
+	fmt.Println()
+
+	apiURL := prompt(reader, forge+" server URL", defaultURL)
+	owner := prompt(reader, "Owner (group, org or username)", "")
+	repo := prompt(reader, "Repository name", "")
+	branch := prompt(reader, "Branch", "main")
+
+	authorName := prompt(reader, "Git commit author name", "njgit")
+	authorEmail := prompt(reader, "Git commit author email", "njgit@localhost")
+
+	fmt.Fprintf(config, "api_url = \"%s\"\n", apiURL)
+	fmt.Fprintf(config, "owner = \"%s\"\n", owner)
+	fmt.Fprintf(config, "repo = \"%s\"\n", repo)
+	fmt.Fprintf(config, "branch = \"%s\"\n", branch)
+	fmt.Fprintf(config, "author_name = \"%s\"\n", authorName)
+	fmt.Fprintf(config, "author_email = \"%s\"\n", authorEmail)
+	fmt.Fprintf(config, "# token = \"\"  # Or set %s environment variable\n", tokenVar)
 }
 
 // Helper functions for interactive prompts
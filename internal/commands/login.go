@@ -0,0 +1,170 @@
+package commands
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/spf13/cobra"
+	"github.com/wlame/njgit/internal/nomad"
+)
+
+var (
+	// Flags for login command
+	loginAuthMethod string        // Auth method name, overriding [nomad.auth] auth_method
+	loginNoBrowser  bool          // Print the login URL instead of opening a browser
+	loginTimeout    time.Duration // How long to wait for the OIDC callback
+)
+
+// loginCmd represents the login command
+var loginCmd = &cobra.Command{
+	Use:   "login",
+	Short: "Log in to Nomad through an auth method and cache the token",
+	Long: `Log in to Nomad through an OIDC (SSO) or JWT auth method.
+
+With [nomad.auth] method = "oidc", this runs the OIDC flow:
+  1. Opens the identity provider's login page in your browser
+  2. Receives the redirect on a local listener (callback_address)
+  3. Exchanges it for a Nomad ACL token
+
+With method = "jwt", it exchanges the JWT in jwt_file for a Nomad token.
+JWT logins also happen automatically, so this only primes the cache.
+
+The token is cached (token_cache) until it expires; other commands use it
+without logging in again. The auth method must allow the redirect URI
+http://localhost:4649/oidc/callback (or your callback_address).
+
+Examples:
+  # Log in with the auth method from the config
+  njgit login
+
+  # Log in with a specific auth method, printing the URL instead of opening it
+  njgit login --auth-method okta --no-browser`,
+	RunE: loginRun,
+}
+
+func init() {
+	loginCmd.Flags().StringVar(&loginAuthMethod, "auth-method", "",
+		"Nomad auth method to log in with (default: [nomad.auth] auth_method)")
+	loginCmd.Flags().BoolVar(&loginNoBrowser, "no-browser", false,
+		"Print the login URL instead of opening a browser")
+	loginCmd.Flags().DurationVar(&loginTimeout, "timeout", 5*time.Minute,
+		"How long to wait for the browser login to complete")
+
+	rootCmd.AddCommand(loginCmd)
+}
+
+// loginRun executes the login command
+func loginRun(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	authCfg := cfg.Nomad.Auth
+	if loginAuthMethod != "" {
+		authCfg.AuthMethod = loginAuthMethod
+	}
+	if authCfg.AuthMethod == "" {
+		return fmt.Errorf("no auth method configured (set [nomad.auth] auth_method or pass --auth-method)")
+	}
+
+	method := authCfg.Method
+	if method != nomad.LoginMethodJWT {
+		if method != nomad.LoginMethodOIDC {
+			PrintWarning(fmt.Sprintf("[nomad.auth] method is %q; other commands only use this login with method = \"oidc\"", method))
+		}
+		method = nomad.LoginMethodOIDC
+	}
+
+	// Log in without any token; the login endpoints don't need one
+	nomadCfg := cfg.Nomad
+	nomadCfg.Auth.Method = "token"
+	nomadCfg.Token = ""
+	nomadAuth, err := nomad.ResolveAuth(&nomadCfg, "", "")
+	if err != nil {
+		return fmt.Errorf("failed to resolve Nomad auth: %w", err)
+	}
+	nomadAuth.Token = ""
+
+	nomadClient, err := nomad.NewClient(nomadAuth)
+	if err != nil {
+		return fmt.Errorf("failed to create Nomad client: %w", err)
+	}
+	defer func() { _ = nomadClient.Close() }()
+
+	PrintInfo(fmt.Sprintf("Logging in to %s with auth method %s (%s)...", nomadAuth.Address, authCfg.AuthMethod, method))
+
+	var token *api.ACLToken
+	if method == nomad.LoginMethodJWT {
+		token, err = nomadClient.LoginJWT(cmd.Context(), authCfg.AuthMethod, authCfg.JWTFile)
+	} else {
+		token, err = nomadClient.LoginOIDC(cmd.Context(), authCfg.AuthMethod, authCfg.CallbackAddress, openLoginURL, loginTimeout)
+	}
+	if err != nil {
+		return err
+	}
+
+	cache := nomad.NewTokenCache(authCfg.TokenCache)
+	if err := cache.Put(nomadAuth.Address, authCfg.AuthMethod, token); err != nil {
+		return fmt.Errorf("logged in, but failed to cache the token: %w", err)
+	}
+
+	PrintSuccess(fmt.Sprintf("Logged in as %s", describeToken(token)))
+	if IsVerbose() {
+		fmt.Printf("   Accessor ID: %s\n", token.AccessorID)
+		fmt.Printf("   Token cache: %s\n", cache.Path())
+	}
+	return nil
+}
+
+// describeToken summarizes a token: its name, policies and expiry
+func describeToken(token *api.ACLToken) string {
+	name := token.Name
+	if name == "" {
+		name = token.AccessorID
+	}
+
+	var details []string
+	if len(token.Policies) > 0 {
+		details = append(details, "policies: "+strings.Join(token.Policies, ", "))
+	}
+	for _, role := range token.Roles {
+		details = append(details, "role: "+role.Name)
+	}
+	if token.ExpirationTime != nil {
+		details = append(details, "expires "+token.ExpirationTime.Local().Format("2006-01-02 15:04"))
+	}
+
+	if len(details) == 0 {
+		return name
+	}
+	return fmt.Sprintf("%s (%s)", name, strings.Join(details, "; "))
+}
+
+// openLoginURL shows the provider URL and tries to open it in a browser
+func openLoginURL(url string) {
+	fmt.Println()
+	fmt.Println("Complete the login in your browser:")
+	fmt.Printf("  %s\n", url)
+	fmt.Println()
+
+	if loginNoBrowser {
+		return
+	}
+
+	var command *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		command = exec.Command("open", url)
+	case "windows":
+		command = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		command = exec.Command("xdg-open", url)
+	}
+	// The URL is printed anyway, so a missing browser is not an error
+	_ = command.Start()
+}
@@ -0,0 +1,60 @@
+package commands
+
+import (
+	"path/filepath"
+	"strings"
+
+	gitpkg "github.com/wlame/njgit/internal/git"
+)
+
+// multiregionDir is the top-level directory for multiregion jobs
+// They exist in several regions at once, so they aren't stored under any one
+// region's directory
+const multiregionDir = gitpkg.MultiregionDir
+
+// jobFilePath returns the repository path of a job's HCL file
+// Layout: <region>/<namespace>/<job>.hcl
+func jobFilePath(region, namespace, name string) string {
+	return filepath.Join(region, namespace, name+".hcl")
+}
+
+// multiregionJobFilePath returns the repository path of a multiregion job
+// Layout: _multiregion/<namespace>/<job>.hcl
+func multiregionJobFilePath(namespace, name string) string {
+	return jobFilePath(multiregionDir, namespace, name)
+}
+
+// Files stored next to a job file when its submitted source is kept:
+//   - <job>.source.hcl / <job>.source.json: the submitted jobspec
+//   - <job>.vars.hcl: the variables it was submitted with
+const (
+	sourceHCLSuffix  = ".source.hcl"
+	sourceJSONSuffix = ".source.json"
+	varsSuffix       = ".vars.hcl"
+)
+
+// submissionSourcePath returns the path of a job's submitted jobspec
+func submissionSourcePath(jobFile, format string) string {
+	base := strings.TrimSuffix(jobFile, ".hcl")
+	if format == "json" {
+		return base + sourceJSONSuffix
+	}
+	return base + sourceHCLSuffix
+}
+
+// submissionVarsPath returns the path of a job's submitted variables
+func submissionVarsPath(jobFile string) string {
+	return strings.TrimSuffix(jobFile, ".hcl") + varsSuffix
+}
+
+// jobPathFromFile returns the job path (<region>/<namespace>/<job>) a
+// repository file belongs to, or "" if it isn't a job file or a file stored
+// next to one
+func jobPathFromFile(file string) string {
+	for _, suffix := range []string{sourceHCLSuffix, sourceJSONSuffix, varsSuffix, ".hcl"} {
+		if strings.HasSuffix(file, suffix) {
+			return strings.TrimSuffix(file, suffix)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,203 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/wlame/njgit/internal/backend"
+	"github.com/wlame/njgit/internal/config"
+	gitpkg "github.com/wlame/njgit/internal/git"
+)
+
+var (
+	proposeFile      string
+	proposeNamespace string
+	proposeRegion    string
+	proposeBranch    string
+	proposeTitle     string
+	proposeMessage   string
+	proposeAuthor    string
+)
+
+// proposeCmd represents the propose command
+var proposeCmd = &cobra.Command{
+	Use:   "propose <job-name> --file <edited.hcl> [flags]",
+	Short: "Propose a job change as a pull request",
+	Long: `Propose a change to a job for review instead of deploying it directly.
+
+The command will:
+  1. Create a branch from the branch njgit commits to
+  2. Commit the edited job file to it
+  3. Open a pull request into the branch njgit commits to
+
+Once the pull request is merged, njgit apply-merged deploys it.
+
+Pull requests need a backend on a forge: github-api, gitlab-api (merge
+requests) or gitea-api.
+
+Examples:
+  # Propose a new version of web-app
+  njgit propose web-app --file web-app.hcl
+
+  # With a title, description and author
+  njgit propose web-app --file web-app.hcl --namespace production \
+    --title "Scale web-app to 5" --message "Traffic is up" \
+    --author "Jane Doe <jane@example.com>"
+
+Workflow:
+  1. Edit a copy of the stored job file (e.g. global/default/web-app.hcl)
+  2. Propose it: njgit propose web-app --file web-app.hcl
+  3. Review and merge the pull request
+  4. Deploy it: njgit apply-merged`,
+	Args: cobra.ExactArgs(1),
+	RunE: proposeRun,
+}
+
+func init() {
+	proposeCmd.Flags().StringVarP(&proposeFile, "file", "f", "", "Edited job file (required)")
+	proposeCmd.Flags().StringVar(&proposeNamespace, "namespace", "default", "Nomad namespace")
+	proposeCmd.Flags().StringVar(&proposeRegion, "region", "global", "Nomad region")
+	proposeCmd.Flags().StringVar(&proposeBranch, "branch", "", "Branch to create (default: njgit/propose/<region>/<namespace>/<job>/<timestamp>)")
+	proposeCmd.Flags().StringVar(&proposeTitle, "title", "", "Pull request title (default: \"Update <region>/<namespace>/<job>\")")
+	proposeCmd.Flags().StringVarP(&proposeMessage, "message", "m", "", "Pull request description")
+	proposeCmd.Flags().StringVar(&proposeAuthor, "author", "", "Commit author, \"Name <email>\" (default: njgit)")
+	_ = proposeCmd.MarkFlagRequired("file")
+
+	rootCmd.AddCommand(proposeCmd)
+}
+
+func proposeRun(cmd *cobra.Command, args []string) error {
+	jobName := args[0]
+	if proposeNamespace == "" {
+		proposeNamespace = "default"
+	}
+	if proposeRegion == "" {
+		proposeRegion = "global"
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	var author *gitpkg.Identity
+	if proposeAuthor != "" {
+		identity, ok := gitpkg.ParseIdentity(proposeAuthor, cfg.Git.AttributionEmailDomain)
+		if !ok {
+			return fmt.Errorf("invalid --author %q: use \"Name <email>\"", proposeAuthor)
+		}
+		author = &identity
+	}
+
+	content, err := os.ReadFile(proposeFile)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", proposeFile, err)
+	}
+	if len(bytes.TrimSpace(content)) == 0 {
+		return fmt.Errorf("%s is empty", proposeFile)
+	}
+
+	ctx := cmd.Context()
+	b, err := openBackend(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeBackend(b)
+
+	forge, ok := b.(backend.Forge)
+	if !ok {
+		return fmt.Errorf("the %s backend can't open pull requests (use github-api, gitlab-api or gitea-api)", b.GetName())
+	}
+
+	// Start from the branch sync commits to
+	base := baseBranch(cfg)
+	if err := b.UseBranch(ctx, base); err != nil {
+		return fmt.Errorf("failed to switch to branch %s: %w", base, err)
+	}
+
+	jobPath := fmt.Sprintf("%s/%s/%s", proposeRegion, proposeNamespace, jobName)
+	filePath := jobFilePath(proposeRegion, proposeNamespace, jobName)
+
+	changeDescription := "Initial version"
+	exists, err := b.FileExists(ctx, filePath)
+	if err != nil {
+		return fmt.Errorf("failed to check %s: %w", filePath, err)
+	}
+	if exists {
+		current, err := b.ReadFile(ctx, filePath)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", filePath, err)
+		}
+		if bytes.Equal(bytes.TrimSpace(current), bytes.TrimSpace(content)) {
+			return fmt.Errorf("%s is the same as %s on %s; nothing to propose", proposeFile, filePath, base)
+		}
+		changeDescription = "Job configuration updated"
+	}
+
+	head := proposeBranch
+	if head == "" {
+		head = fmt.Sprintf("njgit/propose/%s/%s", jobPath, time.Now().UTC().Format("20060102T150405Z"))
+	}
+	if err := b.UseBranch(ctx, head); err != nil {
+		return fmt.Errorf("failed to create branch %s: %w", head, err)
+	}
+
+	PrintInfo(fmt.Sprintf("Committing %s to %s...", filePath, head))
+	if err := b.WriteFile(ctx, filePath, content); err != nil {
+		discardChanges(b)
+		return fmt.Errorf("failed to write %s: %w", filePath, err)
+	}
+	commitMsg := buildCommitMessage(jobPath, changeDescription, &gitpkg.JobTrailers{
+		Job:       jobName,
+		Namespace: proposeNamespace,
+		Region:    proposeRegion,
+		Cluster:   cfg.Nomad.ClusterName(),
+		Action:    gitpkg.ActionPropose,
+	})
+	if _, err := b.Commit(ctx, commitMsg, author); err != nil {
+		discardChanges(b)
+		return fmt.Errorf("failed to commit: %w", err)
+	}
+	if err := b.Push(ctx); err != nil {
+		return fmt.Errorf("failed to push branch %s: %w", head, err)
+	}
+
+	title := proposeTitle
+	if title == "" {
+		title = fmt.Sprintf("Update %s", jobPath)
+	}
+	body := proposeMessage
+	if body != "" && !strings.HasSuffix(body, "\n") {
+		body += "\n"
+	}
+	body += fmt.Sprintf("\nProposed with `njgit propose`. Once merged, `njgit apply-merged` deploys %s.\n", jobPath)
+
+	pr, err := forge.CreatePullRequest(ctx, &backend.PullRequest{
+		Title: title,
+		Body:  strings.TrimLeft(body, "\n"),
+		Head:  head,
+		Base:  base,
+	})
+	if err != nil {
+		return err
+	}
+
+	PrintSuccess(fmt.Sprintf("Opened pull request #%d: %s", pr.Number, pr.URL))
+	return nil
+}
+
+// baseBranch returns the branch sync commits to: the cluster's environment
+// branch if it has one, otherwise git.branch
+func baseBranch(cfg *config.Config) string {
+	if branch := cfg.Git.EnvBranch(cfg.Nomad.ClusterName()); branch != "" {
+		return branch
+	}
+	if cfg.Git.Branch != "" {
+		return cfg.Git.Branch
+	}
+	return "main"
+}
@@ -0,0 +1,143 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/wlame/njgit/internal/config"
+	"github.com/wlame/njgit/internal/hcl"
+	"github.com/wlame/njgit/internal/nomad"
+	"github.com/wlame/njgit/internal/redact"
+)
+
+// newRedactor builds the secret redactor from config
+// Returns nil if redaction is disabled
+func newRedactor(cfg *config.Config) (*redact.Redactor, error) {
+	if !cfg.Redaction.Enabled {
+		return nil, nil
+	}
+
+	rules := make([]redact.Rule, 0, len(cfg.Redaction.Rules))
+	for _, rule := range cfg.Redaction.Rules {
+		rules = append(rules, redact.Rule{Name: rule.Name, Key: rule.Key, Value: rule.Value})
+	}
+
+	return redact.New(redact.Options{
+		Entropy:   cfg.Redaction.Entropy,
+		AllowKeys: cfg.Redaction.AllowKeys,
+		Rules:     rules,
+	})
+}
+
+// newSecretsResolver collects the values redacted placeholders can be
+// restored from:
+//   - the job currently running in Nomad, and the variables it was
+//     submitted with, for secrets that haven't changed since the stored
+//     version (the common rollback case)
+//   - the secrets_file from config
+//
+// Placeholders are matched by hash, so only the exact original value can
+// fill one.
+func newSecretsResolver(ctx context.Context, cfg *config.Config, nomadClient *nomad.Client, namespace, jobID string) (*redact.Resolver, error) {
+	resolver := redact.NewResolver()
+
+	current, err := nomadClient.FetchJobSpec(ctx, namespace, jobID)
+	if err == nil {
+		if redactor, err := newRedactor(cfg); err == nil && redactor != nil {
+			for _, redaction := range nomad.RedactJob(current, redactor) {
+				resolver.Add(redaction.Value)
+			}
+		}
+		if current.Version != nil {
+			submission, err := nomadClient.FetchJobSubmission(ctx, namespace, jobID, int(*current.Version))
+			if err == nil {
+				for _, value := range hcl.SubmissionVariableValues(submission) {
+					resolver.Add(value)
+				}
+			}
+		}
+	}
+
+	if cfg.Redaction.SecretsFile != "" {
+		if err := addSecretsFile(resolver, cfg.Redaction.SecretsFile); err != nil {
+			return nil, err
+		}
+	}
+
+	return resolver, nil
+}
+
+// resolveSecrets restores redacted values in a job about to be registered
+// Returns an error listing every placeholder that is left.
+func resolveSecrets(job *api.Job, resolver *redact.Resolver) error {
+	unresolved, err := nomad.ResolvePlaceholders(job, resolver)
+	if err != nil {
+		return err
+	}
+	return unresolvedError("job", unresolved)
+}
+
+// resolveVariables restores redacted values in a stored var-file about to
+// be sent to Nomad with its jobspec
+// Returns an error listing every variable that still holds a placeholder.
+func resolveVariables(vars []byte, resolver *redact.Resolver) ([]byte, error) {
+	resolved, unresolved, err := hcl.ResolveVariables(vars, resolver)
+	if err != nil {
+		return nil, err
+	}
+	return resolved, unresolvedError("variables", unresolved)
+}
+
+// unresolvedError describes the fields whose placeholders couldn't be
+// restored, or returns nil if there are none
+func unresolvedError(what string, unresolved []string) error {
+	if len(unresolved) == 0 {
+		return nil
+	}
+
+	var msg strings.Builder
+	msg.WriteString(fmt.Sprintf("%s contains %d redacted values that could not be restored:\n", what, len(unresolved)))
+	for _, field := range unresolved {
+		msg.WriteString("  " + field + "\n")
+	}
+	msg.WriteString("\nAdd the original values to the file set in [redaction] secrets_file\n")
+	msg.WriteString("(KEY=value lines; values are matched by hash, keys are only labels)")
+	return fmt.Errorf("%s", msg.String())
+}
+
+// addSecretsFile adds the values of a KEY=value file to a resolver
+// Blank lines and lines starting with # are skipped; values may be quoted
+func addSecretsFile(resolver *redact.Resolver, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open secrets file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		_, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		resolver.Add(value)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read secrets file: %w", err)
+	}
+
+	return nil
+}
@@ -3,10 +3,16 @@
 package commands
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/spf13/cobra"
+	"github.com/wlame/njgit/internal/backend"
+	"github.com/wlame/njgit/internal/config"
+	"github.com/wlame/njgit/internal/hcl"
 	"github.com/wlame/njgit/pkg/version"
 )
 
@@ -22,6 +28,13 @@ var (
 	// unsafeSkipTLS skips TLS certificate verification for Nomad connections
 	// This is set by the --unsafe flag
 	unsafeSkipTLS bool
+
+	// Nomad TLS flags, overriding [nomad] settings from the config file
+	nomadCACert        string
+	nomadCAPath        string
+	nomadClientCert    string
+	nomadClientKey     string
+	nomadTLSServerName string
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -71,8 +84,26 @@ For more information, see: https://github.com/wlame/njgit`,
 // Execute is the main entry point for the CLI
 // It's called from main.go and executes the root command
 // Returns an error if command execution fails
+//
+// The first SIGINT (Ctrl-C) or SIGTERM cancels the command's context, so
+// running requests stop and a sync aborts without committing. After that
+// the default signal handling is restored: a second Ctrl-C kills njgit.
 func Execute() error {
-	return rootCmd.Execute()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			stop()
+			fmt.Fprintln(os.Stderr, "\nInterrupted, stopping... (press Ctrl-C again to force quit)")
+		case <-done:
+		}
+	}()
+
+	return rootCmd.ExecuteContext(ctx)
 }
 
 // init is a special Go function that runs automatically when the package is imported
@@ -94,6 +125,18 @@ func init() {
 	rootCmd.PersistentFlags().BoolVar(&unsafeSkipTLS, "unsafe", false,
 		"skip TLS certificate verification for Nomad connections")
 
+	// Nomad TLS flags: CA, client certificate for mutual TLS, and server name
+	rootCmd.PersistentFlags().StringVar(&nomadCACert, "nomad-ca-cert", "",
+		"path to a CA certificate for Nomad TLS verification")
+	rootCmd.PersistentFlags().StringVar(&nomadCAPath, "nomad-ca-path", "",
+		"path to a directory of CA certificates for Nomad TLS verification")
+	rootCmd.PersistentFlags().StringVar(&nomadClientCert, "nomad-client-cert", "",
+		"path to a client certificate for Nomad mutual TLS")
+	rootCmd.PersistentFlags().StringVar(&nomadClientKey, "nomad-client-key", "",
+		"path to the client certificate's private key")
+	rootCmd.PersistentFlags().StringVar(&nomadTLSServerName, "nomad-tls-server-name", "",
+		"server name to verify the Nomad certificate against")
+
 	// Add version command
 	// This is a built-in command that shows version information
 	rootCmd.AddCommand(versionCmd)
@@ -139,6 +182,69 @@ func GetConfigFile() string {
 	return cfgFile
 }
 
+// loadConfig loads the configuration file and applies the global Nomad
+// connection flags on top of it
+// Flags take precedence over the config file and environment variables
+func loadConfig() (*config.Config, error) {
+	cfg, err := config.Load(GetConfigFile())
+	if err != nil {
+		return nil, err
+	}
+
+	overrides := []struct {
+		field *string
+		value string
+	}{
+		{&cfg.Nomad.CACert, nomadCACert},
+		{&cfg.Nomad.CAPath, nomadCAPath},
+		{&cfg.Nomad.ClientCert, nomadClientCert},
+		{&cfg.Nomad.ClientKey, nomadClientKey},
+		{&cfg.Nomad.TLSServerName, nomadTLSServerName},
+	}
+	for _, override := range overrides {
+		if override.value != "" {
+			*override.field = override.value
+		}
+	}
+	if unsafeSkipTLS {
+		cfg.Nomad.TLSSkipVerify = true
+	}
+
+	return cfg, nil
+}
+
+// nomadParseOptions returns the options for parsing HCL through the
+// configured Nomad cluster
+func nomadParseOptions(cfg *config.Config) hcl.ParseOptions {
+	return hcl.ParseOptions{
+		NomadAddr:     cfg.Nomad.Address,
+		TLSSkipVerify: cfg.Nomad.TLSSkipVerify,
+		CACert:        cfg.Nomad.CACert,
+		CAPath:        cfg.Nomad.CAPath,
+		ClientCert:    cfg.Nomad.ClientCert,
+		ClientKey:     cfg.Nomad.ClientKey,
+		TLSServerName: cfg.Nomad.TLSServerName,
+		Timeout:       cfg.Nomad.Timeout,
+	}
+}
+
+// openBackend creates and initializes the configured backend, for commands
+// that read history and job files from it
+// The caller must Close the returned backend
+func openBackend(ctx context.Context, cfg *config.Config) (backend.Backend, error) {
+	b, err := backend.NewBackend(&cfg.Git)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create backend: %w", err)
+	}
+
+	if err := b.Initialize(ctx); err != nil {
+		_ = b.Close()
+		return nil, fmt.Errorf("failed to initialize backend: %w", err)
+	}
+
+	return b, nil
+}
+
 // IsVerbose returns true if verbose mode is enabled
 // This is used by subcommands to determine output verbosity
 func IsVerbose() bool {
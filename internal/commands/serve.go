@@ -4,8 +4,6 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
-	"github.com/wlame/njgit/internal/config"
-	gitpkg "github.com/wlame/njgit/internal/git"
 	"github.com/wlame/njgit/internal/web"
 )
 
@@ -28,21 +26,18 @@ func init() {
 }
 
 func serveRun(cmd *cobra.Command, args []string) error {
-	cfg, err := config.Load(GetConfigFile())
+	cfg, err := loadConfig()
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
-	if cfg.Git.Backend != "git" {
-		return fmt.Errorf("web dashboard only supports git backend (current: %s)", cfg.Git.Backend)
-	}
-
-	repo, err := gitpkg.NewLocalRepository(cfg.Git.LocalPath)
+	b, err := openBackend(cmd.Context(), cfg)
 	if err != nil {
-		return fmt.Errorf("failed to open repository: %w", err)
+		return err
 	}
+	defer closeBackend(b)
 
-	srv := web.NewServer(repo, serveBind, servePort)
+	srv := web.NewServer(b, serveBind, servePort)
 	PrintInfo(fmt.Sprintf("Starting njgit dashboard at http://%s:%d", serveBind, servePort))
 	return srv.Start()
 }
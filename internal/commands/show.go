@@ -1,12 +1,13 @@
 package commands
 
 import (
+	"context"
 	"fmt"
 	"path/filepath"
 
 	"github.com/spf13/cobra"
 	"github.com/wlame/njgit/internal/config"
-	gitpkg "github.com/wlame/njgit/internal/git"
+	"github.com/wlame/njgit/internal/nomad"
 )
 
 var (
@@ -27,8 +28,8 @@ point in history. You can use this to:
   • See the exact configuration before deploying
   • Compare different versions
 
-For Git backend: Shows the file content from that commit
-For GitHub API backend: Opens the commit view on GitHub
+The commit can be given as a full or abbreviated hash, or as a branch or
+tag name. Works the same with the Git and GitHub API backends.
 
 Examples:
   # Show a specific commit (interactive job selection)
@@ -37,8 +38,8 @@ Examples:
   # Show specific job at a commit
   njgit show a1b2c3d4 --job web-app --namespace default
 
-  # View on GitHub (if using GitHub API backend)
-  njgit show a1b2c3d4`,
+  # Show a job as it is on the main branch
+  njgit show main --job web-app`,
 	Args: cobra.ExactArgs(1),
 	RunE: showRun,
 }
@@ -55,52 +56,32 @@ func showRun(cmd *cobra.Command, args []string) error {
 	commitHash := args[0]
 
 	// Load configuration
-	cfg, err := config.Load(GetConfigFile())
+	cfg, err := loadConfig()
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
-	// Check backend type
-	backendType := cfg.Git.Backend
-	if backendType == "" {
-		backendType = "git"
+	// Child jobs (periodic/dispatched instances) are stored as their parent
+	if parent, ok := nomad.ParentJobID(showJob); ok {
+		PrintInfo(fmt.Sprintf("%s is a child job; showing parent %s", showJob, parent))
+		showJob = parent
 	}
 
-	if backendType != "git" {
-		// For GitHub API backend, show link to GitHub
-		return showGitHubCommit(cfg, commitHash)
-	}
-
-	// For Git backend, show file content
-	return showGitCommit(cfg, commitHash)
+	return showCommit(cmd.Context(), cfg, commitHash)
 }
 
-func showGitCommit(cfg *config.Config, commitHash string) error {
+func showCommit(ctx context.Context, cfg *config.Config, commitHash string) error {
 	PrintInfo(fmt.Sprintf("Loading commit %s...", commitHash))
 
-	// Open local repository
-	repo, err := gitpkg.NewLocalRepository(cfg.Git.LocalPath)
+	b, err := openBackend(ctx, cfg)
 	if err != nil {
-		return fmt.Errorf("failed to open repository at %s: %w", cfg.Git.LocalPath, err)
+		return err
 	}
+	defer closeBackend(b)
 
-	// Get commit info
-	commits, err := repo.GetHistory("", 0)
+	matchingCommit, err := b.ResolveRevision(ctx, commitHash)
 	if err != nil {
-		return fmt.Errorf("failed to get history: %w", err)
-	}
-
-	// Find the commit
-	var matchingCommit *gitpkg.CommitInfo
-	for _, c := range commits {
-		if c.Hash == commitHash || c.FullHash == commitHash {
-			matchingCommit = &c
-			break
-		}
-	}
-
-	if matchingCommit == nil {
-		return fmt.Errorf("commit %s not found", commitHash)
+		return fmt.Errorf("failed to load commit: %w", err)
 	}
 
 	// Display commit header
@@ -111,6 +92,14 @@ func showGitCommit(cfg *config.Config, commitHash string) error {
 	fmt.Println()
 	fmt.Printf("Author: %s <%s>\n", matchingCommit.Author, matchingCommit.Email)
 	fmt.Printf("Date:   %s\n", formatDate(matchingCommit.Date))
+	fmt.Printf("Signed: %s\n", matchingCommit.Signature)
+	if job := matchingCommit.Job; job != nil {
+		if job.Version != nil {
+			fmt.Printf("Job:    %s (version %d)\n", job.Path(), *job.Version)
+		} else {
+			fmt.Printf("Job:    %s\n", job.Path())
+		}
+	}
 	fmt.Println()
 	fmt.Printf("    %s\n", matchingCommit.Message)
 	fmt.Println()
@@ -125,7 +114,7 @@ func showGitCommit(cfg *config.Config, commitHash string) error {
 		if showRegion == "" {
 			showRegion = "global"
 		}
-		filePath = filepath.Join(showRegion, showNamespace, showJob+".hcl")
+		filePath = jobFilePath(showRegion, showNamespace, showJob)
 	} else {
 		// Show all files changed in this commit
 		if len(matchingCommit.Files) == 0 {
@@ -162,16 +151,23 @@ func showGitCommit(cfg *config.Config, commitHash string) error {
 	}
 
 	// Get file content at this commit
+	content, err := b.ReadFileAt(ctx, matchingCommit.FullHash, filePath)
+	if err != nil && showJob != "" {
+		// Multiregion jobs are stored once, outside the region directories
+		mrPath := multiregionJobFilePath(showNamespace, showJob)
+		if mrContent, mrErr := b.ReadFileAt(ctx, matchingCommit.FullHash, mrPath); mrErr == nil {
+			content, filePath, err = mrContent, mrPath, nil
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get file at commit: %w", err)
+	}
+
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 	fmt.Printf("📄 File: %s\n", filePath)
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 	fmt.Println()
 
-	content, err := repo.GetFileAtCommit(matchingCommit.FullHash, filePath)
-	if err != nil {
-		return fmt.Errorf("failed to get file at commit: %w", err)
-	}
-
 	// Display content
 	fmt.Println(string(content))
 
@@ -180,8 +176,8 @@ func showGitCommit(cfg *config.Config, commitHash string) error {
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 	fmt.Println("💡 To deploy this version:")
 
-	// Parse namespace/job from file path
-	namespace := filepath.Dir(filePath)
+	// Parse namespace/job from file path (<region>/<namespace>/<job>.hcl)
+	namespace := filepath.Base(filepath.Dir(filePath))
 	if namespace == "." {
 		namespace = "default"
 	}
@@ -193,48 +189,3 @@ func showGitCommit(cfg *config.Config, commitHash string) error {
 
 	return nil
 }
-
-func showGitHubCommit(cfg *config.Config, commitHash string) error {
-	owner := cfg.Git.Owner
-	repo := cfg.Git.Repo
-
-	fmt.Println()
-	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-	fmt.Printf("📝 Commit %s\n", commitHash)
-	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-	fmt.Println()
-
-	PrintInfo("Using GitHub API backend - viewing on GitHub")
-	fmt.Println()
-
-	if showJob != "" {
-		if showNamespace == "" {
-			showNamespace = "default"
-		}
-		if showRegion == "" {
-			showRegion = "global"
-		}
-		filePath := filepath.Join(showRegion, showNamespace, showJob+".hcl")
-
-		// Link to specific file in commit
-		fileURL := fmt.Sprintf("https://github.com/%s/%s/blob/%s/%s", owner, repo, commitHash, filePath)
-
-		fmt.Printf("📄 Job: %s/%s/%s\n", showRegion, showNamespace, showJob)
-		fmt.Printf("🔗 View on GitHub: %s\n", fileURL)
-	} else {
-		// Link to commit
-		commitURL := fmt.Sprintf("https://github.com/%s/%s/commit/%s", owner, repo, commitHash)
-		fmt.Printf("🔗 View commit on GitHub: %s\n", commitURL)
-	}
-
-	fmt.Println()
-	fmt.Println("💡 To deploy this version:")
-	if showJob != "" {
-		fmt.Printf("  njgit deploy %s %s --namespace %s\n", commitHash, showJob, showNamespace)
-	} else {
-		fmt.Println("  njgit deploy <commit> <job> --namespace <namespace>")
-	}
-	fmt.Println()
-
-	return nil
-}
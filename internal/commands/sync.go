@@ -1,16 +1,19 @@
 package commands
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"path/filepath"
 	"strings"
 
 	"github.com/hashicorp/nomad/api"
 	"github.com/spf13/cobra"
 	"github.com/wlame/njgit/internal/backend"
 	"github.com/wlame/njgit/internal/config"
+	gitpkg "github.com/wlame/njgit/internal/git"
 	"github.com/wlame/njgit/internal/hcl"
 	"github.com/wlame/njgit/internal/nomad"
+	"github.com/wlame/njgit/internal/redact"
 )
 
 var (
@@ -18,6 +21,8 @@ var (
 	syncDryRun bool
 	syncNoPush bool
 	syncJobs   string // Comma-separated list of jobs to sync
+	syncVerify bool
+	syncStrict bool
 )
 
 // syncCmd represents the sync command
@@ -47,7 +52,10 @@ Examples:
   njgit sync --dry-run
 
   # Commit locally but don't push to remote
-  njgit sync --no-push`,
+  njgit sync --no-push
+
+  # Refuse to commit files that can't reproduce the running job
+  njgit sync --strict`,
 	RunE: syncRun,
 }
 
@@ -59,6 +67,10 @@ func init() {
 		"Commit changes locally but don't push to remote")
 	syncCmd.Flags().StringVar(&syncJobs, "jobs", "",
 		"Comma-separated list of jobs to sync (default: all configured jobs)")
+	syncCmd.Flags().BoolVar(&syncVerify, "verify", false,
+		"Parse each rendered file back and warn about fields that don't survive")
+	syncCmd.Flags().BoolVar(&syncStrict, "strict", false,
+		"Like --verify, but don't commit files that don't survive the round-trip")
 
 	// Add to root command
 	rootCmd.AddCommand(syncCmd)
@@ -70,7 +82,7 @@ func syncRun(cmd *cobra.Command, args []string) error {
 
 	// 1. Load configuration
 	PrintInfo("Loading configuration...")
-	cfg, err := config.Load(GetConfigFile())
+	cfg, err := loadConfig()
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
@@ -86,10 +98,13 @@ func syncRun(cmd *cobra.Command, args []string) error {
 	if backendType == "" {
 		backendType = "git"
 	}
-	if backendType == "git" {
+	switch {
+	case backendType == "git":
 		PrintInfo(fmt.Sprintf("Backend: git (local: %s)", cfg.Git.LocalPath))
-	} else {
-		PrintInfo(fmt.Sprintf("Backend: github-api (%s/%s)", cfg.Git.Owner, cfg.Git.Repo))
+	case backendType == "gitlab-api" && cfg.Git.Project != "":
+		PrintInfo(fmt.Sprintf("Backend: gitlab-api (%s)", cfg.Git.Project))
+	default:
+		PrintInfo(fmt.Sprintf("Backend: %s (%s/%s)", backendType, cfg.Git.Owner, cfg.Git.Repo))
 	}
 
 	// 2. Create Nomad client
@@ -106,7 +121,8 @@ func syncRun(cmd *cobra.Command, args []string) error {
 	defer func() { _ = nomadClient.Close() }()
 
 	// Test Nomad connectivity
-	if err := nomadClient.Ping(); err != nil {
+	ctx := cmd.Context()
+	if err := nomadClient.Ping(ctx); err != nil {
 		return fmt.Errorf("failed to connect to Nomad: %w", err)
 	}
 	PrintSuccess("Connected to Nomad")
@@ -118,35 +134,66 @@ func syncRun(cmd *cobra.Command, args []string) error {
 		if err != nil {
 			return fmt.Errorf("failed to create backend: %w", err)
 		}
-		defer func() { _ = backend.Close() }()
+		defer closeBackend(backend)
 
-		if err := backend.Initialize(); err != nil {
+		if err := backend.Initialize(ctx); err != nil {
 			return fmt.Errorf("failed to initialize backend: %w", err)
 		}
 
 		PrintSuccess(fmt.Sprintf("Backend ready (%s)", backend.GetName()))
 
+		// Commit to this cluster's environment branch, if it has one
+		if branch := cfg.Git.EnvBranch(cfg.Nomad.ClusterName()); branch != "" {
+			if err := backend.UseBranch(ctx, branch); err != nil {
+				return fmt.Errorf("failed to switch to branch %s: %w", branch, err)
+			}
+		}
+
+		// Compare and commit against what others pushed
+		if err := backend.Pull(ctx); err != nil {
+			return err
+		}
+
 		// Perform the sync
-		return performSync(cfg, nomadClient, backend)
+		return performSync(ctx, cfg, nomadClient, backend)
 	} else {
 		// Dry run - no backend operations
-		return performDryRun(cfg, nomadClient)
+		return performDryRun(ctx, cfg, nomadClient)
 	}
 }
 
 // performSync performs the actual sync with backend operations
-func performSync(cfg *config.Config, nomadClient *nomad.Client, backend backend.Backend) error {
+// Each changed job is committed on its own. When ctx is canceled (Ctrl-C),
+// the job in progress is discarded and the jobs after it are skipped; jobs
+// committed before the interrupt stay committed.
+func performSync(ctx context.Context, cfg *config.Config, nomadClient *nomad.Client, backend backend.Backend) error {
 	// Filter jobs if --jobs flag was provided
 	jobsToSync := getJobsToSync(cfg)
 
 	PrintInfo(fmt.Sprintf("Syncing %d jobs...", len(jobsToSync)))
 
+	// Secrets are replaced with placeholders before anything is written;
+	// the rules are compiled once for the whole run
+	redactor, err := newRedactor(cfg)
+	if err != nil {
+		return fmt.Errorf("invalid redaction config: %w", err)
+	}
+
 	var changedJobs []string
 	var errors []error
+	children := newChildJobCounts(nomadClient)
 
 	// Process each job
 	for _, jobCfg := range jobsToSync {
-		changed, err := syncJob(cfg, nomadClient, backend, jobCfg)
+		if ctx.Err() != nil {
+			break
+		}
+
+		changed, err := syncJob(ctx, cfg, nomadClient, backend, redactor, children, jobCfg)
+		if ctx.Err() != nil {
+			// Errors after an interrupt are just the interrupt
+			break
+		}
 		if err != nil {
 			// Log error but continue with other jobs
 			PrintError(fmt.Errorf("job %s/%s: %w", jobCfg.Namespace, jobCfg.Name, err))
@@ -159,6 +206,14 @@ func performSync(cfg *config.Config, nomadClient *nomad.Client, backend backend.
 		}
 	}
 
+	if ctx.Err() != nil {
+		PrintWarning(fmt.Sprintf("Sync interrupted; %d jobs were committed before the interrupt", len(changedJobs)))
+		for _, job := range changedJobs {
+			fmt.Printf("  - %s\n", job)
+		}
+		return fmt.Errorf("sync interrupted: %w", ctx.Err())
+	}
+
 	// Report results
 	if len(changedJobs) > 0 {
 		PrintSuccess(fmt.Sprintf("Synced %d jobs with changes:", len(changedJobs)))
@@ -179,12 +234,16 @@ func performSync(cfg *config.Config, nomadClient *nomad.Client, backend backend.
 
 // syncJob syncs a single job
 // Returns true if the job changed, false otherwise
-func syncJob(cfg *config.Config, nomadClient *nomad.Client, backend backend.Backend, jobCfg config.JobConfig) (bool, error) {
+// If ctx is canceled before the job's commit starts, the written files are
+// discarded and nothing is committed. redactor (nil when redaction is off)
+// and children are shared by the jobs of one sync, so the rules are compiled
+// and each namespace is listed once for the verbose child job counts.
+func syncJob(ctx context.Context, cfg *config.Config, nomadClient *nomad.Client, b backend.Backend, redactor *redact.Redactor, children *childJobCounts, jobCfg config.JobConfig) (bool, error) {
 	jobPath := fmt.Sprintf("%s/%s/%s", jobCfg.Region, jobCfg.Namespace, jobCfg.Name)
 	PrintInfo(fmt.Sprintf("Checking %s...", jobPath))
 
 	// 1. Fetch job from Nomad
-	job, err := nomadClient.FetchJobSpec(jobCfg.Namespace, jobCfg.Name)
+	job, err := nomadClient.FetchJobSpec(ctx, jobCfg.Namespace, jobCfg.Name)
 	if err != nil {
 		if _, ok := err.(nomad.JobNotFoundError); ok {
 			// Job not found - warn but don't error
@@ -194,9 +253,42 @@ func syncJob(cfg *config.Config, nomadClient *nomad.Client, backend backend.Back
 		return false, fmt.Errorf("failed to fetch job: %w", err)
 	}
 
+	// Child instances of periodic/parameterized jobs are not tracked;
+	// their spec is a copy of the parent's
+	if nomad.IsChildJob(job) {
+		PrintWarning(fmt.Sprintf("%s: Child of %s (skipping; track the parent job instead)",
+			jobPath, childJobParent(job)))
+		return false, nil
+	}
+
+	if IsVerbose() {
+		reportChildJobs(ctx, children, jobCfg.Namespace, job)
+	}
+
+	// Multiregion jobs get one canonical file instead of one per region
+	filePath := jobFilePath(jobCfg.Region, jobCfg.Namespace, jobCfg.Name)
+	if nomad.IsMultiregion(job) {
+		filePath = multiregionJobFilePath(jobCfg.Namespace, jobCfg.Name)
+		jobPath = strings.TrimSuffix(filePath, ".hcl")
+		if IsVerbose() {
+			PrintInfo(fmt.Sprintf("  Multiregion job, stored as %s", filePath))
+		}
+	}
+
 	// 2. Normalize the job
 	normalized := nomad.NormalizeJob(job, cfg.Changes.IgnoreFields)
 
+	// Replace secrets with placeholders before anything is written
+	if redactor != nil {
+		redactions := nomad.RedactJob(normalized, redactor)
+		if IsVerbose() && len(redactions) > 0 {
+			PrintInfo(fmt.Sprintf("  Redacted %d secrets:", len(redactions)))
+			for _, redaction := range redactions {
+				fmt.Printf("    %s (%s)\n", redaction.Path, redaction.Detector)
+			}
+		}
+	}
+
 	// 3. Convert to HCL
 	hclBytes, err := hcl.FormatJobAsHCL(normalized)
 	if err != nil {
@@ -206,58 +298,140 @@ func syncJob(cfg *config.Config, nomadClient *nomad.Client, backend backend.Back
 	// Normalize HCL for consistent comparison
 	hclBytes = hcl.NormalizeHCL(hclBytes)
 
-	// 4. Check if file exists and compare
-	filePath := filepath.Join(jobCfg.Region, jobCfg.Namespace, jobCfg.Name+".hcl")
-	fileExists, err := backend.FileExists(filePath)
-	if err != nil {
-		return false, fmt.Errorf("failed to check if file exists: %w", err)
-	}
+	// Decide which files make up this job: the generated HCL and/or the
+	// source it was submitted with
+	files := jobFiles(ctx, cfg, nomadClient, redactor, job, jobPath, filePath, hclBytes)
 
-	var hasChanges bool
+	// 4. Check which files are new or changed
+	var changedFiles []syncFile
 	var changeDescription string
 
-	if fileExists {
+	for _, file := range files {
+		fileExists, err := b.FileExists(ctx, file.path)
+		if err != nil {
+			return false, fmt.Errorf("failed to check if file exists: %w", err)
+		}
+
+		if !fileExists {
+			changedFiles = append(changedFiles, file)
+			if file.path == filePath {
+				changeDescription = "Initial version"
+			}
+			continue
+		}
+
 		// Read existing file
-		existingContent, err := backend.ReadFile(filePath)
+		existingContent, err := b.ReadFile(ctx, file.path)
 		if err != nil {
 			return false, fmt.Errorf("failed to read existing file: %w", err)
 		}
 
-		// Compare
-		if hcl.CompareHCL(existingContent, hclBytes) {
-			// No changes
-			if IsVerbose() {
-				PrintInfo(fmt.Sprintf("  %s: No changes", jobPath))
+		// Apply the changes to the existing file instead of replacing it,
+		// keeping the user's comments and layout
+		if file.generated && cfg.Changes.PreserveLayout {
+			merged, err := hcl.MergeHCL(existingContent, file.content)
+			if err != nil {
+				PrintWarning(fmt.Sprintf("%s: Could not preserve layout, rewriting file: %v", jobPath, err))
+			} else {
+				file.content = hcl.NormalizeHCL(merged)
 			}
-			return false, nil
 		}
 
-		hasChanges = true
-		changeDescription = detectChanges(existingContent, hclBytes, job)
-	} else {
-		// New file
-		hasChanges = true
-		changeDescription = "Initial version"
+		// Compare
+		if hcl.CompareHCL(existingContent, file.content) {
+			continue
+		}
+
+		changedFiles = append(changedFiles, file)
+		if file.path == filePath {
+			changeDescription = detectChanges(existingContent, file.content, job)
+		}
 	}
 
-	if !hasChanges {
+	// Check that the generated file parses back into the same job
+	if mode := verifyMode(cfg); mode != "off" {
+		for _, file := range files {
+			if !file.generated {
+				continue
+			}
+			diffs, err := roundTrip(ctx, cfg, redactor, job, file.content)
+			if err != nil {
+				return false, fmt.Errorf("round-trip verification: %w", err)
+			}
+			if len(diffs) == 0 {
+				continue
+			}
+			printRoundTripDiffs(jobPath, diffs)
+			if mode == "strict" && len(changedFiles) > 0 {
+				return false, fmt.Errorf("not committing %s: %d fields did not survive the round-trip (strict mode)",
+					file.path, len(diffs))
+			}
+		}
+	}
+
+	if len(changedFiles) == 0 {
+		// No changes
+		if IsVerbose() {
+			PrintInfo(fmt.Sprintf("  %s: No changes", jobPath))
+		}
 		return false, nil
 	}
 
-	// 5. Write the new HCL file
+	if changeDescription == "" {
+		changeDescription = "Submitted source updated"
+	}
+
+	// 5. Write the new files
 	PrintInfo(fmt.Sprintf("  %s: CHANGED", jobPath))
 	if IsVerbose() && changeDescription != "" {
 		fmt.Printf("    %s\n", changeDescription)
 	}
 
-	if err := backend.WriteFile(filePath, hclBytes); err != nil {
-		return false, fmt.Errorf("failed to write file: %w", err)
+	for _, file := range changedFiles {
+		if err := b.WriteFile(ctx, file.path, file.content); err != nil {
+			discardChanges(b)
+			return false, fmt.Errorf("failed to write file: %w", err)
+		}
 	}
 
 	// 6. Create commit
-	commitMsg := buildCommitMessage(jobPath, changeDescription)
-	hash, err := backend.Commit(commitMsg)
+	// An interrupt before this point leaves nothing behind; once the
+	// commit starts it runs to completion
+	if err := ctx.Err(); err != nil {
+		discardChanges(b)
+		return false, fmt.Errorf("not committing %s: %w", jobPath, err)
+	}
+	commitMsg := buildCommitMessage(jobPath, changeDescription, &gitpkg.JobTrailers{
+		Job:         jobCfg.Name,
+		Namespace:   jobCfg.Namespace,
+		Region:      jobCfg.Region,
+		Multiregion: nomad.IsMultiregion(job),
+		Version:     job.Version,
+		ModifyIndex: job.JobModifyIndex,
+		Cluster:     cfg.Nomad.ClusterName(),
+		Action:      gitpkg.ActionSync,
+	})
+
+	// Credit whoever changed the job (git.attribution); njgit commits
+	var author *gitpkg.Identity
+	if authors := jobAuthors(ctx, cfg, nomadClient, job, jobPath); len(authors) > 0 {
+		author = &authors[0]
+		commitMsg = withCoAuthors(commitMsg, authors[1:])
+		if IsVerbose() {
+			PrintInfo(fmt.Sprintf("  Author: %s", author))
+		}
+	}
+
+	hash, err := b.Commit(ctx, commitMsg, author)
+	if errors.Is(err, backend.ErrNothingToCommit) {
+		// Someone else already committed this version
+		if IsVerbose() {
+			PrintInfo(fmt.Sprintf("  %s: No changes (already committed)", jobPath))
+		}
+		return false, nil
+	}
 	if err != nil {
+		discardChanges(b)
 		return false, fmt.Errorf("failed to commit: %w", err)
 	}
 
@@ -267,7 +441,7 @@ func syncJob(cfg *config.Config, nomadClient *nomad.Client, backend backend.Back
 
 	// 7. Push (unless --no-push)
 	if !syncNoPush {
-		if err := backend.Push(); err != nil {
+		if err := b.Push(ctx); err != nil {
 			return false, fmt.Errorf("failed to push: %w", err)
 		}
 		if IsVerbose() {
@@ -278,8 +452,106 @@ func syncJob(cfg *config.Config, nomadClient *nomad.Client, backend backend.Back
 	return true, nil
 }
 
+// verifyMode returns the round-trip verification mode: "off", "warn" or "strict"
+// The --verify and --strict flags override changes.verify from config
+func verifyMode(cfg *config.Config) string {
+	switch {
+	case syncStrict:
+		return "strict"
+	case syncVerify:
+		return "warn"
+	case cfg.Changes.Verify == "":
+		return "off"
+	}
+	return cfg.Changes.Verify
+}
+
+// syncFile is one file stored for a job
+type syncFile struct {
+	path    string
+	content []byte
+
+	// generated is true for HCL produced by FormatJobAsHCL, which
+	// preserve_layout may merge into the existing file
+	generated bool
+}
+
+// jobFiles returns the files to store for a job
+// With changes.source set to "submission" or "both", the jobspec the current
+// version was submitted with is fetched from Nomad. Jobs without a submission
+// (registered through the API, or Nomad older than 1.6) fall back to the
+// generated HCL.
+func jobFiles(ctx context.Context, cfg *config.Config, nomadClient *nomad.Client, redactor *redact.Redactor, job *api.Job, jobPath, filePath string, generated []byte) []syncFile {
+	generatedFile := syncFile{path: filePath, content: generated, generated: true}
+
+	mode := cfg.Changes.Source
+	if mode != "submission" && mode != "both" {
+		return []syncFile{generatedFile}
+	}
+
+	namespace := "default"
+	if job.Namespace != nil {
+		namespace = *job.Namespace
+	}
+	version := 0
+	if job.Version != nil {
+		version = int(*job.Version)
+	}
+
+	submission, err := nomadClient.FetchJobSubmission(ctx, namespace, jobID(job), version)
+	if err != nil {
+		PrintWarning(fmt.Sprintf("%s: Could not fetch submitted source, using generated HCL: %v", jobPath, err))
+		return []syncFile{generatedFile}
+	}
+	if submission == nil {
+		if IsVerbose() {
+			PrintInfo(fmt.Sprintf("  %s: No submitted source in Nomad, using generated HCL", jobPath))
+		}
+		return []syncFile{generatedFile}
+	}
+
+	source := []byte(submission.Source)
+	if redactor != nil {
+		redacted, _ := redactor.Text(submission.Source)
+		source = []byte(redacted)
+	}
+	var files []syncFile
+
+	switch {
+	case mode == "both":
+		files = append(files, generatedFile, syncFile{
+			path:    submissionSourcePath(filePath, submission.Format),
+			content: source,
+		})
+	case submission.Format == "hcl2":
+		// The submitted HCL takes the place of the generated file
+		files = append(files, syncFile{path: filePath, content: source})
+	default:
+		// JSON and HCL1 sources can't stand in for the .hcl file
+		if IsVerbose() {
+			PrintInfo(fmt.Sprintf("  %s: Submitted as %s, using generated HCL", jobPath, submission.Format))
+		}
+		return []syncFile{generatedFile}
+	}
+
+	if cfg.Changes.SourceVariables == "omit" {
+		return files
+	}
+
+	variables, err := hcl.FormatSubmissionVariables(submission, cfg.Changes.SourceVariables != "keep")
+	if err != nil {
+		PrintWarning(fmt.Sprintf("%s: Not storing submitted variables: %v", jobPath, err))
+		return files
+	}
+	if variables != nil {
+		files = append(files, syncFile{path: submissionVarsPath(filePath), content: variables})
+	}
+
+	return files
+}
+
 // performDryRun performs a dry run (no Git operations)
-func performDryRun(cfg *config.Config, nomadClient *nomad.Client) error {
+func performDryRun(ctx context.Context, cfg *config.Config, nomadClient *nomad.Client) error {
 	jobsToSync := getJobsToSync(cfg)
 
 	PrintInfo(fmt.Sprintf("DRY RUN: Checking %d jobs...", len(jobsToSync)))
@@ -287,10 +559,14 @@ func performDryRun(cfg *config.Config, nomadClient *nomad.Client) error {
 	var changes []string
 
 	for _, jobCfg := range jobsToSync {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("dry run interrupted: %w", err)
+		}
+
 		jobPath := fmt.Sprintf("%s/%s", jobCfg.Namespace, jobCfg.Name)
 
 		// Fetch and normalize
-		job, err := nomadClient.FetchJobSpec(jobCfg.Namespace, jobCfg.Name)
+		job, err := nomadClient.FetchJobSpec(ctx, jobCfg.Namespace, jobCfg.Name)
 		if err != nil {
 			if _, ok := err.(nomad.JobNotFoundError); ok {
 				PrintWarning(fmt.Sprintf("%s: Not found in Nomad", jobPath))
@@ -300,6 +576,11 @@ func performDryRun(cfg *config.Config, nomadClient *nomad.Client) error {
 			continue
 		}
 
+		if nomad.IsChildJob(job) {
+			PrintWarning(fmt.Sprintf("%s: Child of %s (would skip)", jobPath, childJobParent(job)))
+			continue
+		}
+
 		normalized := nomad.NormalizeJob(job, cfg.Changes.IgnoreFields)
 		hclBytes, err := hcl.FormatJobAsHCL(normalized)
 		if err != nil {
@@ -321,15 +602,106 @@ func performDryRun(cfg *config.Config, nomadClient *nomad.Client) error {
 	return nil
 }
 
+// childJobParent returns the parent ID of a periodic or dispatched child job
+func childJobParent(job *api.Job) string {
+	if job.ParentID != nil && *job.ParentID != "" {
+		return *job.ParentID
+	}
+	parent, _ := nomad.ParentJobID(jobID(job))
+	return parent
+}
+
+// jobID returns the job's ID, or an empty string if it isn't set
+func jobID(job *api.Job) string {
+	if job.ID == nil {
+		return ""
+	}
+	return *job.ID
+}
+
+// childJobCounts holds the number of child jobs per parent ID in each
+// namespace, listed from Nomad the first time a namespace is asked for
+type childJobCounts struct {
+	nomadClient *nomad.Client
+
+	// counts is keyed by namespace; nil means listing it failed
+	counts map[string]map[string]int
+}
+
+// newChildJobCounts returns an empty cache of child job counts
+func newChildJobCounts(nomadClient *nomad.Client) *childJobCounts {
+	return &childJobCounts{nomadClient: nomadClient, counts: make(map[string]map[string]int)}
+}
+
+// namespace returns the child job counts of a namespace, listing its jobs
+// on first use
+// If the listing fails, a warning is printed once and false is returned
+// for the rest of the sync.
+func (c *childJobCounts) namespace(ctx context.Context, namespace string) (map[string]int, bool) {
+	if counts, ok := c.counts[namespace]; ok {
+		return counts, counts != nil
+	}
+
+	jobs, err := c.nomadClient.ListJobs(ctx, namespace)
+	if err != nil {
+		PrintWarning(fmt.Sprintf("  Could not list child jobs in namespace %s: %v", namespace, err))
+		c.counts[namespace] = nil
+		return nil, false
+	}
+
+	_, counts := nomad.FilterChildJobs(jobs)
+	c.counts[namespace] = counts
+	return counts, true
+}
+
+// reportChildJobs prints how many child instances a periodic or parameterized
+// job currently has in Nomad. The children themselves are never synced.
+func reportChildJobs(ctx context.Context, children *childJobCounts, namespace string, job *api.Job) {
+	if job.Periodic == nil && job.ParameterizedJob == nil {
+		return
+	}
+
+	counts, ok := children.namespace(ctx, namespace)
+	if !ok {
+		return
+	}
+
+	if count := counts[jobID(job)]; count > 0 {
+		PrintInfo(fmt.Sprintf("  %d child jobs in Nomad (not tracked)", count))
+	}
+}
+
+// discardChanges drops a job's uncommitted files, warning if that fails
+func discardChanges(backend backend.Backend) {
+	if err := backend.Discard(); err != nil {
+		PrintWarning(fmt.Sprintf("Could not discard uncommitted changes: %v", err))
+	}
+}
+
+// closeBackend closes a backend, warning if that fails (e.g. the git
+// backend can't switch back to the branch it started on, or the GitHub
+// backend can't save its response cache)
+func closeBackend(b backend.Backend) {
+	if err := b.Close(); err != nil {
+		PrintWarning(err.Error())
+	}
+}
+
 // getJobsToSync returns the list of jobs to sync based on --jobs flag
 func getJobsToSync(cfg *config.Config) []config.JobConfig {
-	if syncJobs == "" {
-		// Sync all configured jobs
+	return filterJobs(cfg, syncJobs)
+}
+
+// filterJobs returns the configured jobs named in a comma-separated list
+// An empty list selects all configured jobs
+func filterJobs(cfg *config.Config, names string) []config.JobConfig {
+	if names == "" {
+		// All configured jobs
 		return cfg.Jobs
 	}
 
 	// Filter to only specified jobs
-	jobNames := strings.Split(syncJobs, ",")
+	jobNames := strings.Split(names, ",")
 	jobSet := make(map[string]bool)
 	for _, name := range jobNames {
 		jobSet[strings.TrimSpace(name)] = true
@@ -346,7 +718,9 @@ func getJobsToSync(cfg *config.Config) []config.JobConfig {
 }
 
 // buildCommitMessage builds a commit message for a job change
-func buildCommitMessage(jobPath, changeDescription string) string {
+// The message ends with trailers identifying the job (Nomad-Job, ...), so
+// history doesn't have to be parsed from the subject.
+func buildCommitMessage(jobPath, changeDescription string, trailers *gitpkg.JobTrailers) string {
 	var msg strings.Builder
 
 	msg.WriteString(fmt.Sprintf("Update %s", jobPath))
@@ -359,6 +733,9 @@ func buildCommitMessage(jobPath, changeDescription string) string {
 		msg.WriteString("\n\nInitial version")
 	}
 
+	msg.WriteString("\n\n")
+	msg.WriteString(trailers.Format())
+
 	return msg.String()
 }
 
@@ -0,0 +1,105 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	tagMessage string
+	tagList    bool
+)
+
+// tagCmd represents the tag command
+var tagCmd = &cobra.Command{
+	Use:   "tag <revision> <name> | tag --list [prefix]",
+	Short: "Tag a version of the job configurations",
+	Long: `Create an annotated tag on a commit, or list tags.
+
+Tags mark versions you want to come back to, e.g. a known good release.
+Tag names can be used anywhere a revision is accepted (show, deploy).
+
+njgit also tags each commit it deploys:
+  deploy/<region>/<namespace>/<job>/<timestamp>
+
+Examples:
+  # Tag the current version
+  njgit tag HEAD release/2024-01-15
+
+  # Tag a commit with a message
+  njgit tag a1b2c3d4 known-good/web-app --message "Last version before the migration"
+
+  # List all tags
+  njgit tag --list
+
+  # List the deploys of a job
+  njgit tag --list deploy/global/default/web-app/`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if tagList {
+			return cobra.MaximumNArgs(1)(cmd, args)
+		}
+		return cobra.ExactArgs(2)(cmd, args)
+	},
+	RunE: tagRun,
+}
+
+func init() {
+	tagCmd.Flags().StringVarP(&tagMessage, "message", "m", "", "Tag message (default: \"Tag <name>\")")
+	tagCmd.Flags().BoolVarP(&tagList, "list", "l", false, "List tags (optionally only those starting with a prefix)")
+
+	rootCmd.AddCommand(tagCmd)
+}
+
+func tagRun(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	ctx := cmd.Context()
+	b, err := openBackend(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeBackend(b)
+
+	if tagList {
+		prefix := ""
+		if len(args) == 1 {
+			prefix = args[0]
+		}
+
+		tags, err := b.ListTags(ctx, prefix)
+		if err != nil {
+			return err
+		}
+		if len(tags) == 0 {
+			PrintInfo("No tags found")
+			return nil
+		}
+
+		for _, tag := range tags {
+			if tag.Date.IsZero() {
+				fmt.Printf("%s  %s\n", shortHash(tag.Commit), tag.Name)
+			} else {
+				fmt.Printf("%s  %s  (%s)\n", shortHash(tag.Commit), tag.Name, tag.Date.Local().Format("2006-01-02 15:04:05"))
+			}
+		}
+		return nil
+	}
+
+	rev, name := args[0], args[1]
+	message := tagMessage
+	if message == "" {
+		message = fmt.Sprintf("Tag %s", name)
+	}
+
+	tag, err := b.CreateTag(ctx, name, rev, message)
+	if err != nil {
+		return err
+	}
+
+	PrintSuccess(fmt.Sprintf("Tagged %s as %s", shortHash(tag.Commit), tag.Name))
+	return nil
+}
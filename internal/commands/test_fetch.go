@@ -4,7 +4,6 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
-	"github.com/wlame/njgit/internal/config"
 	"github.com/wlame/njgit/internal/hcl"
 	"github.com/wlame/njgit/internal/nomad"
 )
@@ -56,7 +55,7 @@ func init() {
 func testFetchRun(cmd *cobra.Command, args []string) error {
 	// Load configuration
 	PrintInfo("Loading configuration...")
-	cfg, err := config.Load(GetConfigFile())
+	cfg, err := loadConfig()
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
@@ -89,14 +88,14 @@ func testFetchRun(cmd *cobra.Command, args []string) error {
 
 	// Test connectivity
 	PrintInfo("Testing Nomad connectivity...")
-	if err := client.Ping(); err != nil {
+	if err := client.Ping(cmd.Context()); err != nil {
 		return fmt.Errorf("failed to connect to Nomad: %w", err)
 	}
 	PrintSuccess("Successfully connected to Nomad")
 
 	// Fetch the job
 	PrintInfo(fmt.Sprintf("Fetching job %s/%s...", testFetchNamespace, testFetchJob))
-	job, err := client.FetchJobSpec(testFetchNamespace, testFetchJob)
+	job, err := client.FetchJobSpec(cmd.Context(), testFetchNamespace, testFetchJob)
 	if err != nil {
 		// Check if it's a "not found" error
 		if _, ok := err.(nomad.JobNotFoundError); ok {
@@ -136,6 +135,17 @@ func testFetchRun(cmd *cobra.Command, args []string) error {
 	normalized := nomad.NormalizeJob(job, cfg.Changes.IgnoreFields)
 	PrintSuccess("Job normalized")
 
+	// Redact secrets, as sync would
+	redactor, err := newRedactor(cfg)
+	if err != nil {
+		return fmt.Errorf("invalid redaction config: %w", err)
+	}
+	if redactor != nil {
+		if redactions := nomad.RedactJob(normalized, redactor); len(redactions) > 0 {
+			PrintInfo(fmt.Sprintf("Redacted %d secrets", len(redactions)))
+		}
+	}
+
 	// Convert to HCL
 	PrintInfo("Converting to HCL...")
 	hclBytes, err := hcl.FormatJobAsHCL(normalized)
@@ -0,0 +1,160 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/spf13/cobra"
+	"github.com/wlame/njgit/internal/config"
+	"github.com/wlame/njgit/internal/hcl"
+	"github.com/wlame/njgit/internal/nomad"
+	"github.com/wlame/njgit/internal/redact"
+)
+
+var (
+	// Flags for verify command
+	verifyJobs string // Comma-separated list of jobs to verify
+)
+
+// verifyCmd represents the verify command
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Check that rendered job files reproduce the running jobs",
+	Long: `Render each job to HCL, parse it back, and compare it with the job in Nomad.
+
+A job file is only useful for a rollback if it describes the same job that
+is running. This command:
+  1. Fetches each configured job from Nomad
+  2. Renders it to HCL the same way sync does
+  3. Parses the HCL back into a job (using Nomad's parse endpoint)
+  4. Reports every field that did not survive the round-trip
+
+Exits with an error if any job is lossy.
+
+Examples:
+  # Verify all configured jobs
+  njgit verify
+
+  # Verify specific jobs only
+  njgit verify --jobs web-server,api-server`,
+	RunE: verifyRun,
+}
+
+func init() {
+	verifyCmd.Flags().StringVar(&verifyJobs, "jobs", "",
+		"Comma-separated list of jobs to verify (default: all configured jobs)")
+
+	rootCmd.AddCommand(verifyCmd)
+}
+
+// verifyRun executes the verify command
+func verifyRun(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	nomadAuth, err := nomad.ResolveAuth(&cfg.Nomad, "", "")
+	if err != nil {
+		return fmt.Errorf("failed to resolve Nomad auth: %w", err)
+	}
+
+	nomadClient, err := nomad.NewClient(nomadAuth)
+	if err != nil {
+		return fmt.Errorf("failed to create Nomad client: %w", err)
+	}
+	defer func() { _ = nomadClient.Close() }()
+
+	redactor, err := newRedactor(cfg)
+	if err != nil {
+		return fmt.Errorf("invalid redaction config: %w", err)
+	}
+
+	jobsToVerify := filterJobs(cfg, verifyJobs)
+	PrintInfo(fmt.Sprintf("Verifying %d jobs...", len(jobsToVerify)))
+
+	var lossy, failed int
+	for _, jobCfg := range jobsToVerify {
+		jobPath := fmt.Sprintf("%s/%s/%s", jobCfg.Region, jobCfg.Namespace, jobCfg.Name)
+
+		job, err := nomadClient.FetchJobSpec(ctx, jobCfg.Namespace, jobCfg.Name)
+		if err != nil {
+			if _, ok := err.(nomad.JobNotFoundError); ok {
+				PrintWarning(fmt.Sprintf("%s: Job not found in Nomad (skipping)", jobPath))
+				continue
+			}
+			PrintError(fmt.Errorf("%s: %w", jobPath, err))
+			failed++
+			continue
+		}
+
+		normalized := nomad.NormalizeJob(job, cfg.Changes.IgnoreFields)
+		if redactor != nil {
+			nomad.RedactJob(normalized, redactor)
+		}
+
+		hclBytes, err := hcl.FormatJobAsHCL(normalized)
+		if err != nil {
+			PrintError(fmt.Errorf("%s: failed to convert to HCL: %w", jobPath, err))
+			failed++
+			continue
+		}
+
+		diffs, err := roundTrip(ctx, cfg, redactor, job, hclBytes)
+		if err != nil {
+			PrintError(fmt.Errorf("%s: %w", jobPath, err))
+			failed++
+			continue
+		}
+
+		if len(diffs) == 0 {
+			PrintSuccess(fmt.Sprintf("%s: OK", jobPath))
+			continue
+		}
+
+		lossy++
+		printRoundTripDiffs(jobPath, diffs)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("verification failed for %d jobs", failed)
+	}
+	if lossy > 0 {
+		return fmt.Errorf("%d jobs did not survive the round-trip", lossy)
+	}
+
+	PrintSuccess("All jobs survived the round-trip")
+	return nil
+}
+
+// roundTrip parses rendered HCL back into a job through Nomad and compares
+// it with the job it was rendered from
+// With a redactor, the job is compared with its secrets redacted, the same
+// way they are in the rendered file
+func roundTrip(ctx context.Context, cfg *config.Config, redactor *redact.Redactor, job *api.Job, content []byte) ([]nomad.FieldDiff, error) {
+	if redactor != nil {
+		redacted, err := nomad.RedactedCopy(job, redactor)
+		if err != nil {
+			return nil, err
+		}
+		job = redacted
+	}
+
+	parsed, err := hcl.ParseHCL(ctx, content, nomadParseOptions(cfg))
+	if err != nil {
+		return nil, fmt.Errorf("rendered HCL does not parse: %w", err)
+	}
+
+	return nomad.RoundTripDiff(job, parsed)
+}
+
+// printRoundTripDiffs reports the fields that were lost or changed
+func printRoundTripDiffs(jobPath string, diffs []nomad.FieldDiff) {
+	PrintWarning(fmt.Sprintf("%s: %d fields did not survive the round-trip:", jobPath, len(diffs)))
+	for _, diff := range diffs {
+		fmt.Printf("    %s\n", diff)
+	}
+}
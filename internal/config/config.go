@@ -4,9 +4,12 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -25,19 +28,24 @@ type Config struct {
 
 	// Changes contains change detection configuration
 	Changes ChangesConfig `mapstructure:"changes"`
+
+	// Redaction contains secret redaction configuration
+	Redaction RedactionConfig `mapstructure:"redaction"`
 }
 
 // GitConfig holds Git repository configuration
 type GitConfig struct {
-	// Backend specifies which backend to use: "git" or "github-api"
+	// Backend specifies which backend to use
 	// "git" - Uses local Git repository (local-only, no remote operations)
 	// "github-api" - Uses GitHub REST API directly (GitHub only, no local repo)
+	// "gitlab-api" - Uses the GitLab REST API (gitlab.com or self-managed)
+	// "gitea-api" - Uses the Gitea REST API (Gitea or Forgejo)
 	// Default: "git"
 	Backend string `mapstructure:"backend"`
 
 	// === Git Backend Configuration ===
-	// Git backend is local-only: no clone/push/pull operations
-	// User must initialize the repository and manage remotes manually
+	// Git backend works on an existing local repository
+	// User must initialize the repository and add the remote (if any)
 
 	// LocalPath is the path to the local Git repository
 	// The repository must already exist (user runs 'git init')
@@ -45,36 +53,163 @@ type GitConfig struct {
 	// Used by: git backend
 	LocalPath string `mapstructure:"local_path"`
 
+	// Remote is the name of the Git remote to sync with (e.g. "origin")
+	// When set, sync pulls (rebasing njgit's own commits) before it compares
+	// jobs with the stored files; read-only commands don't fetch
+	// Default: "" (local-only, no remote operations)
+	// Used by: git backend
+	Remote string `mapstructure:"remote"`
+
+	// Push pushes each commit to Remote after it is created
+	// Default: false
+	// Used by: git backend
+	Push bool `mapstructure:"push"`
+
+	// Auth is how to authenticate to Remote: "ssh", "token" or "" (none,
+	// e.g. for a remote on the local filesystem)
+	// "token" uses Token over HTTPS
+	// Used by: git backend
+	Auth string `mapstructure:"auth"`
+
+	// SSHKeyPath is the private key for "ssh" auth
+	// Default: "" (SSH agent, then ~/.ssh/id_ed25519, id_rsa, id_ecdsa)
+	// Used by: git backend
+	SSHKeyPath string `mapstructure:"ssh_key_path"`
+
+	// Sign signs the commits njgit creates: "gpg", "ssh" or "" (unsigned)
+	// The github-api backend takes "github" instead: GitHub signs the
+	// commits it creates, so they show as verified
+	// Used by: git backend ("gpg", "ssh"), github-api backend ("github")
+	Sign string `mapstructure:"sign"`
+
+	// SigningKey is the key to sign with
+	//   gpg: an armored secret key file (gpg --export-secret-keys --armor)
+	//   ssh: a private key file, or a public key file (.pub) whose private
+	//        key is in the SSH agent; "" uses the first key in the agent
+	// A passphrase-protected key is unlocked with NJGIT_SIGNING_PASSPHRASE
+	// Used by: git backend
+	SigningKey string `mapstructure:"signing_key"`
+
+	// AllowedSigners is an SSH allowed signers file (the format of git's
+	// gpg.ssh.allowedSignersFile), used to verify SSH-signed commits
+	// Used by: git backend
+	AllowedSigners string `mapstructure:"allowed_signers"`
+
+	// GPGKeyring is an armored public keyring (gpg --export --armor), used
+	// to verify GPG-signed commits
+	// Used by: git backend
+	GPGKeyring string `mapstructure:"gpg_keyring"`
+
 	// === GitHub API Backend Configuration ===
 
 	// Branch is the Git branch to use
 	// Default: "main"
-	// Used by: github-api backend
+	// Used by: github-api, gitlab-api and gitea-api backends
 	Branch string `mapstructure:"branch"`
 
 	// Owner is the GitHub repository owner (user or organization)
 	// Example: "myorg" for github.com/myorg/nomad-jobs
-	// Used by: github-api backend
+	// Used by: github-api, gitea-api backends
 	Owner string `mapstructure:"owner"`
 
 	// Repo is the GitHub repository name
 	// Example: "nomad-jobs" for github.com/myorg/nomad-jobs
-	// Used by: github-api backend
+	// Used by: github-api, gitea-api backends
 	Repo string `mapstructure:"repo"`
 
-	// AuthorName is the name to use in Git commits
+	// === API Server Configuration ===
+
+	// APIURL is the server to talk to, e.g. "https://github.example.com"
+	// (GitHub Enterprise Server), "https://gitlab.example.com" or
+	// "https://gitea.example.com"; the API path (/api/v3, /api/v4, /api/v1)
+	// is added unless the URL already has it
+	// Default: "https://api.github.com" for github-api, "https://gitlab.com"
+	// for gitlab-api; required for gitea-api
+	// Used by: API backends
+	APIURL string `mapstructure:"api_url"`
+
+	// Project is the GitLab project: its numeric ID or its full path
+	// Example: "myorg/infra/nomad-jobs"
+	// Default: "<owner>/<repo>" when owner and repo are set
+	// Used by: gitlab-api backend
+	Project string `mapstructure:"project"`
+
+	// === GitHub App Authentication ===
+
+	// AppID authenticates the github-api backend as a GitHub App instead of
+	// with Token: njgit signs a JWT with AppPrivateKey and exchanges it for
+	// an installation token, which is refreshed before it expires
 	// Used by: github-api backend
-	AuthorName string `mapstructure:"author_name"`
+	AppID int64 `mapstructure:"app_id"`
 
-	// AuthorEmail is the email to use in Git commits
+	// AppInstallationID is the installation of the app on the repository
+	// Default: looked up from owner/repo
 	// Used by: github-api backend
-	AuthorEmail string `mapstructure:"author_email"`
+	AppInstallationID int64 `mapstructure:"app_installation_id"`
 
-	// Token is the GitHub personal access token for API authentication
-	// Can also be set via GITHUB_TOKEN or GH_TOKEN environment variables
+	// AppPrivateKey is the path to the app's private key (PEM), as
+	// downloaded from the app's settings
 	// Used by: github-api backend
+	AppPrivateKey string `mapstructure:"app_private_key"`
+
+	// CacheDir is where the github-api backend keeps API responses with
+	// their ETags, so repeated requests are made conditionally and
+	// unchanged responses don't count against the rate limit
+	// Default: "<user cache dir>/njgit" (e.g. ~/.cache/njgit); "none" keeps
+	// the cache in memory only
+	// Used by: github-api backend
+	CacheDir string `mapstructure:"cache_dir"`
+
+	// AuthorName is the name njgit commits as
+	// njgit is always the committer, and the author unless Attribution
+	// finds who changed the job
+	// Default: "njgit"
+	AuthorName string `mapstructure:"author_name"`
+
+	// AuthorEmail is the email njgit commits as
+	// Default: "njgit@localhost"
+	AuthorEmail string `mapstructure:"author_email"`
+
+	// Attribution lists where to look for the person who changed a job, in order:
+	//   - "meta": the job meta key AttributionMetaKey (e.g. meta.deployed_by)
+	//   - "token": the name of the ACL token that registered the job
+	// The first person found becomes the commit author; any others are
+	// credited with Co-authored-by trailers. Empty: njgit is the author.
+	Attribution []string `mapstructure:"attribution"`
+
+	// AttributionMetaKey is the job meta key naming who deployed the job,
+	// as "Name <email>", an email, or a bare name. Several people can be
+	// listed, separated by commas
+	// Default: "deployed_by"
+	AttributionMetaKey string `mapstructure:"attribution_meta_key"`
+
+	// AttributionEmailDomain turns bare names into emails (name@domain)
+	// Without it, people named without an email are not attributed
+	AttributionEmailDomain string `mapstructure:"attribution_email_domain"`
+
+	// EnvBranches maps Nomad cluster names (nomad.cluster) to the branch
+	// sync commits to, e.g. {staging = "env/staging", prod = "env/prod"}
+	// A cluster that isn't listed commits to the current branch (git) or
+	// Branch (github-api). Missing branches are created.
+	EnvBranches map[string]string `mapstructure:"env_branches"`
+
+	// TagDeploys creates an annotated tag after each successful deploy:
+	// deploy/<region>/<namespace>/<job>/<timestamp>
+	// Default: true
+	TagDeploys bool `mapstructure:"tag_deploys"`
+
+	// Token is the access token for API authentication (github-api can use
+	// a GitHub App instead, see AppID)
+	// Can also be set via environment variables: GITHUB_TOKEN or GH_TOKEN
+	// (github-api, git), GITLAB_TOKEN (gitlab-api), GITEA_TOKEN (gitea-api)
+	// Used by: API backends, git backend with auth = "token"
 	// IMPORTANT: For security, prefer environment variables over config file
 	Token string `mapstructure:"token"`
+
+	// Timeout limits each backend request, e.g. "30s" or "2m"
+	// Used by: API backends (each API call), git backend (fetch and push)
+	// Default: "30s"
+	Timeout time.Duration `mapstructure:"timeout"`
 }
 
 // NomadConfig holds Nomad cluster configuration
@@ -84,16 +219,75 @@ type NomadConfig struct {
 	// Can also be set via NOMAD_ADDR environment variable
 	Address string `mapstructure:"address"`
 
+	// Cluster names the Nomad cluster in commit trailers (Nomad-Cluster)
+	// Default: the host of Address, e.g. "nomad.example.com"
+	Cluster string `mapstructure:"cluster"`
+
 	// Token is the Nomad ACL token for authentication
 	// Can also be set via NOMAD_TOKEN environment variable
 	// IMPORTANT: For security, prefer environment variables over config file
 	Token string `mapstructure:"token"`
 
 	// CACert is the path to the CA certificate for TLS verification (optional)
+	// Can also be set via NOMAD_CACERT environment variable
 	CACert string `mapstructure:"ca_cert"`
 
+	// CAPath is the path to a directory of CA certificates (optional)
+	// Can also be set via NOMAD_CAPATH environment variable
+	CAPath string `mapstructure:"ca_path"`
+
+	// ClientCert is the path to the client certificate for mutual TLS (optional)
+	// Required when the Nomad cluster has verify_https_client enabled
+	// Can also be set via NOMAD_CLIENT_CERT environment variable
+	ClientCert string `mapstructure:"client_cert"`
+
+	// ClientKey is the path to the private key for ClientCert (optional)
+	// Can also be set via NOMAD_CLIENT_KEY environment variable
+	ClientKey string `mapstructure:"client_key"`
+
+	// TLSServerName is the server name to verify the Nomad certificate against (optional)
+	// Useful when connecting through an IP or load balancer, e.g. "server.global.nomad"
+	// Can also be set via NOMAD_TLS_SERVER_NAME environment variable
+	TLSServerName string `mapstructure:"tls_server_name"`
+
 	// TLSSkipVerify skips TLS certificate verification (not recommended for production)
 	TLSSkipVerify bool `mapstructure:"tls_skip_verify"`
+
+	// Auth selects how njgit obtains its Nomad ACL token
+	Auth NomadAuthConfig `mapstructure:"auth"`
+
+	// Timeout limits each Nomad API request, e.g. "30s" or "2m"
+	// Default: "30s"
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// NomadAuthConfig holds Nomad login configuration
+// With a method other than "token", njgit logs in to a Nomad auth method and
+// caches the token it gets back until it expires
+type NomadAuthConfig struct {
+	// Method is how the ACL token is obtained
+	//   - "token": a static token from config, NOMAD_TOKEN or ~/.nomad-token (default)
+	//   - "jwt": exchange a JWT (e.g. a workload identity or CI token) via /v1/acl/login
+	//   - "oidc": use the token from an interactive 'njgit login'
+	Method string `mapstructure:"method"`
+
+	// AuthMethod is the name of the Nomad auth method to log in with
+	// Required for "jwt" and "oidc"
+	AuthMethod string `mapstructure:"auth_method"`
+
+	// JWTFile is the path to the JWT to exchange
+	// It is re-read on every login, so rotated tokens are picked up
+	// Required for "jwt"
+	JWTFile string `mapstructure:"jwt_file"`
+
+	// TokenCache is the file where tokens from logins are cached
+	// Default is nomad-tokens.json in the user cache directory
+	TokenCache string `mapstructure:"token_cache"`
+
+	// CallbackAddress is where 'njgit login' listens for the OIDC redirect
+	// The redirect URI http://<address>/oidc/callback must be allowed by the auth method
+	// Default is "localhost:4649", the same as the Nomad CLI
+	CallbackAddress string `mapstructure:"callback_address"`
 }
 
 // JobConfig represents a single Nomad job to track
@@ -119,6 +313,65 @@ type ChangesConfig struct {
 	// CommitMetadataOnly determines if we should commit when only metadata changes
 	// Default is false - we only commit meaningful changes
 	CommitMetadataOnly bool `mapstructure:"commit_metadata_only"`
+
+	// PreserveLayout updates existing job files in place instead of rewriting them
+	// Comments, attribute order and formatting of unchanged parts are kept
+	// Default is false - files are rewritten in the canonical layout
+	PreserveLayout bool `mapstructure:"preserve_layout"`
+
+	// Source selects what is stored for each job
+	//   - "generated": HCL generated from the job spec (default)
+	//   - "submission": the jobspec the job was submitted with, if Nomad has it
+	//   - "both": the generated HCL plus the submitted jobspec next to it
+	// Jobs without a submission always fall back to the generated HCL
+	Source string `mapstructure:"source"`
+
+	// SourceVariables controls how a submission's variables are stored
+	//   - "redact": variable names are kept, values are replaced (default)
+	//   - "keep": variables are stored as submitted
+	//   - "omit": no variables file is written
+	SourceVariables string `mapstructure:"source_variables"`
+
+	// Verify parses each generated file back and compares it with the job
+	//   - "off": no verification (default)
+	//   - "warn": report fields that didn't survive the round-trip
+	//   - "strict": also refuse to commit such files
+	Verify string `mapstructure:"verify"`
+}
+
+// RedactionConfig holds secret redaction configuration
+// Secrets found in env, meta, driver config and template data are replaced
+// with <redacted:hash> placeholders before job files are written
+type RedactionConfig struct {
+	// Enabled turns redaction on
+	// Default is true
+	Enabled bool `mapstructure:"enabled"`
+
+	// Entropy enables the high-entropy token detector for env and meta values
+	// Default is true
+	Entropy bool `mapstructure:"entropy"`
+
+	// AllowKeys are env/meta/config keys that are never redacted
+	AllowKeys []string `mapstructure:"allow_keys"`
+
+	// Rules are additional detectors
+	Rules []RedactionRule `mapstructure:"rules"`
+
+	// SecretsFile is a KEY=value file deploy uses to restore redacted values
+	// that can't be taken from the job currently running in Nomad
+	SecretsFile string `mapstructure:"secrets_file"`
+}
+
+// RedactionRule is a user-defined secret detector
+type RedactionRule struct {
+	// Name identifies the rule in reports
+	Name string `mapstructure:"name"`
+
+	// Key is a regular expression for key names whose values are secret
+	Key string `mapstructure:"key"`
+
+	// Value is a regular expression for secret values
+	Value string `mapstructure:"value"`
 }
 
 // Load reads the configuration from a file and environment variables
@@ -202,9 +455,15 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("git.branch", "main")
 	v.SetDefault("git.author_name", "njgit")
 	v.SetDefault("git.author_email", "njgit@localhost")
+	v.SetDefault("git.attribution_meta_key", "deployed_by")
+	v.SetDefault("git.tag_deploys", true)
+	v.SetDefault("git.timeout", "30s")
 
 	// Nomad defaults
 	// No defaults for address or token - these must be provided
+	v.SetDefault("nomad.timeout", "30s")
+	v.SetDefault("nomad.auth.method", "token")
+	v.SetDefault("nomad.auth.callback_address", "localhost:4649")
 
 	// Changes defaults
 	// These are Nomad internal fields that should be ignored during change detection
@@ -218,6 +477,14 @@ func setDefaults(v *viper.Viper) {
 		"StatusDescription",
 	})
 	v.SetDefault("changes.commit_metadata_only", false)
+	v.SetDefault("changes.preserve_layout", false)
+	v.SetDefault("changes.source", "generated")
+	v.SetDefault("changes.source_variables", "redact")
+	v.SetDefault("changes.verify", "off")
+
+	// Redaction defaults
+	v.SetDefault("redaction.enabled", true)
+	v.SetDefault("redaction.entropy", true)
 }
 
 // applyEnvOverrides applies environment variable overrides for specific fields
@@ -243,12 +510,37 @@ func applyEnvOverrides(cfg *Config) {
 		}
 	}
 
-	// Override GitHub token from GITHUB_TOKEN or GH_TOKEN if set and config is empty
+	// Override Nomad TLS settings from the variables the Nomad CLI uses
+	envStrings := []struct {
+		field *string
+		name  string
+	}{
+		{&cfg.Nomad.CACert, "NOMAD_CACERT"},
+		{&cfg.Nomad.CAPath, "NOMAD_CAPATH"},
+		{&cfg.Nomad.ClientCert, "NOMAD_CLIENT_CERT"},
+		{&cfg.Nomad.ClientKey, "NOMAD_CLIENT_KEY"},
+		{&cfg.Nomad.TLSServerName, "NOMAD_TLS_SERVER_NAME"},
+	}
+	for _, env := range envStrings {
+		if *env.field == "" {
+			*env.field = os.Getenv(env.name)
+		}
+	}
+	if !cfg.Nomad.TLSSkipVerify {
+		for _, name := range []string{"NOMAD_SKIP_VERIFY", "NOMAD_TLS_SKIP_VERIFY"} {
+			if skip, err := strconv.ParseBool(os.Getenv(name)); err == nil && skip {
+				cfg.Nomad.TLSSkipVerify = true
+			}
+		}
+	}
+
+	// Override the API token from the backend's variables if config is empty
 	if cfg.Git.Token == "" {
-		if token := os.Getenv("GITHUB_TOKEN"); token != "" {
-			cfg.Git.Token = token
-		} else if token := os.Getenv("GH_TOKEN"); token != "" {
-			cfg.Git.Token = token
+		for _, name := range TokenEnvVars(cfg.Git.Backend) {
+			if token := os.Getenv(name); token != "" {
+				cfg.Git.Token = token
+				break
+			}
 		}
 	}
 
@@ -263,6 +555,43 @@ func applyEnvOverrides(cfg *Config) {
 	}
 }
 
+// ClusterName returns the name of the Nomad cluster for commit trailers
+// nomad.cluster if set, otherwise the host of nomad.address
+func (n *NomadConfig) ClusterName() string {
+	if n.Cluster != "" {
+		return n.Cluster
+	}
+	if u, err := url.Parse(n.Address); err == nil && u.Hostname() != "" {
+		return u.Hostname()
+	}
+	return n.Address
+}
+
+// TokenEnvVars returns the environment variables a backend's token is read
+// from, in order
+func TokenEnvVars(backend string) []string {
+	switch backend {
+	case "gitlab-api":
+		return []string{"GITLAB_TOKEN"}
+	case "gitea-api":
+		return []string{"GITEA_TOKEN"}
+	default:
+		return []string{"GITHUB_TOKEN", "GH_TOKEN"}
+	}
+}
+
+// EnvBranch returns the branch sync commits to for a cluster, or "" to
+// use the default branch
+// Cluster names match case-insensitively (config keys are lowercased).
+func (g *GitConfig) EnvBranch(cluster string) string {
+	for name, branch := range g.EnvBranches {
+		if strings.EqualFold(name, cluster) {
+			return branch
+		}
+	}
+	return ""
+}
+
 // readTokenFile attempts to read a Nomad token from ~/.nomad-token
 // This is a common location for storing the Nomad token
 // Returns empty string if file doesn't exist or can't be read
@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"net/url"
+	"regexp"
 	"strings"
 )
 
@@ -20,6 +21,16 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("nomad config: %w", err)
 	}
 
+	// Validate change detection configuration
+	if err := c.Changes.Validate(); err != nil {
+		return fmt.Errorf("changes config: %w", err)
+	}
+
+	// Validate redaction configuration
+	if err := c.Redaction.Validate(); err != nil {
+		return fmt.Errorf("redaction config: %w", err)
+	}
+
 	// Validate Jobs configuration
 	if len(c.Jobs) == 0 {
 		return fmt.Errorf("no jobs configured - at least one job must be specified")
@@ -44,12 +55,33 @@ func (g *GitConfig) Validate() error {
 	}
 
 	// Validate backend type
-	validBackends := []string{"git", "github-api"}
+	validBackends := []string{"git", "github-api", "gitlab-api", "gitea-api"}
 	if !contains(validBackends, backend) {
 		return fmt.Errorf("invalid backend: %s (must be one of: %s)",
 			backend, strings.Join(validBackends, ", "))
 	}
 
+	if g.Timeout < 0 {
+		return fmt.Errorf("timeout must not be negative")
+	}
+
+	validAttribution := []string{"meta", "token"}
+	for _, source := range g.Attribution {
+		if !contains(validAttribution, source) {
+			return fmt.Errorf("invalid attribution source: %s (must be one of: %s)",
+				source, strings.Join(validAttribution, ", "))
+		}
+	}
+	if contains(g.Attribution, "meta") && g.AttributionMetaKey == "" {
+		return fmt.Errorf("attribution_meta_key is required for attribution = [\"meta\"]")
+	}
+
+	for cluster, branch := range g.EnvBranches {
+		if strings.TrimSpace(branch) == "" || strings.ContainsAny(branch, " ~^:?*[\\") || strings.Contains(branch, "..") {
+			return fmt.Errorf("invalid branch for cluster %s in env_branches: %q", cluster, branch)
+		}
+	}
+
 	// Validate based on backend type
 	switch backend {
 	case "git":
@@ -58,6 +90,26 @@ func (g *GitConfig) Validate() error {
 		if g.LocalPath == "" {
 			return fmt.Errorf("local_path is required for git backend")
 		}
+
+		// Remote operations are opt-in
+		validAuth := []string{"", "ssh", "token"}
+		if !contains(validAuth, g.Auth) {
+			return fmt.Errorf("invalid auth: %s (must be \"ssh\", \"token\" or empty)", g.Auth)
+		}
+		if g.Push && g.Remote == "" {
+			return fmt.Errorf("remote is required when push is enabled (e.g. remote = \"origin\")")
+		}
+		if g.Auth == "token" && g.Token == "" {
+			return fmt.Errorf("token is required for auth = \"token\" (set via GITHUB_TOKEN or GH_TOKEN env var)")
+		}
+
+		validSign := []string{"", "gpg", "ssh"}
+		if !contains(validSign, g.Sign) {
+			return fmt.Errorf("invalid sign: %s (must be \"gpg\", \"ssh\" or empty)", g.Sign)
+		}
+		if g.Sign == "gpg" && g.SigningKey == "" {
+			return fmt.Errorf("signing_key is required for sign = \"gpg\" (an armored secret key file)")
+		}
 	case "github-api":
 		// GitHub API backend requires owner, repo, and token
 		if g.Owner == "" {
@@ -66,8 +118,19 @@ func (g *GitConfig) Validate() error {
 		if g.Repo == "" {
 			return fmt.Errorf("repo is required for github-api backend")
 		}
-		if g.Token == "" {
-			return fmt.Errorf("token is required for github-api backend (set via GITHUB_TOKEN or GH_TOKEN env var)")
+		if err := validateAPIURL(g.APIURL, false); err != nil {
+			return err
+		}
+
+		// Authenticate with a token or as a GitHub App
+		if g.AppID == 0 && (g.AppInstallationID != 0 || g.AppPrivateKey != "") {
+			return fmt.Errorf("app_id is required with app_installation_id and app_private_key")
+		}
+		if g.AppID != 0 && g.AppPrivateKey == "" {
+			return fmt.Errorf("app_private_key is required with app_id (the path to the app's private key)")
+		}
+		if g.AppID == 0 && g.Token == "" {
+			return fmt.Errorf("token is required for github-api backend (set via GITHUB_TOKEN or GH_TOKEN env var, or set app_id and app_private_key)")
 		}
 
 		// Branch is required for GitHub API backend
@@ -82,11 +145,71 @@ func (g *GitConfig) Validate() error {
 		if g.AuthorEmail == "" {
 			return fmt.Errorf("author_email is required for github-api backend")
 		}
+
+		// Commits are created by GitHub, so only GitHub can sign them
+		if g.Sign != "" && g.Sign != "github" {
+			return fmt.Errorf("invalid sign for github-api backend: %s (must be \"github\" or empty)", g.Sign)
+		}
+	case "gitlab-api":
+		// The project can be given directly or as owner/repo
+		if g.Project == "" && (g.Owner == "" || g.Repo == "") {
+			return fmt.Errorf("project is required for gitlab-api backend (a project ID or path like \"group/nomad-jobs\")")
+		}
+		if err := validateAPIURL(g.APIURL, false); err != nil {
+			return err
+		}
+		if g.Token == "" {
+			return fmt.Errorf("token is required for gitlab-api backend (set via GITLAB_TOKEN env var)")
+		}
+		if g.Branch == "" {
+			return fmt.Errorf("branch is required for gitlab-api backend")
+		}
+		if g.Sign != "" {
+			return fmt.Errorf("sign is not supported by the gitlab-api backend")
+		}
+	case "gitea-api":
+		if err := validateAPIURL(g.APIURL, true); err != nil {
+			return err
+		}
+		if g.Owner == "" {
+			return fmt.Errorf("owner is required for gitea-api backend")
+		}
+		if g.Repo == "" {
+			return fmt.Errorf("repo is required for gitea-api backend")
+		}
+		if g.Token == "" {
+			return fmt.Errorf("token is required for gitea-api backend (set via GITEA_TOKEN env var)")
+		}
+		if g.Branch == "" {
+			return fmt.Errorf("branch is required for gitea-api backend")
+		}
+		if g.Sign != "" {
+			return fmt.Errorf("sign is not supported by the gitea-api backend")
+		}
 	}
 
 	return nil
 }
 
+// validateAPIURL checks api_url: an http(s) URL with a host
+func validateAPIURL(apiURL string, required bool) error {
+	if apiURL == "" {
+		if required {
+			return fmt.Errorf("api_url is required (e.g. \"https://gitea.example.com\")")
+		}
+		return nil
+	}
+
+	u, err := url.Parse(apiURL)
+	if err != nil {
+		return fmt.Errorf("invalid api_url: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid api_url: %s (must be an http:// or https:// URL)", apiURL)
+	}
+	return nil
+}
+
 // Validate checks if the Nomad configuration is valid
 func (n *NomadConfig) Validate() error {
 	// Address is required
@@ -104,6 +227,80 @@ func (n *NomadConfig) Validate() error {
 	// But if TLS is enabled, we should warn if token is missing
 	// For now, we'll just validate if provided
 
+	// Mutual TLS needs both halves of the key pair
+	if n.ClientCert != "" && n.ClientKey == "" {
+		return fmt.Errorf("client_key is required when client_cert is set")
+	}
+	if n.ClientKey != "" && n.ClientCert == "" {
+		return fmt.Errorf("client_cert is required when client_key is set")
+	}
+
+	if n.Timeout < 0 {
+		return fmt.Errorf("timeout must not be negative")
+	}
+
+	if err := n.Auth.Validate(); err != nil {
+		return fmt.Errorf("auth: %w", err)
+	}
+
+	return nil
+}
+
+// Validate checks if the Nomad login configuration is valid
+func (a *NomadAuthConfig) Validate() error {
+	switch a.Method {
+	case "", "token":
+		return nil
+	case "jwt":
+		if a.JWTFile == "" {
+			return fmt.Errorf("jwt_file is required when method is \"jwt\"")
+		}
+	case "oidc":
+	default:
+		return fmt.Errorf("invalid method: %s (must be one of: token, jwt, oidc)", a.Method)
+	}
+
+	if a.AuthMethod == "" {
+		return fmt.Errorf("auth_method is required when method is %q", a.Method)
+	}
+
+	return nil
+}
+
+// Validate checks if the change detection configuration is valid
+func (c *ChangesConfig) Validate() error {
+	// Empty values mean the defaults
+	validSources := []string{"", "generated", "submission", "both"}
+	if !contains(validSources, c.Source) {
+		return fmt.Errorf("invalid source: %s (must be one of: generated, submission, both)", c.Source)
+	}
+
+	validVariables := []string{"", "redact", "keep", "omit"}
+	if !contains(validVariables, c.SourceVariables) {
+		return fmt.Errorf("invalid source_variables: %s (must be one of: redact, keep, omit)", c.SourceVariables)
+	}
+
+	validVerify := []string{"", "off", "warn", "strict"}
+	if !contains(validVerify, c.Verify) {
+		return fmt.Errorf("invalid verify: %s (must be one of: off, warn, strict)", c.Verify)
+	}
+
+	return nil
+}
+
+// Validate checks if the redaction configuration is valid
+func (r *RedactionConfig) Validate() error {
+	for i, rule := range r.Rules {
+		if rule.Key == "" && rule.Value == "" {
+			return fmt.Errorf("rules[%d] (%s): key or value pattern is required", i, rule.Name)
+		}
+		for _, pattern := range []string{rule.Key, rule.Value} {
+			if _, err := regexp.Compile(pattern); err != nil {
+				return fmt.Errorf("rules[%d] (%s): invalid pattern: %w", i, rule.Name, err)
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -13,32 +13,37 @@ import (
 	"github.com/wlame/njgit/internal/config"
 )
 
-// ResolveAuth is a stub for backward compatibility
-// Git backend is now local-only and doesn't require authentication
-// This function is kept for tests only
+// ResolveAuth creates the authentication for the configured remote
+// git.auth selects the method:
+//   - "ssh": SSH key or agent (see ResolveSSHAuth)
+//   - "token": HTTPS with a token (see ResolveTokenAuth)
+//   - "": no authentication, e.g. for a remote on the local filesystem
 //
 // Parameters:
 //   - cfg: Git configuration from config file
 //
 // Returns:
-//   - transport.AuthMethod: Always returns nil (no auth needed)
-//   - error: Always returns nil
+//   - transport.AuthMethod: The authentication, or nil for none
+//   - error: If the method is unknown or its credentials can't be found
 func ResolveAuth(cfg *config.GitConfig) (transport.AuthMethod, error) {
-	// Git backend is local-only, no authentication needed
-	return nil, nil
-}
-
-// ResolveAuthWithMethod is a stub for backward compatibility (used by tests)
-func ResolveAuthWithMethod(cfg *config.GitConfig, method string) (transport.AuthMethod, error) {
-	// For tests that need actual authentication, implement as needed
-	if method == "ssh" {
+	switch cfg.Auth {
+	case "":
+		return nil, nil
+	case "ssh":
 		return ResolveSSHAuth(cfg)
-	}
-	if method == "token" {
+	case "token":
 		return ResolveTokenAuth(cfg)
+	default:
+		return nil, fmt.Errorf("unsupported git auth method: %s (supported: ssh, token)", cfg.Auth)
 	}
+}
 
-	return nil, nil
+// ResolveAuthWithMethod resolves authentication for an explicit method,
+// ignoring git.auth
+func ResolveAuthWithMethod(cfg *config.GitConfig, method string) (transport.AuthMethod, error) {
+	withMethod := *cfg
+	withMethod.Auth = method
+	return ResolveAuth(&withMethod)
 }
 
 // ResolveSSHAuth creates SSH-based authentication
@@ -56,7 +61,12 @@ func ResolveAuthWithMethod(cfg *config.GitConfig, method string) (transport.Auth
 //   - transport.AuthMethod: SSH authentication
 //   - error: Error if SSH auth cannot be set up
 func ResolveSSHAuth(cfg *config.GitConfig) (transport.AuthMethod, error) {
-	// Try SSH agent first (most secure, no key files on disk)
+	// An explicitly configured key wins
+	if cfg.SSHKeyPath != "" {
+		return loadSSHKey(cfg.SSHKeyPath)
+	}
+
+	// Try SSH agent next (most secure, no key files on disk)
 	// The SSH agent is a program that holds private keys in memory
 	// It's commonly used on developer machines
 	auth, err := ssh.NewSSHAgentAuth("git")
@@ -73,6 +83,11 @@ func ResolveSSHAuth(cfg *config.GitConfig) (transport.AuthMethod, error) {
 		return nil, fmt.Errorf("SSH key not found: %w", err)
 	}
 
+	return loadSSHKey(keyPath)
+}
+
+// loadSSHKey loads an unencrypted SSH private key from a file
+func loadSSHKey(keyPath string) (transport.AuthMethod, error) {
 	// Expand ~ to home directory if present
 	// Go doesn't automatically expand ~ like shells do
 	if keyPath[0] == '~' {
@@ -213,6 +228,8 @@ func GetAuthDescription(auth transport.AuthMethod) string {
 	switch auth.(type) {
 	case *ssh.PublicKeys:
 		return "SSH key"
+	case *ssh.PublicKeysCallback:
+		return "SSH agent"
 	case *http.BasicAuth:
 		return "HTTPS token"
 	default:
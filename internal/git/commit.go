@@ -69,6 +69,25 @@ func (r *Repository) StageAll() error {
 //   - string: The commit hash (SHA)
 //   - error: Any error encountered
 func (r *Repository) Commit(message, author, email string) (string, error) {
+	var identity Identity
+	if author != "" && email != "" {
+		identity = Identity{Name: author, Email: email}
+	}
+	return r.CommitAs(message, identity, Identity{})
+}
+
+// CommitAs creates a commit with the staged changes and explicit identities
+// The author is who made the change; the committer is who recorded it.
+//
+// Parameters:
+//   - message: Commit message
+//   - author: Author (zero to use git config user.name/user.email)
+//   - committer: Committer (zero to use the author)
+//
+// Returns:
+//   - string: The commit hash (SHA)
+//   - error: Any error encountered
+func (r *Repository) CommitAs(message string, author, committer Identity) (string, error) {
 	// Get the worktree
 	w, err := r.GetWorktree()
 	if err != nil {
@@ -76,16 +95,18 @@ func (r *Repository) Commit(message, author, email string) (string, error) {
 	}
 
 	// Create the commit options
-	commitOpts := &git.CommitOptions{}
-
-	// Only set author if both name and email are provided
-	// If empty, go-git will use the repository's git config
-	if author != "" && email != "" {
-		commitOpts.Author = &object.Signature{
-			Name:  author,
-			Email: email,
-			When:  time.Now(),
-		}
+	// With a signer set (git.sign), the commit is signed
+	commitOpts := &git.CommitOptions{Signer: r.signer}
+
+	// Only set identities that are given
+	// Without an author, go-git uses the repository's git config, and
+	// without a committer it uses the author
+	now := time.Now()
+	if !author.IsZero() {
+		commitOpts.Author = &object.Signature{Name: author.Name, Email: author.Email, When: now}
+	}
+	if !committer.IsZero() {
+		commitOpts.Committer = &object.Signature{Name: committer.Name, Email: committer.Email, When: now}
 	}
 
 	// Create the commit
@@ -100,6 +121,55 @@ func (r *Repository) Commit(message, author, email string) (string, error) {
 	return hash.String(), nil
 }
 
+// HasStagedChanges checks if any of the given paths is staged with content
+// that differs from HEAD
+// Unlike HasChanges it ignores the rest of the working tree, so files
+// njgit doesn't manage don't count.
+//
+// Parameters:
+//   - paths: Relative paths that were staged
+//
+// Returns:
+//   - bool: true if committing would change at least one of them
+//   - error: Any error encountered
+func (r *Repository) HasStagedChanges(paths []string) (bool, error) {
+	idx, err := r.repo.Storer.Index()
+	if err != nil {
+		return false, fmt.Errorf("failed to read index: %w", err)
+	}
+
+	var tree *object.Tree
+	if head, err := r.repo.Head(); err == nil {
+		commit, err := r.repo.CommitObject(head.Hash())
+		if err != nil {
+			return false, fmt.Errorf("failed to read HEAD commit: %w", err)
+		}
+		if tree, err = commit.Tree(); err != nil {
+			return false, fmt.Errorf("failed to read HEAD tree: %w", err)
+		}
+	}
+
+	for _, path := range paths {
+		entry, entryErr := idx.Entry(path)
+		if tree == nil {
+			if entryErr == nil {
+				return true, nil
+			}
+			continue
+		}
+		file, fileErr := tree.File(path)
+		switch {
+		case entryErr != nil && fileErr != nil:
+			// Neither staged nor committed
+		case entryErr != nil || fileErr != nil:
+			return true, nil
+		case entry.Hash != file.Hash || entry.Mode != file.Mode:
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 // HasChanges checks if there are any uncommitted changes
 // This is useful to avoid creating empty commits
 //
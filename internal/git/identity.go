@@ -0,0 +1,59 @@
+package git
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+)
+
+// Identity is a name and email recorded as a commit's author or committer
+type Identity struct {
+	Name  string
+	Email string
+}
+
+// IsZero reports whether the identity is unset
+func (i Identity) IsZero() bool {
+	return i.Name == "" && i.Email == ""
+}
+
+// String formats the identity the way git does: "Name <email>"
+func (i Identity) String() string {
+	return fmt.Sprintf("%s <%s>", i.Name, i.Email)
+}
+
+// ParseIdentity parses a person as written in job meta or a token name
+// Accepted forms:
+//   - "Jane Doe <jane@example.com>"
+//   - "jane@example.com" (the name is the part before the @)
+//   - "jane" (the email is jane@<emailDomain>)
+//
+// Parameters:
+//   - s: The text to parse
+//   - emailDomain: Domain for bare names ("" to reject them)
+//
+// Returns:
+//   - Identity: The parsed identity
+//   - bool: false if s doesn't name a person with an email
+func ParseIdentity(s, emailDomain string) (Identity, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Identity{}, false
+	}
+
+	if addr, err := mail.ParseAddress(s); err == nil {
+		name := addr.Name
+		if name == "" {
+			name = addr.Address[:strings.Index(addr.Address, "@")]
+		}
+		return Identity{Name: name, Email: addr.Address}, true
+	}
+
+	// A bare name needs a domain to make an email, and must not look like
+	// a malformed address
+	if emailDomain == "" || strings.ContainsAny(s, "<>@") {
+		return Identity{}, false
+	}
+	login := strings.ToLower(strings.Join(strings.Fields(s), "."))
+	return Identity{Name: s, Email: login + "@" + emailDomain}, true
+}
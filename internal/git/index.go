@@ -0,0 +1,393 @@
+package git
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+)
+
+// historyIndexVersion is bumped when the index format changes; indexes
+// written with another version are rebuilt
+const historyIndexVersion = 2
+
+// historyIndexDir and historyIndexFile locate the index inside .git
+const (
+	historyIndexDir  = "njgit"
+	historyIndexFile = "history-index.json"
+)
+
+// historyIndex maps each file path to the commits that changed it, so the
+// history of one job doesn't need a walk over the whole log
+// It keeps every commit it has indexed, from any HEAD: switching branches
+// only indexes the commits that are new to it, and commits that aren't
+// reachable from the current HEAD (other branches, rewritten history) are
+// filtered out using the parents recorded for each commit.
+type historyIndex struct {
+	Version int `json:"version"`
+
+	// Commits lists the indexed commits: parents always come before their
+	// children
+	Commits []indexedCommit `json:"commits"`
+
+	// Paths maps each file path to the commits that changed it (relative
+	// to their first parent), as positions in Commits
+	Paths map[string][]int `json:"paths"`
+
+	// positions maps each indexed commit hash to its position in Commits
+	positions map[string]int
+
+	// reachable caches which commits reachableHead contains
+	reachableHead string
+	reachable     []bool
+}
+
+// indexedCommit is one commit of the history index
+type indexedCommit struct {
+	Hash string `json:"hash"`
+
+	// Parents are the positions of the commit's parents in Commits
+	Parents []int `json:"parents,omitempty"`
+}
+
+// newHistoryIndex returns an empty index
+func newHistoryIndex() *historyIndex {
+	return &historyIndex{
+		Version:   historyIndexVersion,
+		Paths:     make(map[string][]int),
+		positions: make(map[string]int),
+	}
+}
+
+// add appends a commit whose parents are already indexed and returns its
+// position
+func (index *historyIndex) add(c *object.Commit) int {
+	entry := indexedCommit{Hash: c.Hash.String()}
+	for _, parent := range c.ParentHashes {
+		entry.Parents = append(entry.Parents, index.positions[parent.String()])
+	}
+	position := len(index.Commits)
+	index.Commits = append(index.Commits, entry)
+	index.positions[entry.Hash] = position
+	return position
+}
+
+// contains reports which indexed commits head contains, walking the
+// parents recorded in the index (no objects are read)
+// head must be indexed. The result is cached: when head descends from the
+// previous head, only the commits in between are walked.
+func (index *historyIndex) contains(head plumbing.Hash) []bool {
+	// Commits indexed since can't be ancestors of the cached head, which
+	// had all of its ancestors indexed already
+	for len(index.reachable) < len(index.Commits) {
+		index.reachable = append(index.reachable, false)
+	}
+	if index.reachableHead == head.String() {
+		return index.reachable
+	}
+
+	position := index.positions[head.String()]
+	if index.reachableHead != "" {
+		if added, ok := index.descendants(position); ok {
+			for _, p := range added {
+				index.reachable[p] = true
+			}
+			index.reachableHead = head.String()
+			return index.reachable
+		}
+	}
+
+	reachable := make([]bool, len(index.Commits))
+	stack := []int{position}
+	for len(stack) > 0 {
+		p := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if reachable[p] {
+			continue
+		}
+		reachable[p] = true
+		stack = append(stack, index.Commits[p].Parents...)
+	}
+
+	index.reachableHead = head.String()
+	index.reachable = reachable
+	return reachable
+}
+
+// descendants walks from the commit at position until it meets commits
+// the cached head contains, and returns the ones it passed
+// ok is false if the cached head isn't an ancestor of the commit, in
+// which case the cached commits aren't all reachable from it.
+func (index *historyIndex) descendants(position int) (added []int, ok bool) {
+	cached := index.positions[index.reachableHead]
+	visited := make(map[int]bool)
+	stack := []int{position}
+	for len(stack) > 0 {
+		p := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if index.reachable[p] {
+			// Any path back to the cached head meets it before any other
+			// commit it contains
+			if p == cached {
+				ok = true
+			}
+			continue
+		}
+		if visited[p] {
+			continue
+		}
+		visited[p] = true
+		added = append(added, p)
+		stack = append(stack, index.Commits[p].Parents...)
+	}
+	return added, ok
+}
+
+// pathHistory returns the commits that changed path, newest first, using
+// the history index
+//
+// Parameters:
+//   - head: The commit history starts from (HEAD)
+//   - path: File path relative to the repository root
+//   - maxCount: Maximum number of commits to return (0 for unlimited)
+//
+// Returns:
+//   - []CommitInfo: The commits
+//   - error: If the index can't be brought up to date
+func (r *Repository) pathHistory(head plumbing.Hash, path string, maxCount int) ([]CommitInfo, error) {
+	r.indexMu.Lock()
+	index, err := r.refreshIndex(head)
+	var hashes []string
+	if err == nil {
+		reachable := index.contains(head)
+		positions := index.Paths[path]
+		for i := len(positions) - 1; i >= 0; i-- {
+			if maxCount > 0 && len(hashes) >= maxCount {
+				break
+			}
+			if reachable[positions[i]] {
+				hashes = append(hashes, index.Commits[positions[i]].Hash)
+			}
+		}
+	}
+	r.indexMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []CommitInfo
+	for _, hash := range hashes {
+		c, err := r.repo.CommitObject(plumbing.NewHash(hash))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get commit %s: %w", hash, err)
+		}
+		commits = append(commits, r.commitInfo(c))
+	}
+
+	return commits, nil
+}
+
+// refreshIndex brings the history index up to date with head
+// The index is loaded from .git/njgit/ on first use and saved back after
+// it changes; if it can't be saved, it is kept in memory only.
+// The caller must hold indexMu.
+func (r *Repository) refreshIndex(head plumbing.Hash) (*historyIndex, error) {
+	if r.index == nil {
+		r.index = r.loadIndex()
+	}
+	if _, indexed := r.index.positions[head.String()]; indexed {
+		return r.index, nil
+	}
+
+	added, err := r.indexCommits(r.index, head)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range added {
+		files, err := changedFiles(c)
+		if err != nil {
+			return nil, fmt.Errorf("failed to index commit %s: %w", c.Hash, err)
+		}
+		position := r.index.add(c)
+		for _, file := range files {
+			r.index.Paths[file] = append(r.index.Paths[file], position)
+		}
+	}
+
+	r.saveIndex(r.index)
+	return r.index, nil
+}
+
+// indexCommits finds the commits reachable from head that aren't indexed
+// yet, parents before children
+func (r *Repository) indexCommits(index *historyIndex, head plumbing.Hash) ([]*object.Commit, error) {
+	const (
+		visiting = 1
+		done     = 2
+	)
+
+	var ordered []*object.Commit
+	state := make(map[plumbing.Hash]int)
+	loaded := make(map[plumbing.Hash]*object.Commit)
+
+	// Depth-first walk with an explicit stack (histories are long), adding
+	// each commit once all of its parents are added
+	stack := []plumbing.Hash{head}
+	for len(stack) > 0 {
+		hash := stack[len(stack)-1]
+
+		if _, indexed := index.positions[hash.String()]; indexed {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		switch state[hash] {
+		case done:
+			stack = stack[:len(stack)-1]
+		case visiting:
+			state[hash] = done
+			ordered = append(ordered, loaded[hash])
+			delete(loaded, hash)
+			stack = stack[:len(stack)-1]
+		default:
+			c, err := r.repo.CommitObject(hash)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get commit %s: %w", hash, err)
+			}
+			state[hash] = visiting
+			loaded[hash] = c
+			for _, parent := range c.ParentHashes {
+				if state[parent] == 0 {
+					stack = append(stack, parent)
+				}
+			}
+		}
+	}
+
+	return ordered, nil
+}
+
+// changedFiles lists the files a commit changed relative to its first
+// parent (or all of its files, for a root commit)
+// Only tree entries are compared, so unchanged directories are skipped
+// and no file contents are read.
+func changedFiles(c *object.Commit) ([]string, error) {
+	tree, err := c.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tree: %w", err)
+	}
+
+	var parentTree *object.Tree
+	if c.NumParents() > 0 {
+		parent, err := c.Parent(0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get parent: %w", err)
+		}
+		if parentTree, err = parent.Tree(); err != nil {
+			return nil, fmt.Errorf("failed to get parent tree: %w", err)
+		}
+	}
+
+	changes, err := object.DiffTree(parentTree, tree)
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff trees: %w", err)
+	}
+
+	files := make([]string, 0, len(changes))
+	for _, change := range changes {
+		if change.To.Name != "" {
+			files = append(files, change.To.Name)
+		} else {
+			files = append(files, change.From.Name)
+		}
+	}
+	return files, nil
+}
+
+// indexPath returns where the history index is stored: .git/njgit/
+// Returns "" for repositories that aren't stored on disk
+func (r *Repository) indexPath() string {
+	storage, ok := r.repo.Storer.(*filesystem.Storage)
+	if !ok {
+		return ""
+	}
+	return filepath.Join(storage.Filesystem().Root(), historyIndexDir, historyIndexFile)
+}
+
+// loadIndex reads the history index from disk
+// A missing, unreadable or outdated index yields an empty one, which is
+// then rebuilt from the log.
+func (r *Repository) loadIndex() *historyIndex {
+	path := r.indexPath()
+	if path == "" {
+		return newHistoryIndex()
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return newHistoryIndex()
+	}
+
+	index := newHistoryIndex()
+	if err := json.Unmarshal(data, index); err != nil || index.Version != historyIndexVersion ||
+		index.Paths == nil || !index.valid() {
+		return newHistoryIndex()
+	}
+	for position, c := range index.Commits {
+		index.positions[c.Hash] = position
+	}
+	return index
+}
+
+// valid reports whether every position in the index points at an earlier
+// commit, as one written by refreshIndex does
+func (index *historyIndex) valid() bool {
+	for position, c := range index.Commits {
+		for _, parent := range c.Parents {
+			if parent < 0 || parent >= position {
+				return false
+			}
+		}
+	}
+	for _, positions := range index.Paths {
+		for _, position := range positions {
+			if position < 0 || position >= len(index.Commits) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// saveIndex writes the history index to disk
+// The file is replaced atomically, so a concurrent reader never sees a
+// partial index. Failures are ignored: the index is only a cache.
+func (r *Repository) saveIndex(index *historyIndex) {
+	path := r.indexPath()
+	if path == "" {
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return
+	}
+	data, err := json.Marshal(index)
+	if err != nil {
+		return
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), historyIndexFile+".*")
+	if err != nil {
+		return
+	}
+	_, writeErr := tmp.Write(data)
+	closeErr := tmp.Close()
+	if writeErr != nil || closeErr != nil || os.Rename(tmp.Name(), path) != nil {
+		_ = os.Remove(tmp.Name())
+	}
+}
@@ -0,0 +1,424 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+)
+
+// DeployTagPrefix starts the names of the tags njgit creates after a deploy
+const DeployTagPrefix = "deploy/"
+
+// DeployTagName names the tag for a deploy of a job:
+// deploy/<region>/<namespace>/<job>/<timestamp>, with the UTC time in
+// basic ISO 8601 form (e.g. 20240115T143022Z) so names sort by time
+func DeployTagName(region, namespace, job string, at time.Time) string {
+	return fmt.Sprintf("%s%s/%s/%s/%s", DeployTagPrefix, region, namespace, job, at.UTC().Format("20060102T150405Z"))
+}
+
+// TagInfo describes a tag
+type TagInfo struct {
+	Name   string // Tag name without refs/tags/, e.g. "deploy/global/default/web/20240115T143022Z"
+	Commit string // Full hash of the tagged commit
+
+	// Message, Tagger and Date are only set for annotated tags
+	Message string
+	Tagger  string // "Name <email>"
+	Date    time.Time
+}
+
+// CreateTag creates an annotated tag on the commit a revision names
+//
+// Parameters:
+//   - name: Tag name (without refs/tags/)
+//   - rev: The revision to tag (hash, branch, tag, HEAD~N, ...)
+//   - message: The tag message
+//   - tagger: Who created the tag (zero to use git config)
+//
+// Returns:
+//   - *TagInfo: The new tag
+//   - error: If the revision doesn't exist or the tag already exists
+func (r *Repository) CreateTag(name, rev, message string, tagger Identity) (*TagInfo, error) {
+	commit, err := r.ResolveCommit(rev)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &git.CreateTagOptions{Message: message}
+	if !tagger.IsZero() {
+		opts.Tagger = &object.Signature{Name: tagger.Name, Email: tagger.Email, When: time.Now()}
+	}
+
+	ref, err := r.repo.CreateTag(name, plumbing.NewHash(commit.FullHash), opts)
+	if errors.Is(err, git.ErrTagExists) {
+		return nil, fmt.Errorf("tag %s already exists", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tag %s: %w", name, err)
+	}
+
+	return r.tagInfo(ref)
+}
+
+// ListTags lists the tags whose names start with prefix, sorted by name
+//
+// Parameters:
+//   - prefix: Name prefix, e.g. "deploy/" ("" for all tags)
+//
+// Returns:
+//   - []TagInfo: The tags
+//   - error: Any error that occurred
+func (r *Repository) ListTags(prefix string) ([]TagInfo, error) {
+	refs, err := r.repo.Tags()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+	defer refs.Close()
+
+	var tags []TagInfo
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		if !strings.HasPrefix(ref.Name().Short(), prefix) {
+			return nil
+		}
+		tag, err := r.tagInfo(ref)
+		if err != nil {
+			return err
+		}
+		tags = append(tags, *tag)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+	return tags, nil
+}
+
+// tagInfo describes the tag a reference points to
+func (r *Repository) tagInfo(ref *plumbing.Reference) (*TagInfo, error) {
+	info := &TagInfo{Name: ref.Name().Short(), Commit: ref.Hash().String()}
+
+	tag, err := r.repo.TagObject(ref.Hash())
+	if errors.Is(err, plumbing.ErrObjectNotFound) {
+		// Lightweight tag: the reference points at the commit itself
+		return info, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tag %s: %w", info.Name, err)
+	}
+
+	commit, err := tag.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("tag %s doesn't point to a commit: %w", info.Name, err)
+	}
+	info.Commit = commit.Hash.String()
+	info.Message = strings.TrimSpace(tag.Message)
+	info.Tagger = fmt.Sprintf("%s <%s>", tag.Tagger.Name, tag.Tagger.Email)
+	info.Date = tag.Tagger.When
+	return info, nil
+}
+
+// PushTag pushes a tag to a remote
+// A tag the remote already has is not an error.
+//
+// Parameters:
+//   - ctx: Cancels the push
+//   - remote: The remote name (e.g. "origin")
+//   - name: The tag name
+//
+// Returns:
+//   - error: If the push fails
+func (r *Repository) PushTag(ctx context.Context, remote, name string) error {
+	ref := plumbing.NewTagReferenceName(name)
+	if err := r.pushRef(ctx, remote, fmt.Sprintf("%s:%s", ref, ref)); err != nil {
+		return fmt.Errorf("failed to push tag %s to %s: %w", name, remote, err)
+	}
+	return nil
+}
+
+// AppliedRefPrefix starts the refs that record the last commit of a branch
+// whose merged changes were deployed (see njgit apply-merged)
+const AppliedRefPrefix = "refs/njgit/applied/"
+
+// AppliedRef returns the ref recording the last applied commit of branch
+func AppliedRef(branch string) string {
+	return AppliedRefPrefix + branch
+}
+
+// ReadRef returns the commit a reference points to
+//
+// Parameters:
+//   - name: Full reference name, e.g. "refs/njgit/applied/main"
+//
+// Returns:
+//   - string: The full commit hash, or "" if the reference doesn't exist
+//   - error: Any other error
+func (r *Repository) ReadRef(name string) (string, error) {
+	ref, err := r.repo.Reference(plumbing.ReferenceName(name), true)
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	return ref.Hash().String(), nil
+}
+
+// SetRef points a reference at the commit a revision names, creating it if
+// it doesn't exist
+//
+// Parameters:
+//   - name: Full reference name, e.g. "refs/njgit/applied/main"
+//   - rev: The revision to point it at
+//
+// Returns:
+//   - error: If the revision doesn't exist or the name is invalid
+func (r *Repository) SetRef(name, rev string) error {
+	commit, err := r.ResolveCommit(rev)
+	if err != nil {
+		return err
+	}
+
+	ref := plumbing.ReferenceName(name)
+	if err := ref.Validate(); err != nil {
+		return fmt.Errorf("invalid reference name %s: %w", name, err)
+	}
+	if err := r.repo.Storer.SetReference(plumbing.NewHashReference(ref, plumbing.NewHash(commit.FullHash))); err != nil {
+		return fmt.Errorf("failed to update %s: %w", name, err)
+	}
+	return nil
+}
+
+// FetchRef fetches a reference from a remote into the same name locally,
+// replacing the local one
+// A reference the remote doesn't have is left alone.
+//
+// Parameters:
+//   - ctx: Cancels the fetch
+//   - remote: The remote name (e.g. "origin")
+//   - name: Full reference name
+//
+// Returns:
+//   - error: If the fetch fails
+func (r *Repository) FetchRef(ctx context.Context, remote, name string) error {
+	err := r.repo.FetchContext(ctx, &git.FetchOptions{
+		RemoteName: remote,
+		RefSpecs:   []gitconfig.RefSpec{gitconfig.RefSpec(fmt.Sprintf("+%s:%s", name, name))},
+		Auth:       r.auth,
+	})
+	switch {
+	case err == nil, errors.Is(err, git.NoErrAlreadyUpToDate),
+		errors.Is(err, transport.ErrEmptyRemoteRepository), errors.Is(err, git.NoMatchingRefSpecError{}):
+		return nil
+	default:
+		return fmt.Errorf("failed to fetch %s from %s: %w", name, remote, err)
+	}
+}
+
+// PushRef pushes a reference to a remote, replacing the remote's copy
+//
+// Parameters:
+//   - ctx: Cancels the push
+//   - remote: The remote name (e.g. "origin")
+//   - name: Full reference name
+//
+// Returns:
+//   - error: If the push fails
+func (r *Repository) PushRef(ctx context.Context, remote, name string) error {
+	if err := r.pushRef(ctx, remote, fmt.Sprintf("+%s:%s", name, name)); err != nil {
+		return fmt.Errorf("failed to push %s to %s: %w", name, remote, err)
+	}
+	return nil
+}
+
+// pushRef pushes one refspec; a remote that is already up to date is not
+// an error
+func (r *Repository) pushRef(ctx context.Context, remote, refSpec string) error {
+	err := r.repo.PushContext(ctx, &git.PushOptions{
+		RemoteName: remote,
+		RefSpecs:   []gitconfig.RefSpec{gitconfig.RefSpec(refSpec)},
+		Auth:       r.auth,
+	})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return err
+	}
+	return nil
+}
+
+// CommitsSince lists the commits reachable from rev but not from since,
+// parents before children (oldest first)
+//
+// Parameters:
+//   - since: The revision already seen (e.g. the last applied commit)
+//   - rev: The newer revision (e.g. a branch)
+//
+// Returns:
+//   - []CommitInfo: The commits, with the files each changed
+//   - error: If either revision doesn't exist
+func (r *Repository) CommitsSince(since, rev string) ([]CommitInfo, error) {
+	old, err := r.ResolveCommit(since)
+	if err != nil {
+		return nil, err
+	}
+	head, err := r.ResolveCommit(rev)
+	if err != nil {
+		return nil, err
+	}
+
+	// Mark everything since already contains as seen, then collect the
+	// rest the same way the history index does
+	seen := newHistoryIndex()
+	iter, err := r.repo.Log(&git.LogOptions{From: plumbing.NewHash(old.FullHash)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history of %s: %w", since, err)
+	}
+	err = iter.ForEach(func(c *object.Commit) error {
+		seen.positions[c.Hash.String()] = len(seen.positions)
+		return nil
+	})
+	iter.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history of %s: %w", since, err)
+	}
+
+	added, err := r.indexCommits(seen, plumbing.NewHash(head.FullHash))
+	if err != nil {
+		return nil, err
+	}
+
+	commits := make([]CommitInfo, 0, len(added))
+	for _, c := range added {
+		commits = append(commits, r.commitInfo(c))
+	}
+	return commits, nil
+}
+
+// CreateBranch creates a branch on the commit a revision names
+//
+// Parameters:
+//   - name: Branch name (without refs/heads/)
+//   - rev: The revision to start from
+//
+// Returns:
+//   - error: If the revision doesn't exist or the branch already exists
+func (r *Repository) CreateBranch(name, rev string) error {
+	commit, err := r.ResolveCommit(rev)
+	if err != nil {
+		return err
+	}
+
+	ref := plumbing.NewBranchReferenceName(name)
+	if err := ref.Validate(); err != nil {
+		return fmt.Errorf("invalid branch name %s: %w", name, err)
+	}
+	if _, err := r.repo.Reference(ref, false); err == nil {
+		return fmt.Errorf("branch %s already exists", name)
+	}
+
+	if err := r.repo.Storer.SetReference(plumbing.NewHashReference(ref, plumbing.NewHash(commit.FullHash))); err != nil {
+		return fmt.Errorf("failed to create branch %s: %w", name, err)
+	}
+	return nil
+}
+
+// ListBranches lists the local branches whose names start with prefix,
+// sorted by name
+//
+// Parameters:
+//   - prefix: Name prefix, e.g. "env/" ("" for all branches)
+//
+// Returns:
+//   - []string: Branch names (without refs/heads/)
+//   - error: Any error that occurred
+func (r *Repository) ListBranches(prefix string) ([]string, error) {
+	refs, err := r.repo.Branches()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branches: %w", err)
+	}
+	defer refs.Close()
+
+	branches := []string{}
+	_ = refs.ForEach(func(ref *plumbing.Reference) error {
+		if name := ref.Name().Short(); strings.HasPrefix(name, prefix) {
+			branches = append(branches, name)
+		}
+		return nil
+	})
+
+	sort.Strings(branches)
+	return branches, nil
+}
+
+// CurrentBranch returns the name of the branch HEAD points to
+//
+// Returns:
+//   - string: Branch name (without refs/heads/)
+//   - error: If HEAD is detached
+func (r *Repository) CurrentBranch() (string, error) {
+	branch, err := r.currentBranch()
+	if err != nil {
+		return "", err
+	}
+	return branch.Short(), nil
+}
+
+// SwitchBranch checks out a branch, creating it if it doesn't exist
+// A new branch starts from the same branch on the remote if it has one
+// (so local history isn't pushed onto it), and from HEAD otherwise.
+// Uncommitted changes to tracked files stop the switch.
+//
+// Parameters:
+//   - ctx: Cancels the fetch from the remote
+//   - name: Branch name (without refs/heads/)
+//   - remote: Remote to look for the branch on ("" for none)
+//
+// Returns:
+//   - bool: true if the branch was created
+//   - error: Any error that occurred
+func (r *Repository) SwitchBranch(ctx context.Context, name, remote string) (bool, error) {
+	ref := plumbing.NewBranchReferenceName(name)
+	if err := ref.Validate(); err != nil {
+		return false, fmt.Errorf("invalid branch name %s: %w", name, err)
+	}
+
+	created := false
+	if _, err := r.repo.Reference(ref, false); err != nil {
+		start := "HEAD"
+		if remote != "" {
+			hash, found, err := r.fetchBranch(ctx, remote, ref)
+			if err != nil {
+				return false, err
+			}
+			if found {
+				start = hash.String()
+			}
+		}
+		if err := r.CreateBranch(name, start); err != nil {
+			return false, err
+		}
+		created = true
+	}
+
+	w, err := r.GetWorktree()
+	if err != nil {
+		return false, fmt.Errorf("failed to get worktree: %w", err)
+	}
+	if err := w.Checkout(&git.CheckoutOptions{Branch: ref}); err != nil {
+		if errors.Is(err, git.ErrUnstagedChanges) {
+			return false, fmt.Errorf("cannot switch to branch %s: commit or stash your changes first", name)
+		}
+		return false, fmt.Errorf("failed to check out branch %s: %w", name, err)
+	}
+
+	return created, nil
+}
@@ -0,0 +1,600 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+)
+
+// PullResult describes what PullRebase did to the current branch
+type PullResult struct {
+	// Head is the branch's commit hash after the pull
+	Head string
+
+	// Updated is true if the branch moved
+	Updated bool
+
+	// Rebased is the number of local commits replayed on the remote branch
+	// (zero for a fast-forward)
+	Rebased int
+
+	// Changed lists the files whose content changed in the working tree
+	Changed []string
+}
+
+// SetAuth sets the authentication used for remote operations
+func (r *Repository) SetAuth(auth transport.AuthMethod) {
+	r.auth = auth
+}
+
+// HasRemote reports whether a remote with the given name is configured
+func (r *Repository) HasRemote(name string) bool {
+	_, err := r.repo.Remote(name)
+	return err == nil
+}
+
+// PullRebase brings the current branch up to date with the same branch on
+// a remote, like "git pull --rebase"
+//
+// Commits that exist only locally are replayed on top of the remote branch.
+// go-git can't merge file contents, so a file changed on both sides is a
+// conflict: the pull is aborted with a *ConflictError naming the files,
+// before anything is touched. A local change the remote already has is
+// dropped, as is a replayed commit left empty by that.
+// Files in keep are left alone in the working tree (they hold changes about
+// to be committed); any other file with uncommitted changes that the pull
+// would overwrite aborts it too.
+//
+// Parameters:
+//   - ctx: Cancels the fetch
+//   - remote: The remote name (e.g. "origin")
+//   - keep: Paths with pending changes to leave in the working tree
+//
+// Returns:
+//   - *PullResult: What changed
+//   - error: If the fetch fails, histories are unrelated, files conflict, or
+//     local changes would be lost
+func (r *Repository) PullRebase(ctx context.Context, remote string, keep []string) (*PullResult, error) {
+	branch, err := r.currentBranch()
+	if err != nil {
+		return nil, err
+	}
+
+	localHash := plumbing.ZeroHash
+	if ref, err := r.repo.Reference(branch, true); err == nil {
+		localHash = ref.Hash()
+	} else if !errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return nil, fmt.Errorf("failed to read branch %s: %w", branch.Short(), err)
+	}
+	result := &PullResult{Head: localHash.String()}
+
+	remoteHash, found, err := r.fetchBranch(ctx, remote, branch)
+	if err != nil {
+		return nil, err
+	}
+	if !found || remoteHash == localHash {
+		return result, nil
+	}
+
+	remoteCommit, err := r.repo.CommitObject(remoteHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read remote commit: %w", err)
+	}
+
+	// Local commits to replay, oldest first
+	var local []*object.Commit
+	oldTree := &object.Tree{}
+	if localHash != plumbing.ZeroHash {
+		localCommit, err := r.repo.CommitObject(localHash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read local commit: %w", err)
+		}
+		oldTree, err = localCommit.Tree()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read local tree: %w", err)
+		}
+
+		// Nothing to do if the remote has nothing new
+		if behind, err := remoteCommit.IsAncestor(localCommit); err != nil {
+			return nil, fmt.Errorf("failed to compare with %s/%s: %w", remote, branch.Short(), err)
+		} else if behind {
+			return result, nil
+		}
+
+		local, err = commitsSince(localCommit, remoteCommit)
+		if err != nil {
+			return nil, fmt.Errorf("cannot rebase onto %s/%s: %w", remote, branch.Short(), err)
+		}
+	}
+
+	newHash := remoteHash
+	for _, commit := range local {
+		var conflicts []string
+		newHash, conflicts, err = r.replayCommit(commit, newHash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to rebase commit %s: %w", commit.Hash.String()[:8], err)
+		}
+		if len(conflicts) > 0 {
+			return nil, &ConflictError{Remote: remote, Branch: branch.Short(), Commit: commit.Hash.String()[:8], Paths: conflicts}
+		}
+	}
+
+	newCommit, err := r.repo.CommitObject(newHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rebased commit: %w", err)
+	}
+	newTree, err := newCommit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rebased tree: %w", err)
+	}
+
+	changes, err := object.DiffTree(oldTree, newTree)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare trees: %w", err)
+	}
+
+	// Refuse to overwrite uncommitted work before moving the branch
+	keepSet := make(map[string]bool, len(keep))
+	for _, path := range keep {
+		keepSet[path] = true
+	}
+	for _, change := range changes {
+		path := changePath(change)
+		if keepSet[path] {
+			continue
+		}
+		clean, err := r.worktreeMatches(path, change.From)
+		if err != nil {
+			return nil, err
+		}
+		if !clean {
+			return nil, fmt.Errorf("local changes to %s would be overwritten by the pull from %s; commit or discard them first", path, remote)
+		}
+	}
+
+	if err := r.repo.Storer.SetReference(plumbing.NewHashReference(branch, newHash)); err != nil {
+		return nil, fmt.Errorf("failed to update branch %s: %w", branch.Short(), err)
+	}
+
+	if err := r.checkoutChanges(changes, keepSet); err != nil {
+		return nil, err
+	}
+
+	result.Head = newHash.String()
+	result.Updated = true
+	result.Rebased = len(local)
+	for _, change := range changes {
+		result.Changed = append(result.Changed, changePath(change))
+	}
+	return result, nil
+}
+
+// ConflictError is returned by PullRebase when a local commit changes files
+// that were also changed on the remote
+type ConflictError struct {
+	Remote string   // Remote name
+	Branch string   // Branch name
+	Commit string   // Short hash of the local commit that conflicts
+	Paths  []string // Files changed on both sides
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("commit %s conflicts with %s/%s: %s changed on both sides (pull and resolve them with git, then sync again)",
+		e.Commit, e.Remote, e.Branch, strings.Join(e.Paths, ", "))
+}
+
+// PushBranch pushes the current branch to the same branch on a remote
+// A push rejected as non-fast-forward (someone else pushed first) is retried
+// after rebasing onto the remote with PullRebase, up to attempts times.
+//
+// Parameters:
+//   - ctx: Cancels the push
+//   - remote: The remote name (e.g. "origin")
+//   - attempts: How many times to try the push
+//
+// Returns:
+//   - error: If the push fails or is still rejected after the last attempt
+func (r *Repository) PushBranch(ctx context.Context, remote string, attempts int) error {
+	branch, err := r.currentBranch()
+	if err != nil {
+		return err
+	}
+
+	refSpec := gitconfig.RefSpec(fmt.Sprintf("%s:%s", branch, branch))
+
+	var lastErr error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		err := r.repo.PushContext(ctx, &git.PushOptions{
+			RemoteName: remote,
+			RefSpecs:   []gitconfig.RefSpec{refSpec},
+			Auth:       r.auth,
+		})
+		if err == nil || errors.Is(err, git.NoErrAlreadyUpToDate) {
+			return nil
+		}
+		if !isNonFastForward(err) {
+			return fmt.Errorf("failed to push to %s: %w", remote, err)
+		}
+
+		lastErr = err
+		if attempt < attempts {
+			if _, err := r.PullRebase(ctx, remote, nil); err != nil {
+				return fmt.Errorf("push to %s rejected and rebase failed: %w", remote, err)
+			}
+		}
+	}
+
+	return fmt.Errorf("push to %s still rejected after %d attempts: %w", remote, attempts, lastErr)
+}
+
+// currentBranch returns the branch HEAD points to
+func (r *Repository) currentBranch() (plumbing.ReferenceName, error) {
+	head, err := r.repo.Reference(plumbing.HEAD, false)
+	if err != nil {
+		return "", fmt.Errorf("failed to read HEAD: %w", err)
+	}
+	if head.Type() != plumbing.SymbolicReference || !head.Target().IsBranch() {
+		return "", fmt.Errorf("HEAD is detached; check out a branch to sync with a remote")
+	}
+	return head.Target(), nil
+}
+
+// fetchBranch fetches a branch from a remote into its remote-tracking ref
+// Returns found = false if the remote is empty or doesn't have the branch.
+func (r *Repository) fetchBranch(ctx context.Context, remote string, branch plumbing.ReferenceName) (plumbing.Hash, bool, error) {
+	tracking := plumbing.NewRemoteReferenceName(remote, branch.Short())
+	err := r.repo.FetchContext(ctx, &git.FetchOptions{
+		RemoteName: remote,
+		RefSpecs:   []gitconfig.RefSpec{gitconfig.RefSpec(fmt.Sprintf("+%s:%s", branch, tracking))},
+		Auth:       r.auth,
+	})
+	switch {
+	case err == nil, errors.Is(err, git.NoErrAlreadyUpToDate):
+	case errors.Is(err, transport.ErrEmptyRemoteRepository), errors.Is(err, git.NoMatchingRefSpecError{}):
+		return plumbing.ZeroHash, false, nil
+	default:
+		return plumbing.ZeroHash, false, fmt.Errorf("failed to fetch from %s: %w", remote, err)
+	}
+
+	ref, err := r.repo.Reference(tracking, true)
+	if err != nil {
+		return plumbing.ZeroHash, false, nil
+	}
+	return ref.Hash(), true, nil
+}
+
+// commitsSince returns the commits reachable from head but not from other,
+// oldest first
+// Only linear history is supported; merge commits can't be replayed.
+func commitsSince(head, other *object.Commit) ([]*object.Commit, error) {
+	bases, err := head.MergeBase(other)
+	if err != nil {
+		return nil, err
+	}
+	if len(bases) == 0 {
+		return nil, fmt.Errorf("no common history")
+	}
+	base := bases[0].Hash
+
+	var commits []*object.Commit
+	for commit := head; commit.Hash != base; {
+		if commit.NumParents() != 1 {
+			return nil, fmt.Errorf("commit %s is a merge or root commit", commit.Hash.String()[:8])
+		}
+		commits = append(commits, commit)
+
+		parent, err := commit.Parent(0)
+		if err != nil {
+			return nil, err
+		}
+		commit = parent
+	}
+
+	// Reverse into oldest-first order
+	for i, j := 0, len(commits)-1; i < j; i, j = i+1, j-1 {
+		commits[i], commits[j] = commits[j], commits[i]
+	}
+	return commits, nil
+}
+
+// replayCommit applies a commit's changes on top of parent and stores the
+// resulting commit. Author and message are kept; the committer time is now.
+// Files the parent changed since the commit's original parent are returned
+// as conflicts instead, and a commit with nothing left to apply is dropped
+// (parent is returned).
+func (r *Repository) replayCommit(commit *object.Commit, parent plumbing.Hash) (plumbing.Hash, []string, error) {
+	parentCommit, err := r.repo.CommitObject(parent)
+	if err != nil {
+		return plumbing.ZeroHash, nil, err
+	}
+	baseTree, err := parentCommit.Tree()
+	if err != nil {
+		return plumbing.ZeroHash, nil, err
+	}
+	files, err := flattenTree(baseTree)
+	if err != nil {
+		return plumbing.ZeroHash, nil, err
+	}
+
+	origParent, err := commit.Parent(0)
+	if err != nil {
+		return plumbing.ZeroHash, nil, err
+	}
+	fromTree, err := origParent.Tree()
+	if err != nil {
+		return plumbing.ZeroHash, nil, err
+	}
+	toTree, err := commit.Tree()
+	if err != nil {
+		return plumbing.ZeroHash, nil, err
+	}
+	changes, err := object.DiffTree(fromTree, toTree)
+	if err != nil {
+		return plumbing.ZeroHash, nil, err
+	}
+
+	var conflicts []string
+	for _, change := range changes {
+		if replayConflicts(files, change) {
+			conflicts = append(conflicts, changePath(change))
+		}
+	}
+	if len(conflicts) > 0 {
+		sort.Strings(conflicts)
+		return plumbing.ZeroHash, conflicts, nil
+	}
+
+	for _, change := range changes {
+		if change.To.Name == "" {
+			delete(files, change.From.Name)
+			continue
+		}
+		if change.From.Name != "" && change.From.Name != change.To.Name {
+			delete(files, change.From.Name)
+		}
+		files[change.To.Name] = change.To.TreeEntry
+	}
+
+	treeHash, err := r.writeTree(files)
+	if err != nil {
+		return plumbing.ZeroHash, nil, err
+	}
+	if treeHash == baseTree.Hash {
+		// The remote already has every change of this commit
+		return parent, nil, nil
+	}
+
+	committer := commit.Committer
+	committer.When = time.Now()
+	replayed := &object.Commit{
+		Author:       commit.Author,
+		Committer:    committer,
+		Message:      commit.Message,
+		TreeHash:     treeHash,
+		ParentHashes: []plumbing.Hash{parent},
+	}
+
+	// The original signature doesn't cover the new tree and parent, so a
+	// replayed commit is signed again (or left unsigned without a signer)
+	if r.signer != nil {
+		signature, err := r.signCommit(replayed)
+		if err != nil {
+			return plumbing.ZeroHash, nil, err
+		}
+		replayed.PGPSignature = signature
+	}
+
+	obj := r.repo.Storer.NewEncodedObject()
+	if err := replayed.Encode(obj); err != nil {
+		return plumbing.ZeroHash, nil, err
+	}
+	hash, err := r.repo.Storer.SetEncodedObject(obj)
+	return hash, nil, err
+}
+
+// replayConflicts reports whether a change can't be replayed onto files
+// because they no longer have the change's original version of the file.
+// A change files already contain is not a conflict.
+func replayConflicts(files map[string]object.TreeEntry, change *object.Change) bool {
+	current, exists := files[changePath(change)]
+	if change.To.Name != "" {
+		if exists && current.Hash == change.To.TreeEntry.Hash {
+			return false
+		}
+	} else if !exists {
+		return false
+	}
+
+	if change.From.Name == "" {
+		return exists
+	}
+	return !exists || current.Hash != change.From.TreeEntry.Hash
+}
+
+// flattenTree returns every file in a tree by its full path
+func flattenTree(tree *object.Tree) (map[string]object.TreeEntry, error) {
+	files := make(map[string]object.TreeEntry)
+	walker := object.NewTreeWalker(tree, true, nil)
+	defer walker.Close()
+
+	for {
+		name, entry, err := walker.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if entry.Mode == filemode.Dir {
+			continue
+		}
+		entry.Name = name
+		files[name] = entry
+	}
+}
+
+// writeTree stores the tree objects for a set of files and returns the
+// root tree hash
+func (r *Repository) writeTree(files map[string]object.TreeEntry) (plumbing.Hash, error) {
+	entries := make([]object.TreeEntry, 0)
+	subdirs := make(map[string]map[string]object.TreeEntry)
+
+	for path, entry := range files {
+		dir, rest, nested := strings.Cut(path, "/")
+		if !nested {
+			entry.Name = path
+			entries = append(entries, entry)
+			continue
+		}
+		if subdirs[dir] == nil {
+			subdirs[dir] = make(map[string]object.TreeEntry)
+		}
+		subdirs[dir][rest] = entry
+	}
+
+	for dir, subFiles := range subdirs {
+		hash, err := r.writeTree(subFiles)
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+		entries = append(entries, object.TreeEntry{Name: dir, Mode: filemode.Dir, Hash: hash})
+	}
+
+	// Git sorts tree entries by name, comparing directories as "name/"
+	sort.Slice(entries, func(i, j int) bool {
+		return treeSortKey(entries[i]) < treeSortKey(entries[j])
+	})
+
+	tree := &object.Tree{Entries: entries}
+	obj := r.repo.Storer.NewEncodedObject()
+	if err := tree.Encode(obj); err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return r.repo.Storer.SetEncodedObject(obj)
+}
+
+// treeSortKey is the name git sorts a tree entry by
+func treeSortKey(entry object.TreeEntry) string {
+	if entry.Mode == filemode.Dir {
+		return entry.Name + "/"
+	}
+	return entry.Name
+}
+
+// changePath returns the path a tree change applies to
+func changePath(change *object.Change) string {
+	if change.To.Name != "" {
+		return change.To.Name
+	}
+	return change.From.Name
+}
+
+// worktreeMatches reports whether a working tree file still has the content
+// it has in the old tree (entry.Name is empty if it wasn't in the tree)
+func (r *Repository) worktreeMatches(path string, entry object.ChangeEntry) (bool, error) {
+	content, err := os.ReadFile(r.getAbsolutePath(path))
+	if os.IsNotExist(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if entry.Name == "" {
+		// An untracked file in the way of an incoming one
+		return false, nil
+	}
+
+	blob, err := r.repo.BlobObject(entry.TreeEntry.Hash)
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s from the old tree: %w", path, err)
+	}
+	reader, err := blob.Reader()
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = reader.Close() }()
+	committed, err := io.ReadAll(reader)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(content, committed), nil
+}
+
+// checkoutChanges applies tree changes to the working tree and the index
+// Paths in keep only get their index entry updated.
+func (r *Repository) checkoutChanges(changes object.Changes, keep map[string]bool) error {
+	idx, err := r.repo.Storer.Index()
+	if err != nil {
+		return fmt.Errorf("failed to read index: %w", err)
+	}
+
+	for _, change := range changes {
+		if change.From.Name != "" && change.From.Name != change.To.Name {
+			_, _ = idx.Remove(change.From.Name)
+			if !keep[change.From.Name] {
+				if err := r.DeleteFile(change.From.Name); err != nil {
+					return err
+				}
+			}
+		}
+		if change.To.Name == "" {
+			continue
+		}
+
+		path := change.To.Name
+		if !keep[path] {
+			blob, err := r.repo.BlobObject(change.To.TreeEntry.Hash)
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", path, err)
+			}
+			reader, err := blob.Reader()
+			if err != nil {
+				return err
+			}
+			content, err := io.ReadAll(reader)
+			_ = reader.Close()
+			if err != nil {
+				return err
+			}
+			if err := r.WriteFile(path, content); err != nil {
+				return err
+			}
+		}
+
+		entry, err := idx.Entry(path)
+		if err != nil {
+			entry = idx.Add(path)
+		}
+		entry.Hash = change.To.TreeEntry.Hash
+		entry.Mode = change.To.TreeEntry.Mode
+		entry.Size = 0
+		entry.ModifiedAt = time.Time{}
+	}
+
+	if err := r.repo.Storer.SetIndex(idx); err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	return nil
+}
+
+// isNonFastForward reports whether a push was rejected because the remote
+// branch has commits the local one doesn't
+func isNonFastForward(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "non-fast-forward") ||
+		strings.Contains(msg, "fetch first") ||
+		errors.Is(err, git.ErrForceNeeded)
+}
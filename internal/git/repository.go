@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-git/go-git/v5"
@@ -26,6 +28,16 @@ type Repository struct {
 
 	// auth is the authentication method
 	auth transport.AuthMethod
+
+	// signer signs new commits (nil for unsigned commits)
+	signer git.Signer
+
+	// verifier checks the signatures of commits read from history
+	verifier *Verifier
+
+	// index speeds up per-file history (see historyIndex); indexMu guards it
+	indexMu sync.Mutex
+	index   *historyIndex
 }
 
 // NewLocalRepository creates a Repository wrapper for an existing local repository
@@ -282,11 +294,20 @@ type CommitInfo struct {
 	Email    string    // Author email
 	Date     time.Time // Commit date
 	Files    []string  // Files changed in this commit
+
+	// Signature is the commit's signature status and signer
+	Signature SignatureInfo
+
+	// Job is the job identity from the message's trailers (nil for commits
+	// without them, e.g. made before njgit wrote trailers)
+	Job *JobTrailers
 }
 
 // GetHistory returns the commit history for a specific file or all files
 // If path is empty, returns all commits
-// If path is specified, returns only commits that touched that file
+// If path is specified, returns only commits that touched that file; these
+// are looked up in the history index under .git/njgit/, which is brought up
+// to date with HEAD first
 //
 // Parameters:
 //   - path: File path to filter by (empty for all commits)
@@ -304,17 +325,12 @@ func (r *Repository) GetHistory(path string, maxCount int) ([]CommitInfo, error)
 		return nil, fmt.Errorf("failed to get HEAD: %w", err)
 	}
 
-	// Get commit log
-	logOptions := &git.LogOptions{
-		From: ref.Hash(),
-	}
 	if path != "" {
-		logOptions.PathFilter = func(p string) bool {
-			return p == path
-		}
+		return r.pathHistory(ref.Hash(), path, maxCount)
 	}
 
-	commitIter, err := r.repo.Log(logOptions)
+	// Get commit log
+	commitIter, err := r.repo.Log(&git.LogOptions{From: ref.Hash()})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get log: %w", err)
 	}
@@ -326,40 +342,7 @@ func (r *Repository) GetHistory(path string, maxCount int) ([]CommitInfo, error)
 			return storer.ErrStop
 		}
 
-		// Get files changed in this commit
-		var files []string
-		if c.NumParents() > 0 {
-			parent, err := c.Parent(0)
-			if err == nil {
-				changes, err := parent.Patch(c)
-				if err == nil {
-					for _, fileStat := range changes.FilePatches() {
-						from, to := fileStat.Files()
-						if to != nil {
-							files = append(files, to.Path())
-						} else if from != nil {
-							files = append(files, from.Path())
-						}
-					}
-				}
-			}
-		}
-
-		hash := c.Hash.String()
-		shortHash := hash
-		if len(hash) > 8 {
-			shortHash = hash[:8]
-		}
-
-		commits = append(commits, CommitInfo{
-			Hash:     shortHash,
-			FullHash: hash,
-			Message:  strings.TrimSpace(c.Message),
-			Author:   c.Author.Name,
-			Email:    c.Author.Email,
-			Date:     c.Author.When,
-			Files:    files,
-		})
+		commits = append(commits, r.commitInfo(c))
 
 		count++
 		return nil
@@ -372,6 +355,108 @@ func (r *Repository) GetHistory(path string, maxCount int) ([]CommitInfo, error)
 	return commits, nil
 }
 
+// commitInfo builds the CommitInfo for a commit, including the files it
+// changed relative to its first parent (or all of its files, for a root
+// commit) and its signature status
+func (r *Repository) commitInfo(c *object.Commit) CommitInfo {
+	files, _ := changedFiles(c)
+
+	hash := c.Hash.String()
+	shortHash := hash
+	if len(hash) > 8 {
+		shortHash = hash[:8]
+	}
+
+	return CommitInfo{
+		Hash:     shortHash,
+		FullHash: hash,
+		Message:  strings.TrimSpace(c.Message),
+		Author:   c.Author.Name,
+		Email:    c.Author.Email,
+		Date:     c.Author.When,
+		Files:    files,
+
+		Signature: r.verifier.Verify(c),
+		Job:       ParseJobTrailers(c.Message),
+	}
+}
+
+// ResolveCommit resolves a revision to the commit it names
+// Accepts anything git does for a single commit: full or abbreviated
+// hashes, branch and tag names, HEAD, and suffixes such as HEAD~2
+//
+// Parameters:
+//   - rev: The revision to resolve
+//
+// Returns:
+//   - *CommitInfo: The commit, with the files it changed
+//   - error: If the revision doesn't name a commit
+func (r *Repository) ResolveCommit(rev string) (*CommitInfo, error) {
+	hash, err := r.repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return nil, fmt.Errorf("revision %s not found: %w", rev, err)
+	}
+
+	commit, err := r.repo.CommitObject(*hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit %s: %w", rev, err)
+	}
+
+	info := r.commitInfo(commit)
+	return &info, nil
+}
+
+// ListFilesAt lists the files under a directory as of a commit
+// Unlike ListFiles, this reads the committed tree (not the working
+// directory) and walks subdirectories
+//
+// Parameters:
+//   - commitHash: The full commit hash
+//   - dir: Relative directory path ("" for the whole repository)
+//
+// Returns:
+//   - []string: Paths relative to the repository root, sorted
+//   - error: Any error that occurred
+func (r *Repository) ListFilesAt(commitHash, dir string) ([]string, error) {
+	commit, err := r.repo.CommitObject(plumbing.NewHash(commitHash))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit %s: %w", commitHash, err)
+	}
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tree: %w", err)
+	}
+
+	prefix := strings.Trim(dir, "/")
+	if prefix == "." {
+		prefix = ""
+	}
+	if prefix != "" {
+		tree, err = tree.Tree(prefix)
+		if err == object.ErrDirectoryNotFound {
+			// Directory doesn't exist at this commit - return empty list
+			return []string{}, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read directory %s at commit %s: %w", dir, commitHash, err)
+		}
+		prefix += "/"
+	}
+
+	files := []string{}
+	err = tree.Files().ForEach(func(f *object.File) error {
+		files = append(files, prefix+f.Name)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files at commit %s: %w", commitHash, err)
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
 // GetFileAtCommit retrieves the content of a file at a specific commit
 //
 // Parameters:
@@ -0,0 +1,617 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	pgperrors "github.com/ProtonMail/go-crypto/openpgp/errors"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/wlame/njgit/internal/config"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// SigningPassphraseEnv is the environment variable holding the passphrase
+// for a passphrase-protected signing key
+const SigningPassphraseEnv = "NJGIT_SIGNING_PASSPHRASE"
+
+// Signature statuses reported for commits
+const (
+	// SignatureUnsigned means the commit has no signature
+	SignatureUnsigned = "unsigned"
+
+	// SignatureVerified means the signature is valid and made by a trusted key
+	SignatureVerified = "verified"
+
+	// SignatureUnverified means the commit is signed, but not by a trusted
+	// key, so the signature can't be (or wasn't) checked against an identity
+	SignatureUnverified = "unverified"
+
+	// SignatureBad means the signature doesn't match the commit
+	SignatureBad = "bad"
+)
+
+// SignatureInfo describes the signature of a commit
+type SignatureInfo struct {
+	Type   string // "gpg", "ssh", "github", or "" when unsigned
+	Status string // One of the Signature* statuses
+	Signer string // Signer identity if verified, else the key ID or why it isn't verified
+}
+
+// String formats the signature for display, e.g.
+// "verified (ssh: alice@example.com)"
+func (s SignatureInfo) String() string {
+	if s.Status == "" || s.Status == SignatureUnsigned {
+		return SignatureUnsigned
+	}
+	if s.Signer == "" {
+		return fmt.Sprintf("%s (%s)", s.Status, s.Type)
+	}
+	return fmt.Sprintf("%s (%s: %s)", s.Status, s.Type, s.Signer)
+}
+
+// sshSignatureHeader starts an armored SSH signature (PROTOCOL.sshsig)
+const sshSignatureHeader = "-----BEGIN SSH SIGNATURE-----"
+
+// sshSignatureNamespace is the namespace git uses for SSH signatures
+const sshSignatureNamespace = "git"
+
+// NewSigner creates the signer for git.sign
+//
+// Parameters:
+//   - cfg: Git configuration (sign and signing_key)
+//
+// Returns:
+//   - git.Signer: The signer, or nil if signing is off
+//   - error: If the signing key can't be loaded
+func NewSigner(cfg *config.GitConfig) (git.Signer, error) {
+	switch cfg.Sign {
+	case "":
+		return nil, nil
+	case "gpg":
+		entity, err := loadGPGSigningKey(cfg.SigningKey)
+		if err != nil {
+			return nil, err
+		}
+		return &gpgSigner{entity: entity}, nil
+	case "ssh":
+		signer, err := loadSSHSigningKey(cfg.SigningKey)
+		if err != nil {
+			return nil, err
+		}
+		return &sshSigner{signer: signer}, nil
+	default:
+		return nil, fmt.Errorf("unsupported signing format: %s (supported: gpg, ssh)", cfg.Sign)
+	}
+}
+
+// SetSigning sets the signer for new commits and the verifier for commits
+// read from history
+func (r *Repository) SetSigning(signer git.Signer, verifier *Verifier) {
+	r.signer = signer
+	r.verifier = verifier
+}
+
+// signCommit signs a commit object built outside of a worktree commit
+func (r *Repository) signCommit(c *object.Commit) (string, error) {
+	encoded := &plumbing.MemoryObject{}
+	if err := c.EncodeWithoutSignature(encoded); err != nil {
+		return "", fmt.Errorf("failed to encode commit: %w", err)
+	}
+	reader, err := encoded.Reader()
+	if err != nil {
+		return "", fmt.Errorf("failed to encode commit: %w", err)
+	}
+	signature, err := r.signer.Sign(reader)
+	if err != nil {
+		return "", err
+	}
+	return string(signature), nil
+}
+
+// gpgSigner signs commits with an OpenPGP key
+type gpgSigner struct {
+	entity *openpgp.Entity
+}
+
+// Sign creates an armored detached OpenPGP signature
+func (s *gpgSigner) Sign(message io.Reader) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := openpgp.ArmoredDetachSign(&buf, s.entity, message, nil); err != nil {
+		return nil, fmt.Errorf("failed to sign with GPG key: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// sshSigner signs commits with an SSH key, in the format git uses for
+// gpg.format = ssh
+type sshSigner struct {
+	signer ssh.Signer
+}
+
+// Sign creates an armored SSH signature (PROTOCOL.sshsig)
+func (s *sshSigner) Sign(message io.Reader) ([]byte, error) {
+	h := sha512.New()
+	if _, err := io.Copy(h, message); err != nil {
+		return nil, fmt.Errorf("failed to read commit: %w", err)
+	}
+	signed := sshSignedData(sshSignatureNamespace, "sha512", h.Sum(nil))
+
+	// RSA keys must not sign with SHA-1 (ssh-rsa); git requires rsa-sha2-512
+	var sig *ssh.Signature
+	var err error
+	if algSigner, ok := s.signer.(ssh.AlgorithmSigner); ok && s.signer.PublicKey().Type() == ssh.KeyAlgoRSA {
+		sig, err = algSigner.SignWithAlgorithm(rand.Reader, signed, ssh.KeyAlgoRSASHA512)
+	} else {
+		sig, err = s.signer.Sign(rand.Reader, signed)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign with SSH key: %w", err)
+	}
+
+	var blob []byte
+	blob = append(blob, "SSHSIG"...)
+	blob = binary.BigEndian.AppendUint32(blob, 1)
+	blob = appendSSHString(blob, s.signer.PublicKey().Marshal())
+	blob = appendSSHString(blob, []byte(sshSignatureNamespace))
+	blob = appendSSHString(blob, nil)
+	blob = appendSSHString(blob, []byte("sha512"))
+	blob = appendSSHString(blob, ssh.Marshal(sig))
+
+	// ssh-keygen wraps the base64 body at 70 columns
+	encoded := base64.StdEncoding.EncodeToString(blob)
+	var buf bytes.Buffer
+	buf.WriteString(sshSignatureHeader + "\n")
+	for len(encoded) > 70 {
+		buf.WriteString(encoded[:70] + "\n")
+		encoded = encoded[70:]
+	}
+	buf.WriteString(encoded + "\n")
+	buf.WriteString("-----END SSH SIGNATURE-----\n")
+	return buf.Bytes(), nil
+}
+
+// sshSignedData builds the data an SSH signature is computed over
+func sshSignedData(namespace, hashAlg string, digest []byte) []byte {
+	var data []byte
+	data = append(data, "SSHSIG"...)
+	data = appendSSHString(data, []byte(namespace))
+	data = appendSSHString(data, nil)
+	data = appendSSHString(data, []byte(hashAlg))
+	data = appendSSHString(data, digest)
+	return data
+}
+
+// appendSSHString appends a length-prefixed SSH wire-format string
+func appendSSHString(b, s []byte) []byte {
+	b = binary.BigEndian.AppendUint32(b, uint32(len(s)))
+	return append(b, s...)
+}
+
+// loadGPGSigningKey loads the first secret key from an armored key file
+func loadGPGSigningKey(path string) (*openpgp.Entity, error) {
+	path, err := expandHome(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open GPG signing key: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	keyring, err := openpgp.ReadArmoredKeyRing(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read GPG signing key %s: %w", path, err)
+	}
+
+	for _, entity := range keyring {
+		if entity.PrivateKey == nil {
+			continue
+		}
+		if entity.PrivateKey.Encrypted {
+			passphrase := os.Getenv(SigningPassphraseEnv)
+			if passphrase == "" {
+				return nil, fmt.Errorf("GPG signing key %s is passphrase-protected (set %s)", path, SigningPassphraseEnv)
+			}
+			if err := entity.DecryptPrivateKeys([]byte(passphrase)); err != nil {
+				return nil, fmt.Errorf("failed to unlock GPG signing key %s: %w", path, err)
+			}
+		}
+		return entity, nil
+	}
+
+	return nil, fmt.Errorf("no secret key found in %s (export it with: gpg --export-secret-keys --armor <key-id>)", path)
+}
+
+// loadSSHSigningKey loads the SSH key to sign with
+// A private key file is used directly; a public key file (.pub) selects the
+// matching key in the SSH agent; no path uses the agent's first key.
+func loadSSHSigningKey(path string) (ssh.Signer, error) {
+	if path == "" || strings.HasSuffix(path, ".pub") {
+		return sshAgentSigner(path)
+	}
+
+	path, err := expandHome(path)
+	if err != nil {
+		return nil, err
+	}
+
+	pemBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SSH signing key: %w", err)
+	}
+
+	signer, err := ssh.ParsePrivateKey(pemBytes)
+	var missing *ssh.PassphraseMissingError
+	if errors.As(err, &missing) {
+		passphrase := os.Getenv(SigningPassphraseEnv)
+		if passphrase == "" {
+			return nil, fmt.Errorf("SSH signing key %s is passphrase-protected (set %s or use the SSH agent)", path, SigningPassphraseEnv)
+		}
+		signer, err = ssh.ParsePrivateKeyWithPassphrase(pemBytes, []byte(passphrase))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load SSH signing key %s: %w", path, err)
+	}
+
+	return signer, nil
+}
+
+// sshAgentSigner picks a signing key from the SSH agent: the one matching
+// the public key file, or the first one
+func sshAgentSigner(pubKeyPath string) (ssh.Signer, error) {
+	var want ssh.PublicKey
+	if pubKeyPath != "" {
+		path, err := expandHome(pubKeyPath)
+		if err != nil {
+			return nil, err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read SSH signing key: %w", err)
+		}
+		want, _, _, _, err = ssh.ParseAuthorizedKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse SSH public key %s: %w", pubKeyPath, err)
+		}
+	}
+
+	socket := os.Getenv("SSH_AUTH_SOCK")
+	if socket == "" {
+		return nil, fmt.Errorf("SSH agent not available (SSH_AUTH_SOCK is not set); set signing_key to a private key file")
+	}
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to SSH agent: %w", err)
+	}
+
+	// The connection stays open: the agent signs each commit
+	signers, err := agent.NewClient(conn).Signers()
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("failed to list SSH agent keys: %w", err)
+	}
+
+	for _, signer := range signers {
+		if want == nil || bytes.Equal(signer.PublicKey().Marshal(), want.Marshal()) {
+			return signer, nil
+		}
+	}
+
+	_ = conn.Close()
+	if want != nil {
+		return nil, fmt.Errorf("SSH agent has no key matching %s", pubKeyPath)
+	}
+	return nil, fmt.Errorf("SSH agent has no keys")
+}
+
+// expandHome expands a leading ~ to the home directory
+func expandHome(path string) (string, error) {
+	if !strings.HasPrefix(path, "~") {
+		return path, nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, path[1:]), nil
+}
+
+// Verifier checks commit signatures against trusted keys
+// A nil Verifier trusts no keys: signed commits are reported as unverified
+// (or bad, for SSH signatures that don't match the commit)
+type Verifier struct {
+	gpgKeyring openpgp.EntityList
+	allowed    []allowedSigner
+}
+
+// allowedSigner is one entry of an SSH allowed signers file
+type allowedSigner struct {
+	principals string
+	key        ssh.PublicKey
+}
+
+// NewVerifier creates the verifier for git.gpg_keyring and
+// git.allowed_signers
+// The key of the signer njgit signs with is trusted as well, so its own
+// commits verify without further setup.
+//
+// Parameters:
+//   - cfg: Git configuration
+//   - signer: The signer from NewSigner (may be nil)
+//
+// Returns:
+//   - *Verifier: The verifier
+//   - error: If a keyring or allowed signers file can't be read
+func NewVerifier(cfg *config.GitConfig, signer git.Signer) (*Verifier, error) {
+	v := &Verifier{}
+
+	if cfg.GPGKeyring != "" {
+		path, err := expandHome(cfg.GPGKeyring)
+		if err != nil {
+			return nil, err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open GPG keyring: %w", err)
+		}
+		defer func() { _ = f.Close() }()
+
+		v.gpgKeyring, err = openpgp.ReadArmoredKeyRing(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read GPG keyring %s: %w", path, err)
+		}
+	}
+
+	if cfg.AllowedSigners != "" {
+		allowed, err := readAllowedSigners(cfg.AllowedSigners)
+		if err != nil {
+			return nil, err
+		}
+		v.allowed = allowed
+	}
+
+	switch s := signer.(type) {
+	case *gpgSigner:
+		v.gpgKeyring = append(v.gpgKeyring, s.entity)
+	case *sshSigner:
+		key := s.signer.PublicKey()
+		v.allowed = append(v.allowed, allowedSigner{principals: ssh.FingerprintSHA256(key), key: key})
+	}
+
+	return v, nil
+}
+
+// readAllowedSigners parses an SSH allowed signers file
+// Each line is: principals [options] key-type base64-key [comment]
+// Entries restricted to namespaces other than "git" are skipped.
+func readAllowedSigners(path string) ([]allowedSigner, error) {
+	path, err := expandHome(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open allowed signers file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var allowed []allowedSigner
+	scanner := bufio.NewScanner(f)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		principals, rest, _ := strings.Cut(line, " ")
+		key, _, options, _, err := ssh.ParseAuthorizedKey([]byte(strings.TrimSpace(rest)))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid allowed signer: %w", path, lineNo, err)
+		}
+
+		if !allowsGitNamespace(options) {
+			continue
+		}
+		allowed = append(allowed, allowedSigner{principals: principals, key: key})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read allowed signers file: %w", err)
+	}
+
+	return allowed, nil
+}
+
+// allowsGitNamespace reports whether an allowed signer's options permit
+// signatures in the "git" namespace
+func allowsGitNamespace(options []string) bool {
+	for _, option := range options {
+		name, value, ok := strings.Cut(option, "=")
+		if !ok || !strings.EqualFold(name, "namespaces") {
+			continue
+		}
+		for _, ns := range strings.Split(strings.Trim(value, `"`), ",") {
+			if strings.TrimSpace(ns) == sshSignatureNamespace {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// Verify checks a commit's signature
+//
+// Parameters:
+//   - c: The commit
+//
+// Returns:
+//   - SignatureInfo: The signature type, status and signer
+func (v *Verifier) Verify(c *object.Commit) SignatureInfo {
+	if c.PGPSignature == "" {
+		return SignatureInfo{Status: SignatureUnsigned}
+	}
+
+	encoded := &plumbing.MemoryObject{}
+	if err := c.EncodeWithoutSignature(encoded); err != nil {
+		return SignatureInfo{Status: SignatureBad}
+	}
+	reader, err := encoded.Reader()
+	if err != nil {
+		return SignatureInfo{Status: SignatureBad}
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return SignatureInfo{Status: SignatureBad}
+	}
+
+	if strings.HasPrefix(strings.TrimSpace(c.PGPSignature), sshSignatureHeader) {
+		return v.verifySSH(data, c.PGPSignature)
+	}
+	return v.verifyGPG(data, c.PGPSignature)
+}
+
+// verifyGPG checks an OpenPGP signature against the trusted keyring
+func (v *Verifier) verifyGPG(data []byte, signature string) SignatureInfo {
+	info := SignatureInfo{Type: "gpg"}
+
+	var keyring openpgp.EntityList
+	if v != nil {
+		keyring = v.gpgKeyring
+	}
+
+	entity, err := openpgp.CheckArmoredDetachedSignature(keyring, bytes.NewReader(data), strings.NewReader(signature), nil)
+	switch {
+	case err == nil:
+		info.Status = SignatureVerified
+		if identity := entity.PrimaryIdentity(); identity != nil {
+			info.Signer = identity.Name
+		} else {
+			info.Signer = fmt.Sprintf("key %016X", entity.PrimaryKey.KeyId)
+		}
+	case errors.Is(err, pgperrors.ErrUnknownIssuer):
+		info.Status = SignatureUnverified
+		info.Signer = gpgIssuer(signature)
+	default:
+		info.Status = SignatureBad
+		info.Signer = gpgIssuer(signature)
+	}
+
+	return info
+}
+
+// gpgIssuer returns the ID of the key that made an OpenPGP signature
+func gpgIssuer(signature string) string {
+	block, err := armor.Decode(strings.NewReader(signature))
+	if err != nil {
+		return ""
+	}
+	p, err := packet.Read(block.Body)
+	if err != nil {
+		return ""
+	}
+	sig, ok := p.(*packet.Signature)
+	if !ok || sig.IssuerKeyId == nil {
+		return ""
+	}
+	return fmt.Sprintf("key %016X", *sig.IssuerKeyId)
+}
+
+// verifySSH checks an SSH signature, then looks its key up in the allowed
+// signers
+func (v *Verifier) verifySSH(data []byte, signature string) SignatureInfo {
+	info := SignatureInfo{Type: "ssh", Status: SignatureBad}
+
+	block, _ := pem.Decode([]byte(signature))
+	if block == nil || block.Type != "SSH SIGNATURE" {
+		return info
+	}
+
+	blob := block.Bytes
+	if len(blob) < 10 || string(blob[:6]) != "SSHSIG" || binary.BigEndian.Uint32(blob[6:10]) != 1 {
+		return info
+	}
+	fields, ok := readSSHStrings(blob[10:], 5)
+	if !ok {
+		return info
+	}
+	pubBlob, namespace, hashAlg, sigBlob := fields[0], string(fields[1]), string(fields[3]), fields[4]
+
+	key, err := ssh.ParsePublicKey(pubBlob)
+	if err != nil {
+		return info
+	}
+	info.Signer = ssh.FingerprintSHA256(key)
+
+	var h hash.Hash
+	switch hashAlg {
+	case "sha512":
+		h = sha512.New()
+	case "sha256":
+		h = sha256.New()
+	default:
+		return info
+	}
+	h.Write(data)
+
+	sig := new(ssh.Signature)
+	if err := ssh.Unmarshal(sigBlob, sig); err != nil {
+		return info
+	}
+	if namespace != sshSignatureNamespace || key.Verify(sshSignedData(namespace, hashAlg, h.Sum(nil)), sig) != nil {
+		return info
+	}
+
+	info.Status = SignatureUnverified
+	if v != nil {
+		for _, signer := range v.allowed {
+			if bytes.Equal(signer.key.Marshal(), key.Marshal()) {
+				info.Status = SignatureVerified
+				info.Signer = signer.principals
+				break
+			}
+		}
+	}
+
+	return info
+}
+
+// readSSHStrings reads n length-prefixed SSH wire-format strings
+func readSSHStrings(b []byte, n int) ([][]byte, bool) {
+	fields := make([][]byte, 0, n)
+	for i := 0; i < n; i++ {
+		if len(b) < 4 {
+			return nil, false
+		}
+		length := binary.BigEndian.Uint32(b)
+		b = b[4:]
+		if uint32(len(b)) < length {
+			return nil, false
+		}
+		fields = append(fields, b[:length])
+		b = b[length:]
+	}
+	return fields, true
+}
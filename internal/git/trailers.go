@@ -0,0 +1,150 @@
+package git
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Trailer keys njgit writes at the end of its commit messages, so tools can
+// tell which job a commit is about without parsing the subject
+const (
+	TrailerJob            = "Nomad-Job"
+	TrailerNamespace      = "Nomad-Namespace"
+	TrailerRegion         = "Nomad-Region"
+	TrailerMultiregion    = "Nomad-Multiregion"
+	TrailerJobVersion     = "Nomad-Job-Version"
+	TrailerJobModifyIndex = "Nomad-Job-Modify-Index"
+	TrailerCluster        = "Nomad-Cluster"
+	TrailerAction         = "Njgit-Action"
+)
+
+// Values of the Njgit-Action trailer
+const (
+	// ActionSync records a job fetched from Nomad
+	ActionSync = "sync"
+
+	// ActionDeploy records a job deployed to Nomad from the repository
+	ActionDeploy = "deploy"
+
+	// ActionDelete records a job removed from the repository. Nothing
+	// writes it yet: sync keeps the files of jobs that are gone from Nomad
+	ActionDelete = "delete"
+
+	// ActionPropose records a job change proposed in a pull request
+	ActionPropose = "propose"
+)
+
+// MultiregionDir is the top-level directory multiregion jobs are stored
+// under, instead of a region directory
+const MultiregionDir = "_multiregion"
+
+// JobTrailers is the job identity recorded in a commit's trailers
+type JobTrailers struct {
+	Job       string
+	Namespace string
+	Region    string
+	Cluster   string
+	Action    string
+
+	// Multiregion marks a multiregion job: Region is the region it was
+	// fetched from, and the file lives under MultiregionDir
+	Multiregion bool
+
+	// Version and ModifyIndex are the Nomad job's version and modify
+	// index at the time of the commit (nil if not recorded)
+	Version     *uint64
+	ModifyIndex *uint64
+}
+
+// Path returns the job path: <region>/<namespace>/<job>, or
+// _multiregion/<namespace>/<job> for multiregion jobs
+func (t *JobTrailers) Path() string {
+	if t.Multiregion {
+		return fmt.Sprintf("%s/%s/%s", MultiregionDir, t.Namespace, t.Job)
+	}
+	return fmt.Sprintf("%s/%s/%s", t.Region, t.Namespace, t.Job)
+}
+
+// Format renders the trailers as the lines of a trailer block
+// Empty fields are left out.
+func (t *JobTrailers) Format() string {
+	var lines []string
+	add := func(key, value string) {
+		if value != "" {
+			lines = append(lines, fmt.Sprintf("%s: %s", key, value))
+		}
+	}
+
+	add(TrailerJob, t.Job)
+	add(TrailerNamespace, t.Namespace)
+	add(TrailerRegion, t.Region)
+	if t.Multiregion {
+		add(TrailerMultiregion, "true")
+	}
+	if t.Version != nil {
+		add(TrailerJobVersion, strconv.FormatUint(*t.Version, 10))
+	}
+	if t.ModifyIndex != nil {
+		add(TrailerJobModifyIndex, strconv.FormatUint(*t.ModifyIndex, 10))
+	}
+	add(TrailerCluster, t.Cluster)
+	add(TrailerAction, t.Action)
+
+	return strings.Join(lines, "\n")
+}
+
+// ParseJobTrailers reads the job trailers from a commit message
+// Like git, only the last paragraph is considered, and only if every line
+// in it is a "Key: value" trailer. Keys are matched case-insensitively.
+//
+// Parameters:
+//   - message: The full commit message
+//
+// Returns:
+//   - *JobTrailers: The trailers, or nil if there is no Nomad-Job trailer
+func ParseJobTrailers(message string) *JobTrailers {
+	message = strings.TrimRight(message, "\n")
+	paragraphs := strings.Split(message, "\n\n")
+	if len(paragraphs) < 2 {
+		// A subject alone has no trailers
+		return nil
+	}
+
+	trailers := &JobTrailers{}
+	for _, line := range strings.Split(paragraphs[len(paragraphs)-1], "\n") {
+		key, value, ok := strings.Cut(line, ": ")
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return nil
+		}
+		value = strings.TrimSpace(value)
+
+		switch {
+		case strings.EqualFold(key, TrailerJob):
+			trailers.Job = value
+		case strings.EqualFold(key, TrailerNamespace):
+			trailers.Namespace = value
+		case strings.EqualFold(key, TrailerRegion):
+			trailers.Region = value
+		case strings.EqualFold(key, TrailerMultiregion):
+			trailers.Multiregion, _ = strconv.ParseBool(value)
+		case strings.EqualFold(key, TrailerCluster):
+			trailers.Cluster = value
+		case strings.EqualFold(key, TrailerAction):
+			trailers.Action = value
+		case strings.EqualFold(key, TrailerJobVersion):
+			if n, err := strconv.ParseUint(value, 10, 64); err == nil {
+				trailers.Version = &n
+			}
+		case strings.EqualFold(key, TrailerJobModifyIndex):
+			if n, err := strconv.ParseUint(value, 10, 64); err == nil {
+				trailers.ModifyIndex = &n
+			}
+		}
+	}
+
+	if trailers.Job == "" {
+		return nil
+	}
+	return trailers
+}
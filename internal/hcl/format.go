@@ -3,11 +3,14 @@
 package hcl
 
 import (
+	"context"
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/nomad/api"
+	"github.com/wlame/njgit/internal/nomad"
 )
 
 // FormatJobAsHCL converts a Nomad job to HCL format
@@ -94,11 +97,34 @@ func writeJobAttributes(b *strings.Builder, job *api.Job) {
 		writeMapBlock(b, 1, "meta", job.Meta)
 	}
 
+	// Batch launch settings: cron schedule and dispatch parameters
+	if job.Periodic != nil {
+		writePeriodicBlock(b, 1, job.Periodic)
+	}
+
+	if job.ParameterizedJob != nil {
+		writeParameterizedBlock(b, 1, job.ParameterizedJob)
+	}
+
 	// Update strategy
 	if job.Update != nil {
 		writeUpdateBlock(b, 1, job.Update)
 	}
 
+	// Reschedule and migrate policies set at the job level apply to all groups
+	if job.Reschedule != nil {
+		writeRescheduleBlock(b, 1, job.Reschedule)
+	}
+
+	if job.Migrate != nil {
+		writeMigrateBlock(b, 1, job.Migrate)
+	}
+
+	// Multiregion deployment (regions the job is fanned out to)
+	if job.Multiregion != nil {
+		writeMultiregionBlock(b, 1, job.Multiregion)
+	}
+
 	// Add a blank line after attributes for readability
 	b.WriteString("\n")
 }
@@ -120,6 +146,63 @@ func writeTaskGroup(b *strings.Builder, tg *api.TaskGroup) {
 		writeIntAttribute(b, 2, "count", *tg.Count)
 	}
 
+	// Scaling policy (count bounds and autoscaler policy)
+	if tg.Scaling != nil {
+		writeScalingBlock(b, 2, tg.Scaling)
+	}
+
+	// Update strategy (group-level; Nomad merges in the job-level strategy)
+	if tg.Update != nil {
+		writeUpdateBlock(b, 2, tg.Update)
+	}
+
+	// Shutdown delay (time between deregistering services and killing tasks)
+	if tg.ShutdownDelay != nil && *tg.ShutdownDelay > 0 {
+		writeDurationAttribute(b, 2, "shutdown_delay", *tg.ShutdownDelay)
+	}
+
+	// Restart, reschedule and migrate policies
+	if tg.RestartPolicy != nil {
+		writeRestartBlock(b, 2, tg.RestartPolicy)
+	}
+
+	if tg.ReschedulePolicy != nil {
+		writeRescheduleBlock(b, 2, tg.ReschedulePolicy)
+	}
+
+	if tg.Migrate != nil {
+		writeMigrateBlock(b, 2, tg.Migrate)
+	}
+
+	// Ephemeral disk (local scratch space shared by the group's tasks)
+	if tg.EphemeralDisk != nil {
+		writeEphemeralDiskBlock(b, 2, tg.EphemeralDisk)
+	}
+
+	// Volumes (host and CSI volume requests)
+	// Volumes is a map keyed by name, so sort for consistent output
+	if len(tg.Volumes) > 0 {
+		names := make([]string, 0, len(tg.Volumes))
+		for name := range tg.Volumes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			writeVolumeBlock(b, 2, name, tg.Volumes[name])
+		}
+	}
+
+	// Networks (mode and port labels used by services)
+	for _, network := range tg.Networks {
+		writeNetworkBlock(b, 2, network)
+	}
+
+	// Group services (including Consul Connect sidecars and gateways)
+	for _, service := range tg.Services {
+		writeServiceBlock(b, 2, service)
+	}
+
 	// Meta
 	if len(tg.Meta) > 0 {
 		writeMapBlock(b, 2, "meta", tg.Meta)
@@ -155,6 +238,28 @@ func writeTask(b *strings.Builder, task *api.Task) {
 		writeAttribute(b, 3, "driver", task.Driver)
 	}
 
+	// User to run the task as
+	writeAttribute(b, 3, "user", task.User)
+
+	// Leader task (other tasks in the group are killed when it exits)
+	if task.Leader {
+		writeBoolAttribute(b, 3, "leader", true)
+	}
+
+	// Kill and shutdown settings
+	if task.KillTimeout != nil && *task.KillTimeout > 0 {
+		writeDurationAttribute(b, 3, "kill_timeout", *task.KillTimeout)
+	}
+	writeAttribute(b, 3, "kill_signal", task.KillSignal)
+	if task.ShutdownDelay > 0 {
+		writeDurationAttribute(b, 3, "shutdown_delay", task.ShutdownDelay)
+	}
+
+	// Lifecycle hook (prestart/poststart/poststop, optionally as a sidecar)
+	if task.Lifecycle != nil {
+		writeLifecycleBlock(b, 3, task.Lifecycle)
+	}
+
 	// Config (driver-specific configuration)
 	if len(task.Config) > 0 {
 		writeConfigBlock(b, 3, task.Config)
@@ -170,6 +275,54 @@ func writeTask(b *strings.Builder, task *api.Task) {
 		writeResourcesBlock(b, 3, task.Resources)
 	}
 
+	// Task services
+	for _, service := range task.Services {
+		writeServiceBlock(b, 3, service)
+	}
+
+	// Volume mounts (where group volumes appear inside the task)
+	for _, mount := range task.VolumeMounts {
+		writeVolumeMountBlock(b, 3, mount)
+	}
+
+	// Artifacts (files downloaded before the task starts)
+	for _, artifact := range task.Artifacts {
+		writeArtifactBlock(b, 3, artifact)
+	}
+
+	// Dispatch payload (where a dispatched job's input is written)
+	if task.DispatchPayload != nil {
+		writeDispatchPayloadBlock(b, 3, task.DispatchPayload)
+	}
+
+	// Templates (rendered config files)
+	for _, tmpl := range task.Templates {
+		writeTemplateBlock(b, 3, tmpl)
+	}
+
+	// Vault integration
+	if task.Vault != nil {
+		writeVaultBlock(b, 3, task.Vault)
+	}
+
+	// Workload identities (default identity first, then named ones)
+	if task.Identity != nil {
+		writeIdentityBlock(b, 3, task.Identity)
+	}
+	for _, identity := range task.Identities {
+		writeIdentityBlock(b, 3, identity)
+	}
+
+	// Task-level restart policy (overrides the group policy)
+	if task.RestartPolicy != nil {
+		writeRestartBlock(b, 3, task.RestartPolicy)
+	}
+
+	// Log rotation settings
+	if task.LogConfig != nil {
+		writeLogsBlock(b, 3, task.LogConfig)
+	}
+
 	// Meta
 	if len(task.Meta) > 0 {
 		writeMapBlock(b, 3, "meta", task.Meta)
@@ -197,6 +350,81 @@ func writeUpdateBlock(b *strings.Builder, level int, update *api.UpdateStrategy)
 		writeAttribute(b, level+1, "health_check", *update.HealthCheck)
 	}
 
+	// Timing and rollout safety settings. Like the restart and reschedule
+	// policies, every field that is set is written so changes to canary or
+	// auto_revert always show up in the history.
+	if update.MinHealthyTime != nil {
+		writeDurationAttribute(b, level+1, "min_healthy_time", *update.MinHealthyTime)
+	}
+	if update.HealthyDeadline != nil {
+		writeDurationAttribute(b, level+1, "healthy_deadline", *update.HealthyDeadline)
+	}
+	if update.ProgressDeadline != nil {
+		writeDurationAttribute(b, level+1, "progress_deadline", *update.ProgressDeadline)
+	}
+	if update.Stagger != nil {
+		writeDurationAttribute(b, level+1, "stagger", *update.Stagger)
+	}
+	if update.Canary != nil {
+		writeIntAttribute(b, level+1, "canary", *update.Canary)
+	}
+	if update.AutoRevert != nil {
+		writeBoolAttribute(b, level+1, "auto_revert", *update.AutoRevert)
+	}
+	if update.AutoPromote != nil {
+		writeBoolAttribute(b, level+1, "auto_promote", *update.AutoPromote)
+	}
+
+	indent(b, level)
+	b.WriteString("}\n")
+}
+
+// writeMultiregionBlock writes a multiregion block
+// The strategy block is omitted when it only holds Nomad's defaults
+func writeMultiregionBlock(b *strings.Builder, level int, multiregion *api.Multiregion) {
+	indent(b, level)
+	b.WriteString("multiregion {\n")
+
+	if strategy := multiregion.Strategy; strategy != nil {
+		maxParallel := 0
+		if strategy.MaxParallel != nil {
+			maxParallel = *strategy.MaxParallel
+		}
+		onFailure := stringValue(strategy.OnFailure)
+
+		if maxParallel != 0 || onFailure != "" {
+			indent(b, level+1)
+			b.WriteString("strategy {\n")
+			if maxParallel != 0 {
+				writeIntAttribute(b, level+2, "max_parallel", maxParallel)
+			}
+			writeAttribute(b, level+2, "on_failure", onFailure)
+			indent(b, level+1)
+			b.WriteString("}\n")
+		}
+	}
+
+	// Region order is significant: it is the rollout order
+	for _, region := range multiregion.Regions {
+		if region == nil {
+			continue
+		}
+		indent(b, level+1)
+		fmt.Fprintf(b, "region \"%s\" {\n", escapeString(region.Name))
+		if region.Count != nil {
+			writeIntAttribute(b, level+2, "count", *region.Count)
+		}
+		if len(region.Datacenters) > 0 {
+			writeListAttribute(b, level+2, "datacenters", region.Datacenters)
+		}
+		writeAttribute(b, level+2, "node_pool", region.NodePool)
+		if len(region.Meta) > 0 {
+			writeMapBlock(b, level+2, "meta", region.Meta)
+		}
+		indent(b, level+1)
+		b.WriteString("}\n")
+	}
+
 	indent(b, level)
 	b.WriteString("}\n")
 }
@@ -214,40 +442,181 @@ func writeResourcesBlock(b *strings.Builder, level int, resources *api.Resources
 		writeIntAttribute(b, level+1, "cpu", *resources.CPU)
 	}
 
+	if resources.Cores != nil && *resources.Cores > 0 {
+		writeIntAttribute(b, level+1, "cores", *resources.Cores)
+	}
+
 	if resources.MemoryMB != nil && *resources.MemoryMB > 0 {
 		writeIntAttribute(b, level+1, "memory", *resources.MemoryMB)
 	}
 
+	// memory_max enables memory oversubscription up to this limit
+	if resources.MemoryMaxMB != nil && *resources.MemoryMaxMB > 0 {
+		writeIntAttribute(b, level+1, "memory_max", *resources.MemoryMaxMB)
+	}
+
+	if resources.DiskMB != nil && *resources.DiskMB > 0 {
+		writeIntAttribute(b, level+1, "disk", *resources.DiskMB)
+	}
+
+	// Device requests (GPUs, FPGAs, ...)
+	for _, device := range resources.Devices {
+		writeDeviceBlock(b, level+1, device)
+	}
+
 	indent(b, level)
 	b.WriteString("}\n")
 }
 
-// writeConfigBlock writes a config block with driver-specific settings
-func writeConfigBlock(b *strings.Builder, level int, config map[string]interface{}) {
-	if len(config) == 0 {
+// writeDeviceBlock writes a device request inside a resources block
+func writeDeviceBlock(b *strings.Builder, level int, device *api.RequestedDevice) {
+	if device == nil || device.Name == "" {
 		return
 	}
 
 	indent(b, level)
-	b.WriteString("config {\n")
+	fmt.Fprintf(b, "device \"%s\" {\n", escapeString(device.Name))
 
-	// Sort keys for consistent output
-	keys := make([]string, 0, len(config))
-	for k := range config {
-		keys = append(keys, k)
+	if device.Count != nil {
+		indent(b, level+1)
+		fmt.Fprintf(b, "count = %d\n", *device.Count)
 	}
-	sort.Strings(keys)
 
-	// Write each config value
-	for _, key := range keys {
-		value := config[key]
-		writeConfigValue(b, level+1, key, value)
+	for _, constraint := range device.Constraints {
+		writeConstraintBlock(b, level+1, "constraint", constraint.LTarget, constraint.Operand, constraint.RTarget, nil)
+	}
+	for _, affinity := range device.Affinities {
+		writeConstraintBlock(b, level+1, "affinity", affinity.LTarget, affinity.Operand, affinity.RTarget, affinity.Weight)
 	}
 
 	indent(b, level)
 	b.WriteString("}\n")
 }
 
+// writeConstraintBlock writes a constraint or affinity block
+// The two share attribute/operator/value; affinities also carry a weight
+func writeConstraintBlock(b *strings.Builder, level int, name, attribute, operator, value string, weight *int8) {
+	indent(b, level)
+	fmt.Fprintf(b, "%s {\n", name)
+
+	writeAttribute(b, level+1, "attribute", attribute)
+	writeAttribute(b, level+1, "operator", operator)
+	writeAttribute(b, level+1, "value", value)
+	if weight != nil {
+		writeIntAttribute(b, level+1, "weight", int(*weight))
+	}
+
+	indent(b, level)
+	b.WriteString("}\n")
+}
+
+// writeEphemeralDiskBlock writes an ephemeral_disk block
+func writeEphemeralDiskBlock(b *strings.Builder, level int, disk *api.EphemeralDisk) {
+	if disk == nil {
+		return
+	}
+
+	indent(b, level)
+	b.WriteString("ephemeral_disk {\n")
+
+	if disk.Sticky != nil && *disk.Sticky {
+		writeBoolAttribute(b, level+1, "sticky", true)
+	}
+
+	if disk.Migrate != nil && *disk.Migrate {
+		writeBoolAttribute(b, level+1, "migrate", true)
+	}
+
+	if disk.SizeMB != nil && *disk.SizeMB > 0 {
+		writeIntAttribute(b, level+1, "size", *disk.SizeMB)
+	}
+
+	indent(b, level)
+	b.WriteString("}\n")
+}
+
+// writeVolumeBlock writes a group-level volume request
+// This covers both host volumes and CSI volumes; the CSI-only settings
+// (access/attachment mode, per_alloc, mount_options) are simply empty for host volumes
+func writeVolumeBlock(b *strings.Builder, level int, name string, vol *api.VolumeRequest) {
+	if vol == nil {
+		return
+	}
+
+	// The map key and the request name should match, but prefer the
+	// request's own name if it is set
+	if vol.Name != "" {
+		name = vol.Name
+	}
+
+	indent(b, level)
+	fmt.Fprintf(b, "volume \"%s\" {\n", escapeString(name))
+
+	writeAttribute(b, level+1, "type", vol.Type)
+	writeAttribute(b, level+1, "source", vol.Source)
+
+	if vol.ReadOnly {
+		writeBoolAttribute(b, level+1, "read_only", true)
+	}
+
+	if vol.Sticky {
+		writeBoolAttribute(b, level+1, "sticky", true)
+	}
+
+	writeAttribute(b, level+1, "access_mode", vol.AccessMode)
+	writeAttribute(b, level+1, "attachment_mode", vol.AttachmentMode)
+
+	if vol.PerAlloc {
+		writeBoolAttribute(b, level+1, "per_alloc", true)
+	}
+
+	if vol.MountOptions != nil && (vol.MountOptions.FSType != "" || len(vol.MountOptions.MountFlags) > 0) {
+		indent(b, level+1)
+		b.WriteString("mount_options {\n")
+		writeAttribute(b, level+2, "fs_type", vol.MountOptions.FSType)
+		writeListAttribute(b, level+2, "mount_flags", vol.MountOptions.MountFlags)
+		indent(b, level+1)
+		b.WriteString("}\n")
+	}
+
+	indent(b, level)
+	b.WriteString("}\n")
+}
+
+// writeVolumeMountBlock writes a task-level volume_mount block
+func writeVolumeMountBlock(b *strings.Builder, level int, mount *api.VolumeMount) {
+	if mount == nil {
+		return
+	}
+
+	indent(b, level)
+	b.WriteString("volume_mount {\n")
+
+	writeAttribute(b, level+1, "volume", stringValue(mount.Volume))
+	writeAttribute(b, level+1, "destination", stringValue(mount.Destination))
+
+	if mount.ReadOnly != nil && *mount.ReadOnly {
+		writeBoolAttribute(b, level+1, "read_only", true)
+	}
+
+	writeAttribute(b, level+1, "propagation_mode", stringValue(mount.PropagationMode))
+	writeAttribute(b, level+1, "selinux_label", stringValue(mount.SELinuxLabel))
+
+	indent(b, level)
+	b.WriteString("}\n")
+}
+
+// writeConfigBlock writes a config block with driver-specific settings
+// Nested driver blocks (docker auth, mount, logging, ...) arrive as maps or
+// lists of maps and are written back as blocks
+func writeConfigBlock(b *strings.Builder, level int, config map[string]interface{}) {
+	if len(config) == 0 {
+		return
+	}
+
+	writeNestedBlock(b, level, "config", config)
+}
+
 // writeConfigValue writes a single config key-value pair
 // This handles different value types (string, int, bool, list)
 func writeConfigValue(b *strings.Builder, level int, key string, value interface{}) {
@@ -325,6 +694,12 @@ func writeIntAttribute(b *strings.Builder, level int, name string, value int) {
 	fmt.Fprintf(b, "%s = %d\n", name, value)
 }
 
+// writeBoolAttribute writes a boolean attribute
+func writeBoolAttribute(b *strings.Builder, level int, name string, value bool) {
+	indent(b, level)
+	fmt.Fprintf(b, "%s = %t\n", name, value)
+}
+
 // writeListAttribute writes a list of strings
 func writeListAttribute(b *strings.Builder, level int, name string, values []string) {
 	if len(values) == 0 {
@@ -362,7 +737,8 @@ func stringValue(s *string) string {
 }
 
 // escapeString escapes special characters in strings for HCL
-// This handles quotes, newlines, and other special characters
+// This handles quotes, newlines, and other special characters, as well as
+// the ${ and %{ sequences that HCL would otherwise treat as interpolation
 func escapeString(s string) string {
 	// Replace backslashes first
 	s = strings.ReplaceAll(s, "\\", "\\\\")
@@ -370,8 +746,19 @@ func escapeString(s string) string {
 	s = strings.ReplaceAll(s, "\"", "\\\"")
 	// Replace newlines
 	s = strings.ReplaceAll(s, "\n", "\\n")
+	// Replace carriage returns
+	s = strings.ReplaceAll(s, "\r", "\\r")
 	// Replace tabs
 	s = strings.ReplaceAll(s, "\t", "\\t")
+	return escapeTemplateSequences(s)
+}
+
+// escapeTemplateSequences escapes HCL interpolation (${) and directive (%{)
+// sequences by doubling the leading character, so they are kept literally.
+// This applies to both quoted strings and heredocs.
+func escapeTemplateSequences(s string) string {
+	s = strings.ReplaceAll(s, "${", "$${")
+	s = strings.ReplaceAll(s, "%{", "%%{")
 	return s
 }
 
@@ -380,18 +767,30 @@ type ParseOptions struct {
 	NomadAddr     string
 	TLSSkipVerify bool
 	CACert        string
+	CAPath        string
+	ClientCert    string
+	ClientKey     string
+	TLSServerName string
+
+	// Timeout limits the parse request (zero means no limit)
+	Timeout time.Duration
+
+	// Variables is var-file content for the jobspec's variables ("" for none)
+	Variables string
+}
+
+// tlsOptions returns the TLS settings of the parse options
+func (o ParseOptions) tlsOptions() nomad.TLSOptions {
+	return nomad.TLSOptions{
+		CACert:     o.CACert,
+		CAPath:     o.CAPath,
+		ClientCert: o.ClientCert,
+		ClientKey:  o.ClientKey,
+		ServerName: o.TLSServerName,
+		SkipVerify: o.TLSSkipVerify,
+	}
 }
 
-// ParseHCL parses HCL content and returns a Nomad Job struct
-// This uses the Nomad API client to parse HCL
-//
-// Parameters:
-//   - hclContent: The HCL content as bytes
-//   - opts: Configuration for the Nomad API client
-//
-// Returns:
-//   - *api.Job: The parsed job
-//   - error: Any error encountered during parsing
 // BuildNomadConfig creates a Nomad API client config from ParseOptions.
 func BuildNomadConfig(opts ParseOptions) *api.Config {
 	config := api.DefaultConfig()
@@ -400,21 +799,31 @@ func BuildNomadConfig(opts ParseOptions) *api.Config {
 		config.Address = opts.NomadAddr
 	}
 
-	if opts.TLSSkipVerify {
-		config.TLSConfig.Insecure = true
-	}
-	if opts.CACert != "" {
-		config.TLSConfig.CACert = opts.CACert
-	}
+	nomad.ApplyTLS(config.TLSConfig, opts.tlsOptions())
 
 	return config
 }
 
-func ParseHCL(hclContent []byte, opts ParseOptions) (*api.Job, error) {
+// ParseHCL parses HCL content and returns a Nomad Job struct
+// This uses the Nomad API client to parse HCL
+//
+// Parameters:
+//   - ctx: Cancels the parse request; opts.Timeout applies on top
+//   - hclContent: The HCL content as bytes
+//   - opts: Configuration for the Nomad API client
+//
+// Returns:
+//   - *api.Job: The parsed job
+//   - error: Any error encountered during parsing
+func ParseHCL(ctx context.Context, hclContent []byte, opts ParseOptions) (*api.Job, error) {
 	if len(hclContent) == 0 {
 		return nil, fmt.Errorf("HCL content is empty")
 	}
 
+	if err := opts.tlsOptions().Validate(); err != nil {
+		return nil, fmt.Errorf("invalid TLS config: %w", err)
+	}
+
 	config := BuildNomadConfig(opts)
 
 	client, err := api.NewClient(config)
@@ -422,13 +831,20 @@ func ParseHCL(hclContent []byte, opts ParseOptions) (*api.Job, error) {
 		return nil, fmt.Errorf("failed to create API client: %w", err)
 	}
 
-	// Use the client's Jobs().ParseHCL method
-	// Note: This makes a request to Nomad's /v1/jobs/parse endpoint
-	// The canonicalize parameter (false) means we don't process the job further
-	job, err := client.Jobs().ParseHCL(string(hclContent), false)
-	if err != nil {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	// Send the job to Nomad's /v1/jobs/parse endpoint
+	// Jobs().ParseHCL takes no write options, so the raw endpoint is used to
+	// attach the context. Canonicalize is false: the job isn't processed further
+	var job api.Job
+	req := &api.JobsParseRequest{JobHCL: string(hclContent), Variables: opts.Variables, Canonicalize: false}
+	if _, err := client.Raw().Write("/v1/jobs/parse", req, &job, (&api.WriteOptions{}).WithContext(ctx)); err != nil {
 		return nil, fmt.Errorf("failed to parse HCL: %w", err)
 	}
 
-	return job, nil
+	return &job, nil
 }
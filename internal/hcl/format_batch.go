@@ -0,0 +1,72 @@
+package hcl
+
+import (
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+)
+
+// writePeriodicBlock writes a periodic block (cron-launched batch jobs)
+// Nomad canonicalizes enabled=true and time_zone="UTC", so those defaults
+// are omitted to keep files written by hand and files fetched from Nomad equal
+func writePeriodicBlock(b *strings.Builder, level int, periodic *api.PeriodicConfig) {
+	if periodic == nil {
+		return
+	}
+
+	indent(b, level)
+	b.WriteString("periodic {\n")
+
+	writeOptionalBool(b, level+1, "enabled", periodic.Enabled, true)
+
+	// A job uses either a single cron expression or a list of them
+	if len(periodic.Specs) > 0 {
+		writeListAttribute(b, level+1, "crons", periodic.Specs)
+	} else {
+		writeAttribute(b, level+1, "cron", stringValue(periodic.Spec))
+	}
+
+	writeOptionalBool(b, level+1, "prohibit_overlap", periodic.ProhibitOverlap, false)
+	writeAttribute(b, level+1, "time_zone", nonDefault(periodic.TimeZone, "UTC"))
+
+	indent(b, level)
+	b.WriteString("}\n")
+}
+
+// writeParameterizedBlock writes a parameterized block (dispatchable batch jobs)
+func writeParameterizedBlock(b *strings.Builder, level int, params *api.ParameterizedJobConfig) {
+	if params == nil {
+		return
+	}
+
+	indent(b, level)
+	b.WriteString("parameterized {\n")
+
+	// "optional" is Nomad's default payload mode
+	if params.Payload != "optional" {
+		writeAttribute(b, level+1, "payload", params.Payload)
+	}
+	if len(params.MetaRequired) > 0 {
+		writeListAttribute(b, level+1, "meta_required", params.MetaRequired)
+	}
+	if len(params.MetaOptional) > 0 {
+		writeListAttribute(b, level+1, "meta_optional", params.MetaOptional)
+	}
+
+	indent(b, level)
+	b.WriteString("}\n")
+}
+
+// writeDispatchPayloadBlock writes a task's dispatch_payload block, which
+// tells Nomad where to write the payload of a dispatched job
+func writeDispatchPayloadBlock(b *strings.Builder, level int, payload *api.DispatchPayloadConfig) {
+	if payload == nil || payload.File == "" {
+		return
+	}
+
+	indent(b, level)
+	b.WriteString("dispatch_payload {\n")
+	writeAttribute(b, level+1, "file", payload.File)
+	indent(b, level)
+	b.WriteString("}\n")
+}
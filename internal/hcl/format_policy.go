@@ -0,0 +1,181 @@
+package hcl
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+)
+
+// Policy blocks (restart, reschedule, migrate) have defaults that depend on
+// the job type, so every field that is set is written explicitly rather than
+// trying to omit defaults. This also makes the stored file a complete record
+// of the safety settings in effect.
+
+// writeRestartBlock writes a restart policy block (group or task level)
+func writeRestartBlock(b *strings.Builder, level int, policy *api.RestartPolicy) {
+	if policy == nil {
+		return
+	}
+
+	indent(b, level)
+	b.WriteString("restart {\n")
+
+	if policy.Attempts != nil {
+		writeIntAttribute(b, level+1, "attempts", *policy.Attempts)
+	}
+	if policy.Interval != nil {
+		writeDurationAttribute(b, level+1, "interval", *policy.Interval)
+	}
+	if policy.Delay != nil {
+		writeDurationAttribute(b, level+1, "delay", *policy.Delay)
+	}
+	writeAttribute(b, level+1, "mode", stringValue(policy.Mode))
+	if policy.RenderTemplates != nil {
+		writeBoolAttribute(b, level+1, "render_templates", *policy.RenderTemplates)
+	}
+
+	indent(b, level)
+	b.WriteString("}\n")
+}
+
+// writeRescheduleBlock writes a reschedule policy block (job or group level)
+func writeRescheduleBlock(b *strings.Builder, level int, policy *api.ReschedulePolicy) {
+	if policy == nil {
+		return
+	}
+
+	indent(b, level)
+	b.WriteString("reschedule {\n")
+
+	if policy.Attempts != nil {
+		writeIntAttribute(b, level+1, "attempts", *policy.Attempts)
+	}
+	if policy.Interval != nil {
+		writeDurationAttribute(b, level+1, "interval", *policy.Interval)
+	}
+	if policy.Delay != nil {
+		writeDurationAttribute(b, level+1, "delay", *policy.Delay)
+	}
+	writeAttribute(b, level+1, "delay_function", stringValue(policy.DelayFunction))
+	if policy.MaxDelay != nil {
+		writeDurationAttribute(b, level+1, "max_delay", *policy.MaxDelay)
+	}
+	if policy.Unlimited != nil {
+		writeBoolAttribute(b, level+1, "unlimited", *policy.Unlimited)
+	}
+
+	indent(b, level)
+	b.WriteString("}\n")
+}
+
+// writeMigrateBlock writes a migrate strategy block (job or group level)
+// This controls how allocations move off draining nodes
+func writeMigrateBlock(b *strings.Builder, level int, migrate *api.MigrateStrategy) {
+	if migrate == nil {
+		return
+	}
+
+	indent(b, level)
+	b.WriteString("migrate {\n")
+
+	if migrate.MaxParallel != nil {
+		writeIntAttribute(b, level+1, "max_parallel", *migrate.MaxParallel)
+	}
+	writeAttribute(b, level+1, "health_check", stringValue(migrate.HealthCheck))
+	if migrate.MinHealthyTime != nil {
+		writeDurationAttribute(b, level+1, "min_healthy_time", *migrate.MinHealthyTime)
+	}
+	if migrate.HealthyDeadline != nil {
+		writeDurationAttribute(b, level+1, "healthy_deadline", *migrate.HealthyDeadline)
+	}
+
+	indent(b, level)
+	b.WriteString("}\n")
+}
+
+// writeScalingBlock writes a group scaling block
+// Server-assigned fields (ID, target, indexes) are not part of the spec
+func writeScalingBlock(b *strings.Builder, level int, scaling *api.ScalingPolicy) {
+	if scaling == nil {
+		return
+	}
+
+	indent(b, level)
+	b.WriteString("scaling {\n")
+
+	writeOptionalBool(b, level+1, "enabled", scaling.Enabled, true)
+	if scaling.Min != nil {
+		indent(b, level+1)
+		fmt.Fprintf(b, "min = %d\n", *scaling.Min)
+	}
+	if scaling.Max != nil {
+		indent(b, level+1)
+		fmt.Fprintf(b, "max = %d\n", *scaling.Max)
+	}
+
+	// "horizontal" is the only type valid for a group and the default
+	if scaling.Type != "horizontal" {
+		writeAttribute(b, level+1, "type", scaling.Type)
+	}
+
+	// The policy is opaque to Nomad and read by the autoscaler
+	if len(scaling.Policy) > 0 {
+		writeNestedBlock(b, level+1, "policy", scaling.Policy)
+	}
+
+	indent(b, level)
+	b.WriteString("}\n")
+}
+
+// writeNestedBlock writes an arbitrary map as an HCL block
+// Nested maps become sub-blocks and lists of maps become repeated sub-blocks,
+// mirroring how Nomad decodes such blocks into JSON
+func writeNestedBlock(b *strings.Builder, level int, name string, m map[string]interface{}) {
+	indent(b, level)
+	fmt.Fprintf(b, "%s {\n", name)
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		switch v := m[key].(type) {
+		case map[string]interface{}:
+			writeNestedBlock(b, level+1, key, v)
+		case []interface{}:
+			if blocks, ok := mapList(v); ok {
+				for _, block := range blocks {
+					writeNestedBlock(b, level+1, key, block)
+				}
+				continue
+			}
+			writeConfigValue(b, level+1, key, v)
+		default:
+			writeConfigValue(b, level+1, key, v)
+		}
+	}
+
+	indent(b, level)
+	b.WriteString("}\n")
+}
+
+// mapList returns the items of a list if every item is a map
+func mapList(items []interface{}) ([]map[string]interface{}, bool) {
+	if len(items) == 0 {
+		return nil, false
+	}
+
+	maps := make([]map[string]interface{}, 0, len(items))
+	for _, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		maps = append(maps, m)
+	}
+	return maps, true
+}
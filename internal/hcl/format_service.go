@@ -0,0 +1,584 @@
+package hcl
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+)
+
+// writeNetworkBlock writes a group network block
+func writeNetworkBlock(b *strings.Builder, level int, network *api.NetworkResource) {
+	if network == nil {
+		return
+	}
+
+	indent(b, level)
+	b.WriteString("network {\n")
+
+	// "host" is the default network mode
+	if network.Mode != "host" {
+		writeAttribute(b, level+1, "mode", network.Mode)
+	}
+	writeAttribute(b, level+1, "hostname", network.Hostname)
+
+	// Reserved ports carry a static value; dynamic ports don't
+	for _, port := range network.ReservedPorts {
+		writePortBlock(b, level+1, port, true)
+	}
+	for _, port := range network.DynamicPorts {
+		writePortBlock(b, level+1, port, false)
+	}
+
+	if dns := network.DNS; dns != nil {
+		indent(b, level+1)
+		b.WriteString("dns {\n")
+		if len(dns.Servers) > 0 {
+			writeListAttribute(b, level+2, "servers", dns.Servers)
+		}
+		if len(dns.Searches) > 0 {
+			writeListAttribute(b, level+2, "searches", dns.Searches)
+		}
+		if len(dns.Options) > 0 {
+			writeListAttribute(b, level+2, "options", dns.Options)
+		}
+		indent(b, level+1)
+		b.WriteString("}\n")
+	}
+
+	indent(b, level)
+	b.WriteString("}\n")
+}
+
+// writePortBlock writes a port block inside a network block
+func writePortBlock(b *strings.Builder, level int, port api.Port, static bool) {
+	indent(b, level)
+	fmt.Fprintf(b, "port \"%s\" {\n", escapeString(port.Label))
+
+	if static {
+		writeIntAttribute(b, level+1, "static", port.Value)
+	}
+	if port.To != 0 {
+		writeIntAttribute(b, level+1, "to", port.To)
+	}
+	writeAttribute(b, level+1, "host_network", port.HostNetwork)
+	if port.IgnoreCollision {
+		writeBoolAttribute(b, level+1, "ignore_collision", true)
+	}
+
+	indent(b, level)
+	b.WriteString("}\n")
+}
+
+// writeServiceBlock writes a service block (group or task level)
+// Values Nomad fills in on registration (address_mode "auto", provider
+// "consul", on_update "require_healthy", cluster "default") are omitted
+func writeServiceBlock(b *strings.Builder, level int, service *api.Service) {
+	if service == nil {
+		return
+	}
+
+	indent(b, level)
+	b.WriteString("service {\n")
+
+	writeAttribute(b, level+1, "name", service.Name)
+	writeAttribute(b, level+1, "port", service.PortLabel)
+	if service.Provider != "consul" {
+		writeAttribute(b, level+1, "provider", service.Provider)
+	}
+	if len(service.Tags) > 0 {
+		writeListAttribute(b, level+1, "tags", service.Tags)
+	}
+	if len(service.CanaryTags) > 0 {
+		writeListAttribute(b, level+1, "canary_tags", service.CanaryTags)
+	}
+	if service.EnableTagOverride {
+		writeBoolAttribute(b, level+1, "enable_tag_override", true)
+	}
+	if service.AddressMode != "auto" {
+		writeAttribute(b, level+1, "address_mode", service.AddressMode)
+	}
+	writeAttribute(b, level+1, "address", service.Address)
+	writeAttribute(b, level+1, "task", service.TaskName)
+	writeAttribute(b, level+1, "kind", service.Kind)
+	if service.Cluster != "default" {
+		writeAttribute(b, level+1, "cluster", service.Cluster)
+	}
+	if service.OnUpdate != "require_healthy" {
+		writeAttribute(b, level+1, "on_update", service.OnUpdate)
+	}
+
+	if len(service.Meta) > 0 {
+		writeMapBlock(b, level+1, "meta", service.Meta)
+	}
+	if len(service.CanaryMeta) > 0 {
+		writeMapBlock(b, level+1, "canary_meta", service.CanaryMeta)
+	}
+	if len(service.TaggedAddresses) > 0 {
+		writeMapBlock(b, level+1, "tagged_addresses", service.TaggedAddresses)
+	}
+
+	for i := range service.Checks {
+		writeCheckBlock(b, level+1, &service.Checks[i])
+	}
+	if service.CheckRestart != nil {
+		writeCheckRestartBlock(b, level+1, service.CheckRestart)
+	}
+
+	if weights := service.Weights; weights != nil {
+		indent(b, level+1)
+		b.WriteString("weights {\n")
+		writeIntAttribute(b, level+2, "passing", weights.Passing)
+		writeIntAttribute(b, level+2, "warning", weights.Warning)
+		indent(b, level+1)
+		b.WriteString("}\n")
+	}
+
+	if service.Connect != nil {
+		writeConnectBlock(b, level+1, service.Connect)
+	}
+
+	// Workload identity used to register the service with Consul
+	if service.Identity != nil {
+		writeIdentityBlock(b, level+1, service.Identity)
+	}
+
+	indent(b, level)
+	b.WriteString("}\n")
+}
+
+// writeCheckBlock writes a service health check block
+func writeCheckBlock(b *strings.Builder, level int, check *api.ServiceCheck) {
+	indent(b, level)
+	b.WriteString("check {\n")
+
+	writeAttribute(b, level+1, "name", check.Name)
+	writeAttribute(b, level+1, "type", check.Type)
+	writeAttribute(b, level+1, "command", check.Command)
+	if len(check.Args) > 0 {
+		writeListAttribute(b, level+1, "args", check.Args)
+	}
+	writeAttribute(b, level+1, "path", check.Path)
+	writeAttribute(b, level+1, "method", check.Method)
+	writeAttribute(b, level+1, "body", check.Body)
+	writeAttribute(b, level+1, "protocol", check.Protocol)
+	writeAttribute(b, level+1, "port", check.PortLabel)
+	if check.Expose {
+		writeBoolAttribute(b, level+1, "expose", true)
+	}
+	writeAttribute(b, level+1, "address_mode", check.AddressMode)
+	writeAttribute(b, level+1, "advertise", check.Advertise)
+	if check.Interval > 0 {
+		writeDurationAttribute(b, level+1, "interval", check.Interval)
+	}
+	if check.Timeout > 0 {
+		writeDurationAttribute(b, level+1, "timeout", check.Timeout)
+	}
+	writeAttribute(b, level+1, "initial_status", check.InitialStatus)
+	writeAttribute(b, level+1, "notes", check.Notes)
+	writeAttribute(b, level+1, "grpc_service", check.GRPCService)
+	if check.GRPCUseTLS {
+		writeBoolAttribute(b, level+1, "grpc_use_tls", true)
+	}
+	writeAttribute(b, level+1, "tls_server_name", check.TLSServerName)
+	if check.TLSSkipVerify {
+		writeBoolAttribute(b, level+1, "tls_skip_verify", true)
+	}
+	writeAttribute(b, level+1, "task", check.TaskName)
+	if check.SuccessBeforePassing > 0 {
+		writeIntAttribute(b, level+1, "success_before_passing", check.SuccessBeforePassing)
+	}
+	if check.FailuresBeforeCritical > 0 {
+		writeIntAttribute(b, level+1, "failures_before_critical", check.FailuresBeforeCritical)
+	}
+	if check.FailuresBeforeWarning > 0 {
+		writeIntAttribute(b, level+1, "failures_before_warning", check.FailuresBeforeWarning)
+	}
+	if check.OnUpdate != "require_healthy" {
+		writeAttribute(b, level+1, "on_update", check.OnUpdate)
+	}
+
+	// Headers are multi-valued, so each one is a list
+	if len(check.Header) > 0 {
+		names := make([]string, 0, len(check.Header))
+		for name := range check.Header {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		indent(b, level+1)
+		b.WriteString("header {\n")
+		for _, name := range names {
+			writeListAttribute(b, level+2, name, check.Header[name])
+		}
+		indent(b, level+1)
+		b.WriteString("}\n")
+	}
+
+	if check.CheckRestart != nil {
+		writeCheckRestartBlock(b, level+1, check.CheckRestart)
+	}
+
+	indent(b, level)
+	b.WriteString("}\n")
+}
+
+// writeCheckRestartBlock writes a check_restart block
+func writeCheckRestartBlock(b *strings.Builder, level int, restart *api.CheckRestart) {
+	indent(b, level)
+	b.WriteString("check_restart {\n")
+
+	if restart.Limit > 0 {
+		writeIntAttribute(b, level+1, "limit", restart.Limit)
+	}
+	if restart.Grace != nil {
+		writeDurationAttribute(b, level+1, "grace", *restart.Grace)
+	}
+	if restart.IgnoreWarnings {
+		writeBoolAttribute(b, level+1, "ignore_warnings", true)
+	}
+
+	indent(b, level)
+	b.WriteString("}\n")
+}
+
+// writeConnectBlock writes a Consul Connect block
+func writeConnectBlock(b *strings.Builder, level int, connect *api.ConsulConnect) {
+	indent(b, level)
+	b.WriteString("connect {\n")
+
+	if connect.Native {
+		writeBoolAttribute(b, level+1, "native", true)
+	}
+	if connect.SidecarService != nil {
+		writeSidecarServiceBlock(b, level+1, connect.SidecarService)
+	}
+	if connect.SidecarTask != nil {
+		writeSidecarTaskBlock(b, level+1, connect.SidecarTask)
+	}
+	if connect.Gateway != nil {
+		writeGatewayBlock(b, level+1, connect.Gateway)
+	}
+
+	indent(b, level)
+	b.WriteString("}\n")
+}
+
+// writeSidecarServiceBlock writes a sidecar_service block
+func writeSidecarServiceBlock(b *strings.Builder, level int, sidecar *api.ConsulSidecarService) {
+	indent(b, level)
+	b.WriteString("sidecar_service {\n")
+
+	writeAttribute(b, level+1, "port", sidecar.Port)
+	if len(sidecar.Tags) > 0 {
+		writeListAttribute(b, level+1, "tags", sidecar.Tags)
+	}
+	if sidecar.DisableDefaultTCPCheck {
+		writeBoolAttribute(b, level+1, "disable_default_tcp_check", true)
+	}
+	if len(sidecar.Meta) > 0 {
+		writeMapBlock(b, level+1, "meta", sidecar.Meta)
+	}
+	if sidecar.Proxy != nil {
+		writeProxyBlock(b, level+1, sidecar.Proxy)
+	}
+
+	indent(b, level)
+	b.WriteString("}\n")
+}
+
+// writeProxyBlock writes a sidecar proxy block with its upstreams
+func writeProxyBlock(b *strings.Builder, level int, proxy *api.ConsulProxy) {
+	indent(b, level)
+	b.WriteString("proxy {\n")
+
+	writeAttribute(b, level+1, "local_service_address", proxy.LocalServiceAddress)
+	if proxy.LocalServicePort > 0 {
+		writeIntAttribute(b, level+1, "local_service_port", proxy.LocalServicePort)
+	}
+
+	for _, upstream := range proxy.Upstreams {
+		writeUpstreamsBlock(b, level+1, upstream)
+	}
+
+	if proxy.Expose != nil && len(proxy.Expose.Paths) > 0 {
+		indent(b, level+1)
+		b.WriteString("expose {\n")
+		for _, path := range proxy.Expose.Paths {
+			indent(b, level+2)
+			b.WriteString("path {\n")
+			writeAttribute(b, level+3, "path", path.Path)
+			writeAttribute(b, level+3, "protocol", path.Protocol)
+			if path.LocalPathPort > 0 {
+				writeIntAttribute(b, level+3, "local_path_port", path.LocalPathPort)
+			}
+			writeAttribute(b, level+3, "listener_port", path.ListenerPort)
+			indent(b, level+2)
+			b.WriteString("}\n")
+		}
+		indent(b, level+1)
+		b.WriteString("}\n")
+	}
+
+	if tp := proxy.TransparentProxy; tp != nil {
+		indent(b, level+1)
+		b.WriteString("transparent_proxy {\n")
+		writeAttribute(b, level+2, "uid", tp.UID)
+		if tp.OutboundPort > 0 {
+			writeIntAttribute(b, level+2, "outbound_port", int(tp.OutboundPort))
+		}
+		if len(tp.ExcludeInboundPorts) > 0 {
+			writeListAttribute(b, level+2, "exclude_inbound_ports", tp.ExcludeInboundPorts)
+		}
+		if len(tp.ExcludeOutboundPorts) > 0 {
+			ports := make([]string, len(tp.ExcludeOutboundPorts))
+			for i, port := range tp.ExcludeOutboundPorts {
+				ports[i] = fmt.Sprint(port)
+			}
+			indent(b, level+2)
+			fmt.Fprintf(b, "exclude_outbound_ports = [%s]\n", strings.Join(ports, ", "))
+		}
+		if len(tp.ExcludeOutboundCIDRs) > 0 {
+			writeListAttribute(b, level+2, "exclude_outbound_cidrs", tp.ExcludeOutboundCIDRs)
+		}
+		if len(tp.ExcludeUIDs) > 0 {
+			writeListAttribute(b, level+2, "exclude_uids", tp.ExcludeUIDs)
+		}
+		if tp.NoDNS {
+			writeBoolAttribute(b, level+2, "no_dns", true)
+		}
+		indent(b, level+1)
+		b.WriteString("}\n")
+	}
+
+	if len(proxy.Config) > 0 {
+		writeNestedBlock(b, level+1, "config", proxy.Config)
+	}
+
+	indent(b, level)
+	b.WriteString("}\n")
+}
+
+// writeUpstreamsBlock writes a single upstreams block of a sidecar proxy
+func writeUpstreamsBlock(b *strings.Builder, level int, upstream *api.ConsulUpstream) {
+	if upstream == nil {
+		return
+	}
+
+	indent(b, level)
+	b.WriteString("upstreams {\n")
+
+	writeAttribute(b, level+1, "destination_name", upstream.DestinationName)
+	writeAttribute(b, level+1, "destination_namespace", upstream.DestinationNamespace)
+	writeAttribute(b, level+1, "destination_peer", upstream.DestinationPeer)
+	writeAttribute(b, level+1, "destination_partition", upstream.DestinationPartition)
+	writeAttribute(b, level+1, "destination_type", upstream.DestinationType)
+	if upstream.LocalBindPort > 0 {
+		writeIntAttribute(b, level+1, "local_bind_port", upstream.LocalBindPort)
+	}
+	writeAttribute(b, level+1, "local_bind_address", upstream.LocalBindAddress)
+	writeAttribute(b, level+1, "local_bind_socket_path", upstream.LocalBindSocketPath)
+	writeAttribute(b, level+1, "local_bind_socket_mode", upstream.LocalBindSocketMode)
+	writeAttribute(b, level+1, "datacenter", upstream.Datacenter)
+
+	if upstream.MeshGateway != nil && upstream.MeshGateway.Mode != "" {
+		indent(b, level+1)
+		b.WriteString("mesh_gateway {\n")
+		writeAttribute(b, level+2, "mode", upstream.MeshGateway.Mode)
+		indent(b, level+1)
+		b.WriteString("}\n")
+	}
+
+	if len(upstream.Config) > 0 {
+		writeNestedBlock(b, level+1, "config", upstream.Config)
+	}
+
+	indent(b, level)
+	b.WriteString("}\n")
+}
+
+// writeSidecarTaskBlock writes a sidecar_task block overriding the injected
+// proxy task. This is the only place the proxy task is recorded.
+func writeSidecarTaskBlock(b *strings.Builder, level int, task *api.SidecarTask) {
+	indent(b, level)
+	b.WriteString("sidecar_task {\n")
+
+	writeAttribute(b, level+1, "name", task.Name)
+	writeAttribute(b, level+1, "driver", task.Driver)
+	writeAttribute(b, level+1, "user", task.User)
+	if task.KillTimeout != nil && *task.KillTimeout > 0 {
+		writeDurationAttribute(b, level+1, "kill_timeout", *task.KillTimeout)
+	}
+	writeAttribute(b, level+1, "kill_signal", task.KillSignal)
+	if task.ShutdownDelay != nil && *task.ShutdownDelay > 0 {
+		writeDurationAttribute(b, level+1, "shutdown_delay", *task.ShutdownDelay)
+	}
+
+	if len(task.Config) > 0 {
+		writeConfigBlock(b, level+1, task.Config)
+	}
+	if len(task.Env) > 0 {
+		writeMapBlock(b, level+1, "env", task.Env)
+	}
+	if task.Resources != nil {
+		writeResourcesBlock(b, level+1, task.Resources)
+	}
+	for _, mount := range task.VolumeMounts {
+		writeVolumeMountBlock(b, level+1, mount)
+	}
+	if task.LogConfig != nil {
+		writeLogsBlock(b, level+1, task.LogConfig)
+	}
+	if len(task.Meta) > 0 {
+		writeMapBlock(b, level+1, "meta", task.Meta)
+	}
+
+	indent(b, level)
+	b.WriteString("}\n")
+}
+
+// writeGatewayBlock writes a Connect gateway block (ingress, terminating or mesh)
+func writeGatewayBlock(b *strings.Builder, level int, gateway *api.ConsulGateway) {
+	indent(b, level)
+	b.WriteString("gateway {\n")
+
+	if gateway.Proxy != nil {
+		writeGatewayProxyBlock(b, level+1, gateway.Proxy)
+	}
+
+	if ingress := gateway.Ingress; ingress != nil {
+		indent(b, level+1)
+		b.WriteString("ingress {\n")
+		if ingress.TLS != nil {
+			writeGatewayTLSBlock(b, level+2, ingress.TLS)
+		}
+		for _, listener := range ingress.Listeners {
+			if listener == nil {
+				continue
+			}
+			indent(b, level+2)
+			b.WriteString("listener {\n")
+			writeIntAttribute(b, level+3, "port", listener.Port)
+			writeAttribute(b, level+3, "protocol", listener.Protocol)
+			for _, service := range listener.Services {
+				if service == nil {
+					continue
+				}
+				indent(b, level+3)
+				b.WriteString("service {\n")
+				writeAttribute(b, level+4, "name", service.Name)
+				if len(service.Hosts) > 0 {
+					writeListAttribute(b, level+4, "hosts", service.Hosts)
+				}
+				if service.TLS != nil {
+					writeGatewayTLSBlock(b, level+4, service.TLS)
+				}
+				indent(b, level+3)
+				b.WriteString("}\n")
+			}
+			indent(b, level+2)
+			b.WriteString("}\n")
+		}
+		indent(b, level+1)
+		b.WriteString("}\n")
+	}
+
+	if terminating := gateway.Terminating; terminating != nil {
+		indent(b, level+1)
+		b.WriteString("terminating {\n")
+		for _, service := range terminating.Services {
+			if service == nil {
+				continue
+			}
+			indent(b, level+2)
+			b.WriteString("service {\n")
+			writeAttribute(b, level+3, "name", service.Name)
+			writeAttribute(b, level+3, "ca_file", service.CAFile)
+			writeAttribute(b, level+3, "cert_file", service.CertFile)
+			writeAttribute(b, level+3, "key_file", service.KeyFile)
+			writeAttribute(b, level+3, "sni", service.SNI)
+			indent(b, level+2)
+			b.WriteString("}\n")
+		}
+		indent(b, level+1)
+		b.WriteString("}\n")
+	}
+
+	if gateway.Mesh != nil {
+		indent(b, level+1)
+		b.WriteString("mesh {}\n")
+	}
+
+	indent(b, level)
+	b.WriteString("}\n")
+}
+
+// writeGatewayProxyBlock writes the Envoy settings of a gateway
+func writeGatewayProxyBlock(b *strings.Builder, level int, proxy *api.ConsulGatewayProxy) {
+	indent(b, level)
+	b.WriteString("proxy {\n")
+
+	if proxy.ConnectTimeout != nil {
+		writeDurationAttribute(b, level+1, "connect_timeout", *proxy.ConnectTimeout)
+	}
+	if proxy.EnvoyGatewayBindTaggedAddresses {
+		writeBoolAttribute(b, level+1, "envoy_gateway_bind_tagged_addresses", true)
+	}
+	if proxy.EnvoyGatewayNoDefaultBind {
+		writeBoolAttribute(b, level+1, "envoy_gateway_no_default_bind", true)
+	}
+	writeAttribute(b, level+1, "envoy_dns_discovery_type", proxy.EnvoyDNSDiscoveryType)
+
+	if len(proxy.EnvoyGatewayBindAddresses) > 0 {
+		names := make([]string, 0, len(proxy.EnvoyGatewayBindAddresses))
+		for name := range proxy.EnvoyGatewayBindAddresses {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			addr := proxy.EnvoyGatewayBindAddresses[name]
+			if addr == nil {
+				continue
+			}
+			indent(b, level+1)
+			fmt.Fprintf(b, "envoy_gateway_bind_addresses \"%s\" {\n", escapeString(name))
+			writeAttribute(b, level+2, "address", addr.Address)
+			writeIntAttribute(b, level+2, "port", addr.Port)
+			indent(b, level+1)
+			b.WriteString("}\n")
+		}
+	}
+
+	if len(proxy.Config) > 0 {
+		writeNestedBlock(b, level+1, "config", proxy.Config)
+	}
+
+	indent(b, level)
+	b.WriteString("}\n")
+}
+
+// writeGatewayTLSBlock writes a gateway tls block
+func writeGatewayTLSBlock(b *strings.Builder, level int, tls *api.ConsulGatewayTLSConfig) {
+	indent(b, level)
+	b.WriteString("tls {\n")
+
+	writeBoolAttribute(b, level+1, "enabled", tls.Enabled)
+	writeAttribute(b, level+1, "tls_min_version", tls.TLSMinVersion)
+	writeAttribute(b, level+1, "tls_max_version", tls.TLSMaxVersion)
+	if len(tls.CipherSuites) > 0 {
+		writeListAttribute(b, level+1, "cipher_suites", tls.CipherSuites)
+	}
+	if sds := tls.SDS; sds != nil {
+		indent(b, level+1)
+		b.WriteString("sds {\n")
+		writeAttribute(b, level+2, "cluster_name", sds.ClusterName)
+		writeAttribute(b, level+2, "cert_resource", sds.CertResource)
+		indent(b, level+1)
+		b.WriteString("}\n")
+	}
+
+	indent(b, level)
+	b.WriteString("}\n")
+}
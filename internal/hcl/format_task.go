@@ -0,0 +1,315 @@
+package hcl
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/nomad/api"
+)
+
+// heredocDelimiter is the marker used for multi-line template bodies
+// EOH is the conventional delimiter in Nomad job files
+const heredocDelimiter = "EOH"
+
+// writeTemplateBlock writes a template block
+// Templates render config files (consul-template syntax) into the task directory.
+// Values equal to Nomad's defaults are omitted to keep the output readable
+func writeTemplateBlock(b *strings.Builder, level int, tmpl *api.Template) {
+	if tmpl == nil {
+		return
+	}
+
+	indent(b, level)
+	b.WriteString("template {\n")
+
+	writeAttribute(b, level+1, "source", stringValue(tmpl.SourcePath))
+	writeAttribute(b, level+1, "destination", stringValue(tmpl.DestPath))
+
+	if tmpl.EmbeddedTmpl != nil && *tmpl.EmbeddedTmpl != "" {
+		writeStringOrHeredoc(b, level+1, "data", *tmpl.EmbeddedTmpl)
+	}
+
+	writeAttribute(b, level+1, "change_mode", nonDefault(tmpl.ChangeMode, "restart"))
+	writeAttribute(b, level+1, "change_signal", stringValue(tmpl.ChangeSignal))
+
+	if tmpl.ChangeScript != nil {
+		writeChangeScriptBlock(b, level+1, tmpl.ChangeScript)
+	}
+
+	writeOptionalBool(b, level+1, "once", tmpl.Once, false)
+	writeOptionalBool(b, level+1, "env", tmpl.Envvars, false)
+	writeOptionalBool(b, level+1, "error_on_missing_key", tmpl.ErrMissingKey, false)
+
+	if tmpl.Splay != nil && *tmpl.Splay != 5*time.Second {
+		writeDurationAttribute(b, level+1, "splay", *tmpl.Splay)
+	}
+
+	writeAttribute(b, level+1, "perms", nonDefault(tmpl.Perms, "0644"))
+
+	if tmpl.Uid != nil && *tmpl.Uid >= 0 {
+		writeIntAttribute(b, level+1, "uid", *tmpl.Uid)
+	}
+	if tmpl.Gid != nil && *tmpl.Gid >= 0 {
+		writeIntAttribute(b, level+1, "gid", *tmpl.Gid)
+	}
+
+	writeAttribute(b, level+1, "left_delimiter", nonDefault(tmpl.LeftDelim, "{{"))
+	writeAttribute(b, level+1, "right_delimiter", nonDefault(tmpl.RightDelim, "}}"))
+
+	if tmpl.VaultGrace != nil && *tmpl.VaultGrace > 0 {
+		writeDurationAttribute(b, level+1, "vault_grace", *tmpl.VaultGrace)
+	}
+
+	if tmpl.Wait != nil && (tmpl.Wait.Min != nil || tmpl.Wait.Max != nil) {
+		indent(b, level+1)
+		b.WriteString("wait {\n")
+		if tmpl.Wait.Min != nil {
+			writeDurationAttribute(b, level+2, "min", *tmpl.Wait.Min)
+		}
+		if tmpl.Wait.Max != nil {
+			writeDurationAttribute(b, level+2, "max", *tmpl.Wait.Max)
+		}
+		indent(b, level+1)
+		b.WriteString("}\n")
+	}
+
+	indent(b, level)
+	b.WriteString("}\n")
+}
+
+// writeChangeScriptBlock writes a template change_script block
+func writeChangeScriptBlock(b *strings.Builder, level int, script *api.ChangeScript) {
+	indent(b, level)
+	b.WriteString("change_script {\n")
+
+	writeAttribute(b, level+1, "command", stringValue(script.Command))
+	writeListAttribute(b, level+1, "args", script.Args)
+
+	if script.Timeout != nil && *script.Timeout > 0 {
+		writeDurationAttribute(b, level+1, "timeout", *script.Timeout)
+	}
+
+	writeOptionalBool(b, level+1, "fail_on_error", script.FailOnError, false)
+
+	indent(b, level)
+	b.WriteString("}\n")
+}
+
+// writeArtifactBlock writes an artifact block
+// Artifacts are files fetched by go-getter before the task starts
+func writeArtifactBlock(b *strings.Builder, level int, artifact *api.TaskArtifact) {
+	if artifact == nil {
+		return
+	}
+
+	indent(b, level)
+	b.WriteString("artifact {\n")
+
+	writeAttribute(b, level+1, "source", stringValue(artifact.GetterSource))
+	writeAttribute(b, level+1, "destination", stringValue(artifact.RelativeDest))
+	writeAttribute(b, level+1, "mode", nonDefault(artifact.GetterMode, "any"))
+	writeOptionalBool(b, level+1, "insecure", artifact.GetterInsecure, false)
+
+	if artifact.Chown {
+		writeBoolAttribute(b, level+1, "chown", true)
+	}
+
+	if len(artifact.GetterOptions) > 0 {
+		writeMapBlock(b, level+1, "options", artifact.GetterOptions)
+	}
+
+	if len(artifact.GetterHeaders) > 0 {
+		writeMapBlock(b, level+1, "headers", artifact.GetterHeaders)
+	}
+
+	indent(b, level)
+	b.WriteString("}\n")
+}
+
+// writeVaultBlock writes a vault block
+func writeVaultBlock(b *strings.Builder, level int, vault *api.Vault) {
+	if vault == nil {
+		return
+	}
+
+	indent(b, level)
+	b.WriteString("vault {\n")
+
+	writeListAttribute(b, level+1, "policies", vault.Policies)
+	writeAttribute(b, level+1, "role", vault.Role)
+	writeAttribute(b, level+1, "namespace", stringValue(vault.Namespace))
+
+	if vault.Cluster != "default" {
+		writeAttribute(b, level+1, "cluster", vault.Cluster)
+	}
+
+	writeOptionalBool(b, level+1, "env", vault.Env, true)
+	writeOptionalBool(b, level+1, "disable_file", vault.DisableFile, false)
+	writeAttribute(b, level+1, "change_mode", nonDefault(vault.ChangeMode, "restart"))
+	writeAttribute(b, level+1, "change_signal", nonDefault(vault.ChangeSignal, "SIGHUP"))
+	writeOptionalBool(b, level+1, "allow_token_expiration", vault.AllowTokenExpiration, false)
+
+	indent(b, level)
+	b.WriteString("}\n")
+}
+
+// writeIdentityBlock writes a workload identity block
+// The default identity has no name; additional identities are named
+func writeIdentityBlock(b *strings.Builder, level int, identity *api.WorkloadIdentity) {
+	if identity == nil {
+		return
+	}
+
+	indent(b, level)
+	b.WriteString("identity {\n")
+
+	if identity.Name != "default" {
+		writeAttribute(b, level+1, "name", identity.Name)
+	}
+
+	writeListAttribute(b, level+1, "aud", identity.Audience)
+
+	if identity.Env {
+		writeBoolAttribute(b, level+1, "env", true)
+	}
+	if identity.File {
+		writeBoolAttribute(b, level+1, "file", true)
+	}
+
+	writeAttribute(b, level+1, "filepath", identity.Filepath)
+	writeAttribute(b, level+1, "change_mode", identity.ChangeMode)
+	writeAttribute(b, level+1, "change_signal", identity.ChangeSignal)
+
+	if identity.TTL > 0 {
+		writeDurationAttribute(b, level+1, "ttl", identity.TTL)
+	}
+
+	indent(b, level)
+	b.WriteString("}\n")
+}
+
+// writeStringOrHeredoc writes a string attribute, using heredoc syntax for
+// multi-line values so template bodies stay readable in diffs.
+//
+// A heredoc always ends with a newline and NormalizeHCL strips trailing
+// whitespace, so values that would not survive that round-trip fall back
+// to a regular quoted string.
+func writeStringOrHeredoc(b *strings.Builder, level int, name, value string) {
+	if !canUseHeredoc(value) {
+		writeAttribute(b, level, name, value)
+		return
+	}
+
+	delimiter := heredocDelimiter
+	for strings.Contains(value, delimiter) {
+		delimiter += "_"
+	}
+
+	indent(b, level)
+	fmt.Fprintf(b, "%s = <<%s\n", name, delimiter)
+	b.WriteString(escapeTemplateSequences(value))
+	b.WriteString(delimiter)
+	b.WriteString("\n")
+}
+
+// canUseHeredoc reports whether a value can be written as a heredoc
+// without changing its content
+func canUseHeredoc(value string) bool {
+	if !strings.Contains(value, "\n") || !strings.HasSuffix(value, "\n") {
+		return false
+	}
+
+	if strings.Contains(value, "\r") {
+		return false
+	}
+
+	for _, line := range strings.Split(value, "\n") {
+		if strings.TrimRight(line, " \t") != line {
+			return false
+		}
+	}
+
+	return true
+}
+
+// writeOptionalBool writes a *bool attribute only when it is set and
+// differs from Nomad's default for that field
+func writeOptionalBool(b *strings.Builder, level int, name string, value *bool, def bool) {
+	if value == nil || *value == def {
+		return
+	}
+	writeBoolAttribute(b, level, name, *value)
+}
+
+// writeDurationAttribute writes a duration as a Go duration string (e.g. "30s")
+func writeDurationAttribute(b *strings.Builder, level int, name string, d time.Duration) {
+	writeAttribute(b, level, name, formatDuration(d))
+}
+
+// formatDuration formats a duration without the redundant zero units
+// that time.Duration.String() adds (e.g. "5m" instead of "5m0s")
+func formatDuration(d time.Duration) string {
+	if d == 0 {
+		return "0s"
+	}
+
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// nonDefault returns the string value, or empty string if it equals the default
+// Combined with writeAttribute (which skips empty values) this omits defaults
+func nonDefault(s *string, def string) string {
+	if s == nil || *s == def {
+		return ""
+	}
+	return *s
+}
+
+// writeLifecycleBlock writes a task lifecycle block
+// Lifecycle hooks turn a task into an init container (prestart),
+// a post-start helper (poststart), or a sidecar
+func writeLifecycleBlock(b *strings.Builder, level int, lifecycle *api.TaskLifecycle) {
+	if lifecycle == nil || lifecycle.Hook == "" {
+		return
+	}
+
+	indent(b, level)
+	b.WriteString("lifecycle {\n")
+
+	writeAttribute(b, level+1, "hook", lifecycle.Hook)
+	if lifecycle.Sidecar {
+		writeBoolAttribute(b, level+1, "sidecar", true)
+	}
+
+	indent(b, level)
+	b.WriteString("}\n")
+}
+
+// writeLogsBlock writes a task logs block
+func writeLogsBlock(b *strings.Builder, level int, logs *api.LogConfig) {
+	if logs == nil {
+		return
+	}
+
+	indent(b, level)
+	b.WriteString("logs {\n")
+
+	if logs.MaxFiles != nil {
+		writeIntAttribute(b, level+1, "max_files", *logs.MaxFiles)
+	}
+	if logs.MaxFileSizeMB != nil {
+		writeIntAttribute(b, level+1, "max_file_size", *logs.MaxFileSizeMB)
+	}
+	writeOptionalBool(b, level+1, "disabled", logs.Disabled, false)
+
+	indent(b, level)
+	b.WriteString("}\n")
+}
@@ -0,0 +1,194 @@
+package hcl
+
+import (
+	"fmt"
+	"strings"
+
+	hcl2 "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// MergeHCL applies the semantic content of a freshly generated job file to an
+// existing, possibly hand-edited, file and returns the result
+//
+// FormatJobAsHCL always produces the same canonical layout, so writing its
+// output directly discards any comments or block ordering a team has added
+// to the stored file. MergeHCL instead edits the existing file in place:
+//   - attributes whose value is unchanged are left exactly as written
+//     (including comments and formatting)
+//   - attributes whose value changed get the new expression, keeping their
+//     comments
+//   - attributes and blocks that no longer exist are removed
+//   - new attributes and blocks are appended to their enclosing block
+//
+// Blocks are matched by type and labels; repeated unlabeled blocks (template,
+// service, check, ...) are matched by their "name" or "destination" attribute
+// where present, otherwise by position.
+//
+// If nothing changed semantically the existing file is returned unchanged,
+// so comparing the result with the existing file is a valid change check.
+//
+// Parameters:
+//   - existing: The current file content from the repository
+//   - generated: The output of FormatJobAsHCL for the current job
+//
+// Returns:
+//   - []byte: The merged file content
+//   - error: If either input is not valid HCL
+func MergeHCL(existing, generated []byte) ([]byte, error) {
+	existingFile, diags := hclwrite.ParseConfig(existing, "existing.hcl", hcl2.InitialPos)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to parse existing file: %s", diags.Error())
+	}
+
+	generatedFile, diags := hclwrite.ParseConfig(generated, "generated.hcl", hcl2.InitialPos)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to parse generated file: %s", diags.Error())
+	}
+
+	mergeBody(existingFile.Body(), generatedFile.Body())
+
+	// File.Bytes would run hclwrite's formatter over the whole file, which
+	// realigns the user's attributes; the raw tokens keep their spacing
+	return existingFile.BuildTokens(nil).Bytes(), nil
+}
+
+// mergeBody merges the attributes and nested blocks of a generated body into
+// an existing body
+func mergeBody(existing, generated *hclwrite.Body) {
+	// Attributes
+	existingAttrs := existing.Attributes()
+	generatedAttrs := generated.Attributes()
+
+	for name := range existingAttrs {
+		if _, ok := generatedAttrs[name]; !ok {
+			existing.RemoveAttribute(name)
+		}
+	}
+
+	for _, name := range attributeOrder(generated) {
+		genAttr := generatedAttrs[name]
+		tokens := genAttr.Expr().BuildTokens(nil)
+
+		oldAttr, ok := existingAttrs[name]
+		if ok && sameExpression(oldAttr.Expr().BuildTokens(nil), tokens) {
+			continue
+		}
+		existing.SetAttributeRaw(name, tokens)
+
+		// A new attribute is created without indentation or spacing around
+		// "="; copy both from the generated attribute
+		if !ok {
+			added := existing.GetAttribute(name).BuildTokens(nil)
+			generatedTokens := genAttr.BuildTokens(nil)
+			added[0].SpacesBefore = generatedTokens[0].SpacesBefore
+			added[1].SpacesBefore = generatedTokens[1].SpacesBefore
+		}
+	}
+
+	// Blocks
+	generatedBlocks := keyedBlocks(generated.Blocks())
+	generatedKeys := orderedBlockKeys(generated.Blocks())
+
+	existingBlocks := keyedBlocks(existing.Blocks())
+	for key, block := range existingBlocks {
+		if genBlock, ok := generatedBlocks[key]; ok {
+			mergeBody(block.Body(), genBlock.Body())
+			continue
+		}
+		existing.RemoveBlock(block)
+	}
+
+	for _, key := range generatedKeys {
+		if _, ok := existingBlocks[key]; ok {
+			continue
+		}
+		existing.AppendBlock(generatedBlocks[key])
+	}
+}
+
+// keyedBlocks indexes blocks by their matching key
+func keyedBlocks(blocks []*hclwrite.Block) map[string]*hclwrite.Block {
+	keys := orderedBlockKeys(blocks)
+	indexed := make(map[string]*hclwrite.Block, len(blocks))
+	for i, block := range blocks {
+		indexed[keys[i]] = block
+	}
+	return indexed
+}
+
+// orderedBlockKeys returns the matching key of each block, in order
+// A key is the block type and labels plus, for blocks that can repeat
+// without labels, an identifying attribute or an occurrence counter
+func orderedBlockKeys(blocks []*hclwrite.Block) []string {
+	keys := make([]string, len(blocks))
+	seen := make(map[string]int)
+
+	for i, block := range blocks {
+		base := block.Type() + "\x00" + strings.Join(block.Labels(), "\x00")
+		for _, identity := range []string{"name", "destination"} {
+			if attr := block.Body().GetAttribute(identity); attr != nil {
+				base += "\x00" + identity + "=" + strings.TrimSpace(string(attr.Expr().BuildTokens(nil).Bytes()))
+				break
+			}
+		}
+
+		keys[i] = fmt.Sprintf("%s\x00#%d", base, seen[base])
+		seen[base]++
+	}
+
+	return keys
+}
+
+// attributeOrder returns the names of a body's own attributes in source order
+// hclwrite exposes attributes only as a map, so the order is recovered from
+// the tokens: an attribute is an identifier at the start of a line, at brace
+// depth zero, followed by "="
+func attributeOrder(body *hclwrite.Body) []string {
+	tokens := body.BuildTokens(nil)
+
+	var names []string
+	depth := 0
+	lineStart := true
+
+	for i, tok := range tokens {
+		switch tok.Type {
+		case hclsyntax.TokenOBrace, hclsyntax.TokenOBrack, hclsyntax.TokenOParen, hclsyntax.TokenTemplateInterp, hclsyntax.TokenTemplateControl:
+			depth++
+		case hclsyntax.TokenCBrace, hclsyntax.TokenCBrack, hclsyntax.TokenCParen, hclsyntax.TokenTemplateSeqEnd:
+			depth--
+		case hclsyntax.TokenIdent:
+			if depth == 0 && lineStart && i+1 < len(tokens) && tokens[i+1].Type == hclsyntax.TokenEqual {
+				names = append(names, string(tok.Bytes))
+			}
+		}
+		// Line comments carry their own newline
+		lineStart = tok.Type == hclsyntax.TokenNewline ||
+			(tok.Type == hclsyntax.TokenComment && strings.HasSuffix(string(tok.Bytes), "\n"))
+	}
+
+	return names
+}
+
+// sameExpression reports whether two expressions are semantically equal
+// Expressions that can be evaluated without variables (all literals, which
+// is everything FormatJobAsHCL writes) are compared by value, so a quoted
+// string and an equivalent heredoc are equal. Anything else is compared by
+// its formatted tokens.
+func sameExpression(a, b hclwrite.Tokens) bool {
+	aBytes := a.Bytes()
+	bBytes := b.Bytes()
+
+	aExpr, aDiags := hclsyntax.ParseExpression(aBytes, "a.hcl", hcl2.InitialPos)
+	bExpr, bDiags := hclsyntax.ParseExpression(bBytes, "b.hcl", hcl2.InitialPos)
+	if !aDiags.HasErrors() && !bDiags.HasErrors() {
+		aVal, aDiags := aExpr.Value(nil)
+		bVal, bDiags := bExpr.Value(nil)
+		if !aDiags.HasErrors() && !bDiags.HasErrors() && aVal.IsWhollyKnown() && bVal.IsWhollyKnown() {
+			return aVal.Equals(bVal).True()
+		}
+	}
+
+	return strings.TrimSpace(string(hclwrite.Format(aBytes))) == strings.TrimSpace(string(hclwrite.Format(bBytes)))
+}
@@ -0,0 +1,243 @@
+package hcl
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	hcl2 "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/hashicorp/nomad/api"
+	"github.com/wlame/njgit/internal/redact"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// FormatSubmissionVariables renders the variables a job was submitted with
+// as a var-file
+//
+// A submission carries variables in two places: the var-file content (from
+// -var-file or the UI) and the -var flags. Both are written to one file, the
+// var-file content first and the flags after it, sorted by name.
+//
+// Parameters:
+//   - submission: The job submission fetched from Nomad
+//   - redactValues: Replace every value with its placeholder, keeping only the names
+//
+// Returns:
+//   - []byte: The var-file content, or nil if the submission has no variables
+//   - error: If the var-file content can't be parsed for redaction
+func FormatSubmissionVariables(submission *api.JobSubmission, redactValues bool) ([]byte, error) {
+	if submission == nil || (submission.Variables == "" && len(submission.VariableFlags) == 0) {
+		return nil, nil
+	}
+
+	var buf bytes.Buffer
+
+	if submission.Variables != "" {
+		content := []byte(submission.Variables)
+		if redactValues {
+			file, diags := hclwrite.ParseConfig(content, "variables.hcl", hcl2.InitialPos)
+			if diags.HasErrors() {
+				return nil, fmt.Errorf("failed to parse submitted variables: %s", diags.Error())
+			}
+			values, err := variableValues(content)
+			if err != nil {
+				return nil, err
+			}
+			for name := range file.Body().Attributes() {
+				file.Body().SetAttributeValue(name, cty.StringVal(redact.Placeholder(values[name])))
+			}
+			content = file.Bytes()
+		}
+		buf.Write(bytes.TrimRight(content, "\n"))
+		buf.WriteString("\n")
+	}
+
+	if len(submission.VariableFlags) > 0 {
+		if buf.Len() > 0 {
+			buf.WriteString("\n")
+		}
+		buf.WriteString("# -var flags\n")
+
+		names := make([]string, 0, len(submission.VariableFlags))
+		for name := range submission.VariableFlags {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		flags := hclwrite.NewEmptyFile()
+		for _, name := range names {
+			value := submission.VariableFlags[name]
+			if redactValues {
+				value = redact.Placeholder(value)
+			}
+			flags.Body().SetAttributeValue(name, cty.StringVal(value))
+		}
+		buf.Write(flags.Bytes())
+	}
+
+	return buf.Bytes(), nil
+}
+
+// variableValues returns the value of each variable in var-file content,
+// as the string its placeholder is made from
+// Strings, numbers and bools give their value without HCL quoting, the same
+// as a -var flag or a secrets_file entry would hold it; lists, maps and
+// other expressions give their HCL source.
+func variableValues(content []byte) (map[string]string, error) {
+	file, diags := hclsyntax.ParseConfig(content, "variables.hcl", hcl2.InitialPos)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to parse submitted variables: %s", diags.Error())
+	}
+	attrs, diags := file.Body.JustAttributes()
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to parse submitted variables: %s", diags.Error())
+	}
+
+	values := make(map[string]string, len(attrs))
+	for name, attr := range attrs {
+		values[name] = strings.TrimSpace(string(attr.Expr.Range().SliceBytes(content)))
+
+		value, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() || !value.IsWhollyKnown() || value.IsNull() || !value.Type().IsPrimitiveType() {
+			continue
+		}
+		if str, err := convert.Convert(value, cty.String); err == nil {
+			values[name] = str.AsString()
+		}
+	}
+	return values, nil
+}
+
+// SubmissionVariableValues returns the values of the variables a job was
+// submitted with, from both the var-file content and the -var flags
+// These are the values placeholders in a stored var-file were made from, so
+// adding them to a redact.Resolver restores the ones that haven't changed.
+func SubmissionVariableValues(submission *api.JobSubmission) []string {
+	if submission == nil {
+		return nil
+	}
+
+	var values []string
+	if submission.Variables != "" {
+		// A var-file that doesn't parse has no values to offer
+		fileValues, _ := variableValues([]byte(submission.Variables))
+		for _, value := range fileValues {
+			values = append(values, value)
+		}
+	}
+	for _, value := range submission.VariableFlags {
+		values = append(values, value)
+	}
+	return values
+}
+
+// ResolveVariables restores the placeholders in a stored var-file, so it
+// can be sent to Nomad with the jobspec
+// A variable that is exactly one placeholder gets the original value back:
+// a list or map as HCL, anything else as a string (Nomad converts strings
+// to the variable's declared type).
+//
+// Parameters:
+//   - content: The var-file, as written by FormatSubmissionVariables
+//   - resolver: The known secret values
+//
+// Returns:
+//   - []byte: The var-file with the known placeholders replaced
+//   - []string: The variables that still hold placeholders
+//   - error: If the var-file can't be parsed
+func ResolveVariables(content []byte, resolver *redact.Resolver) ([]byte, []string, error) {
+	file, diags := hclwrite.ParseConfig(content, "variables.hcl", hcl2.InitialPos)
+	if diags.HasErrors() {
+		return nil, nil, fmt.Errorf("failed to parse variables: %s", diags.Error())
+	}
+	values, err := variableValues(content)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var unresolved []string
+	for _, name := range names {
+		value := values[name]
+		if !redact.PlaceholderPattern.MatchString(value) {
+			continue
+		}
+
+		resolved, missing := resolver.Resolve(value)
+		if len(missing) > 0 {
+			unresolved = append(unresolved, name)
+		}
+		if resolved == value {
+			continue
+		}
+		if redact.PlaceholderPattern.FindString(value) == value {
+			file.Body().SetAttributeRaw(name, restoredValueTokens(resolved))
+		} else {
+			file.Body().SetAttributeValue(name, cty.StringVal(resolved))
+		}
+	}
+
+	return file.Bytes(), unresolved, nil
+}
+
+// restoredValueTokens returns the tokens of a restored variable value
+// Lists and maps were hashed by their HCL source (see variableValues), so a
+// value that is one is written back as HCL; anything else is a string.
+func restoredValueTokens(value string) hclwrite.Tokens {
+	source := []byte("value = " + value + "\n")
+	if isCollectionSource(source) {
+		file, diags := hclwrite.ParseConfig(source, "value.hcl", hcl2.InitialPos)
+		if !diags.HasErrors() {
+			return file.Body().GetAttribute("value").Expr().BuildTokens(nil)
+		}
+	}
+	return hclwrite.TokensForValue(cty.StringVal(value))
+}
+
+// isCollectionSource reports whether the value attribute in source is a
+// list, map or object literal
+func isCollectionSource(source []byte) bool {
+	parsed, diags := hclsyntax.ParseConfig(source, "value.hcl", hcl2.InitialPos)
+	if diags.HasErrors() {
+		return false
+	}
+	attrs, diags := parsed.Body.JustAttributes()
+	if diags.HasErrors() {
+		return false
+	}
+	value, diags := attrs["value"].Expr.Value(nil)
+	if diags.HasErrors() {
+		return false
+	}
+	ty := value.Type()
+	return ty.IsCollectionType() || ty.IsObjectType() || ty.IsTupleType()
+}
+
+// DeclaresVariables reports whether a jobspec has variable blocks, i.e.
+// whether it is a submitted jobspec that needs its var-file to be parsed
+// Generated HCL has every value filled in and declares none.
+func DeclaresVariables(jobHCL []byte) bool {
+	file, diags := hclsyntax.ParseConfig(jobHCL, "job.hcl", hcl2.InitialPos)
+	if diags.HasErrors() {
+		return false
+	}
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return false
+	}
+	for _, block := range body.Blocks {
+		if block.Type == "variable" {
+			return true
+		}
+	}
+	return false
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/wlame/njgit/internal/config"
 )
@@ -20,12 +21,15 @@ type AuthConfig struct {
 	// Token is the Nomad ACL token for authentication
 	Token string
 
-	// CACert is the path to a CA certificate for TLS verification
-	CACert string
+	// TLS holds the CA, client certificate and verification settings
+	TLS TLSOptions
 
-	// TLSSkipVerify controls whether to skip TLS certificate verification
-	// This should be false in production
-	TLSSkipVerify bool
+	// Login is set when the token comes from logging in to an auth method
+	// (JWT or OIDC) rather than from Token
+	Login *LoginConfig
+
+	// Timeout limits each API request (zero means no limit)
+	Timeout time.Duration
 }
 
 // ResolveAuth resolves Nomad authentication from multiple sources
@@ -35,6 +39,9 @@ type AuthConfig struct {
 //  3. Environment variables (NOMAD_ADDR, NOMAD_TOKEN)
 //  4. Token file (~/.nomad-token)
 //
+// With [nomad.auth] method "jwt" or "oidc", steps 2-4 are replaced by a login
+// to the auth method; the client gets and refreshes the token itself.
+//
 // This function implements the authentication precedence logic described in the architecture plan.
 //
 // Parameters:
@@ -47,8 +54,8 @@ type AuthConfig struct {
 //   - error: Error if authentication cannot be resolved
 func ResolveAuth(cfg *config.NomadConfig, cliToken, cliAddr string) (*AuthConfig, error) {
 	auth := &AuthConfig{
-		CACert:        cfg.CACert,
-		TLSSkipVerify: cfg.TLSSkipVerify,
+		TLS:     TLSOptionsFromConfig(cfg),
+		Timeout: cfg.Timeout,
 	}
 
 	// Resolve Address
@@ -67,6 +74,18 @@ func ResolveAuth(cfg *config.NomadConfig, cliToken, cliAddr string) (*AuthConfig
 		return nil, fmt.Errorf("nomad address not configured (set via --nomad-addr flag, config file, or NOMAD_ADDR env var)")
 	}
 
+	// With a login method the token comes from the auth method, unless
+	// one was passed on the command line
+	if method := cfg.Auth.Method; (method == LoginMethodJWT || method == LoginMethodOIDC) && cliToken == "" {
+		auth.Login = &LoginConfig{
+			Method:     method,
+			AuthMethod: cfg.Auth.AuthMethod,
+			JWTFile:    cfg.Auth.JWTFile,
+			Cache:      NewTokenCache(cfg.Auth.TokenCache),
+		}
+		return auth, nil
+	}
+
 	// Resolve Token
 	// Priority: CLI flag > config > NOMAD_TOKEN env var > ~/.nomad-token file
 	if cliToken != "" {
@@ -143,6 +162,19 @@ func (a *AuthConfig) ValidateAuth() error {
 	// we might want to warn the user
 	// For now, we'll just validate what we have
 
+	if a.Login != nil {
+		if a.Login.AuthMethod == "" {
+			return fmt.Errorf("auth method name is required for %s login", a.Login.Method)
+		}
+		if a.Login.Method == LoginMethodJWT && a.Login.JWTFile == "" {
+			return fmt.Errorf("JWT file is required for jwt login")
+		}
+	}
+
+	if err := a.TLS.Validate(); err != nil {
+		return fmt.Errorf("invalid TLS config: %w", err)
+	}
+
 	return nil
 }
 
@@ -150,11 +182,13 @@ func (a *AuthConfig) ValidateAuth() error {
 // This is useful for debugging, but we need to redact the token
 func (a *AuthConfig) String() string {
 	token := "none"
-	if a.Token != "" {
+	if a.Login != nil {
+		token = a.Login.Method + " login (" + a.Login.AuthMethod + ")"
+	} else if a.Token != "" {
 		// Don't log the actual token - security best practice
 		token = "********"
 	}
 
-	return fmt.Sprintf("AuthConfig{Address: %s, Token: %s, TLSSkipVerify: %v}",
-		a.Address, token, a.TLSSkipVerify)
+	return fmt.Sprintf("AuthConfig{Address: %s, Token: %s, ClientCert: %s, TLSSkipVerify: %v}",
+		a.Address, token, a.TLS.ClientCert, a.TLS.SkipVerify)
 }
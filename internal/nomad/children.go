@@ -0,0 +1,58 @@
+package nomad
+
+import (
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+)
+
+// Child jobs are instances launched from a periodic or parameterized parent:
+//   - periodic:      "<parent>/periodic-<unix-time>"
+//   - parameterized: "<parent>/dispatch-<unix-time>-<random>"
+//
+// They carry a copy of the parent's spec, so tracking them would flood the
+// repository with one file per launch. Only the parent spec is tracked.
+var childJobMarkers = []string{"/periodic-", "/dispatch-"}
+
+// IsChildJob reports whether a job was launched from a periodic or
+// parameterized parent job
+func IsChildJob(job *api.Job) bool {
+	if job == nil {
+		return false
+	}
+	if job.ParentID != nil && *job.ParentID != "" {
+		return true
+	}
+	return job.Dispatched
+}
+
+// ParentJobID returns the parent job ID encoded in a child job ID
+// The second return value is false if the ID doesn't look like a child job
+func ParentJobID(jobID string) (string, bool) {
+	for _, marker := range childJobMarkers {
+		if idx := strings.LastIndex(jobID, marker); idx > 0 {
+			return jobID[:idx], true
+		}
+	}
+	return jobID, false
+}
+
+// FilterChildJobs splits a job listing into parent (trackable) jobs and a
+// count of child instances per parent ID
+func FilterChildJobs(jobs []*api.JobListStub) ([]*api.JobListStub, map[string]int) {
+	parents := make([]*api.JobListStub, 0, len(jobs))
+	children := make(map[string]int)
+
+	for _, job := range jobs {
+		if job == nil {
+			continue
+		}
+		if job.ParentID != "" {
+			children[job.ParentID]++
+			continue
+		}
+		parents = append(parents, job)
+	}
+
+	return parents, children
+}
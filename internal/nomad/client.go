@@ -1,7 +1,9 @@
 package nomad
 
 import (
+	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/hashicorp/nomad/api"
@@ -19,6 +21,25 @@ type Client struct {
 
 	// auth stores the authentication configuration used to create this client
 	auth *AuthConfig
+
+	// mu guards the token fields, which change when a login token is refreshed
+	mu sync.Mutex
+
+	// token is the current token from a login (unused for static tokens)
+	token string
+
+	// tokenExpiry is when token expires (nil if it doesn't)
+	tokenExpiry *time.Time
+}
+
+// withTimeout derives the context for a single Nomad request
+// The configured timeout (if any) applies per request, on top of whatever
+// deadline or cancellation the caller's context already carries.
+func (c *Client) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.auth.Timeout > 0 {
+		return context.WithTimeout(ctx, c.auth.Timeout)
+	}
+	return context.WithCancel(ctx)
 }
 
 // NewClient creates a new Nomad client with the given authentication
@@ -61,15 +82,7 @@ func NewClient(auth *AuthConfig) (*Client, error) {
 
 	// Configure TLS settings
 	// TLSConfig controls how the client handles HTTPS connections
-	if auth.TLSSkipVerify {
-		// Skip certificate verification (not recommended for production)
-		config.TLSConfig.Insecure = true
-	}
-
-	if auth.CACert != "" {
-		// Use a custom CA certificate for verification
-		config.TLSConfig.CACert = auth.CACert
-	}
+	ApplyTLS(config.TLSConfig, auth.TLS)
 
 	// Create the actual Nomad API client
 	// This is the HashiCorp-provided client that talks to Nomad's HTTP API
@@ -91,16 +104,26 @@ func NewClient(auth *AuthConfig) (*Client, error) {
 // In Go, it's common to have a "ping" or "health check" function that validates
 // connectivity without doing any real operations.
 //
+// Parameters:
+//   - ctx: Cancels the request; the configured timeout applies on top
+//
 // Returns:
 //   - error: nil if Nomad is reachable and responding, error otherwise
-func (c *Client) Ping() error {
+func (c *Client) Ping(ctx context.Context) error {
 	// Use the Agent API to get the agent's own information
 	// This is a lightweight operation that requires authentication if ACLs are enabled
-	// The context.Background() pattern will be explained when we add context support
+	if err := c.refreshToken(ctx); err != nil {
+		return err
+	}
+
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
 
 	// Get the agent's self information
-	// This returns information about the Nomad agent we're connected to
-	_, err := c.client.Agent().Self()
+	// Agent().Self() takes no query options, so the raw endpoint is queried
+	// to attach the context
+	var self api.AgentSelf
+	_, err := c.client.Raw().Query("/v1/agent/self", &self, (&api.QueryOptions{}).WithContext(ctx))
 	if err != nil {
 		return fmt.Errorf("failed to ping Nomad: %w", err)
 	}
@@ -136,12 +159,13 @@ func (c *Client) Close() error {
 // This is useful for discovering what jobs exist in Nomad
 //
 // Parameters:
+//   - ctx: Cancels the request; the configured timeout applies on top
 //   - namespace: The Nomad namespace to query (e.g., "default", "production")
 //
 // Returns:
 //   - []*api.JobListStub: A list of job stubs (summary information, not full specs)
 //   - error: Any error encountered
-func (c *Client) ListJobs(namespace string) ([]*api.JobListStub, error) {
+func (c *Client) ListJobs(ctx context.Context, namespace string) ([]*api.JobListStub, error) {
 	// Create query options
 	// QueryOptions control how the query is executed (namespace, consistency, etc.)
 	opts := &api.QueryOptions{
@@ -152,7 +176,12 @@ func (c *Client) ListJobs(namespace string) ([]*api.JobListStub, error) {
 	// The List() method returns job stubs, which contain summary info but not full specs
 	// The second return value (*QueryMeta) contains metadata about the query (index, etc.)
 	// We ignore it with _ since we don't need it for this use case
-	jobs, _, err := c.client.Jobs().List(opts)
+	if err := c.refreshToken(ctx); err != nil {
+		return nil, err
+	}
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	jobs, _, err := c.client.Jobs().List(opts.WithContext(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("failed to list jobs in namespace %s: %w", namespace, err)
 	}
@@ -165,7 +194,9 @@ func (c *Client) ListJobs(namespace string) ([]*api.JobListStub, error) {
 // In Go, it's common to provide access to the underlying client for advanced use cases
 //
 // Use this sparingly - prefer adding methods to Client for common operations
+// With a login config, the token is refreshed before the client is returned
 func (c *Client) GetAPIClient() *api.Client {
+	_ = c.refreshToken(context.Background())
 	return c.client
 }
 
@@ -173,16 +204,17 @@ func (c *Client) GetAPIClient() *api.Client {
 // This is useful when starting up - Nomad might not be immediately available
 //
 // Parameters:
+//   - ctx: Stops the retries when canceled
 //   - maxAttempts: Maximum number of connection attempts
 //   - delay: Time to wait between attempts
 //
 // Returns:
 //   - error: nil if connection succeeded, error if all attempts failed
-func (c *Client) WaitForConnection(maxAttempts int, delay time.Duration) error {
+func (c *Client) WaitForConnection(ctx context.Context, maxAttempts int, delay time.Duration) error {
 	var lastErr error
 
 	for attempt := 1; attempt <= maxAttempts; attempt++ {
-		err := c.Ping()
+		err := c.Ping(ctx)
 		if err == nil {
 			// Success!
 			return nil
@@ -193,7 +225,11 @@ func (c *Client) WaitForConnection(maxAttempts int, delay time.Duration) error {
 
 		// If this isn't the last attempt, wait before retrying
 		if attempt < maxAttempts {
-			time.Sleep(delay)
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
 		}
 	}
 
@@ -204,16 +240,31 @@ func (c *Client) WaitForConnection(maxAttempts int, delay time.Duration) error {
 // DeployJob submits a job to Nomad for deployment
 // This is used to deploy or update a job in Nomad
 //
+// A multiregion job is sent to the region it names (see
+// MultiregionDeployRegion to pick one); Nomad fans it out from there.
+// The job is not modified.
+//
 // Parameters:
+//   - ctx: Cancels the request; the configured timeout applies on top
 //   - job: The Job specification to deploy
 //
 // Returns:
 //   - string: The evaluation ID created by Nomad
 //   - error: Any error encountered during deployment
-func (c *Client) DeployJob(job *api.Job) (string, error) {
+func (c *Client) DeployJob(ctx context.Context, job *api.Job) (string, error) {
+	opts := &api.WriteOptions{}
+	if IsMultiregion(job) && job.Region != nil {
+		opts.Region = *job.Region
+	}
+
 	// Register the job with Nomad
 	// This creates or updates the job
-	resp, _, err := c.client.Jobs().Register(job, nil)
+	if err := c.refreshToken(ctx); err != nil {
+		return "", err
+	}
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	resp, _, err := c.client.Jobs().Register(job, opts.WithContext(ctx))
 	if err != nil {
 		return "", fmt.Errorf("failed to register job: %w", err)
 	}
@@ -0,0 +1,241 @@
+package nomad
+
+import (
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+)
+
+// Consul Connect makes Nomad inject objects into a job when it is registered:
+//   - a sidecar task per sidecar_service ("connect-proxy-<service>")
+//   - a gateway task per gateway service ("connect-ingress-<service>", ...)
+//   - a dynamic port in the group network for each sidecar
+//
+// None of these are written by the user; they are derived from the connect
+// block on every registration. Committing them would make the stored spec
+// disagree with what the user wrote and duplicate the proxy on redeploy.
+
+// connectProxyPrefix is the prefix of injected sidecar task names and port labels
+const connectProxyPrefix = "connect-proxy-"
+
+// injectedTaskKinds are the task kind prefixes Nomad uses for injected tasks
+// "connect-native:" is deliberately absent: native tasks are user-defined
+var injectedTaskKinds = []string{
+	"connect-proxy:",
+	"connect-ingress:",
+	"connect-terminating:",
+	"connect-mesh:",
+}
+
+// IsConnectInjectedTask reports whether a task was injected by Nomad for a
+// Connect sidecar or gateway rather than defined in the job
+func IsConnectInjectedTask(task *api.Task) bool {
+	if task == nil {
+		return false
+	}
+	for _, kind := range injectedTaskKinds {
+		if strings.HasPrefix(task.Kind, kind) {
+			return true
+		}
+	}
+	return false
+}
+
+// removeConnectInjections strips Nomad-injected Connect objects from a group
+func removeConnectInjections(group *api.TaskGroup) {
+	if group.Tasks != nil {
+		tasks := make([]*api.Task, 0, len(group.Tasks))
+		for _, task := range group.Tasks {
+			if !IsConnectInjectedTask(task) {
+				tasks = append(tasks, task)
+			}
+		}
+		group.Tasks = tasks
+	}
+
+	for _, network := range group.Networks {
+		if network == nil || network.DynamicPorts == nil {
+			continue
+		}
+		ports := make([]api.Port, 0, len(network.DynamicPorts))
+		for _, port := range network.DynamicPorts {
+			if !strings.HasPrefix(port.Label, connectProxyPrefix) {
+				ports = append(ports, port)
+			}
+		}
+		network.DynamicPorts = ports
+	}
+
+	for _, service := range group.Services {
+		removeServiceInjections(service)
+	}
+	for _, task := range group.Tasks {
+		for _, service := range task.Services {
+			removeServiceInjections(service)
+		}
+	}
+}
+
+// removeServiceInjections clears server-derived values from a service
+func removeServiceInjections(service *api.Service) {
+	if service == nil {
+		return
+	}
+
+	// The sidecar port label defaults to the injected port
+	if service.Connect != nil && service.Connect.SidecarService != nil &&
+		service.Connect.SidecarService.Port == connectProxyPrefix+service.Name {
+		service.Connect.SidecarService.Port = ""
+	}
+
+	// Service identity names are always derived by Nomad
+	// ("consul-service_<service>-<port>") and can't be set in a job file
+	if service.Identity != nil {
+		service.Identity.Name = ""
+	}
+}
+
+// deepCopyConsulConnect creates a deep copy of a service's connect block
+func deepCopyConsulConnect(c *api.ConsulConnect) *api.ConsulConnect {
+	if c == nil {
+		return nil
+	}
+
+	return &api.ConsulConnect{
+		Native:         c.Native,
+		Gateway:        deepCopyConsulGateway(c.Gateway),
+		SidecarService: deepCopySidecarService(c.SidecarService),
+		SidecarTask:    deepCopySidecarTask(c.SidecarTask),
+	}
+}
+
+// deepCopySidecarService creates a deep copy of a sidecar_service block
+func deepCopySidecarService(s *api.ConsulSidecarService) *api.ConsulSidecarService {
+	if s == nil {
+		return nil
+	}
+
+	return &api.ConsulSidecarService{
+		Tags:                   copyStrings(s.Tags),
+		Port:                   s.Port,
+		Proxy:                  deepCopyConsulProxy(s.Proxy),
+		DisableDefaultTCPCheck: s.DisableDefaultTCPCheck,
+		Meta:                   copyStringMap(s.Meta),
+	}
+}
+
+// deepCopyConsulProxy creates a deep copy of a sidecar proxy block
+func deepCopyConsulProxy(p *api.ConsulProxy) *api.ConsulProxy {
+	if p == nil {
+		return nil
+	}
+
+	copied := &api.ConsulProxy{
+		LocalServiceAddress: p.LocalServiceAddress,
+		LocalServicePort:    p.LocalServicePort,
+		Config:              copyInterfaceMap(p.Config),
+	}
+
+	// ExposeConfig is the deprecated spelling of Expose
+	expose := p.Expose
+	if expose == nil {
+		expose = p.ExposeConfig
+	}
+	if expose != nil {
+		paths := expose.Paths
+		if paths == nil {
+			paths = expose.Path
+		}
+		copied.Expose = &api.ConsulExposeConfig{}
+		for _, path := range paths {
+			copied.Expose.Paths = append(copied.Expose.Paths, copyPointer(path))
+		}
+	}
+
+	if p.Upstreams != nil {
+		copied.Upstreams = make([]*api.ConsulUpstream, len(p.Upstreams))
+		for i, upstream := range p.Upstreams {
+			copied.Upstreams[i] = upstream.Copy()
+		}
+	}
+
+	if tp := p.TransparentProxy; tp != nil {
+		copied.TransparentProxy = &api.ConsulTransparentProxy{
+			UID:                  tp.UID,
+			OutboundPort:         tp.OutboundPort,
+			ExcludeInboundPorts:  copyStrings(tp.ExcludeInboundPorts),
+			ExcludeOutboundCIDRs: copyStrings(tp.ExcludeOutboundCIDRs),
+			ExcludeUIDs:          copyStrings(tp.ExcludeUIDs),
+			NoDNS:                tp.NoDNS,
+		}
+		if tp.ExcludeOutboundPorts != nil {
+			copied.TransparentProxy.ExcludeOutboundPorts = append([]uint16(nil), tp.ExcludeOutboundPorts...)
+		}
+	}
+
+	return copied
+}
+
+// deepCopySidecarTask creates a deep copy of a sidecar_task override block
+func deepCopySidecarTask(t *api.SidecarTask) *api.SidecarTask {
+	if t == nil {
+		return nil
+	}
+
+	copied := &api.SidecarTask{
+		Name:          t.Name,
+		Driver:        t.Driver,
+		User:          t.User,
+		Config:        copyInterfaceMap(t.Config),
+		Env:           copyStringMap(t.Env),
+		Resources:     deepCopyResources(t.Resources),
+		Meta:          copyStringMap(t.Meta),
+		KillTimeout:   copyPointer(t.KillTimeout),
+		LogConfig:     deepCopyLogConfig(t.LogConfig),
+		ShutdownDelay: copyPointer(t.ShutdownDelay),
+		KillSignal:    t.KillSignal,
+	}
+
+	if t.VolumeMounts != nil {
+		copied.VolumeMounts = make([]*api.VolumeMount, len(t.VolumeMounts))
+		for i, mount := range t.VolumeMounts {
+			copied.VolumeMounts[i] = deepCopyVolumeMount(mount)
+		}
+	}
+
+	return copied
+}
+
+// deepCopyConsulGateway creates a deep copy of a gateway block
+// api.ConsulGateway.Copy drops the mesh block, so it isn't used here
+func deepCopyConsulGateway(g *api.ConsulGateway) *api.ConsulGateway {
+	if g == nil {
+		return nil
+	}
+
+	copied := &api.ConsulGateway{
+		Ingress:     g.Ingress.Copy(),
+		Terminating: g.Terminating.Copy(),
+	}
+	if g.Mesh != nil {
+		copied.Mesh = &api.ConsulMeshConfigEntry{}
+	}
+
+	if p := g.Proxy; p != nil {
+		copied.Proxy = &api.ConsulGatewayProxy{
+			ConnectTimeout:                  copyPointer(p.ConnectTimeout),
+			EnvoyGatewayBindTaggedAddresses: p.EnvoyGatewayBindTaggedAddresses,
+			EnvoyGatewayNoDefaultBind:       p.EnvoyGatewayNoDefaultBind,
+			EnvoyDNSDiscoveryType:           p.EnvoyDNSDiscoveryType,
+			Config:                          copyInterfaceMap(p.Config),
+		}
+		if p.EnvoyGatewayBindAddresses != nil {
+			copied.Proxy.EnvoyGatewayBindAddresses = make(map[string]*api.ConsulGatewayBindAddress, len(p.EnvoyGatewayBindAddresses))
+			for name, addr := range p.EnvoyGatewayBindAddresses {
+				copied.Proxy.EnvoyGatewayBindAddresses[name] = copyPointer(addr)
+			}
+		}
+	}
+
+	return copied
+}
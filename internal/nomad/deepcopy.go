@@ -0,0 +1,419 @@
+package nomad
+
+import (
+	"github.com/hashicorp/nomad/api"
+)
+
+// copyPointer returns a pointer to a copy of the value p points to
+// Most optional fields in the Nomad API are pointers to simple values
+// (strings, ints, bools, durations), so this covers the common case
+func copyPointer[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+	v := *p
+	return &v
+}
+
+// copyStrings returns an independent copy of a string slice
+func copyStrings(s []string) []string {
+	if s == nil {
+		return nil
+	}
+	copied := make([]string, len(s))
+	copy(copied, s)
+	return copied
+}
+
+// copyStringMap returns an independent copy of a string map
+func copyStringMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	copied := make(map[string]string, len(m))
+	for k, v := range m {
+		copied[k] = v
+	}
+	return copied
+}
+
+// copyInterface returns an independent copy of a driver config value,
+// copying nested maps and lists (scalars are immutable and shared)
+func copyInterface(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for k, inner := range v {
+			copied[k] = copyInterface(inner)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, inner := range v {
+			copied[i] = copyInterface(inner)
+		}
+		return copied
+	case []map[string]interface{}:
+		copied := make([]map[string]interface{}, len(v))
+		for i, inner := range v {
+			copied[i], _ = copyInterface(inner).(map[string]interface{})
+		}
+		return copied
+	case []string:
+		return copyStrings(v)
+	case map[string]string:
+		return copyStringMap(v)
+	}
+	return value
+}
+
+// deepCopyTemplate creates a deep copy of a task template
+func deepCopyTemplate(t *api.Template) *api.Template {
+	if t == nil {
+		return nil
+	}
+
+	copied := &api.Template{
+		SourcePath:    copyPointer(t.SourcePath),
+		DestPath:      copyPointer(t.DestPath),
+		EmbeddedTmpl:  copyPointer(t.EmbeddedTmpl),
+		ChangeMode:    copyPointer(t.ChangeMode),
+		ChangeSignal:  copyPointer(t.ChangeSignal),
+		Once:          copyPointer(t.Once),
+		Splay:         copyPointer(t.Splay),
+		Perms:         copyPointer(t.Perms),
+		Uid:           copyPointer(t.Uid),
+		Gid:           copyPointer(t.Gid),
+		LeftDelim:     copyPointer(t.LeftDelim),
+		RightDelim:    copyPointer(t.RightDelim),
+		Envvars:       copyPointer(t.Envvars),
+		VaultGrace:    copyPointer(t.VaultGrace),
+		ErrMissingKey: copyPointer(t.ErrMissingKey),
+	}
+
+	if t.ChangeScript != nil {
+		copied.ChangeScript = &api.ChangeScript{
+			Command:     copyPointer(t.ChangeScript.Command),
+			Args:        copyStrings(t.ChangeScript.Args),
+			Timeout:     copyPointer(t.ChangeScript.Timeout),
+			FailOnError: copyPointer(t.ChangeScript.FailOnError),
+		}
+	}
+
+	if t.Wait != nil {
+		copied.Wait = &api.WaitConfig{
+			Min: copyPointer(t.Wait.Min),
+			Max: copyPointer(t.Wait.Max),
+		}
+	}
+
+	return copied
+}
+
+// deepCopyArtifact creates a deep copy of a task artifact
+func deepCopyArtifact(a *api.TaskArtifact) *api.TaskArtifact {
+	if a == nil {
+		return nil
+	}
+
+	return &api.TaskArtifact{
+		GetterSource:   copyPointer(a.GetterSource),
+		GetterOptions:  copyStringMap(a.GetterOptions),
+		GetterHeaders:  copyStringMap(a.GetterHeaders),
+		GetterMode:     copyPointer(a.GetterMode),
+		GetterInsecure: copyPointer(a.GetterInsecure),
+		RelativeDest:   copyPointer(a.RelativeDest),
+		Chown:          a.Chown,
+	}
+}
+
+// deepCopyVault creates a deep copy of a task's Vault configuration
+func deepCopyVault(v *api.Vault) *api.Vault {
+	if v == nil {
+		return nil
+	}
+
+	return &api.Vault{
+		Policies:             copyStrings(v.Policies),
+		Role:                 v.Role,
+		Namespace:            copyPointer(v.Namespace),
+		Cluster:              v.Cluster,
+		Env:                  copyPointer(v.Env),
+		DisableFile:          copyPointer(v.DisableFile),
+		ChangeMode:           copyPointer(v.ChangeMode),
+		ChangeSignal:         copyPointer(v.ChangeSignal),
+		AllowTokenExpiration: copyPointer(v.AllowTokenExpiration),
+	}
+}
+
+// deepCopyWorkloadIdentity creates a deep copy of a workload identity
+func deepCopyWorkloadIdentity(w *api.WorkloadIdentity) *api.WorkloadIdentity {
+	if w == nil {
+		return nil
+	}
+
+	copied := *w
+	copied.Audience = copyStrings(w.Audience)
+	return &copied
+}
+
+// deepCopyRestartPolicy creates a deep copy of a restart policy
+func deepCopyRestartPolicy(p *api.RestartPolicy) *api.RestartPolicy {
+	if p == nil {
+		return nil
+	}
+
+	return &api.RestartPolicy{
+		Interval:        copyPointer(p.Interval),
+		Attempts:        copyPointer(p.Attempts),
+		Delay:           copyPointer(p.Delay),
+		Mode:            copyPointer(p.Mode),
+		RenderTemplates: copyPointer(p.RenderTemplates),
+	}
+}
+
+// deepCopyReschedulePolicy creates a deep copy of a reschedule policy
+func deepCopyReschedulePolicy(p *api.ReschedulePolicy) *api.ReschedulePolicy {
+	if p == nil {
+		return nil
+	}
+
+	return &api.ReschedulePolicy{
+		Attempts:      copyPointer(p.Attempts),
+		Interval:      copyPointer(p.Interval),
+		Delay:         copyPointer(p.Delay),
+		DelayFunction: copyPointer(p.DelayFunction),
+		MaxDelay:      copyPointer(p.MaxDelay),
+		Unlimited:     copyPointer(p.Unlimited),
+	}
+}
+
+// deepCopyMigrateStrategy creates a deep copy of a migrate strategy
+func deepCopyMigrateStrategy(m *api.MigrateStrategy) *api.MigrateStrategy {
+	if m == nil {
+		return nil
+	}
+
+	return &api.MigrateStrategy{
+		MaxParallel:     copyPointer(m.MaxParallel),
+		HealthCheck:     copyPointer(m.HealthCheck),
+		MinHealthyTime:  copyPointer(m.MinHealthyTime),
+		HealthyDeadline: copyPointer(m.HealthyDeadline),
+	}
+}
+
+// deepCopyLogConfig creates a deep copy of a task's log configuration
+func deepCopyLogConfig(l *api.LogConfig) *api.LogConfig {
+	if l == nil {
+		return nil
+	}
+
+	return &api.LogConfig{
+		MaxFiles:      copyPointer(l.MaxFiles),
+		MaxFileSizeMB: copyPointer(l.MaxFileSizeMB),
+		Enabled:       copyPointer(l.Enabled),
+		Disabled:      copyPointer(l.Disabled),
+	}
+}
+
+// deepCopyPeriodicConfig creates a deep copy of a periodic (cron) configuration
+func deepCopyPeriodicConfig(p *api.PeriodicConfig) *api.PeriodicConfig {
+	if p == nil {
+		return nil
+	}
+
+	return &api.PeriodicConfig{
+		Enabled:         copyPointer(p.Enabled),
+		Spec:            copyPointer(p.Spec),
+		Specs:           copyStrings(p.Specs),
+		SpecType:        copyPointer(p.SpecType),
+		ProhibitOverlap: copyPointer(p.ProhibitOverlap),
+		TimeZone:        copyPointer(p.TimeZone),
+	}
+}
+
+// deepCopyParameterizedJobConfig creates a deep copy of a parameterized job configuration
+func deepCopyParameterizedJobConfig(p *api.ParameterizedJobConfig) *api.ParameterizedJobConfig {
+	if p == nil {
+		return nil
+	}
+
+	return &api.ParameterizedJobConfig{
+		Payload:      p.Payload,
+		MetaRequired: copyStrings(p.MetaRequired),
+		MetaOptional: copyStrings(p.MetaOptional),
+	}
+}
+
+// deepCopyResources creates a deep copy of task resources, including
+// device requests
+func deepCopyResources(r *api.Resources) *api.Resources {
+	if r == nil {
+		return nil
+	}
+
+	copied := &api.Resources{
+		CPU:         copyPointer(r.CPU),
+		Cores:       copyPointer(r.Cores),
+		MemoryMB:    copyPointer(r.MemoryMB),
+		MemoryMaxMB: copyPointer(r.MemoryMaxMB),
+		DiskMB:      copyPointer(r.DiskMB),
+	}
+
+	if r.Devices != nil {
+		copied.Devices = make([]*api.RequestedDevice, len(r.Devices))
+		for i, device := range r.Devices {
+			copied.Devices[i] = deepCopyRequestedDevice(device)
+		}
+	}
+
+	return copied
+}
+
+// deepCopyRequestedDevice creates a deep copy of a device request
+func deepCopyRequestedDevice(d *api.RequestedDevice) *api.RequestedDevice {
+	if d == nil {
+		return nil
+	}
+
+	copied := &api.RequestedDevice{
+		Name:  d.Name,
+		Count: copyPointer(d.Count),
+	}
+
+	if d.Constraints != nil {
+		copied.Constraints = make([]*api.Constraint, len(d.Constraints))
+		for i, c := range d.Constraints {
+			copied.Constraints[i] = copyPointer(c)
+		}
+	}
+	if d.Affinities != nil {
+		copied.Affinities = make([]*api.Affinity, len(d.Affinities))
+		for i, a := range d.Affinities {
+			copied.Affinities[i] = &api.Affinity{
+				LTarget: a.LTarget,
+				RTarget: a.RTarget,
+				Operand: a.Operand,
+				Weight:  copyPointer(a.Weight),
+			}
+		}
+	}
+
+	return copied
+}
+
+// deepCopyScalingPolicy creates a deep copy of a group scaling policy
+// Server-assigned fields (ID, namespace, target, indexes) are dropped
+func deepCopyScalingPolicy(p *api.ScalingPolicy) *api.ScalingPolicy {
+	if p == nil {
+		return nil
+	}
+
+	return &api.ScalingPolicy{
+		Min:     copyPointer(p.Min),
+		Max:     copyPointer(p.Max),
+		Policy:  copyInterfaceMap(p.Policy),
+		Enabled: copyPointer(p.Enabled),
+		Type:    p.Type,
+	}
+}
+
+// copyInterfaceMap deep copies a decoded JSON/HCL map, including nested
+// maps and lists
+func copyInterfaceMap(m map[string]interface{}) map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+
+	copied := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		copied[k] = copyInterfaceValue(v)
+	}
+	return copied
+}
+
+// copyInterfaceValue deep copies a single decoded JSON/HCL value
+func copyInterfaceValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		return copyInterfaceMap(value)
+	case []interface{}:
+		copied := make([]interface{}, len(value))
+		for i, item := range value {
+			copied[i] = copyInterfaceValue(item)
+		}
+		return copied
+	default:
+		return value
+	}
+}
+
+// deepCopyService creates a deep copy of a service registration block
+func deepCopyService(s *api.Service) *api.Service {
+	if s == nil {
+		return nil
+	}
+
+	copied := *s
+	copied.Tags = copyStrings(s.Tags)
+	copied.CanaryTags = copyStrings(s.CanaryTags)
+	copied.Meta = copyStringMap(s.Meta)
+	copied.CanaryMeta = copyStringMap(s.CanaryMeta)
+	copied.TaggedAddresses = copyStringMap(s.TaggedAddresses)
+	copied.CheckRestart = copyPointer(s.CheckRestart)
+	copied.Connect = deepCopyConsulConnect(s.Connect)
+	copied.Identity = deepCopyWorkloadIdentity(s.Identity)
+	copied.Weights = copyPointer(s.Weights)
+
+	if s.Checks != nil {
+		copied.Checks = make([]api.ServiceCheck, len(s.Checks))
+		for i, check := range s.Checks {
+			copied.Checks[i] = deepCopyServiceCheck(check)
+		}
+	}
+
+	return &copied
+}
+
+// deepCopyServiceCheck creates a deep copy of a service health check
+func deepCopyServiceCheck(c api.ServiceCheck) api.ServiceCheck {
+	copied := c
+	copied.Args = copyStrings(c.Args)
+	copied.CheckRestart = copyPointer(c.CheckRestart)
+
+	if c.Header != nil {
+		copied.Header = make(map[string][]string, len(c.Header))
+		for name, values := range c.Header {
+			copied.Header[name] = copyStrings(values)
+		}
+	}
+
+	return copied
+}
+
+// deepCopyNetworkResource creates a deep copy of a group network block
+func deepCopyNetworkResource(n *api.NetworkResource) *api.NetworkResource {
+	if n == nil {
+		return nil
+	}
+
+	copied := &api.NetworkResource{
+		Mode:     n.Mode,
+		Hostname: n.Hostname,
+	}
+	if n.ReservedPorts != nil {
+		copied.ReservedPorts = append([]api.Port(nil), n.ReservedPorts...)
+	}
+	if n.DynamicPorts != nil {
+		copied.DynamicPorts = append([]api.Port(nil), n.DynamicPorts...)
+	}
+	if n.DNS != nil {
+		copied.DNS = &api.DNSConfig{
+			Servers:  copyStrings(n.DNS.Servers),
+			Searches: copyStrings(n.DNS.Searches),
+			Options:  copyStrings(n.DNS.Options),
+		}
+	}
+
+	return copied
+}
@@ -1,6 +1,7 @@
 package nomad
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -36,13 +37,14 @@ func (e JobNotFoundError) Error() string {
 //   - And much more...
 //
 // Parameters:
+//   - ctx: Cancels the request; the configured timeout applies on top
 //   - namespace: The Nomad namespace (e.g., "default", "production")
 //   - jobName: The name of the job to fetch
 //
 // Returns:
 //   - *api.Job: The complete job specification
 //   - error: Any error encountered (including JobNotFoundError)
-func (c *Client) FetchJobSpec(namespace, jobName string) (*api.Job, error) {
+func (c *Client) FetchJobSpec(ctx context.Context, namespace, jobName string) (*api.Job, error) {
 	// Validate inputs
 	if namespace == "" {
 		return nil, fmt.Errorf("namespace cannot be empty")
@@ -60,7 +62,12 @@ func (c *Client) FetchJobSpec(namespace, jobName string) (*api.Job, error) {
 	// Fetch the job from Nomad
 	// The Info() method returns the full job specification
 	// The second return value (*QueryMeta) contains query metadata we don't need here
-	job, _, err := c.client.Jobs().Info(jobName, opts)
+	if err := c.refreshToken(ctx); err != nil {
+		return nil, err
+	}
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	job, _, err := c.client.Jobs().Info(jobName, opts.WithContext(ctx))
 	if err != nil {
 		// Check if this is a 404 (job not found)
 		// The Nomad API returns a specific error message for this case
@@ -90,31 +97,33 @@ func (c *Client) FetchJobSpec(namespace, jobName string) (*api.Job, error) {
 // This is separate from the spec because we don't need it for changelog tracking
 //
 // Parameters:
+//   - ctx: Cancels the request
 //   - namespace: The Nomad namespace
 //   - jobName: The name of the job
 //
 // Returns:
 //   - *api.Job: The job with status information
 //   - error: Any error encountered
-func (c *Client) FetchJobStatus(namespace, jobName string) (*api.Job, error) {
+func (c *Client) FetchJobStatus(ctx context.Context, namespace, jobName string) (*api.Job, error) {
 	// For status, we can use the same Info() call
 	// But in the future, we might want to add more status-specific logic
-	return c.FetchJobSpec(namespace, jobName)
+	return c.FetchJobSpec(ctx, namespace, jobName)
 }
 
 // JobExists checks if a job exists in Nomad without fetching the full spec
 // This is more efficient than FetchJobSpec if you only need to check existence
 //
 // Parameters:
+//   - ctx: Cancels the request
 //   - namespace: The Nomad namespace
 //   - jobName: The name of the job
 //
 // Returns:
 //   - bool: true if the job exists, false otherwise
 //   - error: Any error encountered (not including "not found")
-func (c *Client) JobExists(namespace, jobName string) (bool, error) {
+func (c *Client) JobExists(ctx context.Context, namespace, jobName string) (bool, error) {
 	// Try to fetch the job
-	_, err := c.FetchJobSpec(namespace, jobName)
+	_, err := c.FetchJobSpec(ctx, namespace, jobName)
 	if err != nil {
 		// Check if it's a "not found" error
 		if _, ok := err.(JobNotFoundError); ok {
@@ -134,19 +143,61 @@ func (c *Client) JobExists(namespace, jobName string) (bool, error) {
 // This is useful for discovering jobs or syncing all jobs in a namespace
 //
 // Parameters:
+//   - ctx: Cancels the request
 //   - namespace: The Nomad namespace
 //
 // Returns:
 //   - []*api.JobListStub: List of job stubs (summary information)
 //   - error: Any error encountered
-func (c *Client) ListJobsByNamespace(namespace string) ([]*api.JobListStub, error) {
-	return c.ListJobs(namespace)
+func (c *Client) ListJobsByNamespace(ctx context.Context, namespace string) ([]*api.JobListStub, error) {
+	return c.ListJobs(ctx, namespace)
+}
+
+// FetchJobSubmission fetches the source a job version was submitted with
+// Nomad 1.6+ keeps the original jobspec and its variables for each version
+// that was registered with its source attached (the CLI and UI do this).
+// Jobs registered through the API without a source, and older clusters,
+// have no submission.
+//
+// Parameters:
+//   - ctx: Cancels the request; the configured timeout applies on top
+//   - namespace: The Nomad namespace
+//   - jobID: The ID of the job
+//   - version: The job version to fetch the submission for
+//
+// Returns:
+//   - *api.JobSubmission: The submission, or nil if Nomad has none
+//   - error: Any other error encountered
+func (c *Client) FetchJobSubmission(ctx context.Context, namespace, jobID string, version int) (*api.JobSubmission, error) {
+	opts := &api.QueryOptions{
+		Namespace: namespace,
+	}
+
+	if err := c.refreshToken(ctx); err != nil {
+		return nil, err
+	}
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	submission, _, err := c.client.Jobs().Submission(jobID, version, opts.WithContext(ctx))
+	if err != nil {
+		// A missing submission (and a cluster without the endpoint) is a 404
+		if isJobNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to fetch submission for %s/%s version %d: %w", namespace, jobID, version, err)
+	}
+
+	if submission == nil || submission.Source == "" {
+		return nil, nil
+	}
+
+	return submission, nil
 }
 
 // GetJobByID is an alias for FetchJobSpec
 // Some APIs use "ID" instead of "name" - this provides compatibility
-func (c *Client) GetJobByID(namespace, jobID string) (*api.Job, error) {
-	return c.FetchJobSpec(namespace, jobID)
+func (c *Client) GetJobByID(ctx context.Context, namespace, jobID string) (*api.Job, error) {
+	return c.FetchJobSpec(ctx, namespace, jobID)
 }
 
 // isJobNotFoundError checks if an error indicates a job was not found
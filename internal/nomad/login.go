@@ -0,0 +1,367 @@
+package nomad
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/nomad/api"
+)
+
+// Login methods
+const (
+	// LoginMethodJWT exchanges a JWT for a Nomad token via /v1/acl/login
+	LoginMethodJWT = "jwt"
+
+	// LoginMethodOIDC uses the token from an interactive 'njgit login'
+	LoginMethodOIDC = "oidc"
+)
+
+// tokenRefreshMargin is how long before its expiry a token is replaced,
+// so a token doesn't expire halfway through a sync
+const tokenRefreshMargin = time.Minute
+
+// oidcCallbackPath is the path of the OIDC redirect URI
+// Same as the Nomad CLI, so auth methods set up for it work with njgit
+const oidcCallbackPath = "/oidc/callback"
+
+// LoginConfig describes how a Client obtains its ACL token by logging in to
+// a Nomad auth method instead of using a static token
+type LoginConfig struct {
+	// Method is LoginMethodJWT or LoginMethodOIDC
+	Method string
+
+	// AuthMethod is the name of the Nomad auth method
+	AuthMethod string
+
+	// JWTFile is the path to the JWT exchanged by LoginMethodJWT
+	JWTFile string
+
+	// Cache stores tokens between runs
+	Cache *TokenCache
+}
+
+// refreshToken makes sure the client has a token that isn't about to expire
+// It is called before every request. Static tokens are never refreshed;
+// with a login config the token comes from the cache or, for JWT logins, a
+// new login.
+func (c *Client) refreshToken(ctx context.Context) error {
+	login := c.auth.Login
+	if login == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if c.token != "" && !expiresSoon(c.tokenExpiry, now) {
+		return nil
+	}
+
+	// Another njgit run (or 'njgit login') may have logged in already
+	if cached, err := login.Cache.Get(c.auth.Address, login.AuthMethod); err == nil && cached != nil && !expiresSoon(cached.ExpiresAt, now) {
+		c.setToken(cached.SecretID, cached.ExpiresAt)
+		return nil
+	}
+
+	if login.Method != LoginMethodJWT {
+		return fmt.Errorf("no valid Nomad token for auth method %s (run 'njgit login')", login.AuthMethod)
+	}
+
+	token, err := c.LoginJWT(ctx, login.AuthMethod, login.JWTFile)
+	if err != nil {
+		return err
+	}
+
+	// A cache that can't be written only costs a login on the next run
+	_ = login.Cache.Put(c.auth.Address, login.AuthMethod, token)
+
+	c.setToken(token.SecretID, token.ExpirationTime)
+	return nil
+}
+
+// setToken switches the client to a new token
+// The caller must hold c.mu
+func (c *Client) setToken(secretID string, expiresAt *time.Time) {
+	c.token = secretID
+	c.tokenExpiry = expiresAt
+	c.client.SetSecretID(secretID)
+}
+
+// expiresSoon reports whether a token expiring at expiresAt needs replacing
+// Tokens without an expiry never do
+func expiresSoon(expiresAt *time.Time, now time.Time) bool {
+	return expiresAt != nil && !now.Add(tokenRefreshMargin).Before(*expiresAt)
+}
+
+// LoginJWT exchanges a JWT for a Nomad ACL token
+// The JWT file is read on every call, so tokens rotated by the workload
+// identity or CI system are picked up.
+//
+// Parameters:
+//   - ctx: Cancels the login; the configured timeout applies on top
+//   - authMethod: The name of the Nomad JWT auth method
+//   - jwtFile: Path to the file holding the JWT
+//
+// Returns:
+//   - *api.ACLToken: The Nomad token
+//   - error: If the file can't be read or Nomad rejects the login
+func (c *Client) LoginJWT(ctx context.Context, authMethod, jwtFile string) (*api.ACLToken, error) {
+	content, err := os.ReadFile(jwtFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWT: %w", err)
+	}
+
+	jwt := strings.TrimSpace(string(content))
+	if jwt == "" {
+		return nil, fmt.Errorf("JWT file %s is empty", jwtFile)
+	}
+
+	// Logging in needs no token, and an expired one would be rejected
+	c.client.SetSecretID("")
+
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	token, _, err := c.client.ACLAuth().Login(&api.ACLLoginRequest{
+		AuthMethodName: authMethod,
+		LoginToken:     jwt,
+	}, (&api.WriteOptions{}).WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to log in with auth method %s: %w", authMethod, err)
+	}
+
+	return token, nil
+}
+
+// LoginOIDC runs the OIDC login flow and returns the resulting Nomad token
+// A local HTTP listener receives the provider's redirect, so the auth method
+// must allow the redirect URI http://<callbackAddress>/oidc/callback.
+//
+// Parameters:
+//   - ctx: Cancels the login, including the wait for the browser
+//   - authMethod: The name of the Nomad OIDC auth method
+//   - callbackAddress: host:port to listen on for the redirect
+//   - openURL: Called with the provider URL the user has to visit
+//   - timeout: How long to wait for the user to finish logging in
+//
+// Returns:
+//   - *api.ACLToken: The Nomad token
+//   - error: If the flow fails, is denied or times out
+func (c *Client) LoginOIDC(ctx context.Context, authMethod, callbackAddress string, openURL func(string), timeout time.Duration) (*api.ACLToken, error) {
+	listener, err := net.Listen("tcp", callbackAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for the OIDC callback on %s: %w", callbackAddress, err)
+	}
+
+	// Keep the configured host in the redirect URI ("localhost" and
+	// "127.0.0.1" are different URIs to the provider) but take the port
+	// from the listener, in case it was 0
+	host, _, err := net.SplitHostPort(callbackAddress)
+	if err != nil {
+		_ = listener.Close()
+		return nil, fmt.Errorf("invalid callback address %s: %w", callbackAddress, err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	redirectURI := fmt.Sprintf("http://%s%s", net.JoinHostPort(host, fmt.Sprint(port)), oidcCallbackPath)
+
+	nonce, err := randomNonce()
+	if err != nil {
+		_ = listener.Close()
+		return nil, err
+	}
+
+	type callback struct {
+		code  string
+		state string
+		err   error
+	}
+	results := make(chan callback, 1)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(oidcCallbackPath, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		result := callback{code: query.Get("code"), state: query.Get("state")}
+		if providerErr := query.Get("error"); providerErr != "" {
+			result.err = fmt.Errorf("login denied by the provider: %s %s", providerErr, query.Get("error_description"))
+		} else if result.code == "" {
+			result.err = errors.New("OIDC callback without an authorization code")
+		}
+
+		if result.err != nil {
+			http.Error(w, result.err.Error(), http.StatusBadRequest)
+		} else {
+			_, _ = fmt.Fprintln(w, "Login complete. You can close this window and return to njgit.")
+		}
+
+		select {
+		case results <- result:
+		default:
+		}
+	})
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() { _ = server.Serve(listener) }()
+	defer func() { _ = server.Close() }()
+
+	c.client.SetSecretID("")
+
+	urlCtx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	authURL, _, err := c.client.ACLAuth().GetAuthURL(&api.ACLOIDCAuthURLRequest{
+		AuthMethodName: authMethod,
+		RedirectURI:    redirectURI,
+		ClientNonce:    nonce,
+	}, (&api.WriteOptions{}).WithContext(urlCtx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to start OIDC login with auth method %s: %w", authMethod, err)
+	}
+
+	openURL(authURL.AuthURL)
+
+	var result callback
+	select {
+	case result = <-results:
+	case <-time.After(timeout):
+		return nil, fmt.Errorf("timed out after %s waiting for the OIDC login to complete", timeout)
+	case <-ctx.Done():
+		return nil, fmt.Errorf("OIDC login canceled: %w", ctx.Err())
+	}
+	if result.err != nil {
+		return nil, result.err
+	}
+
+	completeCtx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	token, _, err := c.client.ACLAuth().CompleteAuth(&api.ACLOIDCCompleteAuthRequest{
+		AuthMethodName: authMethod,
+		ClientNonce:    nonce,
+		State:          result.state,
+		Code:           result.code,
+		RedirectURI:    redirectURI,
+	}, (&api.WriteOptions{}).WithContext(completeCtx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to complete OIDC login: %w", err)
+	}
+
+	return token, nil
+}
+
+// randomNonce returns a random client nonce for the OIDC flow
+func randomNonce() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate OIDC nonce: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// TokenCache stores Nomad tokens from logins in a file, keyed by Nomad
+// address and auth method
+// The file holds secrets, so it is written with 0600 permissions.
+type TokenCache struct {
+	path string
+}
+
+// CachedToken is a Nomad token in the cache
+type CachedToken struct {
+	SecretID   string     `json:"secret_id"`
+	AccessorID string     `json:"accessor_id"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+}
+
+// NewTokenCache creates a token cache
+// An empty path means nomad-tokens.json in the user cache directory
+// (e.g. ~/.cache/njgit on Linux)
+func NewTokenCache(path string) *TokenCache {
+	if path == "" {
+		if cacheDir, err := os.UserCacheDir(); err == nil {
+			path = filepath.Join(cacheDir, "njgit", "nomad-tokens.json")
+		}
+	}
+	return &TokenCache{path: path}
+}
+
+// Path returns the cache file path
+func (t *TokenCache) Path() string {
+	return t.path
+}
+
+// Get returns the cached token for a Nomad address and auth method
+// Returns nil if there is none; expired tokens are returned as well
+func (t *TokenCache) Get(address, authMethod string) (*CachedToken, error) {
+	tokens, err := t.load()
+	if err != nil {
+		return nil, err
+	}
+	return tokens[cacheKey(address, authMethod)], nil
+}
+
+// Put stores a token for a Nomad address and auth method
+func (t *TokenCache) Put(address, authMethod string, token *api.ACLToken) error {
+	if t.path == "" {
+		return fmt.Errorf("no token cache path")
+	}
+
+	tokens, err := t.load()
+	if err != nil {
+		// A corrupt cache is replaced rather than blocking logins
+		tokens = make(map[string]*CachedToken)
+	}
+	tokens[cacheKey(address, authMethod)] = &CachedToken{
+		SecretID:   token.SecretID,
+		AccessorID: token.AccessorID,
+		ExpiresAt:  token.ExpirationTime,
+	}
+
+	data, err := json.MarshalIndent(tokens, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode token cache: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(t.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create token cache directory: %w", err)
+	}
+	if err := os.WriteFile(t.path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write token cache: %w", err)
+	}
+
+	return nil
+}
+
+// load reads the cache file; a missing file is an empty cache
+func (t *TokenCache) load() (map[string]*CachedToken, error) {
+	tokens := make(map[string]*CachedToken)
+	if t.path == "" {
+		return tokens, nil
+	}
+
+	data, err := os.ReadFile(t.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return tokens, nil
+		}
+		return nil, fmt.Errorf("failed to read token cache: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &tokens); err != nil {
+		return nil, fmt.Errorf("failed to parse token cache: %w", err)
+	}
+	return tokens, nil
+}
+
+// cacheKey identifies a cached token
+func cacheKey(address, authMethod string) string {
+	return strings.TrimSuffix(address, "/") + " " + authMethod
+}
@@ -0,0 +1,106 @@
+package nomad
+
+import (
+	"slices"
+
+	"github.com/hashicorp/nomad/api"
+)
+
+// Multiregion jobs are registered once and fanned out by Nomad to every
+// region in their multiregion block. Each region then reports its own copy,
+// with the region field set to that region, the region's datacenters merged
+// into the job and the region's count applied to the task groups.
+// normalizeMultiregion undoes those per-region values so the stored spec is
+// the same no matter which region it was fetched from.
+
+// IsMultiregion reports whether a job has a multiregion block
+func IsMultiregion(job *api.Job) bool {
+	return job != nil && job.Multiregion != nil && len(job.Multiregion.Regions) > 0
+}
+
+// MultiregionDeployRegion returns the region a multiregion job should be
+// registered in. requested is used if it is one of the job's regions;
+// otherwise the first region of the multiregion block is used.
+func MultiregionDeployRegion(job *api.Job, requested string) string {
+	if !IsMultiregion(job) {
+		return ""
+	}
+
+	for _, region := range job.Multiregion.Regions {
+		if region != nil && region.Name == requested {
+			return requested
+		}
+	}
+	return job.Multiregion.Regions[0].Name
+}
+
+// normalizeMultiregion removes the region-specific values from a multiregion job
+func normalizeMultiregion(job *api.Job) {
+	if !IsMultiregion(job) {
+		return
+	}
+
+	fetchedFrom := ""
+	if job.Region != nil {
+		fetchedFrom = *job.Region
+	}
+
+	// The region is chosen at registration, not by the spec
+	job.Region = nil
+
+	for _, region := range job.Multiregion.Regions {
+		if region == nil || region.Name != fetchedFrom {
+			continue
+		}
+
+		// Datacenters merged in from the fetched region's block are not
+		// part of the job-level spec
+		if len(region.Datacenters) > 0 && slices.Equal(region.Datacenters, job.Datacenters) {
+			job.Datacenters = nil
+		}
+
+		// Nomad replaces the default group count (1) with the region's
+		// count, so a group with exactly that count gets the default back.
+		// The region's count is kept in the multiregion block.
+		if region.Count != nil && *region.Count > 0 {
+			for _, group := range job.TaskGroups {
+				if group != nil && group.Count != nil && *group.Count == *region.Count {
+					one := 1
+					group.Count = &one
+				}
+			}
+		}
+	}
+}
+
+// deepCopyMultiregion creates a deep copy of a multiregion block
+func deepCopyMultiregion(m *api.Multiregion) *api.Multiregion {
+	if m == nil {
+		return nil
+	}
+
+	copied := &api.Multiregion{}
+	if m.Strategy != nil {
+		copied.Strategy = &api.MultiregionStrategy{
+			MaxParallel: copyPointer(m.Strategy.MaxParallel),
+			OnFailure:   copyPointer(m.Strategy.OnFailure),
+		}
+	}
+	if m.Regions != nil {
+		copied.Regions = make([]*api.MultiregionRegion, len(m.Regions))
+		for i, region := range m.Regions {
+			if region == nil {
+				continue
+			}
+			copied.Regions[i] = &api.MultiregionRegion{
+				Name:        region.Name,
+				Count:       copyPointer(region.Count),
+				Datacenters: copyStrings(region.Datacenters),
+				NodePool:    region.NodePool,
+				Meta:        copyStringMap(region.Meta),
+			}
+		}
+	}
+
+	return copied
+}
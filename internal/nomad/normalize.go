@@ -44,6 +44,9 @@ func NormalizeJob(job *api.Job, ignoreFields []string) *api.Job {
 	normalized.Status = nil
 	normalized.StatusDescription = nil
 
+	// Multiregion jobs: drop values that differ between the regional copies
+	normalizeMultiregion(normalized)
+
 	// Normalize nested structures
 	// Jobs contain task groups, which contain tasks, which have configs, etc.
 	// We need to normalize all levels of the hierarchy
@@ -131,6 +134,18 @@ func deepCopyJob(job *api.Job) *api.Job {
 		}
 	}
 
+	// Copy job-level reschedule and migrate policies
+	copied.Reschedule = deepCopyReschedulePolicy(job.Reschedule)
+	copied.Migrate = deepCopyMigrateStrategy(job.Migrate)
+
+	// Copy batch launch settings. ParentID, Dispatched and Payload are
+	// deliberately not copied: they describe a child instance, not the spec
+	copied.Periodic = deepCopyPeriodicConfig(job.Periodic)
+	copied.ParameterizedJob = deepCopyParameterizedJobConfig(job.ParameterizedJob)
+
+	// Copy the multiregion block (regions the job is fanned out to)
+	copied.Multiregion = deepCopyMultiregion(job.Multiregion)
+
 	return copied
 }
 
@@ -169,6 +184,47 @@ func deepCopyTaskGroup(tg *api.TaskGroup) *api.TaskGroup {
 		}
 	}
 
+	// Copy restart, reschedule and migrate policies
+	// Without these, redeploying from Git silently resets them to defaults
+	copied.RestartPolicy = deepCopyRestartPolicy(tg.RestartPolicy)
+	copied.ReschedulePolicy = deepCopyReschedulePolicy(tg.ReschedulePolicy)
+	copied.Migrate = deepCopyMigrateStrategy(tg.Migrate)
+	copied.ShutdownDelay = copyPointer(tg.ShutdownDelay)
+
+	// Copy group networks and services (including Consul Connect)
+	if tg.Networks != nil {
+		copied.Networks = make([]*api.NetworkResource, len(tg.Networks))
+		for i, network := range tg.Networks {
+			copied.Networks[i] = deepCopyNetworkResource(network)
+		}
+	}
+	if tg.Services != nil {
+		copied.Services = make([]*api.Service, len(tg.Services))
+		for i, service := range tg.Services {
+			copied.Services[i] = deepCopyService(service)
+		}
+	}
+
+	// Copy group update strategy and scaling policy
+	if tg.Update != nil {
+		copied.Update = deepCopyUpdateStrategy(tg.Update)
+	}
+	copied.Scaling = deepCopyScalingPolicy(tg.Scaling)
+
+	// Copy ephemeral disk
+	if tg.EphemeralDisk != nil {
+		copied.EphemeralDisk = deepCopyEphemeralDisk(tg.EphemeralDisk)
+	}
+
+	// Copy volume requests (host and CSI volumes)
+	// Losing these on restore would detach a stateful job from its data
+	if tg.Volumes != nil {
+		copied.Volumes = make(map[string]*api.VolumeRequest, len(tg.Volumes))
+		for name, vol := range tg.Volumes {
+			copied.Volumes[name] = deepCopyVolumeRequest(vol)
+		}
+	}
+
 	return copied
 }
 
@@ -188,16 +244,46 @@ func deepCopyTask(task *api.Task) *api.Task {
 	if task.Driver != "" {
 		copied.Driver = task.Driver
 	}
+	copied.User = task.User
+	copied.Leader = task.Leader
+	copied.KillSignal = task.KillSignal
+	copied.ShutdownDelay = task.ShutdownDelay
+	copied.KillTimeout = copyPointer(task.KillTimeout)
+
+	// Copy lifecycle hook (init containers and sidecars)
+	if task.Lifecycle != nil {
+		lifecycle := *task.Lifecycle
+		copied.Lifecycle = &lifecycle
+	}
+
+	// Copy task restart policy and log settings
+	copied.RestartPolicy = deepCopyRestartPolicy(task.RestartPolicy)
+	copied.LogConfig = deepCopyLogConfig(task.LogConfig)
+
+	// Copy the task kind so Nomad-injected Connect tasks can be recognized
+	copied.Kind = task.Kind
+
+	// Copy task-level services
+	if task.Services != nil {
+		copied.Services = make([]*api.Service, len(task.Services))
+		for i, service := range task.Services {
+			copied.Services[i] = deepCopyService(service)
+		}
+	}
+
+	// Copy dispatch payload target (parameterized jobs)
+	if task.DispatchPayload != nil {
+		payload := *task.DispatchPayload
+		copied.DispatchPayload = &payload
+	}
 
 	// Copy config (driver-specific configuration)
 	// This is critical - it contains things like Docker image names
+	// Nested blocks are copied too: redaction rewrites them in place
 	if task.Config != nil {
-		copied.Config = make(map[string]interface{})
+		copied.Config = make(map[string]interface{}, len(task.Config))
 		for k, v := range task.Config {
-			// For simplicity, we do a shallow copy of the config values
-			// This works for most cases (strings, numbers, etc.)
-			// For deeply nested configs, we'd need recursive copying
-			copied.Config[k] = v
+			copied.Config[k] = copyInterface(v)
 		}
 	}
 
@@ -211,11 +297,7 @@ func deepCopyTask(task *api.Task) *api.Task {
 
 	// Copy resources
 	if task.Resources != nil {
-		copied.Resources = &api.Resources{
-			CPU:      task.Resources.CPU,
-			MemoryMB: task.Resources.MemoryMB,
-			DiskMB:   task.Resources.DiskMB,
-		}
+		copied.Resources = deepCopyResources(task.Resources)
 	}
 
 	// Copy meta
@@ -226,6 +308,120 @@ func deepCopyTask(task *api.Task) *api.Task {
 		}
 	}
 
+	// Copy volume mounts
+	if task.VolumeMounts != nil {
+		copied.VolumeMounts = make([]*api.VolumeMount, len(task.VolumeMounts))
+		for i, mount := range task.VolumeMounts {
+			copied.VolumeMounts[i] = deepCopyVolumeMount(mount)
+		}
+	}
+
+	// Copy artifacts
+	if task.Artifacts != nil {
+		copied.Artifacts = make([]*api.TaskArtifact, len(task.Artifacts))
+		for i, artifact := range task.Artifacts {
+			copied.Artifacts[i] = deepCopyArtifact(artifact)
+		}
+	}
+
+	// Copy templates
+	// Templates often carry the actual application config, so they matter
+	// as much as the driver config for change tracking
+	if task.Templates != nil {
+		copied.Templates = make([]*api.Template, len(task.Templates))
+		for i, tmpl := range task.Templates {
+			copied.Templates[i] = deepCopyTemplate(tmpl)
+		}
+	}
+
+	// Copy Vault and workload identity settings
+	copied.Vault = deepCopyVault(task.Vault)
+	copied.Identity = deepCopyWorkloadIdentity(task.Identity)
+	if task.Identities != nil {
+		copied.Identities = make([]*api.WorkloadIdentity, len(task.Identities))
+		for i, identity := range task.Identities {
+			copied.Identities[i] = deepCopyWorkloadIdentity(identity)
+		}
+	}
+
+	return copied
+}
+
+// deepCopyEphemeralDisk creates a deep copy of an ephemeral disk configuration
+func deepCopyEphemeralDisk(d *api.EphemeralDisk) *api.EphemeralDisk {
+	if d == nil {
+		return nil
+	}
+
+	copied := &api.EphemeralDisk{}
+	if d.Sticky != nil {
+		sticky := *d.Sticky
+		copied.Sticky = &sticky
+	}
+	if d.Migrate != nil {
+		migrate := *d.Migrate
+		copied.Migrate = &migrate
+	}
+	if d.SizeMB != nil {
+		size := *d.SizeMB
+		copied.SizeMB = &size
+	}
+
+	return copied
+}
+
+// deepCopyVolumeRequest creates a deep copy of a group volume request
+// VolumeRequest is mostly plain values, so a struct copy handles everything
+// except the mount options pointer and its flag slice
+func deepCopyVolumeRequest(v *api.VolumeRequest) *api.VolumeRequest {
+	if v == nil {
+		return nil
+	}
+
+	copied := *v
+	copied.ExtraKeysHCL = nil
+
+	if v.MountOptions != nil {
+		copied.MountOptions = &api.CSIMountOptions{
+			FSType: v.MountOptions.FSType,
+		}
+		if v.MountOptions.MountFlags != nil {
+			copied.MountOptions.MountFlags = make([]string, len(v.MountOptions.MountFlags))
+			copy(copied.MountOptions.MountFlags, v.MountOptions.MountFlags)
+		}
+	}
+
+	return &copied
+}
+
+// deepCopyVolumeMount creates a deep copy of a task volume mount
+func deepCopyVolumeMount(m *api.VolumeMount) *api.VolumeMount {
+	if m == nil {
+		return nil
+	}
+
+	copied := &api.VolumeMount{}
+	if m.Volume != nil {
+		volume := *m.Volume
+		copied.Volume = &volume
+	}
+	if m.Destination != nil {
+		dest := *m.Destination
+		copied.Destination = &dest
+	}
+	if m.ReadOnly != nil {
+		ro := *m.ReadOnly
+		copied.ReadOnly = &ro
+	}
+	if m.PropagationMode != nil {
+		mode := *m.PropagationMode
+		copied.PropagationMode = &mode
+	}
+	if m.SELinuxLabel != nil {
+		label := *m.SELinuxLabel
+		copied.SELinuxLabel = &label
+	}
+
 	return copied
 }
 
@@ -252,6 +448,11 @@ func deepCopyUpdateStrategy(u *api.UpdateStrategy) *api.UpdateStrategy {
 		hd := *u.HealthyDeadline
 		copied.HealthyDeadline = &hd
 	}
+	copied.ProgressDeadline = copyPointer(u.ProgressDeadline)
+	copied.Stagger = copyPointer(u.Stagger)
+	copied.Canary = copyPointer(u.Canary)
+	copied.AutoRevert = copyPointer(u.AutoRevert)
+	copied.AutoPromote = copyPointer(u.AutoPromote)
 
 	return copied
 }
@@ -269,6 +470,9 @@ func normalizeTaskGroups(groups []*api.TaskGroup) {
 			group.Meta = normalizeMap(group.Meta)
 		}
 
+		// Drop the sidecar/gateway tasks and ports Nomad injects for Connect
+		removeConnectInjections(group)
+
 		// Normalize tasks
 		if group.Tasks != nil {
 			for _, task := range group.Tasks {
@@ -0,0 +1,432 @@
+package nomad
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	hcl2 "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/nomad/api"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Namespace capabilities njgit uses
+const (
+	// CapabilityListJobs is needed to list jobs in a namespace
+	CapabilityListJobs = "list-jobs"
+
+	// CapabilityReadJob is needed by sync, verify and deploy to fetch jobs
+	// and their submissions
+	CapabilityReadJob = "read-job"
+
+	// CapabilitySubmitJob is needed by deploy to register jobs
+	CapabilitySubmitJob = "submit-job"
+
+	// CapabilityParseJob is needed by deploy and verify, which parse job
+	// files with /v1/jobs/parse
+	CapabilityParseJob = "parse-job"
+
+	// capabilityDeny overrides every other capability in a namespace rule
+	capabilityDeny = "deny"
+)
+
+// namespacePolicyCapabilities are the capabilities the namespace policy
+// shorthands expand to (see Nomad's expandNamespacePolicy), limited to the
+// ones njgit checks
+var namespacePolicyCapabilities = map[string][]string{
+	"read":  {CapabilityListJobs, CapabilityParseJob, CapabilityReadJob},
+	"write": {CapabilityListJobs, CapabilityParseJob, CapabilityReadJob, CapabilitySubmitJob},
+	"deny":  {capabilityDeny},
+}
+
+// namespacePolicyVariables are the variable capabilities the namespace policy
+// shorthands grant when a rule has no variables block of its own
+var namespacePolicyVariables = map[string][]string{
+	"read":  {"read", "list"},
+	"write": {"write", "read", "destroy", "list"},
+}
+
+// TokenPermissions describes what the client's ACL token may do
+type TokenPermissions struct {
+	// ACLDisabled is true if the cluster has no ACLs; everything is allowed
+	ACLDisabled bool
+
+	// TokenName and TokenType describe the token ("management" tokens may do anything)
+	TokenName string
+	TokenType string
+
+	// Policies are the policies that apply, directly or through roles
+	Policies []string
+
+	// Unreadable lists policies or roles that couldn't be read or parsed,
+	// so the result may understate the permissions
+	Unreadable []string
+
+	// Namespaces holds the result for each requested namespace
+	Namespaces []NamespacePermissions
+}
+
+// NamespacePermissions describes what a token may do in one namespace
+type NamespacePermissions struct {
+	Namespace string
+
+	// ListJobs, ReadJob, SubmitJob and ParseJob report the matching
+	// capabilities
+	ListJobs  bool
+	ReadJob   bool
+	SubmitJob bool
+	ParseJob  bool
+
+	// ReadVariables is true if Nomad variables can be read at any path
+	ReadVariables bool
+}
+
+// CheckPermissions introspects the client's token and reports the
+// permissions it has in each namespace
+// The token is read from /v1/acl/token/self; its policies (and the policies
+// of its roles) are fetched and evaluated the way Nomad does: the closest
+// matching namespace rule applies, and "deny" wins over everything else.
+//
+// Parameters:
+//   - ctx: Cancels the lookups; the configured timeout applies to the whole check
+//   - namespaces: The namespaces to report on
+//
+// Returns:
+//   - *TokenPermissions: The token and its permissions per namespace
+//   - error: If the token can't be looked up
+func (c *Client) CheckPermissions(ctx context.Context, namespaces []string) (*TokenPermissions, error) {
+	if err := c.refreshToken(ctx); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	opts := (&api.QueryOptions{}).WithContext(ctx)
+
+	perms := &TokenPermissions{}
+
+	token, _, err := c.client.ACLTokens().Self(opts)
+	if err != nil {
+		if strings.Contains(err.Error(), "ACL support disabled") {
+			perms.ACLDisabled = true
+			for _, ns := range namespaces {
+				perms.Namespaces = append(perms.Namespaces, allowAll(ns))
+			}
+			return perms, nil
+		}
+		return nil, fmt.Errorf("failed to look up the ACL token: %w", err)
+	}
+
+	perms.TokenName = token.Name
+	perms.TokenType = token.Type
+
+	if token.Type == "management" {
+		for _, ns := range namespaces {
+			perms.Namespaces = append(perms.Namespaces, allowAll(ns))
+		}
+		return perms, nil
+	}
+
+	// Policies come from the token and from its roles
+	policyNames := make(map[string]bool)
+	for _, name := range token.Policies {
+		policyNames[name] = true
+	}
+	for _, link := range token.Roles {
+		role, _, err := c.client.ACLRoles().Get(link.ID, opts)
+		if err != nil {
+			perms.Unreadable = append(perms.Unreadable, "role "+link.Name)
+			continue
+		}
+		for _, policy := range role.Policies {
+			policyNames[policy.Name] = true
+		}
+	}
+
+	var rules []namespaceRule
+	for name := range policyNames {
+		perms.Policies = append(perms.Policies, name)
+	}
+	sort.Strings(perms.Policies)
+
+	for _, name := range perms.Policies {
+		policy, _, err := c.client.ACLPolicies().Info(name, opts)
+		if err != nil {
+			perms.Unreadable = append(perms.Unreadable, "policy "+name)
+			continue
+		}
+		parsed, err := parseNamespaceRules(policy.Rules)
+		if err != nil {
+			perms.Unreadable = append(perms.Unreadable, fmt.Sprintf("policy %s (%v)", name, err))
+			continue
+		}
+		rules = append(rules, parsed...)
+	}
+
+	merged := mergeNamespaceRules(rules)
+	for _, ns := range namespaces {
+		perms.Namespaces = append(perms.Namespaces, namespacePermissions(ns, merged))
+	}
+
+	return perms, nil
+}
+
+// allowAll returns permissions with every capability granted
+func allowAll(namespace string) NamespacePermissions {
+	return NamespacePermissions{Namespace: namespace, ListJobs: true, ReadJob: true, SubmitJob: true, ParseJob: true, ReadVariables: true}
+}
+
+// namespaceRule is one namespace block of a policy
+type namespaceRule struct {
+	// Name is the namespace name or glob, e.g. "default" or "prod-*"
+	Name string
+
+	// Capabilities includes the expanded policy shorthand
+	Capabilities map[string]bool
+
+	// VariableCapabilities are the variable capabilities of all paths
+	VariableCapabilities map[string]bool
+}
+
+// parseNamespaceRules extracts the namespace rules from an ACL policy
+// Policies may be written in HCL or JSON; blocks other than namespace
+// (node, agent, operator...) are ignored.
+func parseNamespaceRules(rules string) ([]namespaceRule, error) {
+	parser := hclparse.NewParser()
+	var file *hcl2.File
+	var diags hcl2.Diagnostics
+	if strings.HasPrefix(strings.TrimSpace(rules), "{") {
+		file, diags = parser.ParseJSON([]byte(rules), "policy.json")
+	} else {
+		file, diags = parser.ParseHCL([]byte(rules), "policy.hcl")
+	}
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to parse rules: %s", diags.Error())
+	}
+
+	content, _, diags := file.Body.PartialContent(&hcl2.BodySchema{
+		Blocks: []hcl2.BlockHeaderSchema{{Type: "namespace", LabelNames: []string{"name"}}},
+	})
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to parse rules: %s", diags.Error())
+	}
+
+	var parsed []namespaceRule
+	for _, block := range content.Blocks {
+		rule := namespaceRule{
+			Name:                 block.Labels[0],
+			Capabilities:         make(map[string]bool),
+			VariableCapabilities: make(map[string]bool),
+		}
+
+		body, _, diags := block.Body.PartialContent(&hcl2.BodySchema{
+			Attributes: []hcl2.AttributeSchema{{Name: "policy"}, {Name: "capabilities"}},
+			Blocks:     []hcl2.BlockHeaderSchema{{Type: "variables"}},
+		})
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("namespace %q: %s", rule.Name, diags.Error())
+		}
+
+		policy := ""
+		if attr, ok := body.Attributes["policy"]; ok {
+			if policy, diags = stringAttribute(attr); diags.HasErrors() {
+				return nil, fmt.Errorf("namespace %q: %s", rule.Name, diags.Error())
+			}
+			for _, capability := range namespacePolicyCapabilities[policy] {
+				rule.Capabilities[capability] = true
+			}
+		}
+		if attr, ok := body.Attributes["capabilities"]; ok {
+			capabilities, diags := stringListAttribute(attr)
+			if diags.HasErrors() {
+				return nil, fmt.Errorf("namespace %q: %s", rule.Name, diags.Error())
+			}
+			for _, capability := range capabilities {
+				rule.Capabilities[capability] = true
+			}
+		}
+
+		hasVariables := false
+		for _, variables := range body.Blocks {
+			hasVariables = true
+			paths, _, diags := variables.Body.PartialContent(&hcl2.BodySchema{
+				Blocks: []hcl2.BlockHeaderSchema{{Type: "path", LabelNames: []string{"path"}}},
+			})
+			if diags.HasErrors() {
+				return nil, fmt.Errorf("namespace %q variables: %s", rule.Name, diags.Error())
+			}
+			for _, path := range paths.Blocks {
+				attrs, diags := path.Body.JustAttributes()
+				if diags.HasErrors() {
+					return nil, fmt.Errorf("namespace %q variables: %s", rule.Name, diags.Error())
+				}
+				if attr, ok := attrs["capabilities"]; ok {
+					capabilities, diags := stringListAttribute(attr)
+					if diags.HasErrors() {
+						return nil, fmt.Errorf("namespace %q variables: %s", rule.Name, diags.Error())
+					}
+					for _, capability := range capabilities {
+						rule.VariableCapabilities[capability] = true
+					}
+				}
+			}
+		}
+		if !hasVariables {
+			for _, capability := range namespacePolicyVariables[policy] {
+				rule.VariableCapabilities[capability] = true
+			}
+		}
+
+		parsed = append(parsed, rule)
+	}
+
+	return parsed, nil
+}
+
+// stringAttribute evaluates a string attribute
+func stringAttribute(attr *hcl2.Attribute) (string, hcl2.Diagnostics) {
+	value, diags := attr.Expr.Value(nil)
+	if diags.HasErrors() {
+		return "", diags
+	}
+	if value.IsNull() || value.Type() != cty.String {
+		return "", hcl2.Diagnostics{{Severity: hcl2.DiagError, Summary: fmt.Sprintf("%s must be a string", attr.Name)}}
+	}
+	return value.AsString(), nil
+}
+
+// stringListAttribute evaluates a list of strings attribute
+func stringListAttribute(attr *hcl2.Attribute) ([]string, hcl2.Diagnostics) {
+	value, diags := attr.Expr.Value(nil)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	if value.IsNull() || !(value.Type().IsListType() || value.Type().IsTupleType()) {
+		return nil, hcl2.Diagnostics{{Severity: hcl2.DiagError, Summary: fmt.Sprintf("%s must be a list", attr.Name)}}
+	}
+
+	var values []string
+	for it := value.ElementIterator(); it.Next(); {
+		_, element := it.Element()
+		if element.IsNull() || element.Type() != cty.String {
+			return nil, hcl2.Diagnostics{{Severity: hcl2.DiagError, Summary: fmt.Sprintf("%s must be a list of strings", attr.Name)}}
+		}
+		values = append(values, element.AsString())
+	}
+	return values, nil
+}
+
+// mergeNamespaceRules combines the rules of all policies by namespace name
+// A deny in any policy removes every other capability for that name.
+func mergeNamespaceRules(rules []namespaceRule) map[string]namespaceRule {
+	merged := make(map[string]namespaceRule)
+	for _, rule := range rules {
+		existing, ok := merged[rule.Name]
+		if !ok {
+			existing = namespaceRule{
+				Name:                 rule.Name,
+				Capabilities:         make(map[string]bool),
+				VariableCapabilities: make(map[string]bool),
+			}
+		}
+		for capability := range rule.Capabilities {
+			existing.Capabilities[capability] = true
+		}
+		for capability := range rule.VariableCapabilities {
+			existing.VariableCapabilities[capability] = true
+		}
+		merged[rule.Name] = existing
+	}
+	return merged
+}
+
+// namespacePermissions evaluates the merged rules for a namespace
+// An exact rule wins; otherwise the closest matching glob (the one with
+// the fewest characters matched by wildcards) applies, as in Nomad.
+func namespacePermissions(namespace string, rules map[string]namespaceRule) NamespacePermissions {
+	perms := NamespacePermissions{Namespace: namespace}
+
+	rule, ok := rules[namespace]
+	if !ok {
+		best := -1
+		for name, candidate := range rules {
+			if !strings.Contains(name, "*") || !globMatch(name, namespace) {
+				continue
+			}
+			difference := len(namespace) - len(strings.ReplaceAll(name, "*", ""))
+			if best == -1 || difference < best || (difference == best && name < rule.Name) {
+				best = difference
+				rule = candidate
+				ok = true
+			}
+		}
+	}
+	if !ok || rule.Capabilities[capabilityDeny] {
+		return perms
+	}
+
+	perms.ListJobs = rule.Capabilities[CapabilityListJobs]
+	perms.ReadJob = rule.Capabilities[CapabilityReadJob]
+	perms.SubmitJob = rule.Capabilities[CapabilitySubmitJob]
+	perms.ParseJob = rule.Capabilities[CapabilityParseJob]
+	perms.ReadVariables = rule.VariableCapabilities["read"] && !rule.VariableCapabilities[capabilityDeny]
+	return perms
+}
+
+// globMatch matches a name against a pattern where * matches any run of characters
+func globMatch(pattern, name string) bool {
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(name, parts[0]) {
+		return false
+	}
+	name = name[len(parts[0]):]
+
+	last := len(parts) - 1
+	for i := 1; i < last; i++ {
+		index := strings.Index(name, parts[i])
+		if index < 0 {
+			return false
+		}
+		name = name[index+len(parts[i]):]
+	}
+	return strings.HasSuffix(name, parts[last])
+}
+
+// PolicySuggestion returns a policy snippet granting capabilities in a
+// namespace, for error hints
+func PolicySuggestion(namespace string, capabilities []string) string {
+	quoted := make([]string, len(capabilities))
+	for i, capability := range capabilities {
+		quoted[i] = fmt.Sprintf("%q", capability)
+	}
+	return fmt.Sprintf("namespace %q {\n  capabilities = [%s]\n}", namespace, strings.Join(quoted, ", "))
+}
+
+// TokenName looks up the name of an ACL token by its accessor ID
+// Nomad records the accessor of the token that registered a job in
+// Job.NomadTokenID. Reading another token needs a management token, so
+// callers should expect this to fail with narrower tokens.
+//
+// Parameters:
+//   - ctx: Cancels the lookup; the configured timeout applies on top
+//   - accessorID: The token's accessor ID
+//
+// Returns:
+//   - string: The token's name ("" if it has none)
+//   - error: If the token can't be read
+func (c *Client) TokenName(ctx context.Context, accessorID string) (string, error) {
+	if err := c.refreshToken(ctx); err != nil {
+		return "", err
+	}
+
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	token, _, err := c.client.ACLTokens().Info(accessorID, (&api.QueryOptions{}).WithContext(ctx))
+	if err != nil {
+		return "", fmt.Errorf("failed to read ACL token %s: %w", accessorID, err)
+	}
+
+	return token.Name, nil
+}
@@ -0,0 +1,273 @@
+package nomad
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/hashicorp/nomad/api"
+)
+
+// Round-trip verification checks that a rendered job file still describes the
+// job it was rendered from: the file is parsed back into an api.Job and both
+// jobs are compared field by field. Fields the HCL writer doesn't know about
+// show up as differences, instead of surfacing during a rollback.
+
+// FieldDiff is one field that differs between two jobs
+type FieldDiff struct {
+	// Path identifies the field, e.g. TaskGroups[web].Tasks[server].Config[image]
+	// Task groups, tasks and other named elements are keyed by name
+	Path string
+
+	// Expected and Actual are the formatted values ("<unset>" if missing)
+	Expected string
+	Actual   string
+}
+
+// String formats the difference for display
+func (d FieldDiff) String() string {
+	return fmt.Sprintf("%s: %s -> %s", d.Path, d.Expected, d.Actual)
+}
+
+// unsetValue is displayed for a field that has no value
+const unsetValue = "<unset>"
+
+// RoundTripDiff compares a job with the job parsed back from its rendered HCL
+// NormalizeJob only copies the fields the writer knows about, so it can't be
+// used here: a field it drops would be missing on both sides. Instead both
+// jobs are copied in full, and only the normalizations that don't depend on
+// the copy (Connect injections, multiregion values, ordering) are applied.
+// Both are then canonicalized, so defaults the writer leaves out on purpose
+// don't count as differences. Fields that only exist at runtime (version,
+// stability, indexes) are not compared.
+//
+// Parameters:
+//   - original: The job as fetched from Nomad
+//   - parsed: The job parsed from the rendered HCL
+//
+// Returns:
+//   - []FieldDiff: The fields that did not survive the round-trip (nil if none)
+//   - error: If a job can't be copied
+func RoundTripDiff(original, parsed *api.Job) ([]FieldDiff, error) {
+	expected, err := specCopy(original)
+	if err != nil {
+		return nil, err
+	}
+	actual, err := specCopy(parsed)
+	if err != nil {
+		return nil, err
+	}
+
+	return DiffJobs(expected, actual), nil
+}
+
+// specCopy returns a full, canonicalized copy of a job's specification
+func specCopy(job *api.Job) (*api.Job, error) {
+	copied, err := jsonCopy(job)
+	if err != nil {
+		return nil, err
+	}
+
+	normalizeMultiregion(copied)
+	normalizeTaskGroups(copied.TaskGroups)
+	sortJobFields(copied)
+
+	copied.Canonicalize()
+	clearRuntimeFields(copied)
+
+	return copied, nil
+}
+
+// jsonCopy copies a job through JSON
+// Unlike deepCopyJob, this copies every field, including ones the HCL
+// writer doesn't know about.
+func jsonCopy(job *api.Job) (*api.Job, error) {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy job: %w", err)
+	}
+	copied := &api.Job{}
+	if err := json.Unmarshal(data, copied); err != nil {
+		return nil, fmt.Errorf("failed to copy job: %w", err)
+	}
+	return copied, nil
+}
+
+// clearRuntimeFields removes fields that are set by Nomad, not by the job file
+func clearRuntimeFields(job *api.Job) {
+	job.Version = nil
+	job.Stable = nil
+	job.Status = nil
+	job.StatusDescription = nil
+	job.SubmitTime = nil
+	job.CreateIndex = nil
+	job.ModifyIndex = nil
+	job.JobModifyIndex = nil
+	job.NomadTokenID = nil
+	job.ParentID = nil
+	job.Dispatched = false
+	job.Payload = nil
+	job.VersionTag = nil
+}
+
+// DiffJobs lists the fields that differ between two jobs
+// A nil pointer, nil slice or nil map is treated the same as an empty or zero
+// value, since HCL can't tell them apart.
+//
+// Parameters:
+//   - expected: The reference job
+//   - actual: The job to compare against it
+//
+// Returns:
+//   - []FieldDiff: The differing fields, in field order (nil if none)
+func DiffJobs(expected, actual *api.Job) []FieldDiff {
+	var diffs []FieldDiff
+	diffValues("Job", reflect.ValueOf(expected), reflect.ValueOf(actual), &diffs)
+	return diffs
+}
+
+// diffValues walks two values of the same type and records leaf differences
+func diffValues(path string, a, b reflect.Value, diffs *[]FieldDiff) {
+	a, b = indirect(a), indirect(b)
+
+	if isEmptyValue(a) && isEmptyValue(b) {
+		return
+	}
+
+	// Compare a missing value against the zero value of the other side, so
+	// only the fields that are actually set get reported
+	if !a.IsValid() {
+		a = reflect.Zero(b.Type())
+	}
+	if !b.IsValid() {
+		b = reflect.Zero(a.Type())
+	}
+
+	if a.Type() != b.Type() {
+		*diffs = append(*diffs, FieldDiff{Path: path, Expected: formatValue(a), Actual: formatValue(b)})
+		return
+	}
+
+	switch a.Kind() {
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			field := a.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			diffValues(path+"."+field.Name, a.Field(i), b.Field(i), diffs)
+		}
+
+	case reflect.Slice, reflect.Array:
+		aKeys, aElems := keyedElements(a)
+		bKeys, bElems := keyedElements(b)
+
+		for _, key := range aKeys {
+			diffValues(fmt.Sprintf("%s[%s]", path, key), aElems[key], bElems[key], diffs)
+		}
+		for _, key := range bKeys {
+			if _, ok := aElems[key]; !ok {
+				diffValues(fmt.Sprintf("%s[%s]", path, key), reflect.Value{}, bElems[key], diffs)
+			}
+		}
+
+	case reflect.Map:
+		keys := make(map[string]reflect.Value)
+		for _, key := range a.MapKeys() {
+			keys[fmt.Sprint(key.Interface())] = key
+		}
+		for _, key := range b.MapKeys() {
+			keys[fmt.Sprint(key.Interface())] = key
+		}
+
+		names := make([]string, 0, len(keys))
+		for name := range keys {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			key := keys[name]
+			diffValues(fmt.Sprintf("%s[%s]", path, name), a.MapIndex(key), b.MapIndex(key), diffs)
+		}
+
+	default:
+		if !reflect.DeepEqual(a.Interface(), b.Interface()) {
+			*diffs = append(*diffs, FieldDiff{Path: path, Expected: formatValue(a), Actual: formatValue(b)})
+		}
+	}
+}
+
+// keyedElements indexes slice elements by name where they have one
+// (task groups, tasks, services, ...), otherwise by position
+func keyedElements(v reflect.Value) ([]string, map[string]reflect.Value) {
+	keys := make([]string, 0, v.Len())
+	elems := make(map[string]reflect.Value, v.Len())
+
+	for i := 0; i < v.Len(); i++ {
+		elem := v.Index(i)
+		key := elementName(elem)
+		if key == "" {
+			key = fmt.Sprint(i)
+		}
+		if _, ok := elems[key]; ok {
+			// Duplicate names fall back to the position
+			key = fmt.Sprintf("%s#%d", key, i)
+		}
+		keys = append(keys, key)
+		elems[key] = elem
+	}
+
+	return keys, elems
+}
+
+// elementName returns the Name (or Label) field of a struct element
+func elementName(v reflect.Value) string {
+	v = indirect(v)
+	if !v.IsValid() || v.Kind() != reflect.Struct {
+		return ""
+	}
+
+	for _, fieldName := range []string{"Name", "Label"} {
+		field := indirect(v.FieldByName(fieldName))
+		if field.IsValid() && field.Kind() == reflect.String && field.String() != "" {
+			return field.String()
+		}
+	}
+	return ""
+}
+
+// indirect follows pointers and interfaces, returning an invalid value for nil
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// isEmptyValue reports whether a value is missing, zero, or an empty slice or map
+func isEmptyValue(v reflect.Value) bool {
+	if !v.IsValid() {
+		return true
+	}
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
+
+// formatValue formats a leaf value for display
+func formatValue(v reflect.Value) string {
+	if !v.IsValid() || isEmptyValue(v) {
+		return unsetValue
+	}
+	if v.Kind() == reflect.String {
+		return fmt.Sprintf("%q", v.String())
+	}
+	return fmt.Sprint(v.Interface())
+}
@@ -0,0 +1,194 @@
+package nomad
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/wlame/njgit/internal/redact"
+)
+
+// Secrets end up in job specs in a few places: env blocks, meta, driver
+// config (docker auth, command arguments) and template data. These are the
+// fields RedactJob scans and ResolvePlaceholders restores.
+
+// Redaction is one secret replaced in a job
+type Redaction struct {
+	// Path identifies the field, e.g. TaskGroups[web].Tasks[server].Env[DB_PASSWORD]
+	Path string
+
+	redact.Finding
+}
+
+// RedactJob replaces secrets in a job with placeholders, in place
+// Call it on the normalized copy, never on a job that will be registered.
+//
+// Parameters:
+//   - job: The normalized job
+//   - r: The redactor with the configured detectors
+//
+// Returns:
+//   - []Redaction: The secrets that were replaced (nil if none)
+func RedactJob(job *api.Job, r *redact.Redactor) []Redaction {
+	var redactions []Redaction
+
+	walkSecretFields(job, func(path, key, value string, text bool) string {
+		var redacted string
+		var findings []redact.Finding
+		if text {
+			redacted, findings = r.Text(value)
+		} else {
+			redacted, findings = r.Value(key, value)
+		}
+		for _, finding := range findings {
+			redactions = append(redactions, Redaction{Path: path, Finding: finding})
+		}
+		return redacted
+	})
+
+	return redactions
+}
+
+// RedactedCopy returns a full copy of a job with its secrets redacted
+// Round-trip verification compares against this, since the rendered file
+// has placeholders where the fetched job has secrets.
+func RedactedCopy(job *api.Job, r *redact.Redactor) (*api.Job, error) {
+	copied, err := jsonCopy(job)
+	if err != nil {
+		return nil, err
+	}
+	RedactJob(copied, r)
+	return copied, nil
+}
+
+// ResolvePlaceholders replaces placeholders in a job with the secrets they
+// were created from, in place
+// Placeholders in fields RedactJob doesn't scan are reported as well, so a
+// job with no unresolved placeholders is safe to register.
+//
+// Parameters:
+//   - job: The job parsed from a stored file
+//   - resolver: The known secret values
+//
+// Returns:
+//   - []string: "<field>: <placeholder>" for each placeholder left (nil if none)
+//   - error: If the job can't be scanned
+func ResolvePlaceholders(job *api.Job, resolver *redact.Resolver) ([]string, error) {
+	walkSecretFields(job, func(path, key, value string, text bool) string {
+		resolved, _ := resolver.Resolve(value)
+		return resolved
+	})
+
+	// Anything left, wherever it is, blocks registration
+	var unresolved []string
+	walkSecretFields(job, func(path, key, value string, text bool) string {
+		for _, placeholder := range redact.PlaceholderPattern.FindAllString(value, -1) {
+			unresolved = append(unresolved, fmt.Sprintf("%s: %s", path, placeholder))
+		}
+		return value
+	})
+
+	data, err := json.Marshal(job)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan job for placeholders: %w", err)
+	}
+	if len(unresolved) == 0 && redact.PlaceholderPattern.Match(data) {
+		for _, placeholder := range redact.PlaceholderPattern.FindAllString(string(data), -1) {
+			unresolved = append(unresolved, "Job: "+placeholder)
+		}
+	}
+
+	return unresolved, nil
+}
+
+// secretFieldFunc receives a field that may hold a secret and returns its new
+// value. key is the map key of the value ("" for list elements and templates);
+// text is true for free-form text such as template data.
+type secretFieldFunc func(path, key, value string, text bool) string
+
+// walkSecretFields calls fn for every field of a job that may hold a secret
+func walkSecretFields(job *api.Job, fn secretFieldFunc) {
+	if job == nil {
+		return
+	}
+
+	job.Meta = walkStringMap("Meta", job.Meta, fn)
+
+	for _, group := range job.TaskGroups {
+		if group == nil {
+			continue
+		}
+		groupPath := fmt.Sprintf("TaskGroups[%s]", stringOrEmpty(group.Name))
+		group.Meta = walkStringMap(groupPath+".Meta", group.Meta, fn)
+
+		for _, task := range group.Tasks {
+			if task == nil {
+				continue
+			}
+			taskPath := fmt.Sprintf("%s.Tasks[%s]", groupPath, task.Name)
+
+			task.Env = walkStringMap(taskPath+".Env", task.Env, fn)
+			task.Meta = walkStringMap(taskPath+".Meta", task.Meta, fn)
+			for key, value := range task.Config {
+				task.Config[key] = walkInterface(fmt.Sprintf("%s.Config[%s]", taskPath, key), key, value, fn)
+			}
+
+			for i, tmpl := range task.Templates {
+				if tmpl == nil || tmpl.EmbeddedTmpl == nil {
+					continue
+				}
+				data := fn(fmt.Sprintf("%s.Templates[%d]", taskPath, i), "", *tmpl.EmbeddedTmpl, true)
+				tmpl.EmbeddedTmpl = &data
+			}
+		}
+	}
+}
+
+// walkStringMap applies fn to every value of a string map
+func walkStringMap(path string, m map[string]string, fn secretFieldFunc) map[string]string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		m[key] = fn(fmt.Sprintf("%s[%s]", path, key), key, m[key], false)
+	}
+	return m
+}
+
+// walkInterface applies fn to every string inside a driver config value
+func walkInterface(path, key string, value interface{}, fn secretFieldFunc) interface{} {
+	switch v := value.(type) {
+	case string:
+		return fn(path, key, v, false)
+	case map[string]interface{}:
+		for k, inner := range v {
+			v[k] = walkInterface(fmt.Sprintf("%s[%s]", path, k), k, inner, fn)
+		}
+		return v
+	case []interface{}:
+		for i, inner := range v {
+			// Blocks (lists of maps) keep the parent key out of their
+			// own keys; plain list elements have no key of their own
+			v[i] = walkInterface(fmt.Sprintf("%s[%d]", path, i), "", inner, fn)
+		}
+		return v
+	case []string:
+		for i, inner := range v {
+			v[i] = fn(fmt.Sprintf("%s[%d]", path, i), "", inner, false)
+		}
+		return v
+	}
+	return value
+}
+
+// stringOrEmpty dereferences a string pointer
+func stringOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
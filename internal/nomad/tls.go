@@ -0,0 +1,104 @@
+package nomad
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/wlame/njgit/internal/config"
+)
+
+// TLSOptions holds the TLS settings for connections to Nomad
+// Both the Nomad client and the HCL parser (which talks to /v1/jobs/parse)
+// build their TLS setup from this, so every connection uses the same
+// certificates.
+type TLSOptions struct {
+	// CACert is the path to a PEM-encoded CA certificate
+	CACert string
+
+	// CAPath is the path to a directory of PEM-encoded CA certificates
+	CAPath string
+
+	// ClientCert is the path to the client certificate for mutual TLS
+	ClientCert string
+
+	// ClientKey is the path to the private key of ClientCert
+	ClientKey string
+
+	// ServerName is the name to verify the server certificate against,
+	// for when it differs from the host in the address
+	ServerName string
+
+	// SkipVerify disables server certificate verification
+	SkipVerify bool
+}
+
+// TLSOptionsFromConfig returns the TLS settings of a Nomad config
+func TLSOptionsFromConfig(cfg *config.NomadConfig) TLSOptions {
+	return TLSOptions{
+		CACert:     cfg.CACert,
+		CAPath:     cfg.CAPath,
+		ClientCert: cfg.ClientCert,
+		ClientKey:  cfg.ClientKey,
+		ServerName: cfg.TLSServerName,
+		SkipVerify: cfg.TLSSkipVerify,
+	}
+}
+
+// ApplyTLS copies TLS options into a Nomad API TLS config
+// Only options that are set are copied, so values api.DefaultConfig() read
+// from the NOMAD_* environment variables are kept otherwise.
+//
+// Parameters:
+//   - tlsConfig: The TLS config of an api.Config, modified in place
+//   - opts: The TLS options to apply
+func ApplyTLS(tlsConfig *api.TLSConfig, opts TLSOptions) {
+	if opts.CACert != "" {
+		tlsConfig.CACert = opts.CACert
+	}
+	if opts.CAPath != "" {
+		tlsConfig.CAPath = opts.CAPath
+	}
+	if opts.ClientCert != "" {
+		tlsConfig.ClientCert = opts.ClientCert
+	}
+	if opts.ClientKey != "" {
+		tlsConfig.ClientKey = opts.ClientKey
+	}
+	if opts.ServerName != "" {
+		tlsConfig.TLSServerName = opts.ServerName
+	}
+	if opts.SkipVerify {
+		// Skip certificate verification (not recommended for production)
+		tlsConfig.Insecure = true
+	}
+}
+
+// Validate checks that the TLS options are usable
+// The Nomad API client only reports certificate problems as a generic
+// "failed to configure TLS", so missing files are caught here first.
+func (o TLSOptions) Validate() error {
+	if (o.ClientCert == "") != (o.ClientKey == "") {
+		return fmt.Errorf("client certificate and client key must be set together")
+	}
+
+	files := []struct {
+		name string
+		path string
+	}{
+		{"CA certificate", o.CACert},
+		{"CA path", o.CAPath},
+		{"client certificate", o.ClientCert},
+		{"client key", o.ClientKey},
+	}
+	for _, file := range files {
+		if file.path == "" {
+			continue
+		}
+		if _, err := os.Stat(file.path); err != nil {
+			return fmt.Errorf("%s not readable: %w", file.name, err)
+		}
+	}
+
+	return nil
+}
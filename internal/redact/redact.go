@@ -0,0 +1,349 @@
+// Package redact finds secrets in job specifications and replaces them with
+// stable placeholders before they are committed.
+//
+// A placeholder has the form <redacted:0123456789ab>, where the hex digits
+// are a prefix of the SHA-256 of the secret. The same secret always yields
+// the same placeholder, so a changed secret still shows up as a change, but
+// the value itself never reaches the repository.
+package redact
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+)
+
+// placeholderHashLength is the number of hex digits of the hash kept in a placeholder
+const placeholderHashLength = 12
+
+// PlaceholderPattern matches placeholders produced by Placeholder
+var PlaceholderPattern = regexp.MustCompile(`<redacted:[0-9a-f]{12}>`)
+
+// Placeholder returns the placeholder that replaces a secret value
+func Placeholder(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return "<redacted:" + hex.EncodeToString(sum[:])[:placeholderHashLength] + ">"
+}
+
+// Finding is one secret that was redacted
+type Finding struct {
+	// Detector is the name of the detector that matched
+	Detector string
+
+	// Value is the original secret
+	// Findings are only used in memory (reporting, resolving placeholders);
+	// never print or store this
+	Value string
+
+	// Placeholder is what the value was replaced with
+	Placeholder string
+}
+
+// Rule is a user-defined detector
+type Rule struct {
+	// Name identifies the rule in reports
+	Name string
+
+	// Key is a regular expression matched against key names; the whole
+	// value of a matching key is redacted
+	Key string
+
+	// Value is a regular expression matched against values; every match
+	// is redacted
+	Value string
+}
+
+// Options configures a Redactor
+type Options struct {
+	// Entropy enables the high-entropy string detector
+	Entropy bool
+
+	// AllowKeys are key names that are never redacted
+	AllowKeys []string
+
+	// Rules are additional detectors
+	Rules []Rule
+}
+
+// Redactor finds and replaces secrets
+type Redactor struct {
+	entropy   bool
+	allowKeys map[string]bool
+	keyRules  []namedPattern
+	valRules  []namedPattern
+}
+
+// namedPattern is a compiled detector
+type namedPattern struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+// secretKeyPattern matches key names that hold secrets, e.g. DB_PASSWORD,
+// api_token, client-secret or a bare "password"
+var secretKeyPattern = regexp.MustCompile(`(?i)(^|[_.-])(password|passwd|pass|token|secret|api_?key|private_?key|credentials?)$`)
+
+// knownFormats are token formats that are secret wherever they appear
+var knownFormats = []namedPattern{
+	{"private-key", regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`)},
+	{"aws-access-key", regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"github-token", regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{60,})\b`)},
+	{"gitlab-token", regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20,}\b`)},
+	{"slack-token", regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}\b`)},
+	{"google-api-key", regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{"stripe-key", regexp.MustCompile(`\b[sr]k_live_[0-9A-Za-z]{20,}\b`)},
+	{"jwt", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}\b`)},
+	{"url-credentials", regexp.MustCompile(`[a-zA-Z][a-zA-Z0-9+.-]*://[^/\s:@]+:([^/\s@]+)@`)},
+}
+
+// secretLinePattern matches "KEY=value" and "key: value" lines in templates
+var secretLinePattern = regexp.MustCompile(`(?m)^([ \t]*(?:export[ \t]+)?)([A-Za-z_][A-Za-z0-9_.-]*)([ \t]*[=:][ \t]*)(["']?)([^\s"'{}]+)(["']?)[ \t]*$`)
+
+// Entropy detector thresholds
+// Hex strings top out at 4 bits per character, so hashes and digests never
+// reach minEntropy; random base64/alphanumeric tokens of this length do.
+const (
+	minEntropyLength = 20
+	minEntropy       = 4.5
+)
+
+// tokenPattern matches values that look like a single opaque token
+var tokenPattern = regexp.MustCompile(`^[A-Za-z0-9+/=_.-]+$`)
+
+// New creates a Redactor
+//
+// Parameters:
+//   - opts: Detector options
+//
+// Returns:
+//   - *Redactor: The redactor
+//   - error: If a rule has an invalid regular expression
+func New(opts Options) (*Redactor, error) {
+	r := &Redactor{
+		entropy:   opts.Entropy,
+		allowKeys: make(map[string]bool, len(opts.AllowKeys)),
+	}
+	for _, key := range opts.AllowKeys {
+		r.allowKeys[key] = true
+	}
+
+	for _, rule := range opts.Rules {
+		if rule.Key == "" && rule.Value == "" {
+			return nil, fmt.Errorf("redaction rule %q: key or value pattern is required", rule.Name)
+		}
+		if rule.Key != "" {
+			pattern, err := regexp.Compile(rule.Key)
+			if err != nil {
+				return nil, fmt.Errorf("redaction rule %q: invalid key pattern: %w", rule.Name, err)
+			}
+			r.keyRules = append(r.keyRules, namedPattern{rule.Name, pattern})
+		}
+		if rule.Value != "" {
+			pattern, err := regexp.Compile(rule.Value)
+			if err != nil {
+				return nil, fmt.Errorf("redaction rule %q: invalid value pattern: %w", rule.Name, err)
+			}
+			r.valRules = append(r.valRules, namedPattern{rule.Name, pattern})
+		}
+	}
+
+	return r, nil
+}
+
+// Value redacts a single key/value pair (an env var, a meta entry, a driver
+// config option)
+// The whole value is replaced if the key looks like it holds a secret, or if
+// the value itself is a high-entropy token; otherwise only known secret
+// formats inside the value are replaced.
+//
+// Parameters:
+//   - key: The key name ("" if the value has none, e.g. a list element)
+//   - value: The value to redact
+//
+// Returns:
+//   - string: The redacted value
+//   - []Finding: What was redacted (nil if nothing)
+func (r *Redactor) Value(key, value string) (string, []Finding) {
+	if value == "" || r.allowKeys[key] || isReference(value) {
+		return value, nil
+	}
+
+	if detector := r.secretKey(key); detector != "" {
+		return redactWhole(detector, value)
+	}
+
+	if r.entropy && isHighEntropyToken(value) {
+		return redactWhole("entropy", value)
+	}
+
+	return r.replacePatterns(value)
+}
+
+// Text redacts secrets inside free-form text such as template data
+// Known secret formats are replaced wherever they appear, and so are the
+// values of "KEY=value" / "key: value" lines whose key looks secret.
+// Values containing template expressions are left alone.
+//
+// Parameters:
+//   - text: The text to redact
+//
+// Returns:
+//   - string: The redacted text
+//   - []Finding: What was redacted (nil if nothing)
+func (r *Redactor) Text(text string) (string, []Finding) {
+	if text == "" {
+		return text, nil
+	}
+
+	text, findings := r.replacePatterns(text)
+
+	text = secretLinePattern.ReplaceAllStringFunc(text, func(line string) string {
+		m := secretLinePattern.FindStringSubmatch(line)
+		prefix, key, sep, open, value, closing := m[1], m[2], m[3], m[4], m[5], m[6]
+
+		detector := r.secretKey(key)
+		if detector == "" || r.allowKeys[key] || PlaceholderPattern.MatchString(value) || isReference(value) {
+			return line
+		}
+
+		redacted, found := redactWhole(detector, value)
+		findings = append(findings, found...)
+		return prefix + key + sep + open + redacted + closing
+	})
+
+	return text, findings
+}
+
+// secretKey returns the detector that marks a key as secret, or ""
+func (r *Redactor) secretKey(key string) string {
+	if key == "" || r.allowKeys[key] {
+		return ""
+	}
+	for _, rule := range r.keyRules {
+		if rule.pattern.MatchString(key) {
+			return rule.name
+		}
+	}
+	if secretKeyPattern.MatchString(key) {
+		return "secret-key-name"
+	}
+	return ""
+}
+
+// replacePatterns replaces known secret formats and value rules in s
+func (r *Redactor) replacePatterns(s string) (string, []Finding) {
+	var findings []Finding
+
+	patterns := append(append([]namedPattern{}, knownFormats...), r.valRules...)
+	for _, p := range patterns {
+		s = p.pattern.ReplaceAllStringFunc(s, func(match string) string {
+			// For URLs only the password part is secret
+			if p.name == "url-credentials" {
+				sub := p.pattern.FindStringSubmatch(match)
+				password := sub[1]
+				if PlaceholderPattern.MatchString(password) || isReference(password) {
+					return match
+				}
+				finding := newFinding(p.name, password)
+				findings = append(findings, finding)
+				return strings.Replace(match, ":"+password+"@", ":"+finding.Placeholder+"@", 1)
+			}
+
+			finding := newFinding(p.name, match)
+			findings = append(findings, finding)
+			return finding.Placeholder
+		})
+	}
+
+	return s, findings
+}
+
+// redactWhole replaces an entire value
+func redactWhole(detector, value string) (string, []Finding) {
+	if PlaceholderPattern.MatchString(value) {
+		return value, nil
+	}
+	finding := newFinding(detector, value)
+	return finding.Placeholder, []Finding{finding}
+}
+
+// newFinding records a redacted value
+func newFinding(detector, value string) Finding {
+	return Finding{Detector: detector, Value: value, Placeholder: Placeholder(value)}
+}
+
+// isReference reports whether a value is an interpolation or template
+// expression rather than a literal, e.g. "${NOMAD_SECRETS_DIR}" or
+// `{{ with secret "kv/db" }}`. These point at secrets, they aren't secrets.
+func isReference(value string) bool {
+	return strings.Contains(value, "${") || strings.Contains(value, "{{")
+}
+
+// isHighEntropyToken reports whether a value looks like a random token
+func isHighEntropyToken(value string) bool {
+	if len(value) < minEntropyLength || !tokenPattern.MatchString(value) {
+		return false
+	}
+	return shannonEntropy(value) >= minEntropy
+}
+
+// shannonEntropy returns the Shannon entropy of s in bits per character
+func shannonEntropy(s string) float64 {
+	counts := make(map[rune]int)
+	for _, c := range s {
+		counts[c]++
+	}
+
+	var entropy float64
+	length := float64(len(s))
+	for _, count := range counts {
+		p := float64(count) / length
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// Resolver restores placeholders from known secret values
+// Values are matched by hash, so a placeholder is only ever replaced with
+// the exact value it was created from.
+type Resolver struct {
+	values map[string]string
+}
+
+// NewResolver creates an empty Resolver
+func NewResolver() *Resolver {
+	return &Resolver{values: make(map[string]string)}
+}
+
+// Add makes a secret value available for resolving
+func (r *Resolver) Add(value string) {
+	if value == "" {
+		return
+	}
+	r.values[Placeholder(value)] = value
+}
+
+// Len returns the number of known values
+func (r *Resolver) Len() int {
+	return len(r.values)
+}
+
+// Resolve replaces every placeholder in s that has a known value
+//
+// Returns:
+//   - string: s with the known placeholders replaced
+//   - []string: The placeholders that could not be resolved
+func (r *Resolver) Resolve(s string) (string, []string) {
+	var unresolved []string
+	resolved := PlaceholderPattern.ReplaceAllStringFunc(s, func(placeholder string) string {
+		if value, ok := r.values[placeholder]; ok {
+			return value
+		}
+		unresolved = append(unresolved, placeholder)
+		return placeholder
+	})
+	return resolved, unresolved
+}
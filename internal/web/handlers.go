@@ -5,11 +5,14 @@ import (
 	"io/fs"
 	"net/http"
 	"strconv"
+
+	gitpkg "github.com/wlame/njgit/internal/git"
 )
 
 func (s *Server) registerRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("GET /api/commits", s.handleListCommits)
 	mux.HandleFunc("GET /api/file/{hash}/{path...}", s.handleGetFile)
+	mux.HandleFunc("GET /api/files/{hash}", s.handleListFiles)
 
 	staticSub, _ := fs.Sub(staticFS, "static")
 	mux.Handle("GET /", http.FileServer(http.FS(staticSub)))
@@ -23,6 +26,27 @@ type commitResponse struct {
 	Email    string   `json:"email"`
 	Date     string   `json:"date"`
 	Files    []string `json:"files"`
+
+	Signature signatureResponse `json:"signature"`
+
+	// Job is parsed from the commit's trailers (null for older commits)
+	Job *jobResponse `json:"job"`
+}
+
+type jobResponse struct {
+	Name        string  `json:"name"`
+	Namespace   string  `json:"namespace"`
+	Region      string  `json:"region"`
+	Version     *uint64 `json:"version,omitempty"`
+	ModifyIndex *uint64 `json:"modify_index,omitempty"`
+	Cluster     string  `json:"cluster,omitempty"`
+	Action      string  `json:"action,omitempty"`
+}
+
+type signatureResponse struct {
+	Type   string `json:"type,omitempty"`
+	Status string `json:"status"`
+	Signer string `json:"signer,omitempty"`
 }
 
 func (s *Server) handleListCommits(w http.ResponseWriter, r *http.Request) {
@@ -32,7 +56,7 @@ func (s *Server) handleListCommits(w http.ResponseWriter, r *http.Request) {
 		limit = l
 	}
 
-	commits, err := s.repo.GetHistory(path, limit)
+	commits, err := s.backend.History(r.Context(), path, limit)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -52,6 +76,12 @@ func (s *Server) handleListCommits(w http.ResponseWriter, r *http.Request) {
 			Email:    c.Email,
 			Date:     c.Date.Format("2006-01-02 15:04:05"),
 			Files:    files,
+			Signature: signatureResponse{
+				Type:   c.Signature.Type,
+				Status: c.Signature.Status,
+				Signer: c.Signature.Signer,
+			},
+			Job: newJobResponse(c.Job),
 		})
 	}
 
@@ -59,11 +89,27 @@ func (s *Server) handleListCommits(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(resp)
 }
 
+// newJobResponse converts commit trailers for the API (nil stays nil)
+func newJobResponse(job *gitpkg.JobTrailers) *jobResponse {
+	if job == nil {
+		return nil
+	}
+	return &jobResponse{
+		Name:        job.Job,
+		Namespace:   job.Namespace,
+		Region:      job.Region,
+		Version:     job.Version,
+		ModifyIndex: job.ModifyIndex,
+		Cluster:     job.Cluster,
+		Action:      job.Action,
+	}
+}
+
 func (s *Server) handleGetFile(w http.ResponseWriter, r *http.Request) {
 	hash := r.PathValue("hash")
 	path := r.PathValue("path")
 
-	content, err := s.repo.GetFileAtCommit(hash, path)
+	content, err := s.backend.ReadFileAt(r.Context(), hash, path)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
@@ -72,3 +118,17 @@ func (s *Server) handleGetFile(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
 	w.Write(content)
 }
+
+func (s *Server) handleListFiles(w http.ResponseWriter, r *http.Request) {
+	hash := r.PathValue("hash")
+	dir := r.URL.Query().Get("dir")
+
+	files, err := s.backend.ListFiles(r.Context(), hash, dir)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(files)
+}
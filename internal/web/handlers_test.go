@@ -1,6 +1,7 @@
 package web
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -11,10 +12,11 @@ import (
 
 	gogit "github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing/object"
-	gitpkg "github.com/wlame/njgit/internal/git"
+	"github.com/wlame/njgit/internal/backend"
+	"github.com/wlame/njgit/internal/config"
 )
 
-func setupTestRepo(t *testing.T) (*gitpkg.Repository, string) {
+func setupTestRepo(t *testing.T) (backend.Backend, string) {
 	t.Helper()
 
 	dir := t.TempDir()
@@ -85,13 +87,20 @@ func setupTestRepo(t *testing.T) (*gitpkg.Repository, string) {
 		t.Fatalf("failed to commit: %v", err)
 	}
 
-	// Open with our wrapper
-	gitRepo, err := gitpkg.NewLocalRepository(dir)
+	// Open through the git backend, as serve does
+	gitBackend, err := backend.NewGitBackend(&config.GitConfig{
+		LocalPath:   dir,
+		AuthorName:  "njgit",
+		AuthorEmail: "njgit@localhost",
+	})
 	if err != nil {
+		t.Fatalf("failed to create backend: %v", err)
+	}
+	if err := gitBackend.Initialize(context.Background()); err != nil {
 		t.Fatalf("failed to open repo: %v", err)
 	}
 
-	return gitRepo, dir
+	return gitBackend, dir
 }
 
 func TestHandleListCommits(t *testing.T) {
@@ -140,6 +149,54 @@ func TestHandleListCommits(t *testing.T) {
 	}
 }
 
+func TestHandleListCommits_JobTrailers(t *testing.T) {
+	repo, _ := setupTestRepo(t)
+	ctx := context.Background()
+
+	if err := repo.WriteFile(ctx, "global/default/web-app.hcl", []byte("job \"web-app\" {}\n")); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	message := "Update global/default/web-app\n\n" +
+		"Nomad-Job: web-app\nNomad-Namespace: default\nNomad-Region: global\n" +
+		"Nomad-Job-Version: 7\nNomad-Job-Modify-Index: 1234\nNomad-Cluster: prod\nNjgit-Action: sync"
+	if _, err := repo.Commit(ctx, message, nil); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+
+	srv := NewServer(repo, "127.0.0.1", 0)
+	mux := http.NewServeMux()
+	srv.registerRoutes(mux)
+
+	req := httptest.NewRequest("GET", "/api/commits", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	var commits []commitResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &commits); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(commits) != 3 {
+		t.Fatalf("expected 3 commits, got %d", len(commits))
+	}
+
+	job := commits[0].Job
+	if job == nil {
+		t.Fatalf("expected job from trailers, got nil")
+	}
+	if job.Name != "web-app" || job.Namespace != "default" || job.Region != "global" ||
+		job.Cluster != "prod" || job.Action != "sync" {
+		t.Errorf("unexpected job: %+v", job)
+	}
+	if job.Version == nil || *job.Version != 7 || job.ModifyIndex == nil || *job.ModifyIndex != 1234 {
+		t.Errorf("unexpected version/modify index: %v/%v", job.Version, job.ModifyIndex)
+	}
+
+	// Commits without trailers have no job
+	if commits[1].Job != nil {
+		t.Errorf("expected no job for a commit without trailers, got %+v", commits[1].Job)
+	}
+}
+
 func TestHandleListCommits_WithLimit(t *testing.T) {
 	repo, _ := setupTestRepo(t)
 	srv := NewServer(repo, "127.0.0.1", 0)
@@ -319,6 +376,64 @@ func TestHandleGetFile_OlderCommit(t *testing.T) {
 	}
 }
 
+func TestHandleGetFile_Revision(t *testing.T) {
+	repo, _ := setupTestRepo(t)
+	srv := NewServer(repo, "127.0.0.1", 0)
+
+	mux := http.NewServeMux()
+	srv.registerRoutes(mux)
+
+	// Revisions other than full hashes resolve too
+	req := httptest.NewRequest("GET", "/api/file/HEAD~1/global/default/web-app.hcl", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if !containsString(w.Body.String(), `datacenters = ["dc1"]`) {
+		t.Errorf("expected the initial version, got %q", w.Body.String())
+	}
+}
+
+func TestHandleListFiles(t *testing.T) {
+	repo, dir := setupTestRepo(t)
+	srv := NewServer(repo, "127.0.0.1", 0)
+
+	// An uncommitted file must not be listed
+	if err := os.WriteFile(filepath.Join(dir, "global", "default", "draft.hcl"), []byte("job"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	srv.registerRoutes(mux)
+
+	req := httptest.NewRequest("GET", "/api/files/HEAD?dir=global", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var files []string
+	if err := json.Unmarshal(w.Body.Bytes(), &files); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(files) != 1 || files[0] != "global/default/web-app.hcl" {
+		t.Errorf("expected [global/default/web-app.hcl], got %v", files)
+	}
+
+	// Unknown revisions are not found
+	req = httptest.NewRequest("GET", "/api/files/no-such-branch", nil)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", w.Code)
+	}
+}
+
 func TestStaticFileServing(t *testing.T) {
 	repo, _ := setupTestRepo(t)
 	srv := NewServer(repo, "127.0.0.1", 0)
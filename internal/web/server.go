@@ -12,23 +12,25 @@ import (
 	"syscall"
 	"time"
 
-	gitpkg "github.com/wlame/njgit/internal/git"
+	"github.com/wlame/njgit/internal/backend"
 )
 
 //go:embed static
 var staticFS embed.FS
 
+// Server serves the dashboard, reading history and job files from an
+// initialized backend
 type Server struct {
-	repo *gitpkg.Repository
-	bind string
-	port int
+	backend backend.Backend
+	bind    string
+	port    int
 }
 
-func NewServer(repo *gitpkg.Repository, bind string, port int) *Server {
+func NewServer(b backend.Backend, bind string, port int) *Server {
 	return &Server{
-		repo: repo,
-		bind: bind,
-		port: port,
+		backend: b,
+		bind:    bind,
+		port:    port,
 	}
 }
 
@@ -0,0 +1,1017 @@
+// Package tests contains unit tests for the HCL job formatter
+package tests
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/nomad/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wlame/njgit/internal/hcl"
+	"github.com/wlame/njgit/internal/nomad"
+	"github.com/wlame/njgit/internal/redact"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// formatNormalized normalizes a job and renders it as HCL, failing the test on error
+func formatNormalized(t *testing.T, job *api.Job) string {
+	t.Helper()
+
+	normalized := nomad.NormalizeJob(job, nil)
+	hclBytes, err := hcl.FormatJobAsHCL(normalized)
+	require.NoError(t, err, "Failed to convert job to HCL")
+
+	return string(hclBytes)
+}
+
+// requireValidHCL checks that the generated output is syntactically valid HCL
+func requireValidHCL(t *testing.T, content string) {
+	t.Helper()
+
+	_, diags := hclparse.NewParser().ParseHCL([]byte(content), "job.hcl")
+	require.False(t, diags.HasErrors(), "Generated HCL should parse: %s\n%s", diags.Error(), content)
+}
+
+// stringAttributeValues parses HCL and returns the evaluated values of every
+// string attribute with the given name, in document order, at any nesting level
+func stringAttributeValues(t *testing.T, content, name string) []string {
+	t.Helper()
+
+	file, diags := hclparse.NewParser().ParseHCL([]byte(content), "job.hcl")
+	require.False(t, diags.HasErrors(), "Generated HCL should parse: %s", diags.Error())
+
+	var values []string
+	var walk func(body *hclsyntax.Body)
+	walk = func(body *hclsyntax.Body) {
+		if attr, ok := body.Attributes[name]; ok {
+			value, diags := attr.Expr.Value(nil)
+			require.False(t, diags.HasErrors(), "Attribute %s should evaluate: %s", name, diags.Error())
+			values = append(values, value.AsString())
+		}
+		for _, block := range body.Blocks {
+			walk(block.Body)
+		}
+	}
+	walk(file.Body.(*hclsyntax.Body))
+
+	return values
+}
+
+// blockAttributes parses HCL, descends through the first block of each given
+// type and returns that block's attributes converted to strings. This is the
+// parse half of the render -> parse round-trip checks.
+func blockAttributes(t *testing.T, content string, path ...string) map[string]string {
+	t.Helper()
+
+	file, diags := hclparse.NewParser().ParseHCL([]byte(content), "job.hcl")
+	require.False(t, diags.HasErrors(), "Generated HCL should parse: %s", diags.Error())
+
+	body := file.Body.(*hclsyntax.Body)
+	for _, blockType := range path {
+		var next *hclsyntax.Body
+		for _, block := range body.Blocks {
+			if block.Type == blockType {
+				next = block.Body
+				break
+			}
+		}
+		require.NotNil(t, next, "Block %q not found along path %v", blockType, path)
+		body = next
+	}
+
+	attrs := make(map[string]string, len(body.Attributes))
+	for name, attr := range body.Attributes {
+		value, diags := attr.Expr.Value(nil)
+		require.False(t, diags.HasErrors(), "Attribute %s should evaluate: %s", name, diags.Error())
+
+		if !value.Type().IsPrimitiveType() {
+			attrs[name] = value.GoString()
+			continue
+		}
+		str, err := convert.Convert(value, cty.String)
+		require.NoError(t, err)
+		attrs[name] = str.AsString()
+	}
+
+	return attrs
+}
+
+// TestHCLFormatting_StorageBlocks tests that volumes, volume mounts and
+// ephemeral disk settings survive normalization and formatting
+func TestHCLFormatting_StorageBlocks(t *testing.T) {
+	job := createSampleJob("stateful-job", uint64(1), int64(1))
+	tg := job.TaskGroups[0]
+
+	tg.EphemeralDisk = &api.EphemeralDisk{
+		Sticky:  boolToPtr(true),
+		Migrate: boolToPtr(true),
+		SizeMB:  intToPtr(500),
+	}
+	tg.Volumes = map[string]*api.VolumeRequest{
+		"data": {
+			Name:     "data",
+			Type:     "host",
+			Source:   "pg-data",
+			ReadOnly: false,
+		},
+		"certs": {
+			Name:           "certs",
+			Type:           "csi",
+			Source:         "tls-certs",
+			ReadOnly:       true,
+			AccessMode:     "single-node-reader-only",
+			AttachmentMode: "file-system",
+			PerAlloc:       true,
+			MountOptions: &api.CSIMountOptions{
+				FSType:     "ext4",
+				MountFlags: []string{"noatime", "nodiratime"},
+			},
+		},
+	}
+	tg.Tasks[0].VolumeMounts = []*api.VolumeMount{
+		{
+			Volume:          stringToPtr("data"),
+			Destination:     stringToPtr("/var/lib/postgresql/data"),
+			ReadOnly:        boolToPtr(false),
+			PropagationMode: stringToPtr("private"),
+		},
+		{
+			Volume:      stringToPtr("certs"),
+			Destination: stringToPtr("/etc/ssl/private"),
+			ReadOnly:    boolToPtr(true),
+		},
+	}
+
+	hclString := formatNormalized(t, job)
+	requireValidHCL(t, hclString)
+
+	// Ephemeral disk
+	assert.Contains(t, hclString, "ephemeral_disk {\n      sticky = true\n      migrate = true\n      size = 500\n    }")
+
+	// Host volume
+	assert.Contains(t, hclString, `volume "data" {`)
+	assert.Contains(t, hclString, `type = "host"`)
+	assert.Contains(t, hclString, `source = "pg-data"`)
+
+	// CSI volume with all CSI-specific settings
+	assert.Contains(t, hclString, `volume "certs" {`)
+	assert.Contains(t, hclString, `type = "csi"`)
+	assert.Contains(t, hclString, `access_mode = "single-node-reader-only"`)
+	assert.Contains(t, hclString, `attachment_mode = "file-system"`)
+	assert.Contains(t, hclString, `per_alloc = true`)
+	assert.Contains(t, hclString, `fs_type = "ext4"`)
+	assert.Contains(t, hclString, `mount_flags = ["noatime", "nodiratime"]`)
+
+	// Volumes are written in sorted order for stable output
+	assert.Less(t, strings.Index(hclString, `volume "certs"`), strings.Index(hclString, `volume "data"`),
+		"Volumes should be sorted by name")
+
+	// Volume mounts
+	assert.Contains(t, hclString, `destination = "/var/lib/postgresql/data"`)
+	assert.Contains(t, hclString, `propagation_mode = "private"`)
+	assert.Contains(t, hclString, `destination = "/etc/ssl/private"`)
+	assert.Contains(t, hclString, "volume_mount {\n        volume = \"certs\"\n        destination = \"/etc/ssl/private\"\n        read_only = true\n      }")
+}
+
+// TestNormalizeJob_StorageBlocksAreCopied tests that normalization deep copies
+// storage settings instead of sharing them with the original job
+func TestNormalizeJob_StorageBlocksAreCopied(t *testing.T) {
+	job := createSampleJob("stateful-job", uint64(1), int64(1))
+	job.TaskGroups[0].EphemeralDisk = &api.EphemeralDisk{SizeMB: intToPtr(300)}
+	job.TaskGroups[0].Volumes = map[string]*api.VolumeRequest{
+		"data": {
+			Name:         "data",
+			Type:         "csi",
+			Source:       "db",
+			MountOptions: &api.CSIMountOptions{MountFlags: []string{"noatime"}},
+		},
+	}
+	job.TaskGroups[0].Tasks[0].VolumeMounts = []*api.VolumeMount{
+		{Volume: stringToPtr("data"), Destination: stringToPtr("/data")},
+	}
+
+	normalized := nomad.NormalizeJob(job, nil)
+
+	// Mutating the original must not leak into the normalized copy
+	*job.TaskGroups[0].EphemeralDisk.SizeMB = 1000
+	job.TaskGroups[0].Volumes["data"].MountOptions.MountFlags[0] = "changed"
+	*job.TaskGroups[0].Tasks[0].VolumeMounts[0].Destination = "/changed"
+
+	tg := normalized.TaskGroups[0]
+	require.NotNil(t, tg.EphemeralDisk)
+	assert.Equal(t, 300, *tg.EphemeralDisk.SizeMB)
+	require.Contains(t, tg.Volumes, "data")
+	assert.Equal(t, []string{"noatime"}, tg.Volumes["data"].MountOptions.MountFlags)
+	require.Len(t, tg.Tasks[0].VolumeMounts, 1)
+	assert.Equal(t, "/data", *tg.Tasks[0].VolumeMounts[0].Destination)
+}
+
+// TestHCLFormatting_TaskBlocks tests that template, artifact, vault and
+// identity blocks are rendered
+func TestHCLFormatting_TaskBlocks(t *testing.T) {
+	templateData := "{{ with secret \"kv/data/db\" }}\nDB_PASSWORD={{ .Data.data.password }}\n{{ end }}\nLISTEN=${NOMAD_PORT_http}\nGREETING=%{ not a directive }\n"
+
+	job := createSampleJob("templated-job", uint64(1), int64(1))
+	task := job.TaskGroups[0].Tasks[0]
+	task.Templates = []*api.Template{
+		{
+			EmbeddedTmpl: stringToPtr(templateData),
+			DestPath:     stringToPtr("secrets/app.env"),
+			ChangeMode:   stringToPtr("signal"),
+			ChangeSignal: stringToPtr("SIGHUP"),
+			Envvars:      boolToPtr(true),
+			Splay:        durationToPtr(5 * time.Second),
+			Perms:        stringToPtr("0644"),
+			LeftDelim:    stringToPtr("{{"),
+			RightDelim:   stringToPtr("}}"),
+		},
+		{
+			SourcePath: stringToPtr("local/nginx.conf.tpl"),
+			DestPath:   stringToPtr("local/nginx.conf"),
+			ChangeMode: stringToPtr("restart"),
+			Wait: &api.WaitConfig{
+				Min: durationToPtr(2 * time.Second),
+				Max: durationToPtr(10 * time.Minute),
+			},
+		},
+	}
+	task.Artifacts = []*api.TaskArtifact{
+		{
+			GetterSource:  stringToPtr("https://releases.example.com/app.tar.gz"),
+			RelativeDest:  stringToPtr("local/app"),
+			GetterMode:    stringToPtr("any"),
+			GetterOptions: map[string]string{"checksum": "sha256:abcd"},
+		},
+	}
+	task.Vault = &api.Vault{
+		Role:       "app",
+		Policies:   []string{"app-read"},
+		Env:        boolToPtr(true),
+		ChangeMode: stringToPtr("noop"),
+		Cluster:    "default",
+	}
+	task.Identities = []*api.WorkloadIdentity{
+		{
+			Name:     "vault_default",
+			Audience: []string{"vault.io"},
+			TTL:      time.Hour,
+		},
+	}
+
+	hclString := formatNormalized(t, job)
+	requireValidHCL(t, hclString)
+
+	// Multi-line template data is written as a heredoc
+	assert.Contains(t, hclString, "data = <<EOH\n")
+	assert.Contains(t, hclString, "LISTEN=$${NOMAD_PORT_http}\n")
+
+	// The heredoc must evaluate back to exactly the original template body
+	data := stringAttributeValues(t, hclString, "data")
+	require.Len(t, data, 1)
+	assert.Equal(t, templateData, data[0])
+
+	// Template settings; Nomad defaults are omitted
+	assert.Contains(t, hclString, `destination = "secrets/app.env"`)
+	assert.Contains(t, hclString, `change_mode = "signal"`)
+	assert.Contains(t, hclString, `change_signal = "SIGHUP"`)
+	assert.Contains(t, hclString, `env = true`)
+	assert.NotContains(t, hclString, `perms =`)
+	assert.NotContains(t, hclString, `splay =`)
+	assert.NotContains(t, hclString, `left_delimiter =`)
+	assert.Contains(t, hclString, `source = "local/nginx.conf.tpl"`)
+	assert.Contains(t, hclString, "wait {\n          min = \"2s\"\n          max = \"10m\"\n        }")
+
+	// Artifact
+	assert.Contains(t, hclString, "artifact {")
+	assert.Contains(t, hclString, `source = "https://releases.example.com/app.tar.gz"`)
+	assert.Contains(t, hclString, `destination = "local/app"`)
+	assert.Contains(t, hclString, `checksum = "sha256:abcd"`)
+	assert.NotContains(t, hclString, `mode = "any"`)
+
+	// Vault
+	assert.Contains(t, hclString, "vault {")
+	assert.Contains(t, hclString, `role = "app"`)
+	assert.Contains(t, hclString, `policies = ["app-read"]`)
+	assert.Contains(t, hclString, `change_mode = "noop"`)
+	assert.NotContains(t, hclString, `cluster = "default"`)
+
+	// Workload identity
+	assert.Contains(t, hclString, "identity {")
+	assert.Contains(t, hclString, `name = "vault_default"`)
+	assert.Contains(t, hclString, `aud = ["vault.io"]`)
+	assert.Contains(t, hclString, `ttl = "1h"`)
+}
+
+// TestHCLFormatting_EscapesInterpolation tests that ${ and %{ sequences in
+// quoted strings are escaped so HCL keeps them literally
+func TestHCLFormatting_EscapesInterpolation(t *testing.T) {
+	job := createSampleJob("escape-job", uint64(1), int64(1))
+	task := job.TaskGroups[0].Tasks[0]
+	task.Env = map[string]string{
+		"ADDR":      "${NOMAD_IP_http}:${NOMAD_PORT_http}",
+		"DIRECTIVE": "%{if true}yes%{endif}",
+		"LITERAL":   "$${already}",
+	}
+	task.Templates = []*api.Template{
+		{
+			// Single-line data without a trailing newline stays a quoted string
+			EmbeddedTmpl: stringToPtr("port=${NOMAD_PORT_http}"),
+			DestPath:     stringToPtr("local/port"),
+		},
+	}
+
+	hclString := formatNormalized(t, job)
+	requireValidHCL(t, hclString)
+
+	assert.Contains(t, hclString, `ADDR = "$${NOMAD_IP_http}:$${NOMAD_PORT_http}"`)
+	assert.Contains(t, hclString, `DIRECTIVE = "%%{if true}yes%%{endif}"`)
+	assert.Contains(t, hclString, `data = "port=$${NOMAD_PORT_http}"`)
+
+	// Each value must evaluate back to the original string
+	assert.Equal(t, []string{"${NOMAD_IP_http}:${NOMAD_PORT_http}"}, stringAttributeValues(t, hclString, "ADDR"))
+	assert.Equal(t, []string{"%{if true}yes%{endif}"}, stringAttributeValues(t, hclString, "DIRECTIVE"))
+	assert.Equal(t, []string{"$${already}"}, stringAttributeValues(t, hclString, "LITERAL"))
+	assert.Equal(t, []string{"port=${NOMAD_PORT_http}"}, stringAttributeValues(t, hclString, "data"))
+}
+
+// TestHCLFormatting_LifecycleAndPolicyBlocks round-trips restart, reschedule,
+// migrate, lifecycle, logs and kill settings through render and parse
+func TestHCLFormatting_LifecycleAndPolicyBlocks(t *testing.T) {
+	job := createSampleJob("policy-job", uint64(1), int64(1))
+	job.Reschedule = &api.ReschedulePolicy{
+		Attempts:  intToPtr(0),
+		Unlimited: boolToPtr(false),
+	}
+
+	tg := job.TaskGroups[0]
+	tg.ShutdownDelay = durationToPtr(10 * time.Second)
+	tg.RestartPolicy = &api.RestartPolicy{
+		Attempts: intToPtr(3),
+		Interval: durationToPtr(30 * time.Minute),
+		Delay:    durationToPtr(15 * time.Second),
+		Mode:     stringToPtr("fail"),
+	}
+	tg.ReschedulePolicy = &api.ReschedulePolicy{
+		Delay:         durationToPtr(30 * time.Second),
+		DelayFunction: stringToPtr("exponential"),
+		MaxDelay:      durationToPtr(time.Hour),
+		Unlimited:     boolToPtr(true),
+	}
+	tg.Migrate = &api.MigrateStrategy{
+		MaxParallel:     intToPtr(2),
+		HealthCheck:     stringToPtr("checks"),
+		MinHealthyTime:  durationToPtr(10 * time.Second),
+		HealthyDeadline: durationToPtr(5 * time.Minute),
+	}
+
+	app := tg.Tasks[0]
+	app.User = "nobody"
+	app.Leader = true
+	app.KillTimeout = durationToPtr(45 * time.Second)
+	app.KillSignal = "SIGTERM"
+	app.ShutdownDelay = 5 * time.Second
+	app.LogConfig = &api.LogConfig{
+		MaxFiles:      intToPtr(5),
+		MaxFileSizeMB: intToPtr(20),
+	}
+
+	// A prestart sidecar (e.g. an init container that keeps running)
+	tg.Tasks = append(tg.Tasks, &api.Task{
+		Name:   "init",
+		Driver: "docker",
+		Config: map[string]interface{}{"image": "busybox:1.36"},
+		Lifecycle: &api.TaskLifecycle{
+			Hook:    "prestart",
+			Sidecar: true,
+		},
+		RestartPolicy: &api.RestartPolicy{
+			Attempts: intToPtr(1),
+			Mode:     stringToPtr("fail"),
+		},
+	})
+
+	hclString := formatNormalized(t, job)
+	requireValidHCL(t, hclString)
+
+	assert.Equal(t, map[string]string{
+		"attempts":  "0",
+		"unlimited": "false",
+	}, blockAttributes(t, hclString, "job", "reschedule"))
+
+	group := blockAttributes(t, hclString, "job", "group")
+	assert.Equal(t, "10s", group["shutdown_delay"])
+
+	assert.Equal(t, map[string]string{
+		"attempts": "3",
+		"interval": "30m",
+		"delay":    "15s",
+		"mode":     "fail",
+	}, blockAttributes(t, hclString, "job", "group", "restart"))
+
+	assert.Equal(t, map[string]string{
+		"delay":          "30s",
+		"delay_function": "exponential",
+		"max_delay":      "1h",
+		"unlimited":      "true",
+	}, blockAttributes(t, hclString, "job", "group", "reschedule"))
+
+	assert.Equal(t, map[string]string{
+		"max_parallel":     "2",
+		"health_check":     "checks",
+		"min_healthy_time": "10s",
+		"healthy_deadline": "5m",
+	}, blockAttributes(t, hclString, "job", "group", "migrate"))
+
+	// Tasks are sorted by name, so "init" comes before "server"
+	assert.Equal(t, map[string]string{
+		"hook":    "prestart",
+		"sidecar": "true",
+	}, blockAttributes(t, hclString, "job", "group", "task", "lifecycle"))
+	assert.Equal(t, map[string]string{
+		"attempts": "1",
+		"mode":     "fail",
+	}, blockAttributes(t, hclString, "job", "group", "task", "restart"))
+
+	assert.Contains(t, hclString, `user = "nobody"`)
+	assert.Contains(t, hclString, `leader = true`)
+	assert.Contains(t, hclString, `kill_timeout = "45s"`)
+	assert.Contains(t, hclString, `kill_signal = "SIGTERM"`)
+	assert.Contains(t, hclString, `shutdown_delay = "5s"`)
+	assert.Contains(t, hclString, "logs {\n        max_files = 5\n        max_file_size = 20\n      }")
+}
+
+// TestNormalizeJob_LifecycleAndPolicyFieldsAreCopied tests that normalization
+// keeps every lifecycle and policy field instead of resetting it
+func TestNormalizeJob_LifecycleAndPolicyFieldsAreCopied(t *testing.T) {
+	job := createSampleJob("policy-job", uint64(1), int64(1))
+	job.Migrate = &api.MigrateStrategy{MaxParallel: intToPtr(1)}
+	tg := job.TaskGroups[0]
+	tg.RestartPolicy = &api.RestartPolicy{Attempts: intToPtr(2)}
+	tg.ReschedulePolicy = &api.ReschedulePolicy{Attempts: intToPtr(3)}
+	tg.Migrate = &api.MigrateStrategy{MaxParallel: intToPtr(4)}
+	tg.ShutdownDelay = durationToPtr(time.Second)
+
+	task := tg.Tasks[0]
+	task.User = "app"
+	task.Leader = true
+	task.KillTimeout = durationToPtr(time.Minute)
+	task.KillSignal = "SIGINT"
+	task.ShutdownDelay = 2 * time.Second
+	task.Lifecycle = &api.TaskLifecycle{Hook: "poststart"}
+	task.RestartPolicy = &api.RestartPolicy{Mode: stringToPtr("delay")}
+	task.LogConfig = &api.LogConfig{MaxFiles: intToPtr(3)}
+
+	normalized := nomad.NormalizeJob(job, nil)
+
+	// Mutate the original; the normalized copy must be unaffected
+	*tg.RestartPolicy.Attempts = 99
+	task.Lifecycle.Hook = "prestart"
+
+	ntg := normalized.TaskGroups[0]
+	require.NotNil(t, normalized.Migrate)
+	assert.Equal(t, 1, *normalized.Migrate.MaxParallel)
+	assert.Equal(t, 2, *ntg.RestartPolicy.Attempts)
+	assert.Equal(t, 3, *ntg.ReschedulePolicy.Attempts)
+	assert.Equal(t, 4, *ntg.Migrate.MaxParallel)
+	assert.Equal(t, time.Second, *ntg.ShutdownDelay)
+
+	ntask := ntg.Tasks[0]
+	assert.Equal(t, "app", ntask.User)
+	assert.True(t, ntask.Leader)
+	assert.Equal(t, time.Minute, *ntask.KillTimeout)
+	assert.Equal(t, "SIGINT", ntask.KillSignal)
+	assert.Equal(t, 2*time.Second, ntask.ShutdownDelay)
+	assert.Equal(t, "poststart", ntask.Lifecycle.Hook)
+	assert.Equal(t, "delay", *ntask.RestartPolicy.Mode)
+	assert.Equal(t, 3, *ntask.LogConfig.MaxFiles)
+}
+
+// TestHCLFormatting_BatchJobBlocks tests periodic, parameterized and
+// dispatch_payload blocks, including omission of Nomad's defaults
+func TestHCLFormatting_BatchJobBlocks(t *testing.T) {
+	job := createSampleJob("nightly-report", uint64(1), int64(1))
+	job.Type = stringToPtr("batch")
+	job.Periodic = &api.PeriodicConfig{
+		Enabled:         boolToPtr(true),
+		Spec:            stringToPtr("0 3 * * *"),
+		SpecType:        stringToPtr("cron"),
+		ProhibitOverlap: boolToPtr(true),
+		TimeZone:        stringToPtr("Europe/Berlin"),
+	}
+	job.ParameterizedJob = &api.ParameterizedJobConfig{
+		Payload:      "required",
+		MetaRequired: []string{"customer_id"},
+		MetaOptional: []string{"dry_run"},
+	}
+	job.TaskGroups[0].Tasks[0].DispatchPayload = &api.DispatchPayloadConfig{File: "input.json"}
+
+	hclString := formatNormalized(t, job)
+	requireValidHCL(t, hclString)
+
+	assert.Equal(t, map[string]string{
+		"cron":             "0 3 * * *",
+		"prohibit_overlap": "true",
+		"time_zone":        "Europe/Berlin",
+	}, blockAttributes(t, hclString, "job", "periodic"))
+
+	parameterized := blockAttributes(t, hclString, "job", "parameterized")
+	assert.Equal(t, "required", parameterized["payload"])
+	assert.Contains(t, parameterized["meta_required"], "customer_id")
+	assert.Contains(t, parameterized["meta_optional"], "dry_run")
+
+	assert.Equal(t, map[string]string{"file": "input.json"},
+		blockAttributes(t, hclString, "job", "group", "task", "dispatch_payload"))
+
+	// Defaults are omitted
+	job.Periodic.ProhibitOverlap = boolToPtr(false)
+	job.Periodic.TimeZone = stringToPtr("UTC")
+	job.ParameterizedJob.Payload = "optional"
+	hclString = formatNormalized(t, job)
+	assert.Equal(t, map[string]string{"cron": "0 3 * * *"},
+		blockAttributes(t, hclString, "job", "periodic"))
+	assert.NotContains(t, hclString, "payload =")
+}
+
+// TestNormalizeJob_DropsChildJobFields tests that the dispatch-specific
+// fields of a child job never reach the stored spec
+func TestNormalizeJob_DropsChildJobFields(t *testing.T) {
+	job := createSampleJob("report/dispatch-1700000000-ab12cd34", uint64(1), int64(1))
+	job.ParentID = stringToPtr("report")
+	job.Dispatched = true
+	job.Payload = []byte("secret input")
+	job.ParameterizedJob = &api.ParameterizedJobConfig{Payload: "required"}
+
+	normalized := nomad.NormalizeJob(job, nil)
+
+	assert.Nil(t, normalized.ParentID)
+	assert.False(t, normalized.Dispatched)
+	assert.Nil(t, normalized.Payload)
+	require.NotNil(t, normalized.ParameterizedJob)
+	assert.Equal(t, "required", normalized.ParameterizedJob.Payload)
+}
+
+// TestChildJobDetection tests recognition of periodic and dispatched child jobs
+func TestChildJobDetection(t *testing.T) {
+	tests := []struct {
+		id         string
+		wantParent string
+		wantChild  bool
+	}{
+		{id: "backup/periodic-1700000000", wantParent: "backup", wantChild: true},
+		{id: "report/dispatch-1700000000-ab12cd34", wantParent: "report", wantChild: true},
+		{id: "team/report/dispatch-1700000000-ab12cd34", wantParent: "team/report", wantChild: true},
+		{id: "web-app", wantParent: "web-app", wantChild: false},
+		{id: "periodic-cleanup", wantParent: "periodic-cleanup", wantChild: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			parent, ok := nomad.ParentJobID(tt.id)
+			assert.Equal(t, tt.wantChild, ok)
+			assert.Equal(t, tt.wantParent, parent)
+		})
+	}
+
+	child := createSampleJob("backup/periodic-1700000000", uint64(1), int64(1))
+	child.ParentID = stringToPtr("backup")
+	assert.True(t, nomad.IsChildJob(child))
+	assert.False(t, nomad.IsChildJob(createSampleJob("backup", uint64(1), int64(1))))
+
+	parents, children := nomad.FilterChildJobs([]*api.JobListStub{
+		{ID: "backup"},
+		{ID: "backup/periodic-1", ParentID: "backup"},
+		{ID: "backup/periodic-2", ParentID: "backup"},
+		{ID: "web-app"},
+	})
+	require.Len(t, parents, 2)
+	assert.Equal(t, "backup", parents[0].ID)
+	assert.Equal(t, "web-app", parents[1].ID)
+	assert.Equal(t, map[string]int{"backup": 2}, children)
+}
+
+// TestHCLFormatting_UpdateScalingAndResources tests the full update strategy
+// at job and group level, group scaling and extended task resources
+func TestHCLFormatting_UpdateScalingAndResources(t *testing.T) {
+	job := createSampleJob("rollout-job", uint64(1), int64(1))
+	job.Update = &api.UpdateStrategy{
+		Stagger:     durationToPtr(30 * time.Second),
+		MaxParallel: intToPtr(1),
+	}
+
+	tg := job.TaskGroups[0]
+	tg.Update = &api.UpdateStrategy{
+		MaxParallel:      intToPtr(2),
+		HealthCheck:      stringToPtr("checks"),
+		MinHealthyTime:   durationToPtr(10 * time.Second),
+		HealthyDeadline:  durationToPtr(5 * time.Minute),
+		ProgressDeadline: durationToPtr(10 * time.Minute),
+		Canary:           intToPtr(1),
+		AutoRevert:       boolToPtr(true),
+		AutoPromote:      boolToPtr(false),
+	}
+	tg.Scaling = &api.ScalingPolicy{
+		ID:      "server-assigned-id",
+		Min:     int64ToPtr(1),
+		Max:     int64ToPtr(10),
+		Enabled: boolToPtr(true),
+		Type:    "horizontal",
+		Policy: map[string]interface{}{
+			"cooldown": "1m",
+			"check": []interface{}{
+				map[string]interface{}{
+					"source": "prometheus",
+					"query":  "avg(cpu)",
+				},
+			},
+		},
+	}
+
+	count := uint64(2)
+	tg.Tasks[0].Resources = &api.Resources{
+		CPU:         intToPtr(500),
+		Cores:       intToPtr(2),
+		MemoryMB:    intToPtr(256),
+		MemoryMaxMB: intToPtr(1024),
+		DiskMB:      intToPtr(300),
+		Devices: []*api.RequestedDevice{{
+			Name:        "nvidia/gpu",
+			Count:       &count,
+			Constraints: []*api.Constraint{{LTarget: "${device.attr.memory}", Operand: ">=", RTarget: "2 GiB"}},
+		}},
+	}
+
+	hclString := formatNormalized(t, job)
+	requireValidHCL(t, hclString)
+
+	assert.Equal(t, map[string]string{
+		"max_parallel": "1",
+		"stagger":      "30s",
+	}, blockAttributes(t, hclString, "job", "update"))
+
+	assert.Equal(t, map[string]string{
+		"max_parallel":      "2",
+		"health_check":      "checks",
+		"min_healthy_time":  "10s",
+		"healthy_deadline":  "5m",
+		"progress_deadline": "10m",
+		"canary":            "1",
+		"auto_revert":       "true",
+		"auto_promote":      "false",
+	}, blockAttributes(t, hclString, "job", "group", "update"))
+
+	// enabled=true and type="horizontal" are defaults; the ID is server state
+	assert.Equal(t, map[string]string{
+		"min": "1",
+		"max": "10",
+	}, blockAttributes(t, hclString, "job", "group", "scaling"))
+	assert.NotContains(t, hclString, "server-assigned-id")
+	assert.Equal(t, map[string]string{"cooldown": "1m"},
+		blockAttributes(t, hclString, "job", "group", "scaling", "policy"))
+	assert.Equal(t, map[string]string{"source": "prometheus", "query": "avg(cpu)"},
+		blockAttributes(t, hclString, "job", "group", "scaling", "policy", "check"))
+
+	assert.Equal(t, map[string]string{
+		"cpu":        "500",
+		"cores":      "2",
+		"memory":     "256",
+		"memory_max": "1024",
+		"disk":       "300",
+	}, blockAttributes(t, hclString, "job", "group", "task", "resources"))
+	assert.Equal(t, map[string]string{"count": "2"},
+		blockAttributes(t, hclString, "job", "group", "task", "resources", "device"))
+	assert.Equal(t, map[string]string{
+		"attribute": "${device.attr.memory}",
+		"operator":  ">=",
+		"value":     "2 GiB",
+	}, blockAttributes(t, hclString, "job", "group", "task", "resources", "device", "constraint"))
+	assert.Contains(t, hclString, `device "nvidia/gpu" {`)
+}
+
+// TestHCLFormatting_ConsulConnect tests services with Connect sidecars,
+// sidecar_task overrides, gateways and service identities, and that the
+// objects Nomad injects for Connect are filtered out
+func TestHCLFormatting_ConsulConnect(t *testing.T) {
+	job := createSampleJob("mesh-app", uint64(1), int64(1))
+	tg := job.TaskGroups[0]
+	tg.Networks = []*api.NetworkResource{{
+		Mode: "bridge",
+		DynamicPorts: []api.Port{
+			{Label: "http", To: 8080},
+			{Label: "connect-proxy-api", To: -1}, // injected by Nomad
+		},
+	}}
+	tg.Services = []*api.Service{
+		{
+			Name:        "api",
+			PortLabel:   "8080",
+			Provider:    "consul",
+			AddressMode: "auto",
+			OnUpdate:    "require_healthy",
+			Cluster:     "default",
+			Tags:        []string{"v1"},
+			Checks: []api.ServiceCheck{{
+				Type:     "http",
+				Path:     "/health",
+				Expose:   true,
+				Interval: 10 * time.Second,
+				Timeout:  2 * time.Second,
+				OnUpdate: "require_healthy",
+			}},
+			Connect: &api.ConsulConnect{
+				SidecarService: &api.ConsulSidecarService{
+					Port: "connect-proxy-api", // injected default
+					Proxy: &api.ConsulProxy{
+						Upstreams: []*api.ConsulUpstream{{
+							DestinationName: "db",
+							LocalBindPort:   5432,
+							MeshGateway:     &api.ConsulMeshGateway{Mode: "local"},
+						}},
+					},
+				},
+				SidecarTask: &api.SidecarTask{
+					Resources: &api.Resources{CPU: intToPtr(50), MemoryMB: intToPtr(64)},
+				},
+			},
+			Identity: &api.WorkloadIdentity{
+				Name:     "consul-service_api-8080",
+				Audience: []string{"consul.io"},
+				TTL:      time.Hour,
+			},
+		},
+		{
+			Name:      "ingress",
+			PortLabel: "8443",
+			Connect: &api.ConsulConnect{
+				Gateway: &api.ConsulGateway{
+					Proxy: &api.ConsulGatewayProxy{ConnectTimeout: durationToPtr(5 * time.Second)},
+					Ingress: &api.ConsulIngressConfigEntry{
+						Listeners: []*api.ConsulIngressListener{{
+							Port:     8443,
+							Protocol: "http",
+							Services: []*api.ConsulIngressService{{Name: "api", Hosts: []string{"api.example.com"}}},
+						}},
+					},
+				},
+			},
+		},
+	}
+	tg.Tasks = append(tg.Tasks,
+		&api.Task{Name: "connect-proxy-api", Kind: "connect-proxy:api", Driver: "docker"},
+		&api.Task{Name: "connect-ingress-ingress", Kind: "connect-ingress:ingress", Driver: "docker"},
+	)
+
+	hclString := formatNormalized(t, job)
+	requireValidHCL(t, hclString)
+
+	// Injected tasks and ports are not committed
+	assert.NotContains(t, hclString, `task "connect-proxy-api"`)
+	assert.NotContains(t, hclString, `task "connect-ingress-ingress"`)
+	assert.NotContains(t, hclString, "connect-proxy-api")
+	assert.NotContains(t, hclString, "consul-service_api-8080")
+
+	assert.Equal(t, map[string]string{"mode": "bridge"}, blockAttributes(t, hclString, "job", "group", "network"))
+	assert.Equal(t, map[string]string{"to": "8080"}, blockAttributes(t, hclString, "job", "group", "network", "port"))
+
+	service := blockAttributes(t, hclString, "job", "group", "service")
+	assert.Equal(t, "api", service["name"])
+	assert.NotContains(t, service, "provider")
+	assert.NotContains(t, service, "address_mode")
+	assert.NotContains(t, service, "on_update")
+
+	assert.Equal(t, map[string]string{
+		"type":     "http",
+		"path":     "/health",
+		"expose":   "true",
+		"interval": "10s",
+		"timeout":  "2s",
+	}, blockAttributes(t, hclString, "job", "group", "service", "check"))
+
+	assert.Equal(t, map[string]string{
+		"destination_name": "db",
+		"local_bind_port":  "5432",
+	}, blockAttributes(t, hclString, "job", "group", "service", "connect", "sidecar_service", "proxy", "upstreams"))
+	assert.Equal(t, map[string]string{"mode": "local"},
+		blockAttributes(t, hclString, "job", "group", "service", "connect", "sidecar_service", "proxy", "upstreams", "mesh_gateway"))
+	assert.Equal(t, map[string]string{"cpu": "50", "memory": "64"},
+		blockAttributes(t, hclString, "job", "group", "service", "connect", "sidecar_task", "resources"))
+
+	identity := blockAttributes(t, hclString, "job", "group", "service", "identity")
+	assert.Equal(t, "1h", identity["ttl"])
+
+	assert.Contains(t, hclString, "gateway {")
+	assert.Contains(t, hclString, `connect_timeout = "5s"`)
+	assert.Contains(t, hclString, `hosts = ["api.example.com"]`)
+}
+
+// TestNormalizeJob_ConnectIsCopied tests that services and Connect settings
+// survive normalization as independent copies
+func TestNormalizeJob_ConnectIsCopied(t *testing.T) {
+	job := createSampleJob("mesh-app", uint64(1), int64(1))
+	upstream := &api.ConsulUpstream{DestinationName: "db", LocalBindPort: 5432}
+	job.TaskGroups[0].Services = []*api.Service{{
+		Name: "api",
+		Connect: &api.ConsulConnect{
+			SidecarService: &api.ConsulSidecarService{
+				Proxy: &api.ConsulProxy{Upstreams: []*api.ConsulUpstream{upstream}},
+			},
+		},
+	}}
+
+	normalized := nomad.NormalizeJob(job, nil)
+	upstream.LocalBindPort = 1
+
+	services := normalized.TaskGroups[0].Services
+	require.Len(t, services, 1)
+	require.NotNil(t, services[0].Connect)
+	assert.Equal(t, 5432, services[0].Connect.SidecarService.Proxy.Upstreams[0].LocalBindPort)
+	assert.True(t, nomad.IsConnectInjectedTask(&api.Task{Kind: "connect-proxy:api"}))
+	assert.False(t, nomad.IsConnectInjectedTask(&api.Task{Kind: "connect-native:api"}))
+}
+
+// TestHCLFormatting_Multiregion tests the multiregion block and that the
+// per-region values of a fetched copy are removed
+func TestHCLFormatting_Multiregion(t *testing.T) {
+	newJob := func(fetchedFrom string, datacenters []string, count int) *api.Job {
+		job := createSampleJob("global-app", uint64(1), int64(1))
+		job.Region = stringToPtr(fetchedFrom)
+		job.TaskGroups[0].Count = intToPtr(count)
+		job.Datacenters = datacenters
+		job.Multiregion = &api.Multiregion{
+			Strategy: &api.MultiregionStrategy{
+				MaxParallel: intToPtr(1),
+				OnFailure:   stringToPtr("fail_all"),
+			},
+			Regions: []*api.MultiregionRegion{
+				{Name: "west", Count: intToPtr(2), Datacenters: []string{"west-1"}},
+				{Name: "east", Count: intToPtr(1), Datacenters: []string{"east-1", "east-2"}, Meta: map[string]string{"tier": "2"}},
+			},
+		}
+		return job
+	}
+
+	// Each region applied its own count to the group
+	west := formatNormalized(t, newJob("west", []string{"west-1"}, 2))
+	east := formatNormalized(t, newJob("east", []string{"east-1", "east-2"}, 1))
+	requireValidHCL(t, west)
+
+	// Both regional copies produce the same canonical file
+	assert.Equal(t, west, east)
+	assert.NotContains(t, west, "region = ")
+	assert.NotContains(t, west, "\n  datacenters = ")
+	assert.Equal(t, "1", blockAttributes(t, west, "job", "group")["count"])
+
+	assert.Equal(t, map[string]string{
+		"max_parallel": "1",
+		"on_failure":   "fail_all",
+	}, blockAttributes(t, west, "job", "multiregion", "strategy"))
+	westRegion := blockAttributes(t, west, "job", "multiregion", "region")
+	assert.Equal(t, "2", westRegion["count"])
+	assert.Contains(t, westRegion["datacenters"], "west-1")
+	assert.Less(t, strings.Index(west, `region "west"`), strings.Index(west, `region "east"`),
+		"Region order is the rollout order and must be kept")
+
+	// Defaults-only strategy is omitted
+	job := newJob("west", []string{"west-1"}, 1)
+	job.Multiregion.Strategy = &api.MultiregionStrategy{MaxParallel: intToPtr(0), OnFailure: stringToPtr("")}
+	assert.NotContains(t, formatNormalized(t, job), "strategy {")
+}
+
+// TestMultiregionDeployRegion tests choosing the registration region
+func TestMultiregionDeployRegion(t *testing.T) {
+	job := createSampleJob("global-app", uint64(1), int64(1))
+	assert.False(t, nomad.IsMultiregion(job))
+	assert.Equal(t, "", nomad.MultiregionDeployRegion(job, "west"))
+
+	job.Multiregion = &api.Multiregion{
+		Regions: []*api.MultiregionRegion{{Name: "west"}, {Name: "east"}},
+	}
+	assert.True(t, nomad.IsMultiregion(job))
+	assert.Equal(t, "east", nomad.MultiregionDeployRegion(job, "east"))
+	assert.Equal(t, "west", nomad.MultiregionDeployRegion(job, "global"))
+}
+
+// TestMergeHCL_PreservesLayout tests applying a new spec to a hand-edited file
+func TestMergeHCL_PreservesLayout(t *testing.T) {
+	original := formatNormalized(t, createSampleJob("web-app", uint64(1), int64(1)))
+
+	// A user annotates the stored file
+	edited := strings.Replace(original, "    count = 1\n",
+		"    # Scaled by the on-call team, see RUNBOOK.md\n    count = 1 # keep in sync with the LB pool\n", 1)
+	edited = strings.Replace(edited, "job \"web-app\" {\n", "# Public web frontend\njob \"web-app\" {\n", 1)
+	edited = strings.Replace(edited, "  group \"web\" {\n",
+		"  group \"web\" {\n    meta {\n      owner = \"web-team\"\n    }\n\n", 1)
+	require.NotEqual(t, original, edited, "Test setup should have edited the file")
+
+	t.Run("unchanged spec keeps the file byte-identical", func(t *testing.T) {
+		withoutMeta := strings.Replace(edited, "    meta {\n      owner = \"web-team\"\n    }\n\n", "", 1)
+
+		merged, err := hcl.MergeHCL([]byte(withoutMeta), []byte(original))
+		require.NoError(t, err)
+		assert.Equal(t, withoutMeta, string(merged))
+	})
+
+	t.Run("changed values keep comments", func(t *testing.T) {
+		job := createSampleJob("web-app", uint64(2), int64(2))
+		job.TaskGroups[0].Count = intToPtr(3)
+		job.TaskGroups[0].Tasks[0].Config["image"] = "nginx:1.27"
+		job.TaskGroups[0].Tasks[0].Env = map[string]string{"PORT": "8080"}
+		job.TaskGroups[0].Tasks[0].User = "nobody"
+
+		merged, err := hcl.MergeHCL([]byte(edited), []byte(formatNormalized(t, job)))
+		require.NoError(t, err)
+		content := string(merged)
+		requireValidHCL(t, content)
+
+		assert.Contains(t, content, "# Public web frontend\njob \"web-app\" {")
+		assert.Contains(t, content, "# Scaled by the on-call team, see RUNBOOK.md\n")
+		assert.Contains(t, content, "# keep in sync with the LB pool")
+		assert.Equal(t, "3", blockAttributes(t, content, "job", "group")["count"])
+		assert.Equal(t, "nginx:1.27", blockAttributes(t, content, "job", "group", "task", "config")["image"])
+		assert.Equal(t, "8080", blockAttributes(t, content, "job", "group", "task", "env")["PORT"])
+		assert.Contains(t, content, "\n      user = \"nobody\"\n")
+
+		// The meta block is not in the new spec, so it is removed
+		assert.NotContains(t, content, "owner")
+	})
+
+	t.Run("invalid existing file is an error", func(t *testing.T) {
+		_, err := hcl.MergeHCL([]byte("job \"web-app\" {"), []byte(original))
+		assert.Error(t, err)
+	})
+}
+
+// TestFormatSubmissionVariables tests writing a submission's variables
+func TestFormatSubmissionVariables(t *testing.T) {
+	submission := &api.JobSubmission{
+		Source:    "job \"web-app\" {}\n",
+		Format:    "hcl2",
+		Variables: "# database\ndb_password = \"hunter2\"\nreplicas = 3\n",
+		VariableFlags: map[string]string{
+			"image":     "nginx:1.27",
+			"api_token": "s3cr3t",
+		},
+	}
+
+	t.Run("redacted", func(t *testing.T) {
+		content, err := hcl.FormatSubmissionVariables(submission, true)
+		require.NoError(t, err)
+		requireValidHCL(t, string(content))
+
+		assert.NotContains(t, string(content), "hunter2")
+		assert.NotContains(t, string(content), "s3cr3t")
+		assert.Contains(t, string(content), "# database", "Comments in the var-file are kept")
+
+		attrs := blockAttributes(t, string(content))
+		assert.Equal(t, redact.Placeholder("hunter2"), attrs["db_password"])
+		assert.Equal(t, redact.Placeholder("3"), attrs["replicas"])
+		assert.Equal(t, redact.Placeholder("nginx:1.27"), attrs["image"])
+		assert.Equal(t, redact.Placeholder("s3cr3t"), attrs["api_token"])
+		assert.Less(t, strings.Index(string(content), "api_token"), strings.Index(string(content), "image"),
+			"Flags are sorted by name")
+	})
+
+	t.Run("same placeholder in var-file and flags", func(t *testing.T) {
+		content, err := hcl.FormatSubmissionVariables(&api.JobSubmission{
+			Variables:     "db_password = \"hunter2\"\nlabels = [\"a\", \"b\"]\n",
+			VariableFlags: map[string]string{"db_password_flag": "hunter2"},
+		}, true)
+		require.NoError(t, err)
+
+		attrs := blockAttributes(t, string(content))
+		assert.Equal(t, redact.Placeholder("hunter2"), attrs["db_password"])
+		assert.Equal(t, attrs["db_password"], attrs["db_password_flag"])
+		assert.Equal(t, redact.Placeholder(`["a", "b"]`), attrs["labels"], "Lists are hashed by their source")
+
+		// A secrets_file entry holds the bare value and resolves both
+		resolver := redact.NewResolver()
+		resolver.Add("hunter2")
+		resolved, unresolved := resolver.Resolve(attrs["db_password"] + " " + attrs["db_password_flag"])
+		assert.Equal(t, "hunter2 hunter2", resolved)
+		assert.Empty(t, unresolved)
+	})
+
+	t.Run("kept", func(t *testing.T) {
+		content, err := hcl.FormatSubmissionVariables(submission, false)
+		require.NoError(t, err)
+		attrs := blockAttributes(t, string(content))
+		assert.Equal(t, "hunter2", attrs["db_password"])
+		assert.Equal(t, "3", attrs["replicas"])
+		assert.Equal(t, "s3cr3t", attrs["api_token"])
+	})
+
+	t.Run("no variables", func(t *testing.T) {
+		content, err := hcl.FormatSubmissionVariables(&api.JobSubmission{Source: "job \"x\" {}"}, true)
+		require.NoError(t, err)
+		assert.Nil(t, content)
+	})
+
+	t.Run("unparseable var-file is not redacted silently", func(t *testing.T) {
+		_, err := hcl.FormatSubmissionVariables(&api.JobSubmission{Variables: "db_password = "}, true)
+		assert.Error(t, err)
+	})
+}
@@ -55,7 +55,7 @@ func TestNomadContainer(t *testing.T) {
 	// The log message appears before the API is ready to accept connections
 	var pingErr error
 	for i := 0; i < 10; i++ {
-		pingErr = client.Ping()
+		pingErr = client.Ping(ctx)
 		if pingErr == nil {
 			break
 		}
@@ -93,7 +93,7 @@ func TestFetchAndNormalizeJob(t *testing.T) {
 	t.Logf("Deployed test job: %s", jobID)
 
 	// Fetch the job
-	job, err := client.FetchJobSpec("default", jobID)
+	job, err := client.FetchJobSpec(ctx, "default", jobID)
 	require.NoError(t, err, "Failed to fetch job")
 	assert.NotNil(t, job)
 	assert.Equal(t, jobID, *job.ID)
@@ -297,7 +297,7 @@ func TestFullSyncWorkflow(t *testing.T) {
 	require.NoError(t, err)
 
 	// 5. Fetch job from Nomad
-	job, err := nomadClient.FetchJobSpec("default", jobID)
+	job, err := nomadClient.FetchJobSpec(ctx, "default", jobID)
 	require.NoError(t, err)
 
 	// 6. Normalize
@@ -333,7 +333,7 @@ func TestFullSyncWorkflow(t *testing.T) {
 	t.Logf("✅ Full workflow complete! Commit: %s", hash[:8])
 
 	// 10. Verify: Fetch again and compare
-	job2, err := nomadClient.FetchJobSpec("default", jobID)
+	job2, err := nomadClient.FetchJobSpec(ctx, "default", jobID)
 	require.NoError(t, err)
 
 	normalized2 := nomad.NormalizeJob(job2, []string{
@@ -602,7 +602,7 @@ func TestHistoryAndDeploy(t *testing.T) {
 	}
 	defer func() { _ = backend.Close() }()
 
-	if err := backend.Initialize(); err != nil {
+	if err := backend.Initialize(ctx); err != nil {
 		t.Fatalf("Failed to initialize backend: %v", err)
 	}
 
@@ -617,7 +617,7 @@ func TestHistoryAndDeploy(t *testing.T) {
 	defer func() { _ = nomadClient.Close() }()
 
 	// Fetch and write v1
-	spec, err := nomadClient.FetchJobSpec("default", "rollback-test")
+	spec, err := nomadClient.FetchJobSpec(ctx, "default", "rollback-test")
 	if err != nil {
 		t.Fatalf("Failed to fetch job: %v", err)
 	}
@@ -628,16 +628,16 @@ func TestHistoryAndDeploy(t *testing.T) {
 		t.Fatalf("Failed to format HCL: %v", err)
 	}
 
-	if err := backend.WriteFile("global/default/rollback-test.hcl", hclBytes); err != nil {
+	if err := backend.WriteFile(ctx, "global/default/rollback-test.hcl", hclBytes); err != nil {
 		t.Fatalf("Failed to write file: %v", err)
 	}
 
-	commitV1, err := backend.Commit("Update rollback-test\n\nDeployed version 1")
+	commitV1, err := backend.Commit(ctx, "Update rollback-test\n\nDeployed version 1", nil)
 	if err != nil {
 		t.Fatalf("Failed to commit v1: %v", err)
 	}
 
-	if err := backend.Push(); err != nil {
+	if err := backend.Push(ctx); err != nil {
 		t.Fatalf("Failed to push v1: %v", err)
 	}
 
@@ -660,7 +660,7 @@ func TestHistoryAndDeploy(t *testing.T) {
 	t.Log("Deployed job version 2")
 
 	// Sync version 2
-	spec, err = nomadClient.FetchJobSpec("default", "rollback-test")
+	spec, err = nomadClient.FetchJobSpec(ctx, "default", "rollback-test")
 	if err != nil {
 		t.Fatalf("Failed to fetch job v2: %v", err)
 	}
@@ -671,16 +671,16 @@ func TestHistoryAndDeploy(t *testing.T) {
 		t.Fatalf("Failed to format HCL v2: %v", err)
 	}
 
-	if err := backend.WriteFile("global/default/rollback-test.hcl", hclBytes); err != nil {
+	if err := backend.WriteFile(ctx, "global/default/rollback-test.hcl", hclBytes); err != nil {
 		t.Fatalf("Failed to write file v2: %v", err)
 	}
 
-	commitV2, err := backend.Commit("Update rollback-test\n\nDeployed version 2")
+	commitV2, err := backend.Commit(ctx, "Update rollback-test\n\nDeployed version 2", nil)
 	if err != nil {
 		t.Fatalf("Failed to commit v2: %v", err)
 	}
 
-	if err := backend.Push(); err != nil {
+	if err := backend.Push(ctx); err != nil {
 		t.Fatalf("Failed to push v2: %v", err)
 	}
 
@@ -719,7 +719,7 @@ func TestHistoryAndDeploy(t *testing.T) {
 	}
 
 	// Parse the HCL (pass Nomad address since ParseHCL makes a request to Nomad)
-	jobFromV1, err := hcl.ParseHCL(v1Content, hcl.ParseOptions{
+	jobFromV1, err := hcl.ParseHCL(ctx, v1Content, hcl.ParseOptions{
 		NomadAddr:     nomadAddr,
 		TLSSkipVerify: true, // test container doesn't use TLS
 	})
@@ -730,7 +730,7 @@ func TestHistoryAndDeploy(t *testing.T) {
 	t.Logf("✅ Retrieved version 1 from commit %s", secondCommit.Hash)
 
 	// Deploy v1 (rollback)
-	evalID, err := nomadClient.DeployJob(jobFromV1)
+	evalID, err := nomadClient.DeployJob(ctx, jobFromV1)
 	if err != nil {
 		t.Fatalf("Failed to deploy v1: %v", err)
 	}
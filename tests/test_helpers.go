@@ -1,6 +1,30 @@
 // Package tests contains shared test helpers
 package tests
 
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/stretchr/testify/require"
+	gitpkg "github.com/wlame/njgit/internal/git"
+	"golang.org/x/crypto/ssh"
+)
+
 // Helper functions for creating pointers to primitive types
 // Used by both unit and integration tests
 
@@ -11,3 +35,153 @@ func stringToPtr(s string) *string {
 func intToPtr(i int) *int {
 	return &i
 }
+
+func boolToPtr(b bool) *bool {
+	return &b
+}
+
+func durationToPtr(d time.Duration) *time.Duration {
+	return &d
+}
+
+func int64ToPtr(i int64) *int64 {
+	return &i
+}
+
+// uint64ToPtr converts a uint64 to a pointer
+func uint64ToPtr(i uint64) *uint64 {
+	return &i
+}
+
+// writeClientCertificate creates a self-signed client certificate and key in dir
+// Returns the certificate path, the key path, and a pool that trusts the certificate
+func writeClientCertificate(t *testing.T, dir string) (string, string, *x509.CertPool) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "njgit-test-client"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile := filepath.Join(dir, "client.pem")
+	keyFile := filepath.Join(dir, "client-key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+
+	return certFile, keyFile, pool
+}
+
+// runGit runs a git command in dir with a fixed identity and returns its
+// trimmed output, failing the test if it fails
+func runGit(t testing.TB, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@test.com"}, args...)...)
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, "git %v: %s", args, out)
+	return strings.TrimSpace(string(out))
+}
+
+// newRemoteRepos creates a bare repository with one commit on main and two
+// clones of it: one for njgit and one for "someone else"
+func newRemoteRepos(t *testing.T) (bare, njgitClone, otherClone string) {
+	t.Helper()
+	root := t.TempDir()
+	bare = filepath.Join(root, "remote.git")
+	njgitClone = filepath.Join(root, "njgit")
+	otherClone = filepath.Join(root, "other")
+
+	runGit(t, root, "init", "--bare", "--initial-branch=main", bare)
+	runGit(t, root, "clone", bare, otherClone)
+	runGit(t, otherClone, "checkout", "-b", "main")
+	require.NoError(t, os.WriteFile(filepath.Join(otherClone, "README.md"), []byte("jobs\n"), 0644))
+	runGit(t, otherClone, "add", ".")
+	runGit(t, otherClone, "commit", "-m", "Initial commit")
+	runGit(t, otherClone, "push", "origin", "main")
+
+	runGit(t, root, "clone", bare, njgitClone)
+	runGit(t, njgitClone, "config", "user.name", "njgit")
+	runGit(t, njgitClone, "config", "user.email", "njgit@localhost")
+	return bare, njgitClone, otherClone
+}
+
+// initRepo creates a repository on main with a committer identity set
+func initRepo(t testing.TB) string {
+	t.Helper()
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-b", "main")
+	runGit(t, dir, "config", "user.name", "njgit")
+	runGit(t, dir, "config", "user.email", "njgit@example.com")
+	return dir
+}
+
+// writeSSHSigningKey writes a new ed25519 private key in OpenSSH format
+func writeSSHSigningKey(t *testing.T) (string, ssh.PublicKey) {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	block, err := ssh.MarshalPrivateKey(priv, "njgit")
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "id_ed25519")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(block), 0600))
+
+	signer, err := ssh.NewSignerFromKey(priv)
+	require.NoError(t, err)
+	return path, signer.PublicKey()
+}
+
+// writeGPGSigningKey writes a new armored OpenPGP secret key
+func writeGPGSigningKey(t *testing.T) string {
+	t.Helper()
+	entity, err := openpgp.NewEntity("njgit", "", "njgit@example.com", nil)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "signing.asc")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+
+	w, err := armor.Encode(f, openpgp.PrivateKeyType, nil)
+	require.NoError(t, err)
+	require.NoError(t, entity.SerializePrivate(w, nil))
+	require.NoError(t, w.Close())
+	return path
+}
+
+// buildHistoryRepo creates a repository with the given number of commits,
+// each updating one of jobs job files in turn (global/default/job-<n>.hcl)
+func buildHistoryRepo(t testing.TB, commits, jobs int) string {
+	t.Helper()
+	dir := initRepo(t)
+	repo, err := gitpkg.NewLocalRepository(dir)
+	require.NoError(t, err)
+
+	for i := 0; i < commits; i++ {
+		path := fmt.Sprintf("global/default/job-%d.hcl", i%jobs)
+		require.NoError(t, repo.EnsureDirectory(filepath.Dir(path)))
+		require.NoError(t, repo.WriteFile(path, []byte(fmt.Sprintf("job \"job-%d\" { v = %d }\n", i%jobs, i))))
+		require.NoError(t, repo.StageFile(path))
+		_, err := repo.Commit(fmt.Sprintf("Update global/default/job-%d", i%jobs), "njgit", "njgit@example.com")
+		require.NoError(t, err)
+	}
+	return dir
+}